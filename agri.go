@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGDDBase = 50.0
+
+	// a breeze keeps spray moving without carrying it off site, in km/h
+	sprayWindMin = 5.0
+	sprayWindMax = 16.0
+)
+
+// a named bundle of properties along with a summary to print under the table
+type profile struct {
+	properties []string
+	summary    func(req forecastRequest, grid gridPoint, c coordinates, forecast gridForecast) []string
+}
+
+var profiles = map[string]profile{
+	"agri": {
+		properties: []string{"temperature", "quantitativePrecipitation", "probabilityOfPrecipitation", "windSpeed", "windGust", "relativeHumidity"},
+		summary:    agriSummary,
+	},
+}
+
+func profileNames() []string {
+	names := []string{}
+	for name := range profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func rememberPlantingDate(date string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	cfg.Agri.PlantingDate = date
+
+	return saveConfig(cfg)
+}
+
+// how much of the window each point covers, so that e.g. a 6 hour QPF
+// period half inside the window counts for half its amount
+func overlapFraction(p weatherPoint, start, end time.Time) float64 {
+	from := p.StartTime
+	if start.After(from) {
+		from = start
+	}
+
+	to := p.EndTime
+	if end.Before(to) {
+		to = end
+	}
+
+	if !to.After(from) {
+		return 0
+	}
+
+	return float64(to.Sub(from)) / float64(p.EndTime.Sub(p.StartTime))
+}
+
+func totalOver(points series, start, end time.Time) (float64, bool) {
+	total := 0.0
+	found := false
+
+	for i := 0; i < points.Len(); i++ {
+		p := points.At(i)
+		if p.Value == nil {
+			continue
+		}
+
+		if f := overlapFraction(p, start, end); f > 0 {
+			total += *p.Value * f
+			found = true
+		}
+	}
+
+	return total, found
+}
+
+// hourly stretches with a usable breeze and little chance of rain washing
+// the application off
+func sprayWindows(req forecastRequest, forecast gridForecast) []string {
+	windows := []string{}
+
+	var windowStart *time.Time
+
+	closeWindow := func(end time.Time) {
+		if windowStart != nil {
+			windows = append(windows, windowStart.In(req.displayTimeZone).Format("Mon 15:04")+"-"+end.In(req.displayTimeZone).Format("15:04"))
+			windowStart = nil
+		}
+	}
+
+	for at := req.start.Truncate(time.Hour); !at.After(req.end); at = at.Add(time.Hour) {
+		wind, wok := findPointAt(forecast.properties["windSpeed"], at)
+		precip, pok := findPointAt(forecast.properties["probabilityOfPrecipitation"], at)
+
+		good := wok && pok && wind.Value != nil && precip.Value != nil &&
+			*wind.Value >= sprayWindMin && *wind.Value <= sprayWindMax &&
+			*precip.Value < precipChanceThreshold
+
+		if good && windowStart == nil {
+			t := at
+			windowStart = &t
+		}
+
+		if !good {
+			closeWindow(at)
+		}
+	}
+
+	closeWindow(req.end.Truncate(time.Hour).Add(time.Hour))
+
+	return windows
+}
+
+// accumulated growing degree days in F at the station between the two dates
+func getObservedGDD(stationID string, base float64, from, to time.Time) (float64, error) {
+	params := map[string]interface{}{
+		"sid":   stationID + " 5",
+		"sdate": from.Format("2006-01-02"),
+		"edate": to.Format("2006-01-02"),
+		"elems": []interface{}{
+			map[string]interface{}{
+				"name":      "gdd",
+				"base":      base,
+				"interval":  "dly",
+				"duration":  1,
+				"smry":      "sum",
+				"smry_only": 1,
+			},
+		},
+	}
+
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return 0, fmt.Errorf("could not encode ACIS request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", "https://data.rcc-acis.org/StnData", bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	body := struct {
+		Error string   `json:"error"`
+		Smry  []string `json:"smry"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	if body.Error != "" {
+		return 0, fmt.Errorf("climate data unavailable for %s: %s", stationID, body.Error)
+	}
+
+	if len(body.Smry) != 1 {
+		return 0, fmt.Errorf("unexpected climate summary for %s", stationID)
+	}
+
+	gdd, err := strconv.ParseFloat(body.Smry[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("no growing degree days recorded at %s since planting", stationID)
+	}
+
+	return gdd, nil
+}
+
+// the usual (max + min) / 2 - base, floored at zero, from the forecast
+// daily extremes in F
+func forecastGDD(days []dailyExtremes, base float64) float64 {
+	total := 0.0
+
+	for _, d := range days {
+		if d.high == nil || d.low == nil {
+			continue
+		}
+
+		total += math.Max(0, (*d.high+*d.low)/2-base)
+	}
+
+	return total
+}
+
+func agriSummary(req forecastRequest, grid gridPoint, c coordinates, forecast gridForecast) []string {
+	lines := []string{}
+
+	if total, ok := totalOver(forecast.properties["quantitativePrecipitation"], req.start, req.end); ok {
+		lines = append(lines, "Precipitation total: "+formatWeatherValue("quantitativePrecipitation", req.liberate(weatherPoint{Value: &total, Unit: "wmoUnit:mm"}), false))
+	}
+
+	temps := []*float64{}
+	for at := req.start.Truncate(time.Hour); !at.After(req.end); at = at.Add(time.Hour) {
+		if p, ok := findPointAt(forecast.properties["temperature"], at); ok {
+			temps = append(temps, p.Value)
+		}
+	}
+
+	if low := rangeOf(temps).min; low != nil {
+		line := "Lowest temperature: " + formatWeatherValue("temperature", req.liberate(weatherPoint{Value: low, Unit: "wmoUnit:degC"}), false)
+		if *low <= 0 {
+			line += " (frost risk)"
+		}
+
+		lines = append(lines, line)
+	}
+
+	if windows := sprayWindows(req, forecast); len(windows) > 0 {
+		lines = append(lines, "Spray windows: "+strings.Join(windows, ", "))
+	} else {
+		lines = append(lines, "Spray windows: none in this window")
+	}
+
+	lines = append(lines, gddSummary(req, grid, c, forecast))
+
+	return lines
+}
+
+func gddSummary(req forecastRequest, grid gridPoint, c coordinates, forecast gridForecast) string {
+	cfg, err := loadConfig()
+	if err != nil {
+		return "Growing degree days: " + err.Error()
+	}
+
+	if cfg.Agri.PlantingDate == "" {
+		return "Growing degree days: set a planting date with -planting-date to track accumulation"
+	}
+
+	planted, err := time.Parse("2006-01-02", cfg.Agri.PlantingDate)
+	if err != nil {
+		return fmt.Sprintf("Growing degree days: bad planting date '%s' in config", cfg.Agri.PlantingDate)
+	}
+
+	base := cfg.Agri.GDDBase
+	if base == 0 {
+		base = defaultGDDBase
+	}
+
+	stations, err := getStations(grid, c)
+	if err != nil {
+		return "Growing degree days: " + err.Error()
+	}
+
+	s, err := selectStation(stations, req.station)
+	if err != nil {
+		return "Growing degree days: " + err.Error()
+	}
+
+	observed, err := getObservedGDD(s.id, base, planted, time.Now().AddDate(0, 0, -1))
+	if err != nil {
+		return "Growing degree days: " + err.Error()
+	}
+
+	ahead := forecastGDD(forecastDailyExtremes(req, forecast.properties["temperature"]), base)
+
+	return fmt.Sprintf(
+		"Growing degree days (base %s F): %s since %s at %s, %s more by %s",
+		formatNumber(base, 1), formatNumber(observed, 0), planted.Format("Jan 02"), s.id,
+		formatNumber(ahead, 0), req.end.In(req.displayTimeZone).Format("Jan 02"),
+	)
+}
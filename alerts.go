@@ -0,0 +1,481 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/packrat386/agwc/schema"
+)
+
+type alert struct {
+	id          string
+	event       string
+	headline    string
+	severity    string
+	urgency     string
+	certainty   string
+	areas       string
+	sent        time.Time
+	onset       time.Time
+	ends        time.Time
+	description string
+	instruction string
+	geometry    geoJSONGeometry
+
+	// the UGC zones and SAME county codes the alert is for
+	zones []string
+	same  []string
+}
+
+// from least to most, as NWS spells them
+var severities = []string{"Unknown", "Minor", "Moderate", "Severe", "Extreme"}
+var urgencies = []string{"Unknown", "Past", "Future", "Expected", "Immediate"}
+
+// a filter like "severe" matches only that level, "severe+" matches that
+// level or worse
+type levelFilter struct {
+	scale   []string
+	level   int
+	atLeast bool
+}
+
+func parseLevelFilter(s string, scale []string, what string) (levelFilter, error) {
+	f := levelFilter{scale: scale}
+
+	name := s
+	if strings.HasSuffix(name, "+") {
+		f.atLeast = true
+		name = strings.TrimSuffix(name, "+")
+	}
+
+	f.level = levelOf(scale, name)
+	if f.level < 0 {
+		return levelFilter{}, fmt.Errorf("%s '%s' is not in %v, optionally followed by '+'", what, s, scale)
+	}
+
+	return f, nil
+}
+
+func levelOf(scale []string, name string) int {
+	for i, v := range scale {
+		if strings.EqualFold(v, name) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (f levelFilter) matches(name string) bool {
+	level := levelOf(f.scale, name)
+	if f.atLeast {
+		return level >= f.level
+	}
+
+	return level == f.level
+}
+
+type alertsRequest struct {
+	address         string
+	severity        *levelFilter
+	urgency         *levelFilter
+	events          []string
+	sortBy          string
+	displayTimeZone *time.Location
+	follow          bool
+	interval        time.Duration
+	showIDs         bool
+	includeZone     bool
+
+	// once the address is resolved, alerts drawn as polygons have to cover it
+	point *coordinates
+}
+
+func (req alertsRequest) wants(a alert) bool {
+	if req.severity != nil && !req.severity.matches(a.severity) {
+		return false
+	}
+
+	if req.urgency != nil && !req.urgency.matches(a.urgency) {
+		return false
+	}
+
+	if len(req.events) > 0 {
+		found := false
+		for _, e := range req.events {
+			if strings.EqualFold(e, a.event) {
+				found = true
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if req.point != nil && !req.includeZone && !covers(a, *req.point) {
+		return false
+	}
+
+	return true
+}
+
+// alerts issued for whole zones have no geometry of their own and cover
+// everything in them. the ones drawn as polygons, like storm based warnings,
+// only cover what's inside.
+func covers(a alert, c coordinates) bool {
+	polygons, err := a.geometry.polygons()
+	if err != nil || len(polygons) == 0 {
+		return true
+	}
+
+	return anyContains(polygons, c)
+}
+
+func sortAlerts(alerts []alert, by string) {
+	sort.SliceStable(alerts, func(i, j int) bool {
+		switch by {
+		case "severity":
+			return levelOf(severities, alerts[i].severity) > levelOf(severities, alerts[j].severity)
+		default:
+			return alerts[i].onset.Before(alerts[j].onset)
+		}
+	})
+}
+
+func activeAlertsURL(c coordinates) string {
+	queryURL := &url.URL{
+		Scheme:   "https",
+		Host:     "api.weather.gov",
+		Path:     "/alerts/active",
+		RawQuery: url.Values{"point": []string{c.String()}}.Encode(),
+	}
+
+	return queryURL.String()
+}
+
+func getActiveAlerts(ctx context.Context, c coordinates) ([]alert, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", activeAlertsURL(c), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/geo+json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	return parseAlerts(res.Body)
+}
+
+func parseAlerts(r io.Reader) ([]alert, error) {
+	body := struct {
+		Features []struct {
+			Geometry   *geoJSONGeometry `json:"geometry"`
+			Properties struct {
+				ID          string    `json:"id"`
+				AreaDesc    string    `json:"areaDesc"`
+				Sent        time.Time `json:"sent"`
+				Onset       time.Time `json:"onset"`
+				Ends        time.Time `json:"ends"`
+				Expires     time.Time `json:"expires"`
+				Severity    string    `json:"severity"`
+				Certainty   string    `json:"certainty"`
+				Urgency     string    `json:"urgency"`
+				Event       string    `json:"event"`
+				Headline    string    `json:"headline"`
+				Description string    `json:"description"`
+				Instruction string    `json:"instruction"`
+				Geocode     struct {
+					UGC  []string `json:"UGC"`
+					SAME []string `json:"SAME"`
+				} `json:"geocode"`
+			} `json:"properties"`
+		} `json:"features"`
+	}{}
+
+	err := json.NewDecoder(r).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	alerts := []alert{}
+
+	for _, f := range body.Features {
+		p := f.Properties
+
+		a := alert{
+			id:          p.ID,
+			event:       p.Event,
+			headline:    p.Headline,
+			severity:    p.Severity,
+			urgency:     p.Urgency,
+			certainty:   p.Certainty,
+			areas:       p.AreaDesc,
+			sent:        p.Sent,
+			onset:       p.Onset,
+			ends:        p.Ends,
+			description: p.Description,
+			instruction: p.Instruction,
+			zones:       p.Geocode.UGC,
+			same:        p.Geocode.SAME,
+		}
+
+		// some alerts never say when they end, only when the message expires
+		if a.ends.IsZero() {
+			a.ends = p.Expires
+		}
+
+		if a.onset.IsZero() {
+			a.onset = p.Sent
+		}
+
+		if f.Geometry != nil {
+			a.geometry = *f.Geometry
+		}
+
+		alerts = append(alerts, a)
+	}
+
+	return alerts, nil
+}
+
+func displayAlerts(req alertsRequest, alerts []alert) {
+	if len(alerts) == 0 {
+		fmt.Println("no active alerts match")
+		return
+	}
+
+	widths := []int{28, 8, 9, 15, 15, 40}
+	header := []string{"event", "severity", "urgency", "onset", "ends", "area"}
+
+	if req.showIDs {
+		widths = append(widths, 60)
+		header = append(header, "id")
+	}
+
+	t := newTable(os.Stdout, widths, header)
+
+	for _, a := range alerts {
+		ends := "-"
+		if !a.ends.IsZero() {
+			ends = a.ends.In(req.displayTimeZone).Format(time.Stamp)
+		}
+
+		t.row([]string{
+			a.event,
+			a.severity,
+			a.urgency,
+			a.onset.In(req.displayTimeZone).Format(time.Stamp),
+			ends,
+			a.areas,
+			a.id,
+		}, nil)
+	}
+
+	t.end()
+}
+
+// repeatable, for matching any of several events
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func alertsFlags(flagset *flag.FlagSet) func() (alertsRequest, error) {
+	var (
+		queryAddress string
+		severity     string
+		urgency      string
+		events       stringList
+		sortBy       string
+		displaytz    string
+		follow       bool
+		interval     time.Duration
+		showIDs      bool
+		includeZone  bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address at which to see active alerts")
+	flagset.StringVar(&severity, "severity", "", fmt.Sprintf("only show alerts of this severity, or worse with a trailing '+', from %v", severities))
+	flagset.StringVar(&urgency, "urgency", "", fmt.Sprintf("only show alerts of this urgency, or more with a trailing '+', from %v", urgencies))
+	flagset.Var(&events, "event", "only show this kind of alert, e.g. 'Tornado Warning', may be repeated")
+	flagset.StringVar(&sortBy, "sort", "onset", "order alerts by 'onset' or 'severity'")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display alert times")
+	flagset.BoolVar(&follow, "follow", false, "keep watching and print alerts as they're issued, updated, or end")
+	flagset.DurationVar(&interval, "interval", time.Minute, "how often to check with -follow, or longer if upstream asks")
+	flagset.BoolVar(&includeZone, "include-zone", false, "include polygon alerts that cover the zone but not the exact address")
+	flagset.BoolVar(&showIDs, "ids", false, "include each alert's id, for 'agwc alerts show <id>'")
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+
+	return func() (alertsRequest, error) {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			return alertsRequest{}, fmt.Errorf("could not load display timezone: %w", err)
+		}
+
+		req := alertsRequest{
+			address:         queryAddress,
+			events:          events,
+			sortBy:          sortBy,
+			displayTimeZone: loc,
+			follow:          follow,
+			interval:        interval,
+			showIDs:         showIDs,
+			includeZone:     includeZone,
+		}
+
+		if req.address == "" {
+			return alertsRequest{}, fmt.Errorf("address cannot be empty")
+		}
+
+		if severity != "" {
+			f, err := parseLevelFilter(severity, severities, "severity")
+			if err != nil {
+				return alertsRequest{}, err
+			}
+
+			req.severity = &f
+		}
+
+		if urgency != "" {
+			f, err := parseLevelFilter(urgency, urgencies, "urgency")
+			if err != nil {
+				return alertsRequest{}, err
+			}
+
+			req.urgency = &f
+		}
+
+		if req.interval < 10*time.Second {
+			return alertsRequest{}, fmt.Errorf("interval must be at least 10s to go easy on upstream, got %s", req.interval)
+		}
+
+		if req.sortBy != "onset" && req.sortBy != "severity" {
+			return alertsRequest{}, fmt.Errorf("sort must be 'onset' or 'severity', got '%s'", req.sortBy)
+		}
+
+		return req, nil
+	}
+}
+
+func runAlerts(flagset *flag.FlagSet) func() {
+	request := alertsFlags(flagset)
+
+	return func() {
+		req, err := request()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		coordinates, err := getAddressCoordinates(req.address)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		req.point = &coordinates
+
+		if req.follow {
+			followAlerts(req, coordinates)
+			return
+		}
+
+		alerts, err := getActiveAlerts(context.Background(), coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		matching := []alert{}
+		for _, a := range alerts {
+			if req.wants(a) {
+				matching = append(matching, a)
+			}
+		}
+
+		sortAlerts(matching, req.sortBy)
+
+		displayAlerts(req, matching)
+	}
+}
+
+// the active alerts at the point for the forecast's -alerts, soonest first
+func forecastAlerts(ctx context.Context, req forecastRequest, c coordinates) ([]alert, error) {
+	alerts, err := getActiveAlerts(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := alertsRequest{severity: req.alertSeverity, point: &c}
+
+	matching := []alert{}
+	for _, a := range alerts {
+		if filter.wants(a) {
+			matching = append(matching, a)
+		}
+	}
+
+	sortAlerts(matching, "onset")
+
+	return matching, nil
+}
+
+func displayAlertHeadlines(w io.Writer, alerts []alert, loc *time.Location) {
+	if len(alerts) == 0 {
+		fmt.Fprintln(w, "no active alerts")
+		return
+	}
+
+	for _, a := range alerts {
+		ends := "until further notice"
+		if !a.ends.IsZero() {
+			ends = "to " + a.ends.In(loc).Format(time.Stamp)
+		}
+
+		fmt.Fprintf(w, "%s (%s) from %s %s\n", a.event, a.severity, a.onset.In(loc).Format(time.Stamp), ends)
+
+		if a.headline != "" {
+			fmt.Fprintf(w, "  %s\n", a.headline)
+		}
+	}
+}
+
+func alertDocuments(alerts []alert) []schema.Alert {
+	docs := []schema.Alert{}
+
+	for _, a := range alerts {
+		d := schema.Alert{
+			ID:       a.id,
+			Event:    a.event,
+			Severity: a.severity,
+			Urgency:  a.urgency,
+			Headline: a.headline,
+			Onset:    a.onset,
+		}
+
+		if !a.ends.IsZero() {
+			ends := a.ends
+			d.Ends = &ends
+		}
+
+		docs = append(docs, d)
+	}
+
+	return docs
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// hours listed below the summary, at most
+const alfredHours = 12
+
+// the script filter JSON Alfred reads, which Raycast's script commands
+// take as well. actioning an item opens the forecast page, and copying it
+// copies the line as shown. there are no uids, since with them Alfred
+// reorders items by how often they're picked, which scrambles the hours.
+type alfredOutput struct {
+	Items []alfredItem `json:"items"`
+}
+
+type alfredItem struct {
+	Title    string     `json:"title"`
+	Subtitle string     `json:"subtitle"`
+	Arg      string     `json:"arg"`
+	Valid    bool       `json:"valid"`
+	Text     alfredText `json:"text"`
+}
+
+type alfredText struct {
+	Copy      string `json:"copy"`
+	LargeType string `json:"largetype"`
+}
+
+// an item for the coming hour's condition and first column, with the rest
+// of the columns under it, then one per hour after that
+func writeForecastAlfred(w io.Writer, req forecastRequest, c coordinates, forecast gridForecast, rows []displayRow) error {
+	upcoming := []displayRow{}
+	for _, r := range rows {
+		if !r.observed && len(upcoming) < alfredHours+1 {
+			upcoming = append(upcoming, r)
+		}
+	}
+
+	if len(upcoming) == 0 {
+		return fmt.Errorf("no hours match your criteria in the requested window")
+	}
+
+	columns := req.columns()
+	visible := req.visibleColumns()
+	link := forecastPageURL(c)
+
+	describe := func(r displayRow) (string, string) {
+		first, rest := "", []string{}
+		for n, i := range visible {
+			if n == 0 {
+				first = r.values[i]
+				continue
+			}
+
+			rest = append(rest, fmt.Sprintf("%s %s", columns[i], r.values[i]))
+		}
+
+		return first, strings.Join(rest, ", ")
+	}
+
+	item := func(title, subtitle string) alfredItem {
+		line := strings.TrimSpace(title + " " + subtitle)
+
+		return alfredItem{
+			Title:    title,
+			Subtitle: subtitle,
+			Arg:      link,
+			Valid:    true,
+			Text:     alfredText{Copy: line, LargeType: line},
+		}
+	}
+
+	current := upcoming[0]
+	now := condition(forecast, current.at, current.at.Add(time.Hour))
+	first, rest := describe(current)
+
+	out := alfredOutput{Items: []alfredItem{
+		item(
+			strings.TrimSpace(fmt.Sprintf("%s %s %s in %s", conditionGlyphs[now], first, strings.ReplaceAll(now, "-", " "), req.address)),
+			rest,
+		),
+	}}
+
+	for _, r := range upcoming[1:] {
+		at := r.at.In(req.displayTimeZone)
+		first, rest := describe(r)
+		cond := condition(forecast, r.at, r.at.Add(time.Hour))
+
+		out.Items = append(out.Items, item(
+			strings.TrimSpace(fmt.Sprintf("%s %s %s", at.Format("Mon 15:04"), conditionGlyphs[cond], first)),
+			rest,
+		))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	err := enc.Encode(out)
+	if err != nil {
+		return fmt.Errorf("could not write alfred JSON: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+)
+
+// the sun's center is this far below the horizon at official sunrise and
+// sunset, once you account for refraction and the size of the disc
+const sunriseAltitude = -0.833
+
+const (
+	julianUnixEpoch = 2440587.5
+	julianJ2000     = 2451545.0
+)
+
+func toJulian(t time.Time) float64 {
+	return float64(t.Unix())/86400.0 + julianUnixEpoch
+}
+
+func fromJulian(j float64) time.Time {
+	return time.Unix(0, int64((j-julianUnixEpoch)*86400.0*float64(time.Second))).UTC()
+}
+
+func degSin(deg float64) float64 { return math.Sin(deg * math.Pi / 180) }
+func degCos(deg float64) float64 { return math.Cos(deg * math.Pi / 180) }
+
+// when the sun crosses the given altitude on the calendar day of the given
+// time, using the sunrise equation. ok is false if the sun never gets that
+// high (or low) that day, like at the poles.
+func sunTimes(day time.Time, c coordinates, altitude float64) (rise time.Time, set time.Time, ok bool) {
+	noon := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, day.Location())
+
+	n := math.Round(toJulian(noon) - julianJ2000 + 0.0008)
+	meanSolarTime := n - c.longitude/360.0
+
+	anomaly := math.Mod(357.5291+0.98560028*meanSolarTime, 360)
+	center := 1.9148*degSin(anomaly) + 0.0200*degSin(2*anomaly) + 0.0003*degSin(3*anomaly)
+	eclipticLongitude := math.Mod(anomaly+center+180+102.9372, 360)
+
+	transit := julianJ2000 + meanSolarTime + 0.0053*degSin(anomaly) - 0.0069*degSin(2*eclipticLongitude)
+
+	declination := math.Asin(degSin(eclipticLongitude) * degSin(23.4397))
+
+	cosHourAngle := (degSin(altitude) - degSin(c.latitude)*math.Sin(declination)) / (degCos(c.latitude) * math.Cos(declination))
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	hourAngle := math.Acos(cosHourAngle) * 180 / math.Pi
+
+	return fromJulian(transit - hourAngle/360), fromJulian(transit + hourAngle/360), true
+}
+
+func sunriseSunset(day time.Time, c coordinates) (time.Time, time.Time, bool) {
+	return sunTimes(day, c, sunriseAltitude)
+}
+
+var astroExpressionMatcher = regexp.MustCompile(`^(?P<event>sunrise|sunset)(?P<offset>[+-]\S+)?$`)
+
+// resolves time expressions that need to know where we are, like
+// "sunrise+1h", falling back to the ones that don't. sunrise and sunset
+// refer to the current day's daylight, or the next day's once the sun has set.
+func resolveTimeExpression(s string, now time.Time, c coordinates, loc *time.Location) (time.Time, error) {
+	matches := astroExpressionMatcher.FindStringSubmatch(s)
+	if len(matches) == 0 {
+		return parseTimeExpression(s, now)
+	}
+
+	day := now.In(loc)
+
+	rise, set, ok := sunriseSunset(day, c)
+	if ok && now.After(set) {
+		day = day.AddDate(0, 0, 1)
+		rise, set, ok = sunriseSunset(day, c)
+	}
+
+	if !ok {
+		return time.Time{}, fmt.Errorf("the sun does not rise or set at %s on %s", c, day.Format("Jan 02"))
+	}
+
+	t := rise
+	if matches[astroExpressionMatcher.SubexpIndex("event")] == "sunset" {
+		t = set
+	}
+
+	if offset := matches[astroExpressionMatcher.SubexpIndex("offset")]; offset != "" {
+		d, err := time.ParseDuration(offset)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not parse offset '%s': %w", offset, err)
+		}
+
+		t = t.Add(d)
+	}
+
+	return t, nil
+}
+
+func isTimeExpression(s string) bool {
+	if astroExpressionMatcher.MatchString(s) {
+		return true
+	}
+
+	_, err := parseTimeExpression(s, time.Now())
+
+	return err == nil
+}
+
+// the rest is a low precision lunar model, good to a fraction of a degree,
+// which is plenty for deciding whether the moon will spoil a night out
+const obliquity = 23.4397
+
+type equatorial struct {
+	rightAscension float64
+	declination    float64
+	distanceKm     float64
+}
+
+func toRadians(deg float64) float64 { return deg * math.Pi / 180 }
+
+func eclipticToEquatorial(longitude, latitude float64) (float64, float64) {
+	e := toRadians(obliquity)
+
+	ra := math.Atan2(math.Sin(longitude)*math.Cos(e)-math.Tan(latitude)*math.Sin(e), math.Cos(longitude))
+	dec := math.Asin(math.Sin(latitude)*math.Cos(e) + math.Cos(latitude)*math.Sin(e)*math.Sin(longitude))
+
+	return ra, dec
+}
+
+func sunEquatorial(d float64) equatorial {
+	anomaly := toRadians(357.5291 + 0.98560028*d)
+	center := toRadians(1.9148*math.Sin(anomaly) + 0.02*math.Sin(2*anomaly) + 0.0003*math.Sin(3*anomaly))
+	longitude := anomaly + center + toRadians(102.9372) + math.Pi
+
+	ra, dec := eclipticToEquatorial(longitude, 0)
+
+	return equatorial{rightAscension: ra, declination: dec, distanceKm: 149598000}
+}
+
+func moonEquatorial(d float64) equatorial {
+	meanLongitude := toRadians(218.316 + 13.176396*d)
+	anomaly := toRadians(134.963 + 13.064993*d)
+	meanDistance := toRadians(93.272 + 13.229350*d)
+
+	longitude := meanLongitude + toRadians(6.289)*math.Sin(anomaly)
+	latitude := toRadians(5.128) * math.Sin(meanDistance)
+
+	ra, dec := eclipticToEquatorial(longitude, latitude)
+
+	return equatorial{rightAscension: ra, declination: dec, distanceKm: 385001 - 20905*math.Cos(anomaly)}
+}
+
+func altitude(eq equatorial, d float64, c coordinates) float64 {
+	siderealTime := toRadians(280.16+360.9856235*d) + toRadians(c.longitude)
+	hourAngle := siderealTime - eq.rightAscension
+	lat := toRadians(c.latitude)
+
+	return math.Asin(math.Sin(lat)*math.Sin(eq.declination)+math.Cos(lat)*math.Cos(eq.declination)*math.Cos(hourAngle)) * 180 / math.Pi
+}
+
+// degrees above the horizon, negative when it's down
+func sunAltitude(t time.Time, c coordinates) float64 {
+	d := toJulian(t) - julianJ2000
+	return altitude(sunEquatorial(d), d, c)
+}
+
+// degrees above the horizon, negative when it's down
+func moonAltitude(t time.Time, c coordinates) float64 {
+	d := toJulian(t) - julianJ2000
+	return altitude(moonEquatorial(d), d, c)
+}
+
+// the fraction of the moon's disc that's lit, from 0 at new moon to 1 at full
+func moonIllumination(t time.Time) float64 {
+	d := toJulian(t) - julianJ2000
+
+	s := sunEquatorial(d)
+	m := moonEquatorial(d)
+
+	elongation := math.Acos(math.Sin(s.declination)*math.Sin(m.declination) + math.Cos(s.declination)*math.Cos(m.declination)*math.Cos(s.rightAscension-m.rightAscension))
+	inclination := math.Atan2(s.distanceKm*math.Sin(elongation), m.distanceKm-s.distanceKm*math.Cos(elongation))
+
+	return (1 + math.Cos(inclination)) / 2
+}
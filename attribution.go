@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// where a column's values come from and what was done to them, for sharing
+// a table or document with people who weren't there when it was run
+type columnSource struct {
+	column      string
+	source      string
+	computation string
+}
+
+func (req forecastRequest) columnSources(grid gridPoint) []columnSource {
+	columns := req.columns()
+	sources := []columnSource{}
+
+	forecast := fmt.Sprintf("NWS gridpoint forecast %s/%d,%d", grid.office, grid.x, grid.y)
+	if req.past > 0 {
+		station := "the nearest station"
+		if req.station != "" {
+			station = "station " + req.station
+		}
+
+		forecast += ", with observations from " + station + " for past hours"
+	}
+
+	for _, i := range req.visibleColumns() {
+		if i < len(req.properties) {
+			s := columnSource{column: columns[i], source: forecast}
+
+			if indexOf(req.deltaProperties, columns[i]) >= 0 {
+				s.computation = "with the change from " + formatLead(req.delta) + " before"
+			}
+
+			sources = append(sources, s)
+
+			continue
+		}
+
+		d := req.derived[i-len(req.properties)]
+
+		switch e := d.expr.(type) {
+		case comfortExpr:
+			sources = append(sources, columnSource{
+				column:      d.name,
+				source:      "derived from " + strings.Join(e.variables(), ", "),
+				computation: comfortIndexes[string(e)].description,
+			})
+		case pluginExpr:
+			sources = append(sources, columnSource{column: d.name, source: "metric plugin " + string(e)})
+		default:
+			expression := d.source
+			if j := strings.Index(expression, "="); j >= 0 {
+				expression = strings.TrimSpace(expression[j+1:])
+			}
+
+			sources = append(sources, columnSource{
+				column:      d.name,
+				source:      "derived from " + strings.Join(d.expr.variables(), ", "),
+				computation: expression,
+			})
+		}
+	}
+
+	return sources
+}
+
+// the footer -attribution adds under the table
+func displayAttribution(w io.Writer, req forecastRequest, grid gridPoint, providers []string) {
+	fmt.Fprintln(w, "sources:")
+
+	for _, s := range req.columnSources(grid) {
+		line := fmt.Sprintf("  %s: %s", s.column, s.source)
+		if s.computation != "" {
+			line += ", " + s.computation
+		}
+
+		fmt.Fprintln(w, line)
+	}
+
+	if req.consensus {
+		fmt.Fprintf(w, "  consensus: %s\n", strings.Join(providers, ", "))
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/packrat386/agwc/nws"
+
+	_ "embed"
+)
+
+// a week of gridpoint data shaped like what api.weather.gov sends, so the
+// benchmarks don't depend on the network or on today's weather
+//
+//go:embed testdata/gridpoint.json
+var benchFixture []byte
+
+var benchFixtureStart = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+var benchProperties = []string{"temperature", "dewpoint", "windSpeed", "probabilityOfPrecipitation"}
+
+var benchmarks = []struct {
+	name string
+	fn   func(b *testing.B)
+}{
+	{"ISO8601Duration", benchmarkISO8601Duration},
+	{"TimeRange", benchmarkTimeRange},
+	{"DecodeGridpoint", benchmarkDecodeGridpoint},
+	{"SeriesLookup", benchmarkSeriesLookup},
+	{"Render", benchmarkRender},
+}
+
+func benchmarkISO8601Duration(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, err := nws.ParseDuration("P1DT12H30M")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkTimeRange(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, _, err := nws.ParseValidTime("2024-05-01T06:00:00+00:00/PT3H")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkDecodeGridpoint(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchFixture)))
+
+	for i := 0; i < b.N; i++ {
+		_, err := parseWeatherData(bytes.NewReader(benchFixture), benchProperties)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkSeriesLookup(b *testing.B) {
+	forecast, err := parseWeatherData(bytes.NewReader(benchFixture), benchProperties)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	points := forecast.properties["temperature"]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		at := benchFixtureStart.Add(time.Duration(i%168) * time.Hour)
+
+		_, ok := findPointAt(points, at)
+		if !ok {
+			b.Fatalf("no point at %s", at)
+		}
+	}
+}
+
+func benchmarkRender(b *testing.B) {
+	forecast, err := parseWeatherData(bytes.NewReader(benchFixture), benchProperties)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	req := forecastRequest{
+		properties:        benchProperties,
+		start:             benchFixtureStart,
+		end:               benchFixtureStart.Add(48 * time.Hour),
+		displayTimeZone:   time.UTC,
+		highlightExtremes: true,
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		render(io.Discard, req, buildRows(req, forecast.properties))
+	}
+}
+
+type benchResult struct {
+	NsPerOp     int64 `json:"nsPerOp"`
+	AllocsPerOp int64 `json:"allocsPerOp"`
+	BytesPerOp  int64 `json:"bytesPerOp"`
+}
+
+func runBench(flagset *flag.FlagSet) func() {
+	var (
+		run       string
+		baseline  string
+		save      string
+		tolerance float64
+	)
+
+	flagset.StringVar(&run, "run", "", "only run benchmarks matching this regular expression")
+	flagset.StringVar(&baseline, "baseline", "", "compare against results saved with -save and fail on regressions")
+	flagset.StringVar(&save, "save", "", "save results to this file for use with -baseline later")
+	flagset.Float64Var(&tolerance, "tolerance", 20, "percent slower than the baseline that counts as a regression")
+
+	return func() {
+		matcher, err := regexp.Compile(run)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not parse -run: %w", err))
+		}
+
+		previous := map[string]benchResult{}
+		if baseline != "" {
+			data, err := os.ReadFile(baseline)
+			if err != nil {
+				errorAndQuit(fmt.Errorf("could not read baseline: %w", err))
+			}
+
+			err = json.Unmarshal(data, &previous)
+			if err != nil {
+				errorAndQuit(fmt.Errorf("could not parse baseline: %w", err))
+			}
+		}
+
+		results := map[string]benchResult{}
+		regressions := []string{}
+
+		widths := []int{15, 10, 12, 10, 10, 8}
+
+		t := newTable(os.Stdout, widths, []string{"benchmark", "runs", "ns/op", "B/op", "allocs/op", "change"})
+
+		for _, bm := range benchmarks {
+			if !matcher.MatchString(bm.name) {
+				continue
+			}
+
+			r := testing.Benchmark(bm.fn)
+			if r.N == 0 {
+				errorAndQuit(fmt.Errorf("benchmark %s failed", bm.name))
+			}
+
+			result := benchResult{NsPerOp: r.NsPerOp(), AllocsPerOp: r.AllocsPerOp(), BytesPerOp: r.AllocedBytesPerOp()}
+			results[bm.name] = result
+
+			change := "-"
+			if before, ok := previous[bm.name]; ok && before.NsPerOp > 0 {
+				percent := 100 * float64(result.NsPerOp-before.NsPerOp) / float64(before.NsPerOp)
+				change = fmt.Sprintf("%+.0f%%", percent)
+
+				if percent > tolerance {
+					regressions = append(regressions, bm.name)
+				}
+			}
+
+			t.row([]string{
+				bm.name,
+				fmt.Sprint(r.N),
+				fmt.Sprint(result.NsPerOp),
+				fmt.Sprint(result.BytesPerOp),
+				fmt.Sprint(result.AllocsPerOp),
+				change,
+			}, nil)
+		}
+
+		t.end()
+
+		if save != "" {
+			data, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				errorAndQuit(fmt.Errorf("could not encode results: %w", err))
+			}
+
+			err = os.WriteFile(save, append(data, '\n'), 0o644)
+			if err != nil {
+				errorAndQuit(fmt.Errorf("could not save results: %w", err))
+			}
+		}
+
+		if len(regressions) > 0 {
+			errorAndQuit(fmt.Errorf("more than %s%% slower than the baseline: %s", formatNumber(tolerance, 1), strings.Join(regressions, ", ")))
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package main
+
+import "testing"
+
+// the bodies live in bench.go so that `agwc bench` can run them too
+
+func BenchmarkISO8601Duration(b *testing.B) { benchmarkISO8601Duration(b) }
+func BenchmarkTimeRange(b *testing.B)       { benchmarkTimeRange(b) }
+func BenchmarkDecodeGridpoint(b *testing.B) { benchmarkDecodeGridpoint(b) }
+func BenchmarkSeriesLookup(b *testing.B)    { benchmarkSeriesLookup(b) }
+func BenchmarkRender(b *testing.B)          { benchmarkRender(b) }
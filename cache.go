@@ -0,0 +1,394 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/packrat386/agwc/nws"
+)
+
+// what an address geocodes to and which grid a point is in essentially
+// never change, so they're kept between runs, along with the last copy of
+// each grid's forecast so a run soon after another doesn't download it again
+var (
+	useCache         = true
+	forecastCacheTTL = 10 * time.Minute
+
+	// how long what an address geocodes to and which grid a point is in
+	// are kept, since upstream does fix a bad match or move a grid now and
+	// then
+	lookupCacheTTL = 30 * 24 * time.Hour
+
+	// the oldest a cached forecast can be and still be shown, whether
+	// it's within the TTL or upstream can't be reached
+	forecastMaxStale = 24 * time.Hour
+
+	// forecasts fetched before this aren't fresh anymore, whatever the TTL
+	// says, for the repl's refresh
+	cacheNotBefore time.Time
+)
+
+type cacheConfig struct {
+	Disable bool `json:"disable,omitempty"`
+
+	// how long a grid's forecast is used without asking upstream, 10m if
+	// unset
+	ForecastTTL string `json:"forecastTTL,omitempty"`
+
+	// how old a cached forecast can be and still be shown when upstream
+	// can't be reached, 24h if unset
+	MaxStale string `json:"maxStale,omitempty"`
+
+	// how long an address's coordinates and a point's grid are kept, 720h
+	// if unset
+	LookupTTL string `json:"lookupTTL,omitempty"`
+}
+
+func configureCache(cfg config) error {
+	useCache = !cfg.Cache.Disable
+
+	if cfg.Cache.ForecastTTL != "" {
+		d, err := time.ParseDuration(cfg.Cache.ForecastTTL)
+		if err != nil {
+			return fmt.Errorf("could not parse forecastTTL: %w", err)
+		}
+
+		forecastCacheTTL = d
+	}
+
+	if cfg.Cache.MaxStale != "" {
+		d, err := time.ParseDuration(cfg.Cache.MaxStale)
+		if err != nil {
+			return fmt.Errorf("could not parse maxStale: %w", err)
+		}
+
+		forecastMaxStale = d
+	}
+
+	if cfg.Cache.LookupTTL != "" {
+		d, err := time.ParseDuration(cfg.Cache.LookupTTL)
+		if err != nil {
+			return fmt.Errorf("could not parse lookupTTL: %w", err)
+		}
+
+		lookupCacheTTL = d
+	}
+
+	return nil
+}
+
+func cacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "agwc"), nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find cache directory: %w", err)
+	}
+
+	return filepath.Join(dir, "agwc"), nil
+}
+
+// keys can be whole URLs or addresses, so they're hashed into file names
+func cachePath(bucket, key string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(dir, bucket, hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// anything wrong with the cache is just a miss
+func readCache(bucket, key string, v interface{}) bool {
+	if !useCache {
+		return false
+	}
+
+	path, err := cachePath(bucket, key)
+	if err != nil {
+		return false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > cacheMaxAge(bucket) {
+		return false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	return json.Unmarshal(data, v) == nil
+}
+
+// and failing to write it isn't worth failing the run over
+func writeCache(bucket, key string, v interface{}) {
+	if !useCache {
+		return
+	}
+
+	path, err := cachePath(bucket, key)
+	if err != nil {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return
+	}
+
+	// so a reader never sees half an entry, with a temp file of its own so
+	// two writers of the same entry don't write into each other's
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+		return
+	}
+
+	err = os.Rename(f.Name(), path)
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	sweepCache()
+}
+
+var cacheBuckets = []string{"geocode", "points", "gridpoints"}
+
+// how old an entry in bucket can be before it's a miss, and swept
+func cacheMaxAge(bucket string) time.Duration {
+	if bucket != "gridpoints" {
+		return lookupCacheTTL
+	}
+
+	// a forecast is only shown for as long as -max-stale allows, but a
+	// week leaves it for a run that allows more than this one
+	if forecastMaxStale > 7*24*time.Hour {
+		return forecastMaxStale
+	}
+
+	return 7 * 24 * time.Hour
+}
+
+// how often the cache is swept, which is noted by touching this file in it
+const (
+	cacheSweepInterval = 24 * time.Hour
+	cacheSweepStamp    = "swept"
+)
+
+// removes what's too old to be read, and temp files a writer didn't get to
+// rename, so the cache doesn't grow forever. it's only done once a day,
+// after a write, and like the rest of the cache, anything going wrong is
+// ignored.
+func sweepCache() {
+	dir, err := cacheDir()
+	if err != nil {
+		return
+	}
+
+	stamp := filepath.Join(dir, cacheSweepStamp)
+
+	info, err := os.Stat(stamp)
+	if err == nil && time.Since(info.ModTime()) < cacheSweepInterval {
+		return
+	}
+
+	// before sweeping, so runs at the same time don't all do it
+	now := time.Now()
+	if os.WriteFile(stamp, nil, 0o644) != nil || os.Chtimes(stamp, now, now) != nil {
+		return
+	}
+
+	for _, bucket := range cacheBuckets {
+		entries, err := os.ReadDir(filepath.Join(dir, bucket))
+		if err != nil {
+			continue
+		}
+
+		maxAge := cacheMaxAge(bucket)
+
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+
+			age := time.Since(info.ModTime())
+			if age > maxAge || (strings.HasSuffix(e.Name(), ".tmp") && age > time.Hour) {
+				os.Remove(filepath.Join(dir, bucket, e.Name()))
+			}
+		}
+	}
+}
+
+type cachedCoordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// "123 Main St" and "123 main st " are the same place, but not necessarily
+// in another benchmark or vintage of the Census's data
+func geocodeCacheKey(address string) string {
+	key := strings.ToLower(strings.Join(strings.Fields(address), " "))
+	if geocodeBenchmark != "" || geocodeVintage != "" {
+		key += "\x00" + geocodeBenchmark + "\x00" + geocodeVintage
+	}
+
+	return key
+}
+
+type cachedGridPoint struct {
+	Office              string `json:"office"`
+	X                   int    `json:"x"`
+	Y                   int    `json:"y"`
+	ForecastGridDataURL string `json:"forecastGridDataURL"`
+	ForecastURL         string `json:"forecastURL,omitempty"`
+	ObservationStations string `json:"observationStations"`
+	RadarStation        string `json:"radarStation"`
+	ForecastZone        string `json:"forecastZone"`
+}
+
+func newCachedGridPoint(g gridPoint) cachedGridPoint {
+	return cachedGridPoint{
+		Office:              g.office,
+		X:                   g.x,
+		Y:                   g.y,
+		ForecastGridDataURL: g.forecastGridDataURL,
+		ForecastURL:         g.forecastURL,
+		ObservationStations: g.observationStations,
+		RadarStation:        g.radarStation,
+		ForecastZone:        g.forecastZone,
+	}
+}
+
+func (c cachedGridPoint) gridPoint() gridPoint {
+	return gridPoint{
+		office:              c.Office,
+		x:                   c.X,
+		y:                   c.Y,
+		forecastGridDataURL: c.ForecastGridDataURL,
+		forecastURL:         c.ForecastURL,
+		observationStations: c.ObservationStations,
+		radarStation:        c.RadarStation,
+		forecastZone:        c.ForecastZone,
+	}
+}
+
+// a grid's forecast, only the layers that have been asked for since it was
+// fetched, decoded, since the response is mostly layers nobody wants and
+// isn't read past the last one that is, along with what it takes to ask
+// upstream whether it's changed
+type cachedGrid struct {
+	FetchedAt    time.Time            `json:"fetchedAt"`
+	ETag         string               `json:"etag,omitempty"`
+	LastModified string               `json:"lastModified,omitempty"`
+	UpdateTime   time.Time            `json:"updateTime"`
+	Cell         [][2]float64         `json:"cell"`
+	Elevation    *float64             `json:"elevation,omitempty"`
+	Layers       map[string]nws.Layer `json:"layers"`
+}
+
+func newCachedGrid(grid nws.Grid, res *http.Response) cachedGrid {
+	return cachedGrid{
+		FetchedAt:    time.Now(),
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		UpdateTime:   grid.UpdateTime,
+		Cell:         grid.Cell,
+		Elevation:    grid.Elevation,
+		Layers:       grid.Layers,
+	}
+}
+
+func (c cachedGrid) grid() nws.Grid {
+	return nws.Grid{UpdateTime: c.UpdateTime, Cell: c.Cell, Elevation: c.Elevation, Layers: c.Layers}
+}
+
+// whether it has every one of properties
+func (c cachedGrid) has(properties []string) bool {
+	for _, name := range properties {
+		if _, ok := c.Layers[name]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// properties, and whatever else it already has, so asking for something
+// new doesn't lose what was asked for before
+func (c cachedGrid) layersWith(properties []string) []string {
+	layers := append([]string{}, properties...)
+	for name := range c.Layers {
+		if indexOf(layers, name) < 0 {
+			layers = append(layers, name)
+		}
+	}
+
+	sort.Strings(layers)
+
+	return layers
+}
+
+func (c cachedGrid) fresh() bool {
+	return c.FetchedAt.After(cacheNotBefore) && time.Since(c.FetchedAt) < forecastCacheTTL && c.usable()
+}
+
+// better than nothing when upstream is down, as long as it's not too old
+func (c cachedGrid) usable() bool {
+	return time.Since(c.FetchedAt) < forecastMaxStale
+}
+
+// so a table from the cache doesn't pass for one just downloaded, or "" if
+// the forecast came from upstream
+func cacheBanner(f gridForecast, loc *time.Location) string {
+	if f.cachedAt.IsZero() {
+		return ""
+	}
+
+	age := "under a minute"
+	if d := time.Since(f.cachedAt).Round(time.Minute); d >= time.Minute {
+		age = formatLead(d)
+	}
+
+	banner := fmt.Sprintf("cached: fetched %s ago", age)
+
+	if !f.updateTime.IsZero() {
+		banner += ", issued " + f.updateTime.In(loc).Format(time.Stamp)
+	}
+
+	if f.offline {
+		banner += ", upstream unreachable"
+	}
+
+	return banner
+}
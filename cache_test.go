@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGeocodeCacheKeyVintage(t *testing.T) {
+	defer func(b, v string) { geocodeBenchmark, geocodeVintage = b, v }(geocodeBenchmark, geocodeVintage)
+
+	geocodeBenchmark, geocodeVintage = "", ""
+	current := geocodeCacheKey("123 Main St")
+
+	if got := geocodeCacheKey(" 123  main st"); got != current {
+		t.Errorf("the same address is %q and %q", got, current)
+	}
+
+	geocodeBenchmark, geocodeVintage = "Public_AR_Census2020", "Census2020_Census2020"
+	if got := geocodeCacheKey("123 Main St"); got == current {
+		t.Errorf("another vintage shares the key %q", got)
+	}
+}
+
+func TestSweepCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	writeCache("geocode", "fresh", cachedCoordinates{})
+	writeCache("geocode", "old", cachedCoordinates{})
+	writeCache("gridpoints", "old", cachedGrid{})
+
+	path := func(bucket, key string) string {
+		p, err := cachePath(bucket, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return p
+	}
+
+	age := func(p string, d time.Duration) {
+		at := time.Now().Add(-d)
+		if err := os.Chtimes(p, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	age(path("geocode", "old"), lookupCacheTTL+time.Hour)
+	age(path("gridpoints", "old"), cacheMaxAge("gridpoints")+time.Hour)
+
+	tmp := path("points", "x") + ".123.tmp"
+	if err := os.MkdirAll(filepath.Dir(tmp), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(tmp, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	age(tmp, 2*time.Hour)
+
+	if readCache("geocode", "old", &cachedCoordinates{}) {
+		t.Errorf("read an entry past its TTL")
+	}
+
+	// the writes above swept before anything was old, so it's due again
+	dir, _ := cacheDir()
+	age(filepath.Join(dir, cacheSweepStamp), cacheSweepInterval+time.Hour)
+
+	sweepCache()
+
+	for p, kept := range map[string]bool{
+		path("geocode", "fresh"):  true,
+		path("geocode", "old"):    false,
+		path("gridpoints", "old"): false,
+		tmp:                       false,
+	} {
+		if _, err := os.Stat(p); (err == nil) != kept {
+			t.Errorf("%s kept is %t, wanted %t", p, err == nil, kept)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the parts of a Common Alerting Protocol 1.2 message worth showing, which
+// has everything the summary list leaves out
+type capAlert struct {
+	Identifier string    `xml:"identifier"`
+	Sender     string    `xml:"sender"`
+	Sent       time.Time `xml:"sent"`
+	Status     string    `xml:"status"`
+	MsgType    string    `xml:"msgType"`
+	References string    `xml:"references"`
+	Info       []capInfo `xml:"info"`
+}
+
+type capInfo struct {
+	Event       string     `xml:"event"`
+	Urgency     string     `xml:"urgency"`
+	Severity    string     `xml:"severity"`
+	Certainty   string     `xml:"certainty"`
+	Onset       time.Time  `xml:"onset"`
+	Expires     time.Time  `xml:"expires"`
+	SenderName  string     `xml:"senderName"`
+	Headline    string     `xml:"headline"`
+	Description string     `xml:"description"`
+	Instruction string     `xml:"instruction"`
+	Areas       []capArea  `xml:"area"`
+	Parameters  []capValue `xml:"parameter"`
+}
+
+type capArea struct {
+	AreaDesc string     `xml:"areaDesc"`
+	Polygons []string   `xml:"polygon"`
+	Geocodes []capValue `xml:"geocode"`
+}
+
+type capValue struct {
+	Name  string `xml:"valueName"`
+	Value string `xml:"value"`
+}
+
+func getCAPAlert(id string) (capAlert, error) {
+	queryURL := &url.URL{
+		Scheme: "https",
+		Host:   "api.weather.gov",
+		Path:   "/alerts/" + id,
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return capAlert{}, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/cap+xml")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return capAlert{}, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return capAlert{}, fmt.Errorf("no alert with id '%s'", id)
+	}
+
+	return parseCAPAlert(res.Body)
+}
+
+func parseCAPAlert(r io.Reader) (capAlert, error) {
+	a := capAlert{}
+
+	err := xml.NewDecoder(r).Decode(&a)
+	if err != nil {
+		return capAlert{}, fmt.Errorf("could not parse CAP alert: %w", err)
+	}
+
+	return a, nil
+}
+
+// CAP polygons are space separated "lat,lon" pairs, the other way around
+// from GeoJSON
+func parseCAPPolygon(s string) ([]coordinates, error) {
+	ring := []coordinates{}
+
+	for _, pair := range strings.Fields(s) {
+		split := strings.Split(pair, ",")
+		if len(split) != 2 {
+			return nil, fmt.Errorf("malformed polygon point '%s'", pair)
+		}
+
+		lat, err := strconv.ParseFloat(split[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed polygon latitude '%s': %w", split[0], err)
+		}
+
+		lon, err := strconv.ParseFloat(split[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed polygon longitude '%s': %w", split[1], err)
+		}
+
+		ring = append(ring, coordinates{latitude: lat, longitude: lon})
+	}
+
+	return ring, nil
+}
+
+func displayCAPAlert(a capAlert, loc *time.Location) {
+	fmt.Println("id: ", a.Identifier)
+	fmt.Println("sender: ", a.Sender)
+	fmt.Println("sent: ", a.Sent.In(loc).Format(time.Stamp))
+	fmt.Println("status: ", a.Status+" "+a.MsgType)
+
+	if a.References != "" {
+		fmt.Println("references:")
+
+		// each reference is sender,identifier,sent
+		for _, ref := range strings.Fields(a.References) {
+			split := strings.Split(ref, ",")
+			if len(split) == 3 {
+				fmt.Printf("  %s (sent %s)\n", split[1], split[2])
+			} else {
+				fmt.Printf("  %s\n", ref)
+			}
+		}
+	}
+
+	for _, info := range a.Info {
+		fmt.Println()
+		fmt.Println(info.Headline)
+		fmt.Println()
+		fmt.Printf("%s: %s severity, %s urgency, %s certainty\n", info.Event, info.Severity, info.Urgency, info.Certainty)
+		fmt.Printf("from %s until %s\n", info.Onset.In(loc).Format(time.Stamp), info.Expires.In(loc).Format(time.Stamp))
+
+		if info.Description != "" {
+			fmt.Println()
+			fmt.Println(strings.TrimSpace(info.Description))
+		}
+
+		if info.Instruction != "" {
+			fmt.Println()
+			fmt.Println("instructions:")
+			fmt.Println(strings.TrimSpace(info.Instruction))
+		}
+
+		for _, area := range info.Areas {
+			fmt.Println()
+			fmt.Println("area: ", area.AreaDesc)
+
+			zones := []string{}
+			for _, g := range area.Geocodes {
+				if g.Name == "UGC" {
+					zones = append(zones, g.Value)
+				}
+			}
+
+			if len(zones) > 0 {
+				fmt.Println("zones: ", strings.Join(zones, ", "))
+			}
+
+			for _, p := range area.Polygons {
+				ring, err := parseCAPPolygon(p)
+				if err != nil {
+					fmt.Println("polygon: ", err)
+					continue
+				}
+
+				corners := []string{}
+				for _, c := range ring {
+					corners = append(corners, c.String())
+				}
+
+				fmt.Println("polygon: ", strings.Join(corners, " "))
+			}
+		}
+	}
+}
+
+func runAlertShow(flagset *flag.FlagSet) func() {
+	var displaytz string
+
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display alert times")
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if flagset.NArg() != 1 {
+			errorAndQuit(fmt.Errorf("expected exactly one alert id, as shown by 'agwc alerts -ids'"))
+		}
+
+		a, err := getCAPAlert(flagset.Arg(0))
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		displayCAPAlert(a, loc)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// eighths of a cell, from empty to full, so a chart of a few lines still
+// shows small changes
+var chartBlocks = []rune(" ▁▂▃▄▅▆▇█")
+
+const chartMissing = '·'
+
+// maps values onto a number of steps, from low at 0 to high at steps
+type chartScale struct {
+	low  float64
+	high float64
+}
+
+// percentages have a natural scale, anything else is scaled to what's in
+// the window, and ok is false if there's nothing to scale
+func newChartScale(name string, values []*float64) (chartScale, bool) {
+	if kind, known := propertyRegistry[name]; known && (kind == kindProbability || kind == kindPercentage) {
+		return chartScale{low: 0, high: 100}, true
+	}
+
+	s := chartScale{low: math.Inf(1), high: math.Inf(-1)}
+	for _, v := range values {
+		if v != nil {
+			s.low, s.high = math.Min(s.low, *v), math.Max(s.high, *v)
+		}
+	}
+
+	return s, !math.IsInf(s.low, 1)
+}
+
+// how many of steps v reaches, at least one so the lowest value still
+// shows up
+func (s chartScale) steps(v float64, steps int) int {
+	if s.high <= s.low {
+		return steps
+	}
+
+	n := 1 + int(math.Round((v-s.low)/(s.high-s.low)*float64(steps-1)))
+	if n < 1 {
+		n = 1
+	}
+
+	if n > steps {
+		n = steps
+	}
+
+	return n
+}
+
+// a panel per visible column with an hour to a character, height lines
+// tall, where a height of 1 is a sparkline
+func displayCharts(w io.Writer, req forecastRequest, rows []displayRow) {
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "no hours match your criteria in the requested window")
+		quit(exitNoRows)
+	}
+
+	// -sort doesn't mean anything on a time axis
+	rows = append([]displayRow{}, rows...)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].at.Before(rows[j].at) })
+
+	columns := req.columns()
+
+	for n, i := range req.visibleColumns() {
+		if n > 0 {
+			fmt.Fprintln(w)
+		}
+
+		values := []*float64{}
+		for _, r := range rows {
+			values = append(values, r.numbers[i])
+		}
+
+		drawChart(w, req, columns[i], rows, values)
+	}
+}
+
+func drawChart(w io.Writer, req forecastRequest, name string, rows []displayRow, values []*float64) {
+	fmt.Fprintf(w, "%s%s, %s\n", name, heatmapUnit(req, name), req.displayTimeZone)
+
+	scale, ok := newChartScale(name, values)
+	if !ok {
+		fmt.Fprintln(w, "no data in the requested window")
+		return
+	}
+
+	precision := kindPrecision[propertyRegistry[name]]
+
+	low, high := -1, -1
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+
+		if low < 0 || *v < *values[low] {
+			low = i
+		}
+
+		if high < 0 || *v > *values[high] {
+			high = i
+		}
+	}
+
+	at := func(i int) string {
+		return rows[i].at.In(req.displayTimeZone).Format("Mon 15:04")
+	}
+
+	top, bottom := formatNumber(scale.high, precision), formatNumber(scale.low, precision)
+
+	gutter := len(top)
+	if len(bottom) > gutter {
+		gutter = len(bottom)
+	}
+
+	height := req.chartHeight
+	levels := height * (len(chartBlocks) - 1)
+
+	for line := height - 1; line >= 0; line-- {
+		// a sparkline has the min and max below it instead
+		label := ""
+		switch {
+		case height == 1:
+		case line == height-1:
+			label = top
+		case line == 0:
+			label = bottom
+		}
+
+		var b strings.Builder
+
+		for _, v := range values {
+			if v == nil {
+				if line == 0 {
+					b.WriteRune(chartMissing)
+				} else {
+					b.WriteRune(' ')
+				}
+
+				continue
+			}
+
+			filled := scale.steps(*v, levels) - line*(len(chartBlocks)-1)
+			if filled < 0 {
+				filled = 0
+			}
+
+			if filled > len(chartBlocks)-1 {
+				filled = len(chartBlocks) - 1
+			}
+
+			b.WriteRune(chartBlocks[filled])
+		}
+
+		fmt.Fprintf(w, "%*s ┤%s\n", gutter, label, strings.TrimRight(b.String(), " "))
+	}
+
+	fmt.Fprintf(w, "%*s └%s\n", gutter, "", strings.Repeat("─", len(values)))
+	fmt.Fprintf(w, "%*s  %s\n", gutter, "", chartAxis(req.displayTimeZone, rows))
+
+	fmt.Fprintf(
+		w, "%*s  min %s at %s, max %s at %s\n", gutter, "",
+		formatNumber(*values[low], precision), at(low), formatNumber(*values[high], precision), at(high),
+	)
+}
+
+// the day at each midnight and the hour every six hours in between, as
+// long as they don't run into each other
+func chartAxis(loc *time.Location, rows []displayRow) string {
+	axis := []rune(strings.Repeat(" ", len(rows)+10))
+	next := 0
+
+	for i, r := range rows {
+		t := r.at.In(loc)
+		if t.Hour()%6 != 0 || i < next {
+			continue
+		}
+
+		label := t.Format("15")
+		if t.Hour() == 0 {
+			label = t.Format("Mon")
+		}
+
+		copy(axis[i:], []rune(label))
+		next = i + len(label) + 1
+	}
+
+	return strings.TrimRight(string(axis), " ")
+}
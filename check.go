@@ -0,0 +1,213 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// check exits like test(1): 0 when the assertion holds, 1 when it doesn't,
+// and something else when we couldn't tell
+const exitCheckError = 2
+
+type checkRequest struct {
+	address   string
+	assertion assertion
+	freedom   bool
+	explain   bool
+}
+
+// e.g. "probabilityOfPrecipitation<20 for next 4h" or "temperature>0 until
+// sunrise", which must hold for every hour from now until the end of the window
+type assertion struct {
+	property  string
+	operator  string
+	threshold float64
+	window    time.Duration
+	until     string
+}
+
+var assertionMatcher = regexp.MustCompile(`^\s*(?P<property>[A-Za-z]+)\s*(?P<operator><=|>=|==|!=|<|>)\s*(?P<threshold>-?[\d.]+)\s*(for\s+next\s+(?P<window>\S+)|until\s+(?P<until>\S+))?\s*$`)
+
+func runCheck(flagset *flag.FlagSet) func() {
+	request := checkFlags(flagset)
+
+	return func() {
+		req, err := request()
+		if err != nil {
+			checkErrorAndQuit(err)
+		}
+
+		coordinates, err := getAddressCoordinates(req.address)
+		if err != nil {
+			checkErrorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			checkErrorAndQuit(err)
+		}
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, []string{req.assertion.property})
+		if err != nil {
+			checkErrorAndQuit(err)
+		}
+
+		now := time.Now()
+
+		end, err := req.assertion.end(now, coordinates)
+		if err != nil {
+			checkErrorAndQuit(err)
+		}
+
+		at, ok := req.assertion.firstFailure(forecast.properties[req.assertion.property], now, end, req.freedom)
+		if !ok {
+			if req.explain {
+				fmt.Fprintf(os.Stderr, "assertion does not hold at %s\n", at.Format(time.RFC3339))
+			}
+
+			quit(1)
+		}
+	}
+}
+
+func checkErrorAndQuit(err error) {
+	fmt.Println("agcw encountered an error: ", err.Error())
+	quit(exitCheckError)
+}
+
+func checkFlags(flagset *flag.FlagSet) func() (checkRequest, error) {
+	var (
+		queryAddress string
+		assert       string
+		freedom      bool
+		explain      bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address at which to see the weather")
+	flagset.StringVar(&assert, "assert", "", "condition to check, e.g. 'probabilityOfPrecipitation<20 for next 4h' or 'temperature>0 until sunrise'")
+	// thresholds are in these units, so metric unless asked otherwise
+	unitSystemFlags(flagset, &freedom, "metric")
+	flagset.BoolVar(&explain, "explain", false, "print the first hour at which the assertion fails")
+	flagset.BoolVar(&strictDecode, "strict-decode", false, "fail on unexpected or missing fields in upstream responses instead of warning")
+
+	return func() (checkRequest, error) {
+		if queryAddress == "" {
+			return checkRequest{}, fmt.Errorf("address cannot be empty")
+		}
+
+		a, err := parseAssertion(assert)
+		if err != nil {
+			return checkRequest{}, err
+		}
+
+		return checkRequest{
+			address:   queryAddress,
+			assertion: a,
+			freedom:   freedom,
+			explain:   explain,
+		}, nil
+	}
+}
+
+func parseAssertion(s string) (assertion, error) {
+	matches := assertionMatcher.FindStringSubmatch(s)
+	if len(matches) == 0 {
+		return assertion{}, fmt.Errorf("'%s' is not a valid assertion, expected something like 'temperature>0 for next 6h'", s)
+	}
+
+	a := assertion{
+		property: matches[assertionMatcher.SubexpIndex("property")],
+		operator: matches[assertionMatcher.SubexpIndex("operator")],
+	}
+
+	if _, ok := propertyRegistry[a.property]; !ok {
+		return assertion{}, fmt.Errorf("asserted property '%s' is not in %v", a.property, permittedProperties())
+	}
+
+	thresholdstr := matches[assertionMatcher.SubexpIndex("threshold")]
+
+	var err error
+	a.threshold, err = strconv.ParseFloat(thresholdstr, 64)
+	if err != nil {
+		return assertion{}, fmt.Errorf("could not parse threshold '%s': %w", thresholdstr, err)
+	}
+
+	if windowstr := matches[assertionMatcher.SubexpIndex("window")]; windowstr != "" {
+		a.window, err = time.ParseDuration(windowstr)
+		if err != nil {
+			return assertion{}, fmt.Errorf("could not parse window '%s': %w", windowstr, err)
+		}
+	}
+
+	if untilstr := matches[assertionMatcher.SubexpIndex("until")]; untilstr != "" {
+		if !isTimeExpression(untilstr) {
+			return assertion{}, fmt.Errorf("'%s' is not a valid time, expected something like 'sunset' or '+6h'", untilstr)
+		}
+
+		a.until = untilstr
+	}
+
+	return a, nil
+}
+
+func (a assertion) end(now time.Time, c coordinates) (time.Time, error) {
+	if a.until != "" {
+		end, err := resolveTimeExpression(a.until, now, c, time.Local)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		// "until sunrise" in the afternoon means tomorrow's
+		if end.Before(now) {
+			end = end.AddDate(0, 0, 1)
+		}
+
+		return end, nil
+	}
+
+	return now.Add(a.window), nil
+}
+
+// hours without data count as failures, since we can't vouch for them
+func (a assertion) firstFailure(points series, now time.Time, end time.Time, freedom bool) (time.Time, bool) {
+	// a zero length window only checks right now
+	for at := now; at.Equal(now) || at.Before(end); at = at.Add(time.Hour) {
+		p, ok := findPointAt(points, at)
+		if !ok || p.Value == nil {
+			return at, false
+		}
+
+		if freedom {
+			p = liberate(p)
+		}
+
+		if !a.holds(*p.Value) {
+			return at, false
+		}
+	}
+
+	return time.Time{}, true
+}
+
+func (a assertion) holds(v float64) bool {
+	switch a.operator {
+	case "<":
+		return v < a.threshold
+	case "<=":
+		return v <= a.threshold
+	case ">":
+		return v > a.threshold
+	case ">=":
+		return v >= a.threshold
+	case "==":
+		return v == a.threshold
+	case "!=":
+		return v != a.threshold
+	default:
+		return false
+	}
+}
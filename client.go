@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/packrat386/agwc/geocode"
+	"github.com/packrat386/agwc/nws"
+)
+
+// every upstream request goes through this one client so that connections
+// to the same host get reused, which adds up when fetching a lot of grid
+// cells or stations in one run
+var httpClient = newHTTPClient(defaultTransportSettings)
+
+// api.weather.gov wants to know who's asking, and now and then turns away
+// requests that don't say
+const defaultUserAgent = "agwc (https://github.com/packrat386/agwc)"
+
+type transportSettings struct {
+	userAgent           string
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+	timeout             time.Duration
+	keepAlive           bool
+	http2               bool
+}
+
+var defaultTransportSettings = transportSettings{
+	userAgent:           defaultUserAgent,
+	maxIdleConnsPerHost: 16,
+	idleConnTimeout:     90 * time.Second,
+	timeout:             30 * time.Second,
+	keepAlive:           true,
+	http2:               true,
+}
+
+func newHTTPClient(s transportSettings) *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   s.maxIdleConnsPerHost,
+		IdleConnTimeout:       s.idleConnTimeout,
+		DisableKeepAlives:     !s.keepAlive,
+		ForceAttemptHTTP2:     s.http2,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	// a non-nil empty map is how you tell the transport not to upgrade
+	if !s.http2 {
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &http.Client{Transport: newFetchCoordinator(userAgentTransport{next: transport, userAgent: s.userAgent}), Timeout: s.timeout}
+}
+
+// what the clients for the nws and geocode packages introduce themselves as
+var userAgent = defaultUserAgent
+
+// so requests made here directly, and not through one of the clients,
+// introduce themselves too
+type userAgentTransport struct {
+	next      http.RoundTripper
+	userAgent string
+}
+
+func (t userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+
+	return t.next.RoundTrip(req)
+}
+
+// the clients share the one HTTP client, and pick up -strict-decode
+func nwsClient() *nws.Client {
+	c := nws.NewClient(userAgent)
+	c.HTTPClient = httpClient
+	c.Strict = strictDecode
+
+	return c
+}
+
+func geocoder() *geocode.Client {
+	c := geocode.NewClient(userAgent)
+	c.HTTPClient = httpClient
+	c.Benchmark = geocodeBenchmark
+	c.Vintage = geocodeVintage
+
+	return c
+}
+
+// several parts of a run can want the same thing, like the station list for
+// both -past and -records, so a GET is shared with anyone asking for it
+// within fetchShareWindow, and anyone asking while one is in flight waits
+// for it rather than asking again. the window keeps long running processes
+// like serve and the daemon from holding on to every response, or to a
+// stale forecast, without each caller having to remember fresh.
+type fetchCoordinator struct {
+	next http.RoundTripper
+
+	mu      sync.Mutex
+	fetches map[string]*fetch
+}
+
+// long enough to cover one run, or one request to serve
+const fetchShareWindow = 30 * time.Second
+
+type fetch struct {
+	done chan struct{}
+	res  *http.Response
+	body []byte
+	err  error
+
+	// when it finished, zero until then
+	at time.Time
+}
+
+func (f *fetch) expired(now time.Time) bool {
+	return !f.at.IsZero() && now.Sub(f.at) > fetchShareWindow
+}
+
+func newFetchCoordinator(next http.RoundTripper) *fetchCoordinator {
+	return &fetchCoordinator{next: next, fetches: map[string]*fetch{}}
+}
+
+type freshKey struct{}
+
+// for requests that have to see the latest upstream, like polling, rather
+// than whatever was fetched earlier in the run
+func fresh(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), freshKey{}, true))
+}
+
+type streamedKey struct{}
+
+// for responses too big to hold on to that whoever asked only reads part
+// of, like the gridpoint forecast, so they go straight through to them
+// rather than being read in full to share
+func streamed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamedKey{}, true)
+}
+
+func (fc *fetchCoordinator) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Context().Value(freshKey{}) != nil || req.Context().Value(streamedKey{}) != nil {
+		return fc.next.RoundTrip(req)
+	}
+
+	// conditional requests can get a 304 that only makes sense to whoever
+	// asked with the same validators
+	key := strings.Join([]string{req.URL.String(), req.Header.Get("Accept"), req.Header.Get("If-None-Match"), req.Header.Get("If-Modified-Since")}, " ")
+
+	now := time.Now()
+
+	fc.mu.Lock()
+	f, ok := fc.fetches[key]
+	if !ok || f.expired(now) {
+		ok = false
+
+		// whatever else has expired goes too, so the map only ever holds
+		// the last window's fetches
+		for k, other := range fc.fetches {
+			if other.expired(now) {
+				delete(fc.fetches, k)
+			}
+		}
+
+		f = &fetch{done: make(chan struct{})}
+		fc.fetches[key] = f
+	}
+	fc.mu.Unlock()
+
+	if !ok {
+		res, body, err := fc.fetch(req)
+
+		fc.mu.Lock()
+		f.res, f.body, f.err = res, body, err
+		f.at = time.Now()
+
+		// only successes are worth sharing, anyone after a failure gets to
+		// try again
+		if f.err != nil || f.res.StatusCode >= 300 {
+			if fc.fetches[key] == f {
+				delete(fc.fetches, key)
+			}
+		}
+		fc.mu.Unlock()
+
+		close(f.done)
+	}
+
+	select {
+	case <-f.done:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	res := *f.res
+	res.Body = io.NopCloser(bytes.NewReader(f.body))
+	res.Request = req
+
+	return &res, nil
+}
+
+// drops everything fetched so far, for long running sessions that want to
+// see what's changed upstream
+func (fc *fetchCoordinator) forget() {
+	fc.mu.Lock()
+	fc.fetches = map[string]*fetch{}
+	fc.mu.Unlock()
+}
+
+// forgets this run's fetches and treats the cached forecasts as stale, so
+// the next request goes upstream
+func forgetFetches() {
+	if fc, ok := httpClient.Transport.(*fetchCoordinator); ok {
+		fc.forget()
+	}
+
+	cacheNotBefore = time.Now()
+}
+
+func (fc *fetchCoordinator) fetch(req *http.Request) (*http.Response, []byte, error) {
+	res, err := fc.next.RoundTrip(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return res, body, nil
+}
+
+// overrides from the config file, where anything left out keeps the default
+type httpConfig struct {
+	UserAgent           string `json:"userAgent,omitempty"`
+	MaxIdleConnsPerHost int    `json:"maxIdleConnsPerHost,omitempty"`
+	IdleConnTimeout     string `json:"idleConnTimeout,omitempty"`
+	Timeout             string `json:"timeout,omitempty"`
+	DisableKeepAlive    bool   `json:"disableKeepAlive,omitempty"`
+	DisableHTTP2        bool   `json:"disableHTTP2,omitempty"`
+}
+
+func (c httpConfig) settings() (transportSettings, error) {
+	s := defaultTransportSettings
+
+	if c.UserAgent != "" {
+		s.userAgent = c.UserAgent
+	}
+
+	if c.MaxIdleConnsPerHost < 0 {
+		return transportSettings{}, fmt.Errorf("maxIdleConnsPerHost cannot be negative, got %d", c.MaxIdleConnsPerHost)
+	}
+
+	if c.MaxIdleConnsPerHost > 0 {
+		s.maxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+
+	if c.IdleConnTimeout != "" {
+		d, err := time.ParseDuration(c.IdleConnTimeout)
+		if err != nil {
+			return transportSettings{}, fmt.Errorf("could not parse idleConnTimeout: %w", err)
+		}
+
+		s.idleConnTimeout = d
+	}
+
+	if c.Timeout != "" {
+		d, err := time.ParseDuration(c.Timeout)
+		if err != nil {
+			return transportSettings{}, fmt.Errorf("could not parse timeout: %w", err)
+		}
+
+		s.timeout = d
+	}
+
+	s.keepAlive = !c.DisableKeepAlive
+	s.http2 = !c.DisableHTTP2
+
+	return s, nil
+}
+
+func configureHTTPClient(cfg config) error {
+	s, err := cfg.HTTP.settings()
+	if err != nil {
+		return fmt.Errorf("invalid http config: %w", err)
+	}
+
+	httpClient = newHTTPClient(s)
+	userAgent = s.userAgent
+
+	return nil
+}
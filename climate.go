@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// how close to a record a forecast has to be before we mention it, in F
+const recordApproachMargin = 2.0
+
+type dailyRecord struct {
+	high     *float64
+	highYear string
+	low      *float64
+	lowYear  string
+}
+
+// asks ACIS for the highest max and lowest min temperature recorded at the
+// station on this calendar day across its whole period of record
+func getDailyRecord(stationID string, day time.Time) (dailyRecord, error) {
+	elem := func(name, reduce string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":     name,
+			"interval": []int{1, 0, 0},
+			"duration": 1,
+			"smry": map[string]interface{}{
+				"reduce": reduce,
+				"add":    "date",
+			},
+			"smry_only": 1,
+		}
+	}
+
+	params := map[string]interface{}{
+		// ACIS wants type 5 to look stations up by ICAO identifier
+		"sid":   stationID + " 5",
+		"sdate": fmt.Sprintf("1850-%s", day.Format("01-02")),
+		"edate": day.AddDate(-1, 0, 0).Format("2006-01-02"),
+		"elems": []interface{}{elem("maxt", "max"), elem("mint", "min")},
+	}
+
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return dailyRecord{}, fmt.Errorf("could not encode ACIS request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", "https://data.rcc-acis.org/StnData", bytes.NewReader(payload))
+	if err != nil {
+		return dailyRecord{}, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return dailyRecord{}, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	body := struct {
+		Error string      `json:"error"`
+		Smry  [][2]string `json:"smry"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return dailyRecord{}, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	if body.Error != "" {
+		return dailyRecord{}, fmt.Errorf("climate data unavailable for %s: %s", stationID, body.Error)
+	}
+
+	if len(body.Smry) != 2 {
+		return dailyRecord{}, fmt.Errorf("unexpected climate summary for %s", stationID)
+	}
+
+	r := dailyRecord{}
+	r.high, r.highYear = parseRecordValue(body.Smry[0])
+	r.low, r.lowYear = parseRecordValue(body.Smry[1])
+
+	return r, nil
+}
+
+// ACIS uses "M" for missing and "T" for trace, and dates as YYYY-MM-DD
+func parseRecordValue(v [2]string) (*float64, string) {
+	f, err := strconv.ParseFloat(v[0], 64)
+	if err != nil {
+		return nil, ""
+	}
+
+	year := v[1]
+	if len(year) >= 4 {
+		year = year[:4]
+	}
+
+	return &f, year
+}
+
+type dailyExtremes struct {
+	day  time.Time
+	high *float64
+	low  *float64
+}
+
+// the forecast high and low for each calendar day in the window, in F
+func forecastDailyExtremes(req forecastRequest, temperatures series) []dailyExtremes {
+	days := []dailyExtremes{}
+
+	for curr := req.start.Truncate(time.Hour); !curr.After(req.end); curr = curr.Add(time.Hour) {
+		p, ok := findPointAt(temperatures, curr)
+		if !ok || p.Value == nil {
+			continue
+		}
+
+		f := unitSystems["us"].convert(p)
+
+		local := curr.In(req.displayTimeZone)
+		day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, req.displayTimeZone)
+
+		if len(days) == 0 || !days[len(days)-1].day.Equal(day) {
+			days = append(days, dailyExtremes{day: day})
+		}
+
+		d := &days[len(days)-1]
+
+		if d.high == nil || *f.Value > *d.high {
+			d.high = f.Value
+		}
+
+		if d.low == nil || *f.Value < *d.low {
+			d.low = f.Value
+		}
+	}
+
+	return days
+}
+
+// lines worth printing for days that come near or beat a record
+func recordNotes(req forecastRequest, days []dailyExtremes, stationID string) []string {
+	notes := []string{}
+
+	for _, d := range days {
+		r, err := getDailyRecord(stationID, d.day)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("%s: %s", d.day.Format("Jan 02"), err.Error()))
+			continue
+		}
+
+		if d.high != nil && r.high != nil && *d.high >= *r.high-recordApproachMargin {
+			notes = append(notes, fmt.Sprintf(
+				"%s: forecast high %s, record %s set %s (%s)",
+				d.day.Format("Jan 02"), formatFahrenheit(req, *d.high), formatFahrenheit(req, *r.high), r.highYear, recordVerdict(*d.high-*r.high),
+			))
+		}
+
+		if d.low != nil && r.low != nil && *d.low <= *r.low+recordApproachMargin {
+			notes = append(notes, fmt.Sprintf(
+				"%s: forecast low %s, record %s set %s (%s)",
+				d.day.Format("Jan 02"), formatFahrenheit(req, *d.low), formatFahrenheit(req, *r.low), r.lowYear, recordVerdict(*r.low-*d.low),
+			))
+		}
+	}
+
+	return notes
+}
+
+// by is how far past the record the forecast goes, negative if it falls short
+func recordVerdict(by float64) string {
+	switch {
+	case by > 0:
+		return "would break the record"
+	case by == 0:
+		return "would tie the record"
+	default:
+		return "approaching the record"
+	}
+}
+
+func fahrenheitToCelsius(v float64) *float64 {
+	c := (v - 32) * 5.0 / 9.0
+
+	return &c
+}
+
+func formatFahrenheit(req forecastRequest, v float64) string {
+	return formatWeatherValue("temperature", req.liberate(weatherPoint{Value: fahrenheitToCelsius(v), Unit: "wmoUnit:degC"}), false)
+}
+
+// the station records are checked at, and how close the forecast gets to
+// them, none if it doesn't
+func getRecords(req forecastRequest, grid gridPoint, c coordinates, forecast gridForecast) (station, []string, error) {
+	stations, err := getStations(grid, c)
+	if err != nil {
+		return station{}, nil, err
+	}
+
+	s, err := selectStation(stations, req.station)
+	if err != nil {
+		return station{}, nil, err
+	}
+
+	return s, recordNotes(req, forecastDailyExtremes(req, forecast.properties["temperature"]), s.id), nil
+}
+
+func displayRecords(s station, notes []string) {
+	if len(notes) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Printf("records at %s (%s)\n", s.name, s.id)
+
+	for _, n := range notes {
+		fmt.Println(n)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// where errors go even while stdout is redirected, so they aren't buried in
+// a file or pasted into a chat
+var terminal = os.Stdout
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func clipboardCommand() (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("pbcopy"), nil
+	case "windows":
+		// clip.exe mangles anything that isn't ASCII, like table borders
+		return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", "[Console]::InputEncoding = [Text.Encoding]::UTF8; $input | Set-Clipboard"), nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		candidates := [][]string{
+			{"wl-copy"},
+			{"xclip", "-selection", "clipboard"},
+			{"xsel", "--clipboard", "--input"},
+		}
+
+		// wayland's only if we're in a wayland session
+		if os.Getenv("WAYLAND_DISPLAY") == "" {
+			candidates = candidates[1:]
+		}
+
+		for _, c := range candidates {
+			if _, err := exec.LookPath(c[0]); err == nil {
+				return exec.Command(c[0], c[1:]...), nil
+			}
+		}
+
+		return nil, fmt.Errorf("no clipboard tool found, install wl-copy, xclip or xsel")
+	default:
+		return nil, fmt.Errorf("copying to the clipboard is not supported on %s", runtime.GOOS)
+	}
+}
+
+func copyToClipboard(text string) error {
+	cmd, err := clipboardCommand()
+	if err != nil {
+		return err
+	}
+
+	cmd.Stdin = strings.NewReader(text)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("could not copy to the clipboard: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// the forecast prints straight to stdout from all over, so -o and -copy swap
+// stdout out from under it rather than threading a writer through. the
+// returned func puts it back and finishes up.
+func redirectStdout(path string, toClipboard bool) (func() error, error) {
+	var file *os.File
+
+	dest := terminal
+	if path != "" {
+		var err error
+
+		file, err = os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not create %s: %w", path, err)
+		}
+
+		dest = file
+	}
+
+	if !toClipboard {
+		os.Stdout = file
+
+		return func() error {
+			os.Stdout = terminal
+
+			err := file.Close()
+			if err != nil {
+				return fmt.Errorf("could not write %s: %w", path, err)
+			}
+
+			return nil
+		}, nil
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("could not capture output: %w", err)
+	}
+
+	copied := &bytes.Buffer{}
+	done := make(chan error)
+
+	go func() {
+		_, err := io.Copy(io.MultiWriter(dest, copied), r)
+		done <- err
+	}()
+
+	os.Stdout = w
+
+	return func() error {
+		os.Stdout = terminal
+		w.Close()
+
+		err := <-done
+		if file != nil {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not write output: %w", err)
+		}
+
+		return copyToClipboard(ansiEscape.ReplaceAllString(copied.String(), ""))
+	}, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// feels-like temperatures other countries forecast with, for -derive
+// name=comfort:<index>. unlike expressions they work on the values as the
+// API sends them, since their formulas only hold in metric, and come out
+// as a temperature in whatever units are displayed.
+type comfortIndex struct {
+	properties  []string
+	description string
+	celsius     func(values map[string]*float64) *float64
+}
+
+var comfortIndexes = map[string]comfortIndex{
+	// Environment Canada's, from the temperature and the dewpoint's vapor
+	// pressure
+	"humidex": {
+		properties:  []string{"temperature", "dewpoint"},
+		description: "Environment Canada's humidex",
+		celsius: func(values map[string]*float64) *float64 {
+			t, td := values["temperature"], values["dewpoint"]
+			if t == nil || td == nil {
+				return nil
+			}
+
+			e := 6.11 * math.Exp(5417.7530*(1/273.16-1/(*td+273.15)))
+			h := *t + 0.5555*(e-10)
+
+			return &h
+		},
+	},
+
+	// the Bureau of Meteorology's, Steadman's apparent temperature in the
+	// shade, which counts the wind as well as the humidity
+	"apparent": {
+		properties:  []string{"temperature", "relativeHumidity", "windSpeed"},
+		description: "the Bureau of Meteorology's apparent temperature",
+		celsius: func(values map[string]*float64) *float64 {
+			t, rh, wind := values["temperature"], values["relativeHumidity"], values["windSpeed"]
+			if t == nil || rh == nil || wind == nil {
+				return nil
+			}
+
+			e := *rh / 100 * 6.105 * math.Exp(17.27**t/(237.7+*t))
+			at := *t + 0.33*e - 0.70*(*wind/3.6) - 4.00
+
+			return &at
+		},
+	},
+}
+
+func comfortIndexNames() []string {
+	names := []string{}
+	for name := range comfortIndexes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+type comfortExpr string
+
+func parseComfortExpr(name string) (comfortExpr, error) {
+	if _, ok := comfortIndexes[name]; !ok {
+		return "", fmt.Errorf("comfort index '%s' is not in %v", name, comfortIndexNames())
+	}
+
+	return comfortExpr(name), nil
+}
+
+// buildRows fills these in from the undisplayed values, so this is never
+// what ends up in the table
+func (comfortExpr) eval(map[string]*float64) *float64 { return nil }
+
+func (c comfortExpr) variables() []string { return comfortIndexes[string(c)].properties }
+
+func (c comfortExpr) point(values map[string]*float64) weatherPoint {
+	return weatherPoint{Value: comfortIndexes[string(c)].celsius(values), Unit: kindUnits[kindTemperature]}
+}
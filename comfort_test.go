@@ -0,0 +1,61 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComfortIndexes(t *testing.T) {
+	for _, tc := range []struct {
+		index  string
+		values map[string]float64
+		want   float64
+	}{
+		// Environment Canada's humidex table, in whole degrees
+		{"humidex", map[string]float64{"temperature": 30, "dewpoint": 15}, 34},
+		{"humidex", map[string]float64{"temperature": 30, "dewpoint": 25}, 42},
+		{"humidex", map[string]float64{"temperature": 35, "dewpoint": 25}, 47},
+
+		// the Bureau's formula, with the wind in km/h as the grid has it
+		{"apparent", map[string]float64{"temperature": 30, "relativeHumidity": 50, "windSpeed": 0}, 33},
+		{"apparent", map[string]float64{"temperature": 20, "relativeHumidity": 50, "windSpeed": 18}, 16},
+	} {
+		values := map[string]*float64{}
+		for name, v := range tc.values {
+			values[name] = floatPtr(v)
+		}
+
+		c, err := parseComfortExpr(tc.index)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := c.point(values)
+		if p.Value == nil || p.Unit != kindUnits[kindTemperature] {
+			t.Errorf("%s of %v is %+v", tc.index, tc.values, p)
+			continue
+		}
+
+		if math.Round(*p.Value) != tc.want {
+			t.Errorf("%s of %v is %.2f, wanted %g", tc.index, tc.values, *p.Value, tc.want)
+		}
+	}
+}
+
+func TestComfortIndexMissingValues(t *testing.T) {
+	for name, index := range comfortIndexes {
+		values := map[string]*float64{}
+		for _, p := range index.properties[1:] {
+			values[p] = floatPtr(20)
+		}
+
+		if v := index.celsius(values); v != nil {
+			t.Errorf("%s without %s is %g", name, index.properties[0], *v)
+		}
+	}
+
+	_, err := parseComfortExpr("heatIndex")
+	if err == nil {
+		t.Errorf("heatIndex is a comfort index")
+	}
+}
@@ -0,0 +1,582 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// every subcommand, so dispatch, help and the man pages all come from the
+// same place and can't disagree about what there is
+type command struct {
+	name    string
+	summary string
+
+	// positional arguments, if any, for the synopsis
+	usage string
+
+	// defines the command's flags and returns what runs once they're
+	// parsed. defining them does nothing else, so help, the man pages and
+	// the repl can see them without running the command. nil for commands
+	// that only group their subcommands.
+	run         func(flagset *flag.FlagSet) func()
+	subcommands []*command
+
+	// for commands that pass their flags through to the forecast, which
+	// are given their args as they are instead of run
+	passthrough func(args []string)
+
+	examples []string
+
+	parent *command
+}
+
+func (c *command) path() string {
+	if c.parent == nil {
+		return c.name
+	}
+
+	return c.parent.path() + " " + c.name
+}
+
+func commandTree() *command {
+	root := &command{
+		name:    "agwc",
+		summary: "a good weather client, showing the hourly forecast for an address",
+		run:     runRoot,
+		examples: []string{
+			"agwc -address '1600 Pennsylvania Ave NW, Washington, DC' -properties temperature,probabilityOfPrecipitation -hours 24",
+			"agwc -address 'Chicago, IL' -profile agri -displaytz America/Chicago",
+			"agwc -address 'Chicago, IL' -format json",
+			"agwc -address 'Chicago, IL' -address 'Denver, CO' -properties windSpeed",
+			"agwc -lat 39.7392 -lon -104.9903",
+			"agwc -location cabin",
+			"agwc -address 'Chicago, IL' -mode periods -hours 48",
+			"agwc -address 'Chicago, IL' -properties temperature,windSpeed -hours 72 -chart",
+			"agwc -location home -properties temperature,probabilityOfPrecipitation -format env",
+			"agwc -address 'Chicago, IL' -properties temperature,dewpoint -hide dewpoint -derive humidex=comfort:humidex",
+			"agwc -location home -format eink-png -eink-display waveshare-7.5 -o frame.png",
+		},
+		subcommands: []*command{
+			{name: "get", summary: "print a single forecast value, for scripts", run: runGet, examples: []string{
+				"agwc get -address 'Chicago, IL' -property temperature -at sunset -freedom",
+			}},
+			{name: "check", summary: "exit non-zero if the forecast doesn't meet a condition", run: runCheck, examples: []string{
+				"agwc check -address 'Chicago, IL' -assert 'probabilityOfPrecipitation<20 for next 4h'",
+			}},
+			{name: "geocode", summary: "look up addresses without fetching a forecast", run: runGeocode, examples: []string{
+				"agwc geocode -address '1600 Pennsylvania Ave NW, Washington, DC' -format json",
+				"agwc geocode -suggest '1600 Penn'",
+				"agwc geocode -benchmarks",
+			}},
+			{name: "stations", summary: "list the observation stations near an address", run: runStations},
+			{name: "now", summary: "show the latest observation from the nearest station", run: runNow},
+			{name: "obs", summary: "show recent observations from the nearest station", run: runObs},
+			{name: "outlook", summary: "show the severe weather outlook", run: runOutlook},
+			{name: "radar", summary: "show the nearest radar's latest loop", run: runRadar},
+			{name: "photo", summary: "plan golden and blue hours around the cloud cover", run: runPhoto},
+			{name: "stars", summary: "score the coming nights for stargazing", run: runStars},
+			{name: "event", summary: "summarize the weather for an outdoor event", run: runEvent, examples: []string{
+				"agwc event -address 'Chicago, IL' -at 'sat 18:30' -duration 3h",
+			}},
+			{name: "when", summary: "rank the weeks in a date range by climate normals and the forecast", run: runWhen, examples: []string{
+				"agwc when -address cabin -range 'jun 1 - aug 31' -criteria 'high>=70,high<=85,precip<0.1'",
+			}},
+			{name: "commute", summary: "conditions at both ends of the trip to work and back", run: runCommute, examples: []string{
+				"agwc commute -leave 8:00 -return 17:30 -duration 45m",
+			}},
+			{name: "decide", summary: "JSON feed of which configured cancellation thresholds the forecast crosses", run: runDecide, examples: []string{
+				"agwc decide -address school",
+			}},
+			{name: "workwindow", summary: "find stretches of weather good enough for pouring, painting or roofing", run: runWorkWindow, examples: []string{
+				"agwc workwindow -address site -needs 'dry 6h, wind<20mph, temp>5C'",
+				"agwc workwindow -address site -needs 'dry 24h, temp>10C' -format ics > windows.ics",
+			}},
+			{name: "irrigate", summary: "JSON skip, run or percent for a sprinkler controller from rain and evapotranspiration", run: runIrrigate, examples: []string{
+				"agwc irrigate -address yard",
+			}},
+			{name: "county", summary: "extremes and active alerts across a whole county", run: runCounty, examples: []string{
+				"agwc county -fips 17031",
+				"agwc county -fips 17031 -hours 48 -format json",
+			}},
+			{name: "hvac", summary: "total up heating and cooling degree hours", run: runHVAC},
+			{name: "snowday", summary: "guess at the chance of a snow day", run: runSnowDay},
+			{name: "dress", summary: "what to wear for a run, ride or walk", run: runDress, examples: []string{
+				"agwc dress -address home -at 'sat 7:00' -duration 90m -activity cycle",
+			}},
+			{name: "pawcheck", summary: "hours when pavement or heat is too much for walking a dog", run: runPawCheck, examples: []string{
+				"agwc pawcheck -address home -hours 12",
+			}},
+			{name: "sail", summary: "wind, gusts, waves and thunder on the water, with small craft flags", run: runSail, examples: []string{
+				"agwc sail -address 'Annapolis, MD' -hours 24 -flag 'windGust>=20'",
+			}},
+			{name: "ski", summary: "new snow, temperatures up top, wind and visibility at a resort", run: runSki, examples: []string{
+				"agwc ski -address 'Alta, UT' -summit 11000ft",
+			}},
+			{name: "bench", summary: "benchmark parsing and rendering against a fixture", run: runBench},
+			{name: "soak", summary: "run the daemon's fetches for hours against a faulty fake NWS, watching for leaks", run: runSoak, examples: []string{
+				"agwc soak -hours 24",
+			}},
+			{name: "alerts", summary: "list the active alerts for an address", run: runAlerts, examples: []string{
+				"agwc alerts -address 'Chicago, IL' -severity severe+ -follow",
+			}, subcommands: []*command{
+				{name: "show", summary: "show the full text of an alert", usage: "<id>", run: runAlertShow},
+			}},
+			{name: "notify", summary: "send notifications for the alert rules in the config", run: runNotify},
+			{name: "record", summary: "record the forecast and observations for verifying later", run: runRecord, subcommands: []*command{
+				{name: "backfill", summary: "record as much past observation as upstream still has", run: runRecordBackfill},
+			}},
+			{name: "history", summary: "work with what's been recorded", subcommands: []*command{
+				{name: "export", summary: "export recorded history as csv or parquet", run: runHistoryExport, examples: []string{
+					"agwc history export -format parquet -since 2024-01-01 -o history.parquet",
+				}},
+				{name: "compact", summary: "compact old hourly records into daily aggregates", run: runHistoryCompact},
+			}},
+			{name: "serve", summary: "serve a dashboard of how good recorded forecasts have been", run: runServe, examples: []string{
+				"agwc serve -address home -address cabin -auth -admin-key env:AGWC_ADMIN_KEY",
+			}},
+			{name: "daemon", summary: "run the scheduled jobs and notify rules in the config", run: runDaemon},
+			{name: "config", summary: "move the config between machines", subcommands: []*command{
+				{name: "export", summary: "print the config with its secrets redacted", run: runConfigExport},
+				{name: "import", summary: "replace the config, keeping secrets already here", usage: "<file>", run: runConfigImport},
+			}},
+			{name: "discussion", summary: "show the forecast office's area forecast discussion", run: runDiscussion},
+			{name: "product", summary: "list and fetch NWS text products", run: runProduct},
+			{name: "snapshot", summary: "save a forecast and everything it fetched to render later", usage: "-o <file> [forecast flags]", passthrough: runSnapshot},
+			{name: "render", summary: "render a snapshot without the network", usage: "<snapshot> [forecast flags]", passthrough: runRender},
+			{name: "view", summary: "run a query saved by name in the config", usage: "<name> [forecast flags]", passthrough: runView, examples: []string{
+				"agwc view save morning -location home -properties temperature,probabilityOfPrecipitation -hours 4 -format env",
+				"agwc view morning",
+				"agwc view morning -hours 8",
+			}, subcommands: []*command{
+				{name: "list", summary: "list the saved views", run: runViewList},
+				{name: "save", summary: "save forecast flags as a view, replacing any by that name", usage: "<name> [forecast flags]", passthrough: runViewSave},
+				{name: "delete", summary: "delete a saved view", usage: "<name>", run: runViewDelete},
+			}},
+			{name: "repl", summary: "run commands interactively, keeping the address and units between them", run: runREPL, examples: []string{
+				"agwc repl -address home",
+			}},
+			{name: "version", summary: "print the version and what it was built from", run: runVersion},
+			{name: "help", summary: "show help for a command", usage: "[command]", run: runHelp},
+			{name: "man", summary: "write man pages for every command", run: runMan},
+		},
+	}
+
+	var link func(c *command)
+	link = func(c *command) {
+		for _, s := range c.subcommands {
+			s.parent = c
+			link(s)
+		}
+	}
+
+	link(root)
+
+	return root
+}
+
+func (c *command) find(name string) *command {
+	for _, s := range c.subcommands {
+		if s.name == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+func (c *command) subcommandNames() []string {
+	names := []string{}
+	for _, s := range c.subcommands {
+		names = append(names, s.name)
+	}
+
+	return names
+}
+
+// the deepest command named by args, and the args it should run with
+func resolveCommand(root *command, args []string) (*command, []string) {
+	c := root
+
+	for len(args) > 1 {
+		s := c.find(args[1])
+		if s == nil {
+			break
+		}
+
+		c = s
+		args = args[1:]
+	}
+
+	return c, args
+}
+
+// the command running now, for -h
+var activeCommand *command
+
+func runCommand(c *command, args []string) {
+	if !c.runnable() {
+		errorAndQuit(fmt.Errorf("expected '%s' to be followed by one of %v", c.path(), c.subcommandNames()))
+	}
+
+	activeCommand = c
+
+	if c.passthrough != nil {
+		c.passthrough(args)
+		return
+	}
+
+	flagset := flag.NewFlagSet(args[0], flag.ExitOnError)
+	run := c.run(flagset)
+
+	parseFlags(flagset, args[1:])
+
+	run()
+}
+
+// false for commands that only group their subcommands
+func (c *command) runnable() bool {
+	return c.run != nil || c.passthrough != nil
+}
+
+// so -h shows the help for the command running now, and the repl can carry
+// on after a mistake
+func parseFlags(flagset *flag.FlagSet, args []string) {
+	if activeCommand != nil {
+		c := activeCommand
+		flagset.Usage = func() { writeCommandHelp(flagset.Output(), c) }
+	}
+
+	if !inREPL {
+		flagset.Parse(args)
+		return
+	}
+
+	// the flag package has already said what was wrong
+	flagset.Init(flagset.Name(), flag.ContinueOnError)
+
+	err := flagset.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		quit(0)
+	}
+
+	if err != nil {
+		quit(2)
+	}
+}
+
+// the flags c takes, defined but not parsed
+func flagsOf(c *command) *flag.FlagSet {
+	if c.passthrough != nil {
+		for c.parent != nil {
+			c = c.parent
+		}
+	}
+
+	if c.run == nil {
+		return nil
+	}
+
+	flagset := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	c.run(flagset)
+
+	return flagset
+}
+
+type flagHelp struct {
+	name, kind, usage, def string
+}
+
+func flagHelps(flagset *flag.FlagSet) []flagHelp {
+	helps := []flagHelp{}
+	if flagset == nil {
+		return helps
+	}
+
+	flagset.VisitAll(func(f *flag.Flag) {
+		kind, usage := flag.UnquoteUsage(f)
+
+		h := flagHelp{name: f.Name, kind: kind, usage: usage}
+		switch f.DefValue {
+		case "", "false", "0", "0s", "[]":
+		default:
+			h.def = f.DefValue
+		}
+
+		helps = append(helps, h)
+	})
+
+	return helps
+}
+
+var kindNames = map[propertyKind]string{
+	kindGeneric:       "generic",
+	kindTemperature:   "temperature",
+	kindPrecipitation: "precipitation",
+	kindProbability:   "probability",
+	kindPercentage:    "percentage",
+	kindSpeed:         "speed",
+	kindDirection:     "direction",
+	kindPressure:      "pressure",
+	kindDistance:      "distance",
+	kindHeight:        "height",
+}
+
+// as the API sends them, before any -unit-system conversion
+var kindUnits = map[propertyKind]string{
+	kindTemperature:   "wmoUnit:degC",
+	kindPrecipitation: "wmoUnit:mm",
+	kindProbability:   "wmoUnit:percent",
+	kindPercentage:    "wmoUnit:percent",
+	kindSpeed:         "wmoUnit:km_h-1",
+	kindDirection:     "wmoUnit:degree_(angle)",
+	kindPressure:      "wmoUnit:Pa",
+	kindDistance:      "wmoUnit:m",
+	kindHeight:        "wmoUnit:m",
+}
+
+// the commands that take forecast properties, which want the table of them
+func (c *command) takesProperties() bool {
+	return c.parent == nil || c.passthrough != nil || c.name == "get" || c.name == "check"
+}
+
+func writePropertyTable(w io.Writer) {
+	widths := []int{28, 13}
+	header := []string{"property", "kind"}
+
+	for _, system := range unitSystemNames() {
+		widths = append(widths, 8)
+		header = append(header, system)
+	}
+
+	t := newTable(w, widths, header)
+
+	for _, name := range permittedProperties() {
+		kind := propertyRegistry[name]
+		row := []string{name, kindNames[kind]}
+
+		for _, system := range unitSystemNames() {
+			row = append(row, unitSystems[system].unit(kindUnits[kind]))
+		}
+
+		t.row(row, nil)
+	}
+
+	t.end()
+}
+
+func writeCommandHelp(w io.Writer, c *command) {
+	usage := "[flags]"
+	if c.usage != "" {
+		usage = c.usage
+	}
+
+	if len(c.subcommands) > 0 && !c.runnable() {
+		usage = "<command>"
+	}
+
+	fmt.Fprintf(w, "usage: %s %s\n\n%s\n", c.path(), usage, c.summary)
+
+	if len(c.subcommands) > 0 {
+		fmt.Fprintf(w, "\ncommands:\n")
+		for _, s := range c.subcommands {
+			fmt.Fprintf(w, "  %-12s %s\n", s.name, s.summary)
+		}
+	}
+
+	helps := flagHelps(flagsOf(c))
+	if len(helps) > 0 {
+		if c.passthrough != nil {
+			fmt.Fprintf(w, "\nforecast flags:\n")
+		} else {
+			fmt.Fprintf(w, "\nflags:\n")
+		}
+
+		for _, h := range helps {
+			fmt.Fprintf(w, "  -%s", h.name)
+			if h.kind != "" {
+				fmt.Fprintf(w, " %s", h.kind)
+			}
+
+			fmt.Fprintf(w, "\n    \t%s", h.usage)
+			if h.def != "" && h.kind == "string" {
+				fmt.Fprintf(w, " (default %q)", h.def)
+			} else if h.def != "" {
+				fmt.Fprintf(w, " (default %s)", h.def)
+			}
+
+			fmt.Fprintln(w)
+		}
+	}
+
+	if c.takesProperties() {
+		fmt.Fprintf(w, "\nproperties:\n")
+		writePropertyTable(w)
+	}
+
+	if len(c.examples) > 0 {
+		fmt.Fprintf(w, "\nexamples:\n")
+		for _, e := range c.examples {
+			fmt.Fprintf(w, "  %s\n", e)
+		}
+	}
+
+	if len(c.subcommands) > 0 {
+		fmt.Fprintf(w, "\nsee 'agwc help <command>' for more on each\n")
+	}
+}
+
+func runHelp(flagset *flag.FlagSet) func() {
+	return func() {
+		c, rest := resolveCommand(commandTree(), append([]string{"agwc"}, flagset.Args()...))
+		if len(rest) > 1 {
+			errorAndQuit(fmt.Errorf("no command '%s', expected one of %v", strings.Join(flagset.Args(), " "), c.subcommandNames()))
+		}
+
+		writeCommandHelp(os.Stdout, c)
+	}
+}
+
+// troff wants backslashes and leading dots and quotes escaped, and dashes
+// spelled as such so they copy and paste
+func manEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+
+	return s
+}
+
+func manName(c *command) string {
+	return strings.ReplaceAll(c.path(), " ", "-")
+}
+
+func writeManPage(w io.Writer, c *command) {
+	fmt.Fprintf(w, ".TH %s 1 \"\" agwc \"agwc manual\"\n", strings.ToUpper(manEscape(manName(c))))
+	fmt.Fprintf(w, ".SH NAME\n%s \\- %s\n", manEscape(manName(c)), manEscape(c.summary))
+
+	usage := "[flags]"
+	if c.usage != "" {
+		usage = c.usage
+	}
+
+	if len(c.subcommands) > 0 && !c.runnable() {
+		usage = "<command>"
+	}
+
+	fmt.Fprintf(w, ".SH SYNOPSIS\n.B %s\n%s\n", manEscape(c.path()), manEscape(usage))
+
+	if len(c.subcommands) > 0 {
+		fmt.Fprintf(w, ".SH COMMANDS\n")
+		for _, s := range c.subcommands {
+			fmt.Fprintf(w, ".TP\n.B %s\n%s\n", manEscape(s.name), manEscape(s.summary))
+		}
+	}
+
+	helps := flagHelps(flagsOf(c))
+	if len(helps) > 0 {
+		fmt.Fprintf(w, ".SH OPTIONS\n")
+		for _, h := range helps {
+			if h.kind != "" {
+				fmt.Fprintf(w, ".TP\n.BI \\-%s \" %s\"", manEscape(h.name), h.kind)
+			} else {
+				fmt.Fprintf(w, ".TP\n.B \\-%s", manEscape(h.name))
+			}
+
+			fmt.Fprintf(w, "\n%s", manEscape(h.usage))
+			if h.def != "" {
+				fmt.Fprintf(w, " (default %s)", manEscape(h.def))
+			}
+
+			fmt.Fprintln(w)
+		}
+	}
+
+	if c.takesProperties() {
+		fmt.Fprintf(w, ".SH PROPERTIES\n")
+		for _, name := range permittedProperties() {
+			kind := propertyRegistry[name]
+			units := []string{}
+			for _, system := range unitSystemNames() {
+				units = append(units, fmt.Sprintf("%s (%s)", unitSystems[system].unit(kindUnits[kind]), system))
+			}
+
+			fmt.Fprintf(w, ".TP\n.B %s\n%s, in %s\n", manEscape(name), kindNames[kind], manEscape(strings.Join(units, ", ")))
+		}
+	}
+
+	if len(c.examples) > 0 {
+		fmt.Fprintf(w, ".SH EXAMPLES\n.nf\n")
+		for _, e := range c.examples {
+			fmt.Fprintln(w, manEscape(e))
+		}
+
+		fmt.Fprintf(w, ".fi\n")
+	}
+
+	related := []string{}
+	if c.parent != nil {
+		related = append(related, manName(c.parent))
+	}
+
+	for _, s := range c.subcommands {
+		related = append(related, manName(s))
+	}
+
+	if len(related) > 0 {
+		sort.Strings(related)
+
+		refs := []string{}
+		for _, r := range related {
+			refs = append(refs, ".BR "+manEscape(r)+" (1)")
+		}
+
+		fmt.Fprintf(w, ".SH SEE ALSO\n%s\n", strings.Join(refs, "\n"))
+	}
+}
+
+func runMan(flagset *flag.FlagSet) func() {
+	var dir string
+
+	flagset.StringVar(&dir, "dir", ".", "directory to write the man pages to")
+
+	return func() {
+		err := os.MkdirAll(dir, 0o755)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not create %s: %w", dir, err))
+		}
+
+		var write func(c *command) error
+		write = func(c *command) error {
+			f, err := os.Create(filepath.Join(dir, manName(c)+".1"))
+			if err != nil {
+				return fmt.Errorf("could not create man page: %w", err)
+			}
+			defer f.Close()
+
+			writeManPage(f, c)
+
+			for _, s := range c.subcommands {
+				err := write(s)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		err = write(commandTree())
+		if err != nil {
+			errorAndQuit(err)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// a leg of the commute and the place and time it's judged at
+type commuteStop struct {
+	label    string
+	location int
+	at       time.Time
+}
+
+// the hour's row for the stop, if the forecast has it
+func (s commuteStop) row(locations []locationForecast) (displayRow, bool) {
+	for _, r := range locations[s.location].rows {
+		if r.at.Equal(s.at.Truncate(time.Hour)) {
+			return r, true
+		}
+	}
+
+	return displayRow{}, false
+}
+
+// umbrella and ice warnings, from the precipitation chance and type at each
+// stop, so nothing in between counts
+func commuteWarnings(req forecastRequest, stops []commuteStop, locations []locationForecast) []string {
+	pop := indexOf(req.columns(), "probabilityOfPrecipitation")
+
+	umbrella, ice := []string{}, []string{}
+
+	for _, s := range stops {
+		r, ok := s.row(locations)
+		if !ok {
+			continue
+		}
+
+		if v := r.numbers[pop]; v != nil && *v >= precipChanceThreshold {
+			umbrella = append(umbrella, s.label)
+		}
+
+		switch r.precipType {
+		case precipSnow, precipSleet, precipFreezingRain:
+			ice = append(ice, fmt.Sprintf("%s (%s)", s.label, r.precipType))
+		}
+	}
+
+	warnings := []string{}
+
+	if len(ice) > 0 {
+		warnings = append(warnings, "watch for slick roads: "+strings.Join(ice, ", "))
+	}
+
+	if len(umbrella) > 0 {
+		warnings = append(warnings, "bring an umbrella: rain possible at "+strings.Join(umbrella, ", "))
+	}
+
+	return warnings
+}
+
+func runCommute(flagset *flag.FlagSet) func() {
+	var (
+		home       string
+		work       string
+		leave      string
+		back       string
+		duration   time.Duration
+		properties string
+		displaytz  string
+		freedom    bool
+	)
+
+	flagset.StringVar(&home, "home", "home", "address or named location the commute starts from")
+	flagset.StringVar(&work, "work", "work", "address or named location the commute goes to")
+	flagset.StringVar(&leave, "leave", "", "when you leave home, e.g. '8:00' or 'mon 8:00' in the display timezone")
+	flagset.StringVar(&back, "return", "", "when you leave work, after -leave")
+	flagset.DurationVar(&duration, "duration", 30*time.Minute, "how long the commute takes each way")
+	flagset.StringVar(&properties, "properties", "temperature,windSpeed", "weather properties to show at each end of the commute, with the chance of precipitation always included")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone of -leave and -return, and in which to display times")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if leave == "" || back == "" {
+			errorAndQuit(fmt.Errorf("need both -leave and -return"))
+		}
+
+		now := time.Now()
+
+		leaveAt, err := parseClockExpression(leave, now, loc)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		returnAt, err := parseClockExpression(back, leaveAt, loc)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		if !strings.Contains(","+properties+",", ",probabilityOfPrecipitation,") {
+			properties += ",probabilityOfPrecipitation"
+		}
+
+		system := "metric"
+		if freedom {
+			system = activeUnitSystem.name
+		}
+
+		// one run over the whole day at both ends, the same as -address given
+		// twice, and the four stops are picked out of it
+		req, err := getForecastRequest([]string{
+			flagset.Name(),
+			"-address", home,
+			"-address", work,
+			"-properties", properties,
+			"-start", leaveAt.Format(time.RFC3339),
+			"-end", returnAt.Add(duration).Format(time.RFC3339),
+			"-displaytz", displaytz,
+			"-unit-system", system,
+			"-precip-type",
+		})
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		locations := getLocationForecasts(req)
+
+		for _, l := range locations {
+			if l.err != nil {
+				errorAndQuit(fmt.Errorf("could not get forecast for '%s': %w", l.address, l.err))
+			}
+		}
+
+		stops := []commuteStop{
+			{label: "leave " + home, location: 0, at: leaveAt},
+			{label: "reach " + work, location: 1, at: leaveAt.Add(duration)},
+			{label: "leave " + work, location: 1, at: returnAt},
+			{label: "reach " + home, location: 0, at: returnAt.Add(duration)},
+		}
+
+		header := []string{""}
+		for _, s := range stops {
+			header = append(header, s.label+" "+s.at.In(loc).Format("15:04"))
+		}
+
+		columns := req.columns()
+
+		t := newTable(os.Stdout, getColumnWidths(header[1:]), header)
+
+		for i, c := range columns {
+			cells := []string{c}
+
+			for _, s := range stops {
+				r, ok := s.row(locations)
+				if !ok {
+					cells = append(cells, "No Data")
+					continue
+				}
+
+				cells = append(cells, r.values[i])
+			}
+
+			t.row(cells, nil)
+		}
+
+		t.end()
+
+		fmt.Println()
+		fmt.Printf("commute on %s\n", leaveAt.In(loc).Format("Mon Jan 02"))
+
+		for _, w := range commuteWarnings(req, stops, locations) {
+			fmt.Println(w)
+		}
+	}
+}
@@ -0,0 +1,521 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+const defaultHourlyRetentionDays = 90
+
+// a day's worth of one property, which is all that's kept once the hourly
+// detail ages out
+type dailyValue struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+	Count int     `json:"count"`
+	Unit  string  `json:"unit"`
+}
+
+func (d dailyValue) merge(o dailyValue) dailyValue {
+	if d.Count == 0 {
+		return o
+	}
+
+	return dailyValue{
+		Min:   math.Min(d.Min, o.Min),
+		Max:   math.Max(d.Max, o.Max),
+		Mean:  (d.Mean*float64(d.Count) + o.Mean*float64(o.Count)) / float64(d.Count+o.Count),
+		Count: d.Count + o.Count,
+		Unit:  d.Unit,
+	}
+}
+
+func dailyOf(v recordedValue) dailyValue {
+	return dailyValue{Min: v.Value, Max: v.Value, Mean: v.Value, Count: 1, Unit: v.Unit}
+}
+
+type dailyObservation struct {
+	Station string                `json:"station"`
+	Date    string                `json:"date"`
+	Values  map[string]dailyValue `json:"values"`
+}
+
+// every issuance from one day, for one day it was forecasting
+type dailyForecast struct {
+	Office   string                `json:"office"`
+	X        int                   `json:"x"`
+	Y        int                   `json:"y"`
+	IssuedOn string                `json:"issuedOn"`
+	Date     string                `json:"date"`
+	LeadDays int                   `json:"leadDays"`
+	Values   map[string]dailyValue `json:"values"`
+}
+
+func mergeDailyValues(into, from map[string]dailyValue) {
+	for property, v := range from {
+		into[property] = into[property].merge(v)
+	}
+}
+
+// what compaction did to one file
+type compaction struct {
+	kind, name        string
+	kept, compacted   int
+	days, droppedDays int
+}
+
+// rewrites a history file in place, so a crash part way leaves either the
+// old file or the new one. the caller holds the history's lock.
+func rewriteHistory(path string, records []interface{}) error {
+	tmp := path + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("could not rewrite history: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	for _, r := range records {
+		err := enc.Encode(r)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("could not write history: %w", err)
+		}
+	}
+
+	// on disk before the rename, or a crash could leave an empty file
+	// where the history was
+	err = w.Flush()
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not write history: %w", err)
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("could not replace history: %w", err)
+	}
+
+	return nil
+}
+
+// locks a history and its dailies, always in that order so two compactions
+// can't each hold one, and finishes whatever compaction of them was cut
+// short before anything's read
+func lockCompaction(path, dailyPath string) (func(), error) {
+	unlock, err := lockHistory(path)
+	if err != nil {
+		return nil, err
+	}
+
+	unlockDaily, err := lockHistory(dailyPath)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+
+	both := func() {
+		unlockDaily()
+		unlock()
+	}
+
+	err = recoverCompaction(path, dailyPath)
+	if err != nil {
+		both()
+		return nil, err
+	}
+
+	return both, nil
+}
+
+// replaces a history and its dailies together. both are staged beside the
+// files they replace, the hourly first, so a staged daily means both were
+// written. swapping in the hourly is the point of no return: a crash before
+// it leaves the old files as they were, and after it the next compaction
+// swaps in the staged daily, so no hour is ever counted twice or lost.
+func swapCompacted(path, dailyPath string, kept, dailies []interface{}) error {
+	err := rewriteHistory(path+".next", kept)
+	if err != nil {
+		return err
+	}
+
+	err = rewriteHistory(dailyPath+".next", dailies)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(path+".next", path)
+	if err != nil {
+		return fmt.Errorf("could not replace history: %w", err)
+	}
+
+	err = os.Rename(dailyPath+".next", dailyPath)
+	if err != nil {
+		return fmt.Errorf("could not replace history: %w", err)
+	}
+
+	return nil
+}
+
+// what a crash part way through swapCompacted left behind, undone if the
+// hourly was never swapped in and finished if it was
+func recoverCompaction(path, dailyPath string) error {
+	_, err := os.Stat(dailyPath + ".next")
+	staged := err == nil
+
+	_, err = os.Stat(path + ".next")
+	hourlyStaged := err == nil
+
+	if !staged || hourlyStaged {
+		for _, p := range []string{path + ".next", dailyPath + ".next"} {
+			err := os.Remove(p)
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("could not clean up an interrupted compaction: %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	err = os.Rename(dailyPath+".next", dailyPath)
+	if err != nil {
+		return fmt.Errorf("could not finish an interrupted compaction: %w", err)
+	}
+
+	return nil
+}
+
+func dateOf(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// hourly records before hourlyCutoff become daily ones, and daily ones
+// before dailyCutoff, if there is one, go away entirely
+func compactObservations(name, path string, hourlyCutoff, dailyCutoff time.Time, dryRun bool) (compaction, error) {
+	result := compaction{kind: "observations", name: name}
+
+	dailyPath, err := historyPath("daily-observations", name)
+	if err != nil {
+		return compaction{}, err
+	}
+
+	unlock, err := lockCompaction(path, dailyPath)
+	if err != nil {
+		return compaction{}, err
+	}
+	defer unlock()
+
+	kept := []interface{}{}
+	daily := map[string]map[string]dailyValue{}
+
+	err = readHistory(path, func(line []byte) error {
+		r := recordedObservation{}
+
+		err := json.Unmarshal(line, &r)
+		if err != nil {
+			return err
+		}
+
+		if !r.Time.Before(hourlyCutoff) {
+			kept = append(kept, r)
+			return nil
+		}
+
+		result.compacted++
+
+		date := dateOf(r.Time)
+		if daily[date] == nil {
+			daily[date] = map[string]dailyValue{}
+		}
+
+		for property, v := range r.Values {
+			daily[date][property] = daily[date][property].merge(dailyOf(v))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return compaction{}, err
+	}
+
+	result.kept = len(kept)
+
+	err = readHistory(dailyPath, func(line []byte) error {
+		d := dailyObservation{}
+
+		err := json.Unmarshal(line, &d)
+		if err != nil {
+			return err
+		}
+
+		if daily[d.Date] == nil {
+			daily[d.Date] = map[string]dailyValue{}
+		}
+
+		mergeDailyValues(daily[d.Date], d.Values)
+
+		return nil
+	})
+	if err != nil {
+		return compaction{}, err
+	}
+
+	dates := []string{}
+	for date := range daily {
+		if !dailyCutoff.IsZero() && date < dateOf(dailyCutoff) {
+			result.droppedDays++
+			continue
+		}
+
+		dates = append(dates, date)
+	}
+
+	sort.Strings(dates)
+	result.days = len(dates)
+
+	if dryRun || (result.compacted == 0 && result.droppedDays == 0) {
+		return result, nil
+	}
+
+	dailies := []interface{}{}
+	for _, date := range dates {
+		dailies = append(dailies, dailyObservation{Station: name, Date: date, Values: daily[date]})
+	}
+
+	return result, swapCompacted(path, dailyPath, kept, dailies)
+}
+
+func compactForecasts(name, path string, hourlyCutoff, dailyCutoff time.Time, dryRun bool) (compaction, error) {
+	result := compaction{kind: "forecasts", name: name}
+
+	dailyPath, err := historyPath("daily-forecasts", name)
+	if err != nil {
+		return compaction{}, err
+	}
+
+	unlock, err := lockCompaction(path, dailyPath)
+	if err != nil {
+		return compaction{}, err
+	}
+	defer unlock()
+
+	type key struct {
+		issuedOn, date string
+	}
+
+	kept := []interface{}{}
+	daily := map[key]*dailyForecast{}
+
+	add := func(d dailyForecast) {
+		k := key{issuedOn: d.IssuedOn, date: d.Date}
+		if daily[k] == nil {
+			d.Values = map[string]dailyValue{}
+			daily[k] = &d
+		}
+	}
+
+	err = readHistory(path, func(line []byte) error {
+		r := recordedForecast{}
+
+		err := json.Unmarshal(line, &r)
+		if err != nil {
+			return err
+		}
+
+		if !r.Time.Before(hourlyCutoff) {
+			kept = append(kept, r)
+			return nil
+		}
+
+		result.compacted++
+
+		issued, _ := time.Parse("2006-01-02", dateOf(r.IssuedAt))
+		day, _ := time.Parse("2006-01-02", dateOf(r.Time))
+
+		d := dailyForecast{
+			Office:   r.Office,
+			X:        r.X,
+			Y:        r.Y,
+			IssuedOn: dateOf(r.IssuedAt),
+			Date:     dateOf(r.Time),
+			LeadDays: int(day.Sub(issued) / (24 * time.Hour)),
+		}
+
+		add(d)
+
+		values := daily[key{issuedOn: d.IssuedOn, date: d.Date}].Values
+		for property, v := range r.Values {
+			values[property] = values[property].merge(dailyOf(v))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return compaction{}, err
+	}
+
+	result.kept = len(kept)
+
+	err = readHistory(dailyPath, func(line []byte) error {
+		d := dailyForecast{}
+
+		err := json.Unmarshal(line, &d)
+		if err != nil {
+			return err
+		}
+
+		values := d.Values
+		add(d)
+		mergeDailyValues(daily[key{issuedOn: d.IssuedOn, date: d.Date}].Values, values)
+
+		return nil
+	})
+	if err != nil {
+		return compaction{}, err
+	}
+
+	keys := []key{}
+	for k := range daily {
+		if !dailyCutoff.IsZero() && k.date < dateOf(dailyCutoff) {
+			result.droppedDays++
+			continue
+		}
+
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].issuedOn != keys[j].issuedOn {
+			return keys[i].issuedOn < keys[j].issuedOn
+		}
+
+		return keys[i].date < keys[j].date
+	})
+
+	result.days = len(keys)
+
+	if dryRun || (result.compacted == 0 && result.droppedDays == 0) {
+		return result, nil
+	}
+
+	dailies := []interface{}{}
+	for _, k := range keys {
+		dailies = append(dailies, *daily[k])
+	}
+
+	return result, swapCompacted(path, dailyPath, kept, dailies)
+}
+
+// meant to be run regularly, like from the daemon, so recordings don't grow
+// forever
+func runHistoryCompact(flagset *flag.FlagSet) func() {
+	var (
+		hourlyDays int
+		dailyDays  int
+		dryRun     bool
+	)
+
+	flagset.IntVar(&hourlyDays, "hourly-days", defaultHourlyRetentionDays, "days of hourly records to keep before compacting them into daily aggregates, if not history.hourlyDays in the config")
+	flagset.IntVar(&dailyDays, "daily-days", 0, "days of daily aggregates to keep, or 0 to keep them forever, if not history.dailyDays in the config")
+	flagset.BoolVar(&dryRun, "dry-run", false, "say what would be compacted without changing anything")
+
+	return func() {
+		// the config's retention is only read now, so that help can list the
+		// flags without loading it
+		explicit := map[string]bool{}
+		flagset.Visit(func(f *flag.Flag) {
+			explicit[f.Name] = true
+		})
+
+		cfg, err := loadConfig()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		if cfg.History.HourlyDays != 0 && !explicit["hourly-days"] {
+			hourlyDays = cfg.History.HourlyDays
+		}
+
+		if !explicit["daily-days"] {
+			dailyDays = cfg.History.DailyDays
+		}
+
+		if hourlyDays < 1 {
+			errorAndQuit(fmt.Errorf("hourly-days must be at least 1, got %d", hourlyDays))
+		}
+
+		if dailyDays < 0 {
+			errorAndQuit(fmt.Errorf("daily-days cannot be negative, got %d", dailyDays))
+		}
+
+		// whole days only, so a day is never split between hourly and daily
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		hourlyCutoff := today.AddDate(0, 0, -hourlyDays)
+
+		dailyCutoff := time.Time{}
+		if dailyDays > 0 {
+			dailyCutoff = today.AddDate(0, 0, -dailyDays)
+		}
+
+		results := []compaction{}
+
+		for kind, compact := range map[string]func(string, string, time.Time, time.Time, bool) (compaction, error){
+			"observations": compactObservations,
+			"forecasts":    compactForecasts,
+		} {
+			files, err := historyFiles(kind)
+			if err != nil {
+				errorAndQuit(err)
+			}
+
+			for name, path := range files {
+				c, err := compact(name, path, hourlyCutoff, dailyCutoff, dryRun)
+				if err != nil {
+					errorAndQuit(err)
+				}
+
+				results = append(results, c)
+			}
+		}
+
+		sort.Slice(results, func(i, j int) bool {
+			if results[i].kind != results[j].kind {
+				return results[i].kind < results[j].kind
+			}
+
+			return results[i].name < results[j].name
+		})
+
+		if dryRun {
+			fmt.Println("dry run, nothing was changed")
+		}
+
+		for _, c := range results {
+			fmt.Printf("%s %s: compacted %d hourly records, kept %d, %d days of aggregates", c.kind, c.name, c.compacted, c.kept, c.days)
+
+			if c.droppedDays > 0 {
+				fmt.Printf(", dropped %d past retention", c.droppedDays)
+			}
+
+			fmt.Println()
+		}
+	}
+}
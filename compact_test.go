@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func countHistory(t *testing.T, path string) int {
+	t.Helper()
+
+	n := 0
+
+	err := readHistory(path, func([]byte) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+func testObservationHistory(t *testing.T) (path, dailyPath string, cutoff time.Time) {
+	t.Helper()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	path, err := historyPath("observations", "KDCA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dailyPath, err = historyPath("daily-observations", "KDCA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(dailyPath), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cutoff = time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)
+
+	// two days before the cutoff and one after, four hours each
+	records := []interface{}{}
+	for day := 1; day <= 3; day++ {
+		for hour := 0; hour < 4; hour++ {
+			records = append(records, recordedObservation{
+				Station: "KDCA",
+				Time:    time.Date(2024, 5, day, hour, 0, 0, 0, time.UTC),
+				Values:  map[string]recordedValue{"temperature": {Value: float64(hour), Unit: "wmoUnit:degC"}},
+			})
+		}
+	}
+
+	unlock, err := lockHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = appendHistory(path, records)
+	unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path, dailyPath, cutoff
+}
+
+func TestCompactObservations(t *testing.T) {
+	path, dailyPath, cutoff := testObservationHistory(t)
+
+	c, err := compactObservations("KDCA", path, cutoff, time.Time{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.compacted != 8 || c.kept != 4 || c.days != 2 {
+		t.Errorf("compacted %d, kept %d, %d days", c.compacted, c.kept, c.days)
+	}
+
+	if n := countHistory(t, path); n != 4 {
+		t.Errorf("%d hourly records left", n)
+	}
+
+	if n := countHistory(t, dailyPath); n != 2 {
+		t.Errorf("%d daily records", n)
+	}
+
+	// nothing's left to compact, so a second run changes nothing
+	c, err = compactObservations("KDCA", path, cutoff, time.Time{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.compacted != 0 || c.days != 2 {
+		t.Errorf("compacted %d again, %d days", c.compacted, c.days)
+	}
+}
+
+func TestCompactionRecovery(t *testing.T) {
+	t.Run("a crash before the hourly swap leaves the old files", func(t *testing.T) {
+		path, dailyPath, _ := testObservationHistory(t)
+
+		err := rewriteHistory(path+".next", []interface{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = rewriteHistory(dailyPath+".next", []interface{}{dailyObservation{Station: "KDCA", Date: "2024-05-01"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		unlock, err := lockCompaction(path, dailyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unlock()
+
+		if n := countHistory(t, path); n != 12 {
+			t.Errorf("%d hourly records, wanted all 12", n)
+		}
+
+		if n := countHistory(t, dailyPath); n != 0 {
+			t.Errorf("%d daily records swapped in", n)
+		}
+
+		for _, p := range []string{path + ".next", dailyPath + ".next"} {
+			if _, err := os.Stat(p); !os.IsNotExist(err) {
+				t.Errorf("%s is still there", p)
+			}
+		}
+	})
+
+	t.Run("a crash after the hourly swap finishes with the daily", func(t *testing.T) {
+		path, dailyPath, _ := testObservationHistory(t)
+
+		err := rewriteHistory(path, []interface{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = rewriteHistory(dailyPath+".next", []interface{}{dailyObservation{Station: "KDCA", Date: "2024-05-01"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		unlock, err := lockCompaction(path, dailyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unlock()
+
+		if n := countHistory(t, dailyPath); n != 1 {
+			t.Errorf("%d daily records, wanted the staged one", n)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// a part of the output beyond the forecast itself, like alerts or the
+// consensus, fetched alongside the others. fetch runs on its own goroutine
+// and returns finish, which keeps what was fetched once everything is in,
+// so nothing it touches is shared while the fetches are running. its
+// context is done once the section's been given up on.
+type section struct {
+	name  string
+	fetch func(ctx context.Context) (finish func(), err error)
+}
+
+type composedSection struct {
+	name   string
+	finish func()
+	err    error
+}
+
+// fetches every section at once, each given up on after timeout, and hands
+// them back in the order given so the output doesn't depend on which
+// upstream answered first. the requests of a section that times out are
+// cancelled as it's given up on, and anything it fetched is thrown away.
+func composeSections(sections []section, timeout time.Duration) []composedSection {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	results := make([]chan composedSection, len(sections))
+
+	for i, s := range sections {
+		results[i] = make(chan composedSection, 1)
+
+		go func(s section, result chan<- composedSection) {
+			finish, err := s.fetch(ctx)
+			result <- composedSection{name: s.name, finish: finish, err: err}
+		}(s, results[i])
+	}
+
+	deadline := ctx.Done()
+	timedOut := false
+
+	composed := []composedSection{}
+	for i, s := range sections {
+		if timedOut {
+			select {
+			case r := <-results[i]:
+				composed = append(composed, r)
+			default:
+				composed = append(composed, composedSection{name: s.name, err: fmt.Errorf("timed out after %s", timeout)})
+			}
+
+			continue
+		}
+
+		select {
+		case r := <-results[i]:
+			composed = append(composed, r)
+		case <-deadline:
+			timedOut = true
+			composed = append(composed, composedSection{name: s.name, err: fmt.Errorf("timed out after %s", timeout)})
+		}
+	}
+
+	return composed
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestComposeSectionsCancelsTimedOut(t *testing.T) {
+	cancelled := make(chan struct{})
+
+	composed := composeSections([]section{
+		{name: "quick", fetch: func(context.Context) (func(), error) {
+			return func() {}, nil
+		}},
+		{name: "slow", fetch: func(ctx context.Context) (func(), error) {
+			<-ctx.Done()
+			close(cancelled)
+			return nil, ctx.Err()
+		}},
+	}, 10*time.Millisecond)
+
+	if len(composed) != 2 || composed[0].name != "quick" || composed[0].err != nil {
+		t.Fatalf("composed %+v", composed)
+	}
+
+	if composed[1].name != "slow" || composed[1].err == nil {
+		t.Errorf("the slow section didn't time out: %+v", composed[1])
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Errorf("the slow section's context was never cancelled")
+	}
+}
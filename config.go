@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// settings that should stick around between runs
+type config struct {
+	Agri agriConfig `json:"agri"`
+	HVAC hvacConfig `json:"hvac"`
+	HTTP httpConfig `json:"http"`
+
+	// which Census address data and geographies addresses are looked up
+	// in, instead of the current ones
+	Geocoder geocoderConfig `json:"geocoder"`
+
+	// how agwc irrigate and serve's /api/irrigation scale watering
+	Irrigation irrigationConfig `json:"irrigation"`
+
+	// what's kept between runs in the cache directory
+	Cache cacheConfig `json:"cache"`
+
+	Notify notifyConfig `json:"notify"`
+
+	// how long recordings keep their hourly detail
+	History historyConfig `json:"history"`
+
+	// places by a short name, like "home", for -location and the repl's
+	// use command, as just an address or with coordinates and defaults
+	Locations map[string]locationConfig `json:"locations,omitempty"`
+
+	// -properties for parts of the year, by name, used unless -properties,
+	// a -location's properties or a -profile say otherwise
+	Seasons map[string]seasonConfig `json:"seasons,omitempty"`
+
+	// whole queries by a short name, like "morning", for agwc view
+	Views map[string]viewConfig `json:"views,omitempty"`
+
+	// commands for the daemon to run on a schedule
+	Jobs []jobConfig `json:"jobs,omitempty"`
+
+	// what agwc dress suggests wearing, instead of its own rules
+	Clothing []clothingRule `json:"clothing,omitempty"`
+
+	// thresholds agwc decide and serve's /api/decisions act on, instead
+	// of their own rules
+	Decisions []decisionRule `json:"decisions,omitempty"`
+
+	// forecast providers to compare with -consensus, like ["nws", "open-meteo"]
+	Providers []string `json:"providers,omitempty"`
+
+	// shell commands run before and after the forecast is fetched and
+	// printed
+	Hooks hooksConfig `json:"hooks"`
+
+	// programs that add providers, -derive metrics and -format outputs
+	Plugins []pluginConfig `json:"plugins,omitempty"`
+
+	// default for -style
+	Style string `json:"style,omitempty"`
+
+	// defaults for -freedom, as "freedom" or "metric", and -displaytz, or
+	// else they follow LC_MEASUREMENT or LANG, and TZ
+	Units    string `json:"units,omitempty"`
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+type agriConfig struct {
+	PlantingDate string  `json:"plantingDate,omitempty"`
+	GDDBase      float64 `json:"gddBase,omitempty"`
+}
+
+type historyConfig struct {
+	// days of hourly records to keep before compacting them into daily
+	// aggregates, 90 if unset
+	HourlyDays int `json:"hourlyDays,omitempty"`
+
+	// days of daily aggregates to keep, forever if unset
+	DailyDays int `json:"dailyDays,omitempty"`
+}
+
+type hvacConfig struct {
+	BalancePoint string `json:"balancePoint,omitempty"`
+}
+
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find config directory: %w", err)
+	}
+
+	return filepath.Join(dir, "agwc", "config.json"), nil
+}
+
+// a missing config file is the same as an empty one
+func loadConfig() (config, error) {
+	path, err := configPath()
+	if err != nil {
+		return config{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config{}, nil
+	}
+
+	if err != nil {
+		return config{}, fmt.Errorf("could not read config: %w", err)
+	}
+
+	c := config{}
+
+	err = json.Unmarshal(data, &c)
+	if err != nil {
+		return config{}, fmt.Errorf("could not parse config %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+func saveConfig(c config) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode config: %w", err)
+	}
+
+	err = os.WriteFile(path, append(data, '\n'), 0o644)
+	if err != nil {
+		return fmt.Errorf("could not write config: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// what goes in place of a secret in an exported config, and on import means
+// "keep whatever this machine already has"
+const redactedSecret = "<redacted>"
+
+const configExportKind = "agwc.config/v1"
+
+// a config as it travels between machines
+type configExport struct {
+	Kind       string    `json:"kind"`
+	ExportedAt time.Time `json:"exportedAt"`
+	Config     config    `json:"config"`
+}
+
+// pointers to every secret in the config, so exporting and importing agree
+// on what counts as one
+func (t *transportConfig) secrets() []*string {
+	secrets := []*string{&t.Password, &t.Token, &t.User}
+
+	// incoming webhooks carry their credentials in the URL
+	if t.Type == "slack" || t.Type == "webhook" {
+		secrets = append(secrets, &t.URL)
+	}
+
+	return secrets
+}
+
+// references to secrets kept elsewhere travel as they are, since they only
+// mean something on a machine that has the secret
+func (c config) redacted() config {
+	rules := []notifyRule{}
+	for _, r := range c.Notify.Rules {
+		transports := append([]transportConfig{}, r.Transports...)
+		for i := range transports {
+			for _, s := range transports[i].secrets() {
+				if *s != "" && !isSecretReference(*s) {
+					*s = redactedSecret
+				}
+			}
+		}
+
+		r.Transports = transports
+		rules = append(rules, r)
+	}
+
+	c.Notify.Rules = rules
+
+	return c
+}
+
+// fills redacted secrets in from the config already here, matching rules by
+// name and transports by position, and says which ones it couldn't
+func (c config) withSecretsFrom(existing config) (config, []string) {
+	missing := []string{}
+
+	for i, r := range c.Notify.Rules {
+		var local *notifyRule
+		for j := range existing.Notify.Rules {
+			if existing.Notify.Rules[j].Name == r.Name {
+				local = &existing.Notify.Rules[j]
+			}
+		}
+
+		for k := range r.Transports {
+			t := &c.Notify.Rules[i].Transports[k]
+
+			var localSecrets []*string
+			if local != nil && k < len(local.Transports) && local.Transports[k].Type == t.Type {
+				localSecrets = local.Transports[k].secrets()
+			}
+
+			filled := true
+			for n, s := range t.secrets() {
+				if *s != redactedSecret {
+					continue
+				}
+
+				if localSecrets != nil && *localSecrets[n] != "" && *localSecrets[n] != redactedSecret {
+					*s = *localSecrets[n]
+					continue
+				}
+
+				*s = ""
+				filled = false
+			}
+
+			if !filled {
+				missing = append(missing, fmt.Sprintf("rule '%s' %s transport", r.Name, t.Type))
+			}
+		}
+	}
+
+	return c, missing
+}
+
+func runConfigExport(flagset *flag.FlagSet) func() {
+	var (
+		output        string
+		redactSecrets bool
+	)
+
+	flagset.StringVar(&output, "o", "", "write the export to this file instead of stdout")
+	flagset.BoolVar(&redactSecrets, "redact", true, "replace secrets with a placeholder, so the file is safe to share")
+
+	return func() {
+		cfg, err := loadConfig()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				errorAndQuit(fmt.Errorf("could not create export file: %w", err))
+			}
+			defer f.Close()
+
+			w = f
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+
+		if redactSecrets {
+			cfg = cfg.redacted()
+		}
+
+		err = enc.Encode(configExport{Kind: configExportKind, ExportedAt: time.Now().UTC(), Config: cfg})
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not write export: %w", err))
+		}
+	}
+}
+
+func runConfigImport(flagset *flag.FlagSet) func() {
+	return func() {
+		if flagset.NArg() != 1 {
+			errorAndQuit(fmt.Errorf("expected exactly one file to import, from 'agwc config export'"))
+		}
+
+		data, err := os.ReadFile(flagset.Arg(0))
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not read import file: %w", err))
+		}
+
+		imported := configExport{}
+
+		err = json.Unmarshal(data, &imported)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not parse import file: %w", err))
+		}
+
+		if imported.Kind != configExportKind {
+			errorAndQuit(fmt.Errorf("import file is a '%s', expected '%s'", imported.Kind, configExportKind))
+		}
+
+		existing, err := loadConfig()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		cfg, missing := imported.Config.withSecretsFrom(existing)
+
+		err = saveConfig(cfg)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		path, _ := configPath()
+		fmt.Printf("imported config from %s into %s\n", flagset.Arg(0), path)
+
+		for _, m := range missing {
+			fmt.Printf("warning: %s needs its secrets filled in again\n", m)
+		}
+	}
+}
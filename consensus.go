@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+func consensusProviders() ([]string, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Providers) < 2 {
+		path, _ := configPath()
+		return nil, fmt.Errorf("-consensus needs at least two providers, add something like \"providers\": [\"nws\", \"open-meteo\"] to %s", path)
+	}
+
+	for _, name := range cfg.Providers {
+		if _, ok := forecastProviders[name]; !ok {
+			return nil, fmt.Errorf("provider '%s' in config is not in %v", name, providerNames())
+		}
+	}
+
+	return cfg.Providers, nil
+}
+
+// each configured provider's forecast, in the order of names
+func getConsensusForecasts(req forecastRequest, c coordinates, names []string) ([]map[string]series, error) {
+	forecasts := []map[string]series{}
+
+	for _, name := range names {
+		f, err := forecastProviders[name](c, req.properties)
+		if err != nil {
+			return nil, fmt.Errorf("could not get forecast from %s: %w", name, err)
+		}
+
+		forecasts = append(forecasts, f)
+	}
+
+	return forecasts, nil
+}
+
+// each provider's take on every hour, and how far apart they are
+func displayConsensus(req forecastRequest, names []string, forecasts []map[string]series) {
+	for _, property := range req.properties {
+		single := req
+		single.properties = []string{property}
+		single.derived = nil
+
+		columns := [][]displayRow{}
+		unit := ""
+
+		for _, f := range forecasts {
+			columns = append(columns, buildRows(single, f))
+
+			if unit == "" && f[property].Unit != "" {
+				unit = req.columnUnit(f[property].Unit)
+			}
+		}
+
+		precision := kindPrecision[propertyRegistry[property]]
+
+		format := func(v float64) string {
+			s := formatNumber(v, precision)
+			if unit != "" {
+				s += " " + unit
+			}
+
+			return s
+		}
+
+		header := append([]string{"time"}, names...)
+		header = append(header, "mean", "spread")
+
+		fmt.Println()
+		fmt.Println(property)
+
+		t := newTable(os.Stdout, getColumnWidths(header[1:]), header)
+
+		for i, r := range columns[0] {
+			cells := []string{r.at.In(req.displayTimeZone).Format(time.Stamp)}
+
+			sum, count := 0.0, 0
+			low, high := math.Inf(1), math.Inf(-1)
+
+			for _, rows := range columns {
+				cells = append(cells, rows[i].values[0])
+
+				if v := rows[i].numbers[0]; v != nil {
+					sum += *v
+					count++
+					low = math.Min(low, *v)
+					high = math.Max(high, *v)
+				}
+			}
+
+			if count == 0 {
+				cells = append(cells, "No Data", "No Data")
+			} else {
+				cells = append(cells, format(sum/float64(count)), format(high-low))
+			}
+
+			t.row(cells, nil)
+		}
+
+		t.end()
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !windows
+
+package main
+
+// everywhere else the terminal already understands color and UTF-8
+func configureConsole() {}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+const enableVirtualTerminalProcessing = 0x0004
+
+// the windows console only understands the escapes we color with once it's
+// asked to, and only draws the unicode table styles in the UTF-8 code page.
+// neither is worth failing over, older consoles just look plainer.
+func configureConsole() {
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+
+	kernel32.NewProc("SetConsoleOutputCP").Call(65001)
+
+	h := syscall.Handle(os.Stdout.Fd())
+
+	var mode uint32
+	if syscall.GetConsoleMode(h, &mode) != nil {
+		return
+	}
+
+	kernel32.NewProc("SetConsoleMode").Call(uintptr(h), uintptr(mode|enableVirtualTerminalProcessing))
+}
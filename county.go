@@ -0,0 +1,424 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/packrat386/agwc/schema"
+)
+
+var countyFormats = []string{"table", "json"}
+
+// the first two digits of a county FIPS code are the state, which NWS
+// county zones spell with the postal code instead, so 17031 is ILC031
+var stateFIPS = map[string]string{
+	"01": "AL", "02": "AK", "04": "AZ", "05": "AR", "06": "CA", "08": "CO",
+	"09": "CT", "10": "DE", "11": "DC", "12": "FL", "13": "GA", "15": "HI",
+	"16": "ID", "17": "IL", "18": "IN", "19": "IA", "20": "KS", "21": "KY",
+	"22": "LA", "23": "ME", "24": "MD", "25": "MA", "26": "MI", "27": "MN",
+	"28": "MS", "29": "MO", "30": "MT", "31": "NE", "32": "NV", "33": "NH",
+	"34": "NJ", "35": "NM", "36": "NY", "37": "NC", "38": "ND", "39": "OH",
+	"40": "OK", "41": "OR", "42": "PA", "44": "RI", "45": "SC", "46": "SD",
+	"47": "TN", "48": "TX", "49": "UT", "50": "VT", "51": "VA", "53": "WA",
+	"54": "WV", "55": "WI", "56": "WY", "72": "PR",
+}
+
+// at most this many points a side are laid over the county to sample it,
+// of which only the ones inside count
+const countySampleGrid = 4
+
+type countyZone struct {
+	fips     string
+	id       string
+	state    string
+	name     string
+	polygons []polygon
+}
+
+func countyZoneID(fips string) (string, string, error) {
+	if len(fips) != 5 || strings.Trim(fips, "0123456789") != "" {
+		return "", "", fmt.Errorf("fips must be a five digit county code like 17031, got '%s'", fips)
+	}
+
+	state, ok := stateFIPS[fips[:2]]
+	if !ok {
+		return "", "", fmt.Errorf("no state with FIPS code '%s'", fips[:2])
+	}
+
+	return state + "C" + fips[2:], state, nil
+}
+
+func getCountyZone(fips string) (countyZone, error) {
+	id, state, err := countyZoneID(fips)
+	if err != nil {
+		return countyZone{}, err
+	}
+
+	req, err := http.NewRequest("GET", "https://api.weather.gov/zones/county/"+id, nil)
+	if err != nil {
+		return countyZone{}, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/geo+json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return countyZone{}, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return countyZone{}, fmt.Errorf("could not get county zone %s: %s", id, res.Status)
+	}
+
+	body := struct {
+		Geometry   geoJSONGeometry `json:"geometry"`
+		Properties struct {
+			Name string `json:"name"`
+		} `json:"properties"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return countyZone{}, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	polygons, err := body.Geometry.polygons()
+	if err != nil {
+		return countyZone{}, fmt.Errorf("could not parse county zone %s: %w", id, err)
+	}
+
+	if len(polygons) == 0 {
+		return countyZone{}, fmt.Errorf("county zone %s has no outline", id)
+	}
+
+	return countyZone{fips: fips, id: id, state: state, name: body.Properties.Name, polygons: polygons}, nil
+}
+
+// the centers of a grid laid over the county's bounding box that fall in
+// the county, or the middle of the box if a small or oddly shaped county
+// has none
+func (z countyZone) samplePoints() []coordinates {
+	var lo, hi coordinates
+	first := true
+
+	for _, p := range z.polygons {
+		for _, c := range p[0] {
+			if first || c.latitude < lo.latitude {
+				lo.latitude = c.latitude
+			}
+
+			if first || c.longitude < lo.longitude {
+				lo.longitude = c.longitude
+			}
+
+			if first || c.latitude > hi.latitude {
+				hi.latitude = c.latitude
+			}
+
+			if first || c.longitude > hi.longitude {
+				hi.longitude = c.longitude
+			}
+
+			first = false
+		}
+	}
+
+	points := []coordinates{}
+
+	for i := 0; i < countySampleGrid; i++ {
+		for j := 0; j < countySampleGrid; j++ {
+			c := coordinates{
+				latitude:  lo.latitude + (hi.latitude-lo.latitude)*(float64(i)+0.5)/countySampleGrid,
+				longitude: lo.longitude + (hi.longitude-lo.longitude)*(float64(j)+0.5)/countySampleGrid,
+			}
+
+			if anyContains(z.polygons, c) {
+				points = append(points, c)
+			}
+		}
+	}
+
+	if len(points) == 0 {
+		points = append(points, coordinates{latitude: (lo.latitude + hi.latitude) / 2, longitude: (lo.longitude + hi.longitude) / 2})
+	}
+
+	return points
+}
+
+type countySample struct {
+	point    coordinates
+	forecast gridForecast
+}
+
+// a forecast per grid cell the sample points land in, skipping any that
+// fail with a note
+func getCountySamples(points []coordinates, properties []string) []countySample {
+	results := make([]*countySample, len(points))
+	urls := make([]string, len(points))
+
+	var wg sync.WaitGroup
+
+	for i, p := range points {
+		wg.Add(1)
+
+		go func(i int, p coordinates) {
+			defer wg.Done()
+
+			grid, err := getGridPoint(p)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "skipping point %s: %s\n", p, err.Error())
+				return
+			}
+
+			forecast, err := getWeatherData(grid.forecastGridDataURL, properties)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "skipping point %s: %s\n", p, err.Error())
+				return
+			}
+
+			urls[i] = grid.forecastGridDataURL
+			results[i] = &countySample{point: p, forecast: forecast}
+		}(i, p)
+	}
+
+	wg.Wait()
+
+	seen := map[string]bool{}
+	samples := []countySample{}
+	for i, r := range results {
+		if r != nil && !seen[urls[i]] {
+			seen[urls[i]] = true
+			samples = append(samples, *r)
+		}
+	}
+
+	return samples
+}
+
+// where and when a property is lowest and highest across the county
+type countyExtreme struct {
+	Property string       `json:"property"`
+	Unit     string       `json:"unit"`
+	Min      *countyValue `json:"min"`
+	Max      *countyValue `json:"max"`
+}
+
+type countyValue struct {
+	Value     float64   `json:"value"`
+	At        time.Time `json:"at"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+}
+
+func countyExtremes(properties []string, samples []countySample, start, end time.Time, freedom bool) []countyExtreme {
+	extremes := []countyExtreme{}
+
+	for _, property := range properties {
+		e := countyExtreme{Property: property}
+
+		for _, s := range samples {
+			for at := start; at.Before(end); at = at.Add(time.Hour) {
+				p, ok := findPointAt(s.forecast.properties[property], at)
+				if !ok || p.Value == nil {
+					continue
+				}
+
+				if freedom {
+					p = liberate(p)
+				}
+
+				e.Unit = displayUnit(p.Unit)
+				v := countyValue{Value: *p.Value, At: at, Latitude: s.point.latitude, Longitude: s.point.longitude}
+
+				if e.Min == nil || v.Value < e.Min.Value {
+					e.Min = &v
+				}
+
+				if e.Max == nil || v.Value > e.Max.Value {
+					e.Max = &v
+				}
+			}
+		}
+
+		extremes = append(extremes, e)
+	}
+
+	return extremes
+}
+
+// every active alert in the state whose SAME codes include the county, which
+// catches the ones issued by forecast zone and by polygon as well as by
+// county
+func getCountyAlerts(z countyZone) ([]alert, error) {
+	alertsURL := &url.URL{
+		Scheme:   "https",
+		Host:     "api.weather.gov",
+		Path:     "/alerts/active",
+		RawQuery: url.Values{"area": []string{z.state}}.Encode(),
+	}
+
+	req, err := http.NewRequest("GET", alertsURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/geo+json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	alerts, err := parseAlerts(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []alert{}
+	for _, a := range alerts {
+		if indexOf(a.same, "0"+z.fips) >= 0 || indexOf(a.zones, z.id) >= 0 {
+			matching = append(matching, a)
+		}
+	}
+
+	sortAlerts(matching, "severity")
+
+	return matching, nil
+}
+
+type countySummary struct {
+	FIPS     string          `json:"fips"`
+	Zone     string          `json:"zone"`
+	Name     string          `json:"name"`
+	Points   int             `json:"points"`
+	Start    time.Time       `json:"start"`
+	End      time.Time       `json:"end"`
+	Extremes []countyExtreme `json:"extremes"`
+	Alerts   []schema.Alert  `json:"alerts"`
+}
+
+func displayCountySummary(s countySummary, alerts []alert, loc *time.Location) {
+	fmt.Printf("%s, %s (%s), %d grid cells from %s to %s\n", s.Name, s.Zone[:2], s.Zone, s.Points, s.Start.In(loc).Format(time.Stamp), s.End.In(loc).Format(time.Stamp))
+	fmt.Println()
+
+	t := newTable(os.Stdout, []int{28, 10, 15, 17, 10, 15, 17}, []string{"property", "min", "at", "where", "max", "at", "where"})
+
+	describe := func(e countyExtreme, v *countyValue) []string {
+		if v == nil {
+			return []string{"No Data", "-", "-"}
+		}
+
+		value := formatNumber(v.Value, kindPrecision[propertyRegistry[e.Property]])
+		if e.Unit == "%" {
+			value += e.Unit
+		} else {
+			value += " " + e.Unit
+		}
+
+		return []string{value, v.At.In(loc).Format(time.Stamp), coordinates{latitude: v.Latitude, longitude: v.Longitude}.String()}
+	}
+
+	for _, e := range s.Extremes {
+		t.row(append(append([]string{e.Property}, describe(e, e.Min)...), describe(e, e.Max)...), nil)
+	}
+
+	t.end()
+
+	fmt.Println()
+	displayAlertHeadlines(os.Stdout, alerts, loc)
+}
+
+func runCounty(flagset *flag.FlagSet) func() {
+	var (
+		fips       string
+		properties string
+		hours      int
+		format     string
+		displaytz  string
+		freedom    bool
+	)
+
+	flagset.StringVar(&fips, "fips", "", "five digit county FIPS code, like 17031 for Cook County, IL")
+	flagset.StringVar(&properties, "properties", "temperature,apparentTemperature,windGust,probabilityOfPrecipitation,quantitativePrecipitation", "comma separated properties to find the extremes of")
+	flagset.IntVar(&hours, "hours", 24, "number of hours ahead to look")
+	flagset.StringVar(&format, "format", "table", fmt.Sprintf("how to print the summary, one of %v", countyFormats))
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display times")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if indexOf(countyFormats, format) < 0 {
+			errorAndQuit(fmt.Errorf("format '%s' is not in %v", format, countyFormats))
+		}
+
+		if hours < 1 {
+			errorAndQuit(fmt.Errorf("hours must be at least 1, got %d", hours))
+		}
+
+		props := strings.Split(properties, ",")
+		for _, p := range props {
+			if _, ok := propertyRegistry[p]; !ok {
+				errorAndQuit(fmt.Errorf("requested property '%s' is not in %v", p, permittedProperties()))
+			}
+		}
+
+		zone, err := getCountyZone(fips)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		samples := getCountySamples(zone.samplePoints(), props)
+		if len(samples) == 0 {
+			errorAndQuit(fmt.Errorf("could not get a forecast for anywhere in %s", zone.id))
+		}
+
+		alerts, err := getCountyAlerts(zone)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		start := time.Now().Truncate(time.Hour)
+		end := start.Add(time.Duration(hours) * time.Hour)
+
+		summary := countySummary{
+			FIPS:     zone.fips,
+			Zone:     zone.id,
+			Name:     zone.name,
+			Points:   len(samples),
+			Start:    start,
+			End:      end,
+			Extremes: countyExtremes(props, samples, start, end, freedom),
+			Alerts:   alertDocuments(alerts),
+		}
+
+		if format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+
+			err = enc.Encode(summary)
+			if err != nil {
+				errorAndQuit(fmt.Errorf("could not write county JSON: %w", err))
+			}
+
+			return
+		}
+
+		displayCountySummary(summary, alerts, loc)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+type coverageGap struct {
+	start time.Time
+	end   time.Time
+}
+
+type coverage struct {
+	property string
+	points   int
+	first    time.Time
+	last     time.Time
+	gaps     []coverageGap
+}
+
+// what stretch of time a series actually covers, and where it doesn't, so
+// a column of "No Data" can be told apart from data that ends early
+func seriesCoverage(property string, s series) coverage {
+	c := coverage{property: property, points: s.Len()}
+
+	for i := 0; i < s.Len(); i++ {
+		p := s.At(i)
+
+		if i == 0 {
+			c.first = p.StartTime
+		} else if p.StartTime.After(c.last) {
+			c.gaps = append(c.gaps, coverageGap{start: c.last, end: p.StartTime})
+		}
+
+		if p.EndTime.After(c.last) {
+			c.last = p.EndTime
+		}
+	}
+
+	return c
+}
+
+func displayCoverage(req forecastRequest, forecast gridForecast) {
+	rows := [][]string{}
+
+	// the gaps go last and get as much room as the longest needs
+	gapWidth := 30
+
+	for _, property := range req.fetchProperties() {
+		c := seriesCoverage(property, forecast.properties[property])
+
+		if c.points == 0 {
+			rows = append(rows, []string{property, "0", "-", "-", "-"})
+			continue
+		}
+
+		gaps := []string{}
+		for _, g := range c.gaps {
+			gaps = append(gaps, g.start.In(req.displayTimeZone).Format("Jan 02 15:04")+"-"+g.end.In(req.displayTimeZone).Format("15:04"))
+		}
+
+		gapText := "none"
+		if len(gaps) > 0 {
+			gapText = strings.Join(gaps, ", ")
+		}
+
+		if w := runewidth.StringWidth(gapText); w > gapWidth {
+			gapWidth = w
+		}
+
+		rows = append(rows, []string{
+			property,
+			fmt.Sprint(c.points),
+			c.first.In(req.displayTimeZone).Format(time.Stamp),
+			c.last.In(req.displayTimeZone).Format(time.Stamp),
+			gapText,
+		})
+	}
+
+	t := newTable(os.Stdout, []int{26, 6, 15, 15, gapWidth}, []string{"property", "points", "first", "last", "gaps"})
+
+	for _, r := range rows {
+		t.row(r, nil)
+	}
+
+	t.end()
+
+	fmt.Println()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a standard five field crontab schedule: minute, hour, day of month, month,
+// and day of week, where Sunday is 0 or 7
+type cronSchedule struct {
+	minute, hour, dom, month, dow []bool
+
+	// like cron, when both days are restricted either one matching is enough
+	domAny, dowAny bool
+}
+
+var cronShortcuts = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+func parseCron(s string) (cronSchedule, error) {
+	if expanded, ok := cronShortcuts[strings.TrimSpace(s)]; ok {
+		s = expanded
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return cronSchedule{}, fmt.Errorf("schedule '%s' should have five fields, like '0 6 * * *'", s)
+	}
+
+	c := cronSchedule{domAny: fields[2] == "*", dowAny: fields[4] == "*"}
+
+	bounds := []struct {
+		into     *[]bool
+		min, max int
+		name     string
+	}{
+		{&c.minute, 0, 59, "minute"},
+		{&c.hour, 0, 23, "hour"},
+		{&c.dom, 1, 31, "day of month"},
+		{&c.month, 1, 12, "month"},
+		{&c.dow, 0, 7, "day of week"},
+	}
+
+	for i, b := range bounds {
+		allowed, err := parseCronField(fields[i], b.min, b.max)
+		if err != nil {
+			return cronSchedule{}, fmt.Errorf("schedule '%s' has a bad %s: %w", s, b.name, err)
+		}
+
+		*b.into = allowed
+	}
+
+	// 7 is another way to say Sunday
+	if c.dow[7] {
+		c.dow[0] = true
+	}
+
+	return c, nil
+}
+
+// one field, as a comma separated list of *, n, or n-m, each optionally
+// followed by /step
+func parseCronField(field string, min, max int) ([]bool, error) {
+	allowed := make([]bool, max+1)
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("'%s' has a bad step", part)
+			}
+
+			step = n
+			part = part[:i]
+		}
+
+		low, high := min, max
+
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			split := strings.SplitN(part, "-", 2)
+
+			var err1, err2 error
+			low, err1 = strconv.Atoi(split[0])
+			high, err2 = strconv.Atoi(split[1])
+
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("'%s' is not a range", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("'%s' is not a number", part)
+			}
+
+			low = n
+
+			// "5/15" means every 15 starting at 5
+			if step == 1 {
+				high = n
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return nil, fmt.Errorf("'%s' is outside %d-%d", part, min, max)
+		}
+
+		for n := low; n <= high; n += step {
+			allowed[n] = true
+		}
+	}
+
+	return allowed, nil
+}
+
+func (c cronSchedule) matches(t time.Time) bool {
+	return c.minute[t.Minute()] && c.hour[t.Hour()] && c.month[int(t.Month())] && c.matchesDay(t)
+}
+
+func (c cronSchedule) matchesDay(t time.Time) bool {
+	dom := c.dom[t.Day()]
+	dow := c.dow[int(t.Weekday())]
+
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// the first minute after t that matches, looking at most a few years out
+// for things like February 30th that never come
+func (c cronSchedule) next(t time.Time) (time.Time, bool) {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !c.month[int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !c.hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !c.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
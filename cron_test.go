@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2024, 5, 1, 12, 34, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		schedule string
+		want     string
+	}{
+		{"0 6 * * *", "2024-05-02 06:00"},
+		{"34 12 * * *", "2024-05-02 12:34"},
+		{"35 12 * * *", "2024-05-01 12:35"},
+		{"*/15 * * * *", "2024-05-01 12:45"},
+		{"5/20 * * * *", "2024-05-01 12:45"},
+		{"0,50 * * * *", "2024-05-01 12:50"},
+		{"@hourly", "2024-05-01 13:00"},
+		{"@daily", "2024-05-02 00:00"},
+		{"0 0 * * 0", "2024-05-05 00:00"},
+		{"0 0 * * 7", "2024-05-05 00:00"},
+		{"@weekly", "2024-05-05 00:00"},
+		{"0 9 * * 1-5", "2024-05-02 09:00"},
+		{"0 0 1 * *", "2024-06-01 00:00"},
+		{"0 0 1,15 * *", "2024-05-15 00:00"},
+		{"0 12 * 1 *", "2025-01-01 12:00"},
+		{"@yearly", "2025-01-01 00:00"},
+
+		// with both days restricted either one will do
+		{"0 0 13 * 5", "2024-05-03 00:00"},
+		{"0 0 2 * 0", "2024-05-02 00:00"},
+
+		{"0 0 29 2 *", "2028-02-29 00:00"},
+		{"0 0 30 2 *", ""},
+	} {
+		c, err := parseCron(tc.schedule)
+		if err != nil {
+			t.Errorf("%s: %s", tc.schedule, err)
+			continue
+		}
+
+		next, ok := c.next(from)
+		if tc.want == "" {
+			if ok {
+				t.Errorf("%s: next is %s, wanted never", tc.schedule, next)
+			}
+
+			continue
+		}
+
+		if got := next.Format("2006-01-02 15:04"); !ok || got != tc.want {
+			t.Errorf("%s: next is %s, wanted %s", tc.schedule, got, tc.want)
+		}
+	}
+}
+
+func TestCronErrors(t *testing.T) {
+	for _, bad := range []string{
+		"",
+		"0 6 * *",
+		"0 6 * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"0 0 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"@sometimes",
+	} {
+		_, err := parseCron(bad)
+		if err == nil {
+			t.Errorf("'%s' parsed", bad)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// an agwc command line to run on a schedule, like a forecast refresh
+// written to a file
+type jobConfig struct {
+	Name string `json:"name"`
+
+	// crontab style, like "0 6 * * *" for every day at 6am, or a shortcut
+	// like "@hourly"
+	Schedule string `json:"schedule"`
+
+	// the arguments to agwc, like ["-address", "...", "-format", "json"]
+	Args []string `json:"args"`
+
+	// in which to read the schedule, local time if unset
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+type scheduledJob struct {
+	job      jobConfig
+	schedule cronSchedule
+	loc      *time.Location
+
+	// a slow job is skipped rather than piling up behind itself
+	mu      sync.Mutex
+	running bool
+}
+
+func newScheduledJob(j jobConfig) (*scheduledJob, error) {
+	if j.Name == "" {
+		return nil, fmt.Errorf("every job needs a name")
+	}
+
+	if len(j.Args) == 0 {
+		return nil, fmt.Errorf("job '%s' has no args to run", j.Name)
+	}
+
+	schedule, err := parseCron(j.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("job '%s': %w", j.Name, err)
+	}
+
+	loc := time.Local
+	if j.TimeZone != "" {
+		loc, err = time.LoadLocation(j.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("job '%s' has a bad time zone: %w", j.Name, err)
+		}
+	}
+
+	return &scheduledJob{job: j, schedule: schedule, loc: loc}, nil
+}
+
+// runs the job as its own agwc process, so one that fails and exits can't
+// take the daemon with it
+func (s *scheduledJob) run(executable string) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		fmt.Printf("[%s %s] still running from last time, skipping\n", time.Now().Format("15:04:05"), s.job.Name)
+		return
+	}
+
+	s.running = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	out, err := exec.Command(executable, s.job.Args...).CombinedOutput()
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fmt.Printf("[%s %s] %s\n", time.Now().Format("15:04:05"), s.job.Name, redact(scanner.Text()))
+	}
+
+	if err != nil {
+		fmt.Printf("[%s %s] failed: %s\n", time.Now().Format("15:04:05"), s.job.Name, err)
+	}
+}
+
+func runDaemon(flagset *flag.FlagSet) func() {
+	var (
+		list     bool
+		interval time.Duration
+	)
+
+	flagset.BoolVar(&list, "list", false, "list the jobs and when they'll next run, then exit")
+	flagset.DurationVar(&interval, "interval", time.Minute, "how often notify rules check for alerts")
+
+	return func() {
+		cfg, err := loadConfig()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		jobs := []*scheduledJob{}
+		for _, j := range cfg.Jobs {
+			s, err := newScheduledJob(j)
+			if err != nil {
+				errorAndQuit(err)
+			}
+
+			jobs = append(jobs, s)
+		}
+
+		if list {
+			t := newTable(os.Stdout, []int{15, 20, 20, 40}, []string{"job", "schedule", "next run", "args"})
+			for _, s := range jobs {
+				next := "never"
+				if at, ok := s.schedule.next(time.Now().In(s.loc)); ok {
+					next = at.Format("Mon Jan _2 15:04")
+				}
+
+				t.row([]string{s.job.Name, s.job.Schedule, next, strings.Join(s.job.Args, " ")}, nil)
+			}
+
+			t.end()
+
+			return
+		}
+
+		if len(jobs) == 0 && len(cfg.Notify.Rules) == 0 {
+			path, _ := configPath()
+			errorAndQuit(fmt.Errorf("nothing to do, add \"jobs\" or \"notify\" rules to %s", path))
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not find the agwc executable to run jobs with: %w", err))
+		}
+
+		if len(cfg.Notify.Rules) > 0 {
+			go func() {
+				err := watchRules(cfg.Notify.Rules, nil, interval)
+				if err != nil {
+					errorAndQuit(err)
+				}
+			}()
+		}
+
+		fmt.Printf("running %d jobs, press ctrl-c to stop\n", len(jobs))
+
+		for {
+			// wake up just after each minute starts, like cron
+			now := time.Now()
+			time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+
+			now = time.Now()
+			for _, s := range jobs {
+				if s.schedule.matches(now.In(s.loc)) {
+					go s.run(executable)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// a threshold a school or camp acts on, like {"name": "lightning", "when":
+// "probabilityOfThunder>=30", "within": "3h", "action": "move indoors"}.
+// when is a check assertion without a window, in metric, and it's enough
+// for it to hold at any hour from now until within is up.
+type decisionRule struct {
+	Name   string `json:"name"`
+	When   string `json:"when"`
+	Within string `json:"within,omitempty"`
+	Action string `json:"action"`
+}
+
+// how far ahead a rule looks if it doesn't say
+const defaultDecisionWindow = 12 * time.Hour
+
+// used unless the config has its own decision rules. the cold and heat ones
+// are roughly where districts call off outdoor recess and practices, -28 C
+// being about -20 F, and thunder is moved in from well before it's overhead.
+var defaultDecisionRules = []decisionRule{
+	{Name: "cold", When: "windChill<=-28", Within: "12h", Action: "indoor recess, delay the buses"},
+	{Name: "heat", When: "heatIndex>=39", Within: "12h", Action: "cancel outdoor practice"},
+	{Name: "heat caution", When: "heatIndex>=32", Within: "12h", Action: "water breaks every 20 minutes"},
+	{Name: "lightning", When: "probabilityOfThunder>=30", Within: "3h", Action: "move indoors"},
+	{Name: "wind", When: "windGust>=65", Within: "12h", Action: "take down tents and canopies"},
+}
+
+type decision struct {
+	Name      string     `json:"name"`
+	Action    string     `json:"action"`
+	When      string     `json:"when"`
+	Within    string     `json:"within"`
+	Triggered bool       `json:"triggered"`
+	At        *time.Time `json:"at,omitempty"`
+	Value     *float64   `json:"value,omitempty"`
+	Unit      string     `json:"unit,omitempty"`
+}
+
+type decisionFeed struct {
+	Location  string     `json:"location"`
+	Generated time.Time  `json:"generated"`
+	Actions   []string   `json:"actions"`
+	Decisions []decision `json:"decisions"`
+}
+
+type parsedDecisionRule struct {
+	rule      decisionRule
+	assertion assertion
+	within    time.Duration
+}
+
+func parseDecisionRules(rules []decisionRule) ([]parsedDecisionRule, error) {
+	parsed := []parsedDecisionRule{}
+
+	for _, r := range rules {
+		a, err := parseAssertion(r.When)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse decision rule '%s': %w", r.Name, err)
+		}
+
+		if a.window != 0 || a.until != "" {
+			return nil, fmt.Errorf("decision rule '%s' has a window in its condition, use within instead", r.Name)
+		}
+
+		p := parsedDecisionRule{rule: r, assertion: a, within: defaultDecisionWindow}
+
+		if r.Within != "" {
+			p.within, err = time.ParseDuration(r.Within)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse within for decision rule '%s': %w", r.Name, err)
+			}
+		} else {
+			p.rule.Within = defaultDecisionWindow.String()
+		}
+
+		parsed = append(parsed, p)
+	}
+
+	return parsed, nil
+}
+
+func decisionProperties(rules []parsedDecisionRule) []string {
+	properties := []string{}
+
+	for _, r := range rules {
+		if indexOf(properties, r.assertion.property) < 0 {
+			properties = append(properties, r.assertion.property)
+		}
+	}
+
+	return properties
+}
+
+// the first hour each rule holds. hours without data don't trigger
+// anything, since a feed that cancels on a gap in the forecast is one
+// nobody will trust.
+func decide(name string, rules []parsedDecisionRule, forecast gridForecast, now time.Time) decisionFeed {
+	feed := decisionFeed{Location: name, Generated: now.UTC(), Actions: []string{}, Decisions: []decision{}}
+
+	start := now.Truncate(time.Hour)
+
+	for _, r := range rules {
+		d := decision{Name: r.rule.Name, Action: r.rule.Action, When: r.rule.When, Within: r.rule.Within}
+
+		for at := start; at.Before(now.Add(r.within)); at = at.Add(time.Hour) {
+			p, ok := findPointAt(forecast.properties[r.assertion.property], at)
+			if !ok || p.Value == nil || !r.assertion.holds(*p.Value) {
+				continue
+			}
+
+			triggeredAt := at.UTC()
+			d.Triggered, d.At, d.Value, d.Unit = true, &triggeredAt, p.Value, displayUnit(p.Unit)
+
+			if indexOf(feed.Actions, r.rule.Action) < 0 {
+				feed.Actions = append(feed.Actions, r.rule.Action)
+			}
+
+			break
+		}
+
+		feed.Decisions = append(feed.Decisions, d)
+	}
+
+	return feed
+}
+
+func loadDecisionRules() ([]parsedDecisionRule, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := cfg.Decisions
+	if len(rules) == 0 {
+		rules = defaultDecisionRules
+	}
+
+	return parseDecisionRules(rules)
+}
+
+func decisionFeedFor(name string, forecastGridDataURL string, rules []parsedDecisionRule) (decisionFeed, error) {
+	forecast, err := getWeatherData(forecastGridDataURL, decisionProperties(rules))
+	if err != nil {
+		return decisionFeed{}, err
+	}
+
+	return decide(name, rules, forecast, time.Now()), nil
+}
+
+// the serve mode version, one feed per location the key can see
+func decisionsHandler(locations []skillLocation, rules []parsedDecisionRule) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		feeds := []decisionFeed{}
+
+		key, limited := requestKey(r)
+
+		for _, l := range locations {
+			if limited && !key.allows(l.name) {
+				continue
+			}
+
+			feed, err := decisionFeedFor(l.name, l.grid.forecastGridDataURL, rules)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+
+			feeds = append(feeds, feed)
+		}
+
+		writeJSON(w, http.StatusOK, feeds)
+	}
+}
+
+func runDecide(flagset *flag.FlagSet) func() {
+	var queryAddress string
+
+	flagset.StringVar(&queryAddress, "address", "", "address or named location of the school, camp or field")
+
+	return func() {
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		rules, err := loadDecisionRules()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		feed, err := decisionFeedFor(queryAddress, grid.forecastGridDataURL, rules)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+
+		err = enc.Encode(feed)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not write decisions: %w", err))
+		}
+	}
+}
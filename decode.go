@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// upstream adds fields and leaves things out often enough that by default we
+// shrug and carry on, but with -strict-decode any surprise in the shape of a
+// response is an error, which is what you want when checking for schema drift
+var strictDecode bool
+
+// for the parts of responses we model completely, where an unknown field
+// means the shape has changed under us
+func decodeExact(data []byte, into interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strictDecode {
+		dec.DisallowUnknownFields()
+	}
+
+	return dec.Decode(into)
+}
+
+type requiredField struct {
+	name    string
+	present bool
+}
+
+// fails on missing fields in strict mode, otherwise warns on stderr so the
+// breakage at least shows up somewhere
+func checkRequired(what string, fields ...requiredField) error {
+	missing := []string{}
+	for _, f := range fields {
+		if !f.present {
+			missing = append(missing, f.name)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if strictDecode {
+		return fmt.Errorf("%s is missing %s", what, strings.Join(missing, ", "))
+	}
+
+	fmt.Fprintf(os.Stderr, "warning: %s is missing %s\n", what, strings.Join(missing, ", "))
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// the parts of a forecast run beyond the forecast itself, like alerts or
+// observations, that couldn't be fetched. with -strict one failing ends the
+// run, otherwise the rest is shown with a notice saying what's missing.
+type notices []string
+
+func (n *notices) add(req forecastRequest, section string, err error) string {
+	if req.strict {
+		errorAndQuit(fmt.Errorf("could not get %s: %w", section, err))
+	}
+
+	notice := fmt.Sprintf("%s left out: %s", section, err.Error())
+	if partial(err) {
+		notice = fmt.Sprintf("%s incomplete: %s", section, err.Error())
+	}
+
+	*n = append(*n, notice)
+
+	return notice
+}
+
+// a section that's missing some of what it fetched, like a spread that
+// skipped a cell, but still has the rest to show under its notice
+type partialError struct {
+	err error
+}
+
+func (e partialError) Error() string {
+	return e.err.Error()
+}
+
+func (e partialError) Unwrap() error {
+	return e.err
+}
+
+func partial(err error) bool {
+	return errors.As(err, &partialError{})
+}
+
+// in the table where the section would have been, and for the other
+// formats on stderr so what's on stdout still parses
+func printNotice(table bool, notice string) {
+	var w io.Writer = os.Stderr
+	if table {
+		w = os.Stdout
+	}
+
+	fmt.Fprintln(w, "notice: "+notice)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// the value from delta ago, as displayed, for comparing against. the forecast
+// reaches back a little way, and anything before that comes from what the
+// nearest station actually saw.
+type deltaSource struct {
+	forecast map[string]series
+	observed map[string]series
+	req      forecastRequest
+}
+
+func (d deltaSource) valueAt(property string, at time.Time) *float64 {
+	for _, source := range []map[string]series{d.forecast, d.observed} {
+		p, ok := findPointAt(source[property], at)
+		if !ok || p.Value == nil {
+			continue
+		}
+
+		return d.req.liberate(p).Value
+	}
+
+	return nil
+}
+
+func formatDelta(v float64, precision int) string {
+	s := formatNumber(v, precision)
+	if !strings.HasPrefix(s, "-") {
+		s = "+" + s
+	}
+
+	return s
+}
+
+// appends "(+3)" to each cell of the delta properties, for the change since
+// the same hour delta earlier
+func applyDeltas(req forecastRequest, grid gridPoint, c coordinates, forecast gridForecast, rows []displayRow) error {
+	source := deltaSource{forecast: forecast.properties, req: req}
+
+	// only ask the station if the forecast doesn't reach back far enough
+	earliest := req.start.Truncate(time.Hour).Add(-req.delta)
+	for _, p := range req.deltaProperties {
+		if _, ok := findPointAt(forecast.properties[p], earliest); !ok && earliest.Before(time.Now()) {
+			observed, err := getDeltaObservations(req, grid, c, earliest)
+			if err != nil {
+				return err
+			}
+
+			source.observed = observed
+
+			break
+		}
+	}
+
+	for _, p := range req.deltaProperties {
+		i := indexOf(req.properties, p)
+		precision := kindPrecision[propertyRegistry[p]]
+
+		for r := range rows {
+			now := rows[r].numbers[i]
+			then := source.valueAt(p, rows[r].at.Add(-req.delta))
+
+			if now == nil || then == nil {
+				continue
+			}
+
+			rows[r].values[i] += " (" + formatDelta(*now-*then, precision) + ")"
+		}
+	}
+
+	return nil
+}
+
+func getDeltaObservations(req forecastRequest, grid gridPoint, c coordinates, since time.Time) (map[string]series, error) {
+	stations, err := getStations(grid, c)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := selectStation(stations, req.station)
+	if err != nil {
+		return nil, err
+	}
+
+	observations, err := getObservationHistory(s, since.Add(-time.Hour), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return observationSeries(observations, req.deltaProperties), nil
+}
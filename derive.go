@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// a user defined column computed from the values of other columns in the same
+// row, e.g. "feels[F]=temperature - (windSpeed/10)"
+type derivedColumn struct {
+	name   string
+	unit   string
+	source string
+	expr   expression
+}
+
+type derivedColumns []derivedColumn
+
+func (d *derivedColumns) String() string {
+	names := []string{}
+	for _, c := range *d {
+		names = append(names, c.name)
+	}
+
+	return strings.Join(names, ",")
+}
+
+func (d *derivedColumns) Set(s string) error {
+	c, err := parseDerivedColumn(s)
+	if err != nil {
+		return err
+	}
+
+	*d = append(*d, c)
+
+	return nil
+}
+
+func parseDerivedColumn(s string) (derivedColumn, error) {
+	split := strings.SplitN(s, "=", 2)
+	if len(split) != 2 {
+		return derivedColumn{}, fmt.Errorf("derived column must look like name=expression, got '%s'", s)
+	}
+
+	c := derivedColumn{name: strings.TrimSpace(split[0]), source: s}
+
+	if open := strings.Index(c.name, "["); open >= 0 {
+		if !strings.HasSuffix(c.name, "]") {
+			return derivedColumn{}, fmt.Errorf("malformed unit annotation in '%s'", c.name)
+		}
+
+		c.unit = c.name[open+1 : len(c.name)-1]
+		c.name = strings.TrimSpace(c.name[:open])
+	}
+
+	if c.name == "" {
+		return derivedColumn{}, fmt.Errorf("derived column name cannot be empty")
+	}
+
+	if source := strings.TrimSpace(split[1]); strings.HasPrefix(source, "plugin:") {
+		name := strings.TrimPrefix(source, "plugin:")
+
+		if _, ok := metricPlugins[name]; !ok {
+			return derivedColumn{}, fmt.Errorf("metric plugin '%s' for '%s' is not in the config", name, c.name)
+		}
+
+		c.expr = pluginExpr(name)
+
+		return c, nil
+	}
+
+	if source := strings.TrimSpace(split[1]); strings.HasPrefix(source, "comfort:") {
+		if c.unit != "" {
+			return derivedColumn{}, fmt.Errorf("'%s' is a temperature in the displayed units, so it can't have a unit annotation", c.name)
+		}
+
+		expr, err := parseComfortExpr(strings.TrimPrefix(source, "comfort:"))
+		if err != nil {
+			return derivedColumn{}, fmt.Errorf("could not parse '%s': %w", c.name, err)
+		}
+
+		c.expr = expr
+
+		return c, nil
+	}
+
+	expr, err := parseExpression(split[1])
+	if err != nil {
+		return derivedColumn{}, fmt.Errorf("could not parse expression for '%s': %w", c.name, err)
+	}
+
+	c.expr = expr
+
+	return c, nil
+}
+
+func (c derivedColumn) format(v *float64) string {
+	if v == nil {
+		return "No Data"
+	}
+
+	value := formatNumber(*v, kindPrecision[kindGeneric])
+	if c.unit == "" {
+		return value
+	}
+
+	return value + " " + c.unit
+}
+
+// evaluates to nil if any referenced value is missing or the math doesn't work out
+type expression interface {
+	eval(values map[string]*float64) *float64
+	variables() []string
+}
+
+type numberExpr float64
+
+func (n numberExpr) eval(map[string]*float64) *float64 {
+	v := float64(n)
+	return &v
+}
+
+func (n numberExpr) variables() []string { return nil }
+
+type variableExpr string
+
+func (v variableExpr) eval(values map[string]*float64) *float64 {
+	return values[string(v)]
+}
+
+func (v variableExpr) variables() []string { return []string{string(v)} }
+
+type negateExpr struct {
+	operand expression
+}
+
+func (n negateExpr) eval(values map[string]*float64) *float64 {
+	v := n.operand.eval(values)
+	if v == nil {
+		return nil
+	}
+
+	r := -*v
+	return &r
+}
+
+func (n negateExpr) variables() []string { return n.operand.variables() }
+
+type binaryExpr struct {
+	op          byte
+	left, right expression
+}
+
+func (b binaryExpr) eval(values map[string]*float64) *float64 {
+	l := b.left.eval(values)
+	r := b.right.eval(values)
+
+	if l == nil || r == nil {
+		return nil
+	}
+
+	var v float64
+
+	switch b.op {
+	case '+':
+		v = *l + *r
+	case '-':
+		v = *l - *r
+	case '*':
+		v = *l * *r
+	case '/':
+		if *r == 0 {
+			return nil
+		}
+
+		v = *l / *r
+	}
+
+	return &v
+}
+
+func (b binaryExpr) variables() []string {
+	return append(b.left.variables(), b.right.variables()...)
+}
+
+// a tiny recursive descent parser for arithmetic over property names:
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = factor { ("*" | "/") factor }
+//	factor = number | name | "-" factor | "(" expr ")"
+type expressionParser struct {
+	tokens []string
+	pos    int
+}
+
+func parseExpression(s string) (expression, error) {
+	tokens, err := tokenizeExpression(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &expressionParser{tokens: tokens}
+
+	expr, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s'", p.tokens[p.pos])
+	}
+
+	return expr, nil
+}
+
+func tokenizeExpression(s string) ([]string, error) {
+	tokens := []string{}
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("+-*/()", r):
+			tokens = append(tokens, string(r))
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case unicode.IsLetter(r):
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character '%c'", r)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (p *expressionParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+
+	return p.tokens[p.pos]
+}
+
+func (p *expressionParser) parseSum() (expression, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "+" || p.peek() == "-" {
+		op := p.peek()[0]
+		p.pos++
+
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binaryExpr{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) parseProduct() (expression, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "*" || p.peek() == "/" {
+		op := p.peek()[0]
+		p.pos++
+
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binaryExpr{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) parseFactor() (expression, error) {
+	tok := p.peek()
+	if tok == "" {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	p.pos++
+
+	switch {
+	case tok == "-":
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+
+		return negateExpr{operand: operand}, nil
+	case tok == "(":
+		inner, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+
+		p.pos++
+
+		return inner, nil
+	case unicode.IsDigit(rune(tok[0])) || tok[0] == '.':
+		v, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse number '%s': %w", tok, err)
+		}
+
+		return numberExpr(v), nil
+	case unicode.IsLetter(rune(tok[0])):
+		return variableExpr(tok), nil
+	default:
+		return nil, fmt.Errorf("unexpected '%s'", tok)
+	}
+}
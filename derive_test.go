@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestExpressionEval(t *testing.T) {
+	values := map[string]*float64{
+		"temperature": floatPtr(20),
+		"windSpeed":   floatPtr(30),
+		"dewpoint":    floatPtr(12.5),
+		"skyCover":    floatPtr(0),
+		"windGust":    nil,
+	}
+
+	for _, tc := range []struct {
+		expr string
+		want *float64
+	}{
+		{"temperature", floatPtr(20)},
+		{"2.5", floatPtr(2.5)},
+		{"temperature - (windSpeed/10)", floatPtr(17)},
+		{"temperature - windSpeed/10", floatPtr(17)},
+		{"(temperature - windSpeed)/10", floatPtr(-1)},
+		{"1 + 2 * 3", floatPtr(7)},
+		{"10 - 4 - 3", floatPtr(3)},
+		{"24 / 4 / 2", floatPtr(3)},
+		{"-temperature + 5", floatPtr(-15)},
+		{"--temperature", floatPtr(20)},
+		{"temperature * 9 / 5 + 32", floatPtr(68)},
+		{"temperature - dewpoint", floatPtr(7.5)},
+
+		// missing values and undefined math are no data, not an error
+		{"windGust - windSpeed", nil},
+		{"pressure", nil},
+		{"temperature / skyCover", nil},
+		{"-(windGust)", nil},
+	} {
+		expr, err := parseExpression(tc.expr)
+		if err != nil {
+			t.Errorf("%s: %s", tc.expr, err)
+			continue
+		}
+
+		got := expr.eval(values)
+
+		switch {
+		case got == nil && tc.want == nil:
+		case got == nil || tc.want == nil:
+			t.Errorf("%s: got %v, wanted %v", tc.expr, got, tc.want)
+		case math.Abs(*got-*tc.want) > 1e-9:
+			t.Errorf("%s: got %g, wanted %g", tc.expr, *got, *tc.want)
+		}
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	for _, bad := range []string{"", "temperature +", "(temperature", "temperature)", "temperature windSpeed", "1..2", "temperature % 2", "*3"} {
+		_, err := parseExpression(bad)
+		if err == nil {
+			t.Errorf("'%s' parsed", bad)
+		}
+	}
+}
+
+func TestParseDerivedColumn(t *testing.T) {
+	for _, tc := range []struct {
+		s, name, unit string
+		variables     []string
+	}{
+		{"feels=temperature - (windSpeed/10)", "feels", "", []string{"temperature", "windSpeed"}},
+		{"feels[F] = temperature * 9/5 + 32", "feels", "F", []string{"temperature"}},
+		{" spread [C]=temperature-dewpoint", "spread", "C", []string{"temperature", "dewpoint"}},
+	} {
+		c, err := parseDerivedColumn(tc.s)
+		if err != nil {
+			t.Errorf("%s: %s", tc.s, err)
+			continue
+		}
+
+		if c.name != tc.name || c.unit != tc.unit || len(c.expr.variables()) != len(tc.variables) {
+			t.Errorf("%s: got %s [%s] of %v", tc.s, c.name, c.unit, c.expr.variables())
+			continue
+		}
+
+		for i, v := range tc.variables {
+			if c.expr.variables()[i] != v {
+				t.Errorf("%s: variables %v, wanted %v", tc.s, c.expr.variables(), tc.variables)
+			}
+		}
+	}
+
+	for _, bad := range []string{"temperature", "=temperature", "feels[F=temperature", "[F]=temperature", "feels=comfort:wetbulb", "feels[F]=comfort:humidex", "ratio=a=b"} {
+		_, err := parseDerivedColumn(bad)
+		if err == nil {
+			t.Errorf("'%s' parsed", bad)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type discussionSection struct {
+	title string
+	body  string
+}
+
+// forecasters start each section with a line like ".SHORT TERM /Tonight
+// through Tuesday/..." and end it with "&&". whatever comes before the
+// first one is the header, and whoever wrote it signs off after "$$".
+func splitDiscussion(text string) []discussionSection {
+	sections := []discussionSection{}
+	current := discussionSection{title: "header"}
+	lines := []string{}
+
+	flush := func() {
+		current.body = strings.TrimSpace(strings.Join(lines, "\n"))
+		if current.body != "" {
+			if current.title == "" {
+				current.title = "notes"
+			}
+
+			sections = append(sections, current)
+		}
+
+		lines = []string{}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, ".") && strings.Contains(trimmed, "...") && !strings.HasPrefix(trimmed, ".."):
+			flush()
+
+			title := strings.TrimPrefix(trimmed, ".")
+			rest := ""
+			if i := strings.Index(title, "..."); i >= 0 {
+				title, rest = title[:i], strings.TrimSpace(title[i+3:])
+			}
+
+			current = discussionSection{title: strings.TrimSpace(title)}
+
+			if rest != "" {
+				lines = append(lines, rest)
+			}
+		case trimmed == "&&":
+			flush()
+			current = discussionSection{}
+		case trimmed == "$$":
+			flush()
+			current = discussionSection{title: "forecasters"}
+		default:
+			lines = append(lines, line)
+		}
+	}
+
+	flush()
+
+	return sections
+}
+
+// a section by any unambiguous start of its name, ignoring case, so "short"
+// finds "SHORT TERM /Tonight through Tuesday/"
+func findSection(sections []discussionSection, name string) (discussionSection, error) {
+	matches := []discussionSection{}
+	for _, s := range sections {
+		if strings.HasPrefix(strings.ToLower(s.title), strings.ToLower(name)) {
+			matches = append(matches, s)
+		}
+	}
+
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+
+	titles := []string{}
+	for _, s := range sections {
+		titles = append(titles, s.title)
+	}
+
+	if len(matches) == 0 {
+		return discussionSection{}, fmt.Errorf("no section '%s', the discussion has %s", name, strings.Join(titles, ", "))
+	}
+
+	return discussionSection{}, fmt.Errorf("section '%s' is ambiguous, the discussion has %s", name, strings.Join(titles, ", "))
+}
+
+func runDiscussion(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		section      string
+		list         bool
+		displaytz    string
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address whose forecast office's discussion to show")
+	flagset.StringVar(&section, "section", "", "only show the section starting with this, e.g. 'synopsis' or 'short'")
+	flagset.BoolVar(&list, "sections", false, "list the sections instead of showing the discussion")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display when it was issued")
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		afd, err := getLatestProduct("AFD", grid.office)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		sections := splitDiscussion(afd.text)
+
+		switch {
+		case list:
+			titles := []string{}
+			for _, s := range sections {
+				titles = append(titles, s.title)
+			}
+
+			afd.text = strings.Join(titles, "\n")
+		case section != "":
+			s, err := findSection(sections, section)
+			if err != nil {
+				errorAndQuit(err)
+			}
+
+			afd.text = s.title + "\n\n" + s.body
+		}
+
+		displayProduct(afd, loc)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// something to wear when every condition in When holds, like
+// {"when": "feelsLike<4", "wear": "gloves"}. conditions are comma
+// separated and in metric, over clothingVariables.
+type clothingRule struct {
+	When string `json:"when"`
+	Wear string `json:"wear"`
+
+	// only for these activities, or any of them if empty
+	Activities []string `json:"activities,omitempty"`
+}
+
+// what rules can look at, taken over the whole outing. feelsLike is the
+// wind chill at the coldest, warmed up by the effort of the activity.
+var clothingVariables = []string{"temperature", "feelsLike", "windSpeed", "probabilityOfPrecipitation"}
+
+// how much warmer it feels once moving, and how much wind comes from the
+// moving itself
+type activity struct {
+	warmth float64
+	wind   float64
+}
+
+var activities = map[string]activity{
+	"run":   {warmth: 8},
+	"cycle": {warmth: 4, wind: 20},
+	"walk":  {warmth: 2},
+}
+
+func activityNames() []string {
+	return []string{"cycle", "run", "walk"}
+}
+
+// used unless the config has its own clothing rules, where the usual
+// advice is to dress for about 10 degrees F warmer than it is
+var defaultClothingRules = []clothingRule{
+	{When: "feelsLike>=15", Wear: "short sleeves"},
+	{When: "feelsLike<15,feelsLike>=7", Wear: "long sleeves"},
+	{When: "feelsLike<7", Wear: "base layer and a jacket"},
+	{When: "feelsLike>=10", Wear: "shorts", Activities: []string{"run", "cycle"}},
+	{When: "feelsLike<10", Wear: "tights", Activities: []string{"run", "cycle"}},
+	{When: "feelsLike<4", Wear: "gloves"},
+	{When: "feelsLike<-2", Wear: "hat or headband"},
+	{When: "feelsLike<-12", Wear: "face cover"},
+	{When: "feelsLike<5", Wear: "shoe covers", Activities: []string{"cycle"}},
+	{When: "windSpeed>=25", Wear: "wind vest", Activities: []string{"cycle"}},
+	{When: "probabilityOfPrecipitation>=40", Wear: "rain shell"},
+	{When: "temperature>=25", Wear: "sunscreen and extra water"},
+}
+
+type clothingCondition struct {
+	variable string
+	operator string
+	value    float64
+}
+
+func parseClothingConditions(s string) ([]clothingCondition, error) {
+	conditions := []clothingCondition{}
+
+	for _, part := range strings.Split(s, ",") {
+		matches := assertionMatcher.FindStringSubmatch(part)
+		if len(matches) == 0 || matches[assertionMatcher.SubexpIndex("window")] != "" || matches[assertionMatcher.SubexpIndex("until")] != "" {
+			return nil, fmt.Errorf("'%s' is not a valid condition, expected something like 'feelsLike<4'", strings.TrimSpace(part))
+		}
+
+		c := clothingCondition{
+			variable: matches[assertionMatcher.SubexpIndex("property")],
+			operator: matches[assertionMatcher.SubexpIndex("operator")],
+		}
+
+		if indexOf(clothingVariables, c.variable) < 0 {
+			return nil, fmt.Errorf("condition variable '%s' is not in %v", c.variable, clothingVariables)
+		}
+
+		v, err := strconv.ParseFloat(matches[assertionMatcher.SubexpIndex("threshold")], 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse value in '%s': %w", strings.TrimSpace(part), err)
+		}
+
+		c.value = v
+		conditions = append(conditions, c)
+	}
+
+	return conditions, nil
+}
+
+// missing data never meets a condition, so nothing is suggested on a guess
+func (c clothingCondition) holds(values map[string]*float64) bool {
+	v := values[c.variable]
+	if v == nil {
+		return false
+	}
+
+	return assertion{operator: c.operator, threshold: c.value}.holds(*v)
+}
+
+// the worst of each over the outing, metric, for the rules to look at
+func outingConditions(samples []eventSample, a activity) map[string]*float64 {
+	temperatures := []*float64{}
+	feels := []*float64{}
+	winds := []*float64{}
+	precips := []*float64{}
+
+	for _, s := range samples {
+		temperatures = append(temperatures, s.temperature)
+		winds = append(winds, s.wind)
+		precips = append(precips, s.precip)
+
+		if s.temperature == nil {
+			continue
+		}
+
+		feel := *s.temperature
+		if s.wind != nil {
+			if chill := windChillCelsius(feel, *s.wind+a.wind); chill != nil {
+				feel = *chill
+			}
+		}
+
+		feel += a.warmth
+		feels = append(feels, &feel)
+	}
+
+	return map[string]*float64{
+		"temperature":                rangeOf(temperatures).min,
+		"feelsLike":                  rangeOf(feels).min,
+		"windSpeed":                  rangeOf(winds).max,
+		"probabilityOfPrecipitation": rangeOf(precips).max,
+	}
+}
+
+func suggestClothing(rules []clothingRule, activityName string, values map[string]*float64) ([]string, error) {
+	wear := []string{}
+
+	for _, r := range rules {
+		if len(r.Activities) > 0 && indexOf(r.Activities, activityName) < 0 {
+			continue
+		}
+
+		conditions, err := parseClothingConditions(r.When)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse clothing rule for '%s': %w", r.Wear, err)
+		}
+
+		holds := true
+		for _, c := range conditions {
+			holds = holds && c.holds(values)
+		}
+
+		if holds && indexOf(wear, r.Wear) < 0 {
+			wear = append(wear, r.Wear)
+		}
+	}
+
+	return wear, nil
+}
+
+func runDress(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		at           string
+		duration     time.Duration
+		activityName string
+		displaytz    string
+		freedom      bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address or named location to head out from")
+	flagset.StringVar(&at, "at", "", "when you head out, e.g. 'sat 7:00' or '18:30' in the display timezone")
+	flagset.DurationVar(&duration, "duration", time.Hour, "how long you'll be out")
+	flagset.StringVar(&activityName, "activity", "run", fmt.Sprintf("what you'll be doing, one of %v", activityNames()))
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone of -at and in which to display times")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		a, ok := activities[activityName]
+		if !ok {
+			errorAndQuit(fmt.Errorf("activity '%s' is not in %v", activityName, activityNames()))
+		}
+
+		start := time.Now()
+		if at != "" {
+			start, err = parseClockExpression(at, time.Now(), loc)
+			if err != nil {
+				errorAndQuit(err)
+			}
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		rules := cfg.Clothing
+		if len(rules) == 0 {
+			rules = defaultClothingRules
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, eventProperties)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		values := outingConditions(sampleEvent(forecast, start, duration), a)
+
+		if values["temperature"] == nil {
+			fmt.Println("no forecast for then")
+			quit(exitNoData)
+		}
+
+		wear, err := suggestClothing(rules, activityName, values)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		fmt.Printf(
+			"%s at %s: %s, feels like %s once you're moving, wind up to %s, %s chance of rain\n",
+			activityName,
+			start.In(loc).Format("Mon 15:04"),
+			formatCelsius(values["temperature"], freedom),
+			formatCelsius(values["feelsLike"], freedom),
+			formatKph(values["windSpeed"], freedom),
+			formatWeatherValue("probabilityOfPrecipitation", weatherPoint{Value: values["probabilityOfPrecipitation"], Unit: "wmoUnit:percent"}, freedom),
+		)
+
+		fmt.Println()
+
+		if len(wear) == 0 {
+			fmt.Println("none of the clothing rules matched")
+			return
+		}
+
+		for _, w := range wear {
+			fmt.Printf("- %s\n", w)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import "time"
+
+// the start of the hour t is in, as the clock in loc reads it. this is the
+// same as truncating in UTC everywhere the offset is a whole number of
+// hours, but in a zone like Asia/Kolkata the rows land on the local hour
+// instead of at half past. it works from the offset t actually has, so the
+// repeated hour when clocks fall back stays the one t is in.
+func localHour(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+
+	return t.Add(-time.Duration(local.Minute())*time.Minute - time.Duration(local.Second())*time.Second - time.Duration(local.Nanosecond()))
+}
+
+// what happens to the clocks in loc between two hours in a row, "spring
+// forward" when an hour is skipped, "fall back" when one repeats, or ""
+func clockChange(prev, next time.Time, loc *time.Location) string {
+	_, before := prev.In(loc).Zone()
+	_, after := next.In(loc).Zone()
+
+	switch {
+	case after > before:
+		return "spring forward"
+	case after < before:
+		return "fall back"
+	default:
+		return ""
+	}
+}
+
+// whether the clock in loc reads the same hour twice around t, which it
+// does on either side of falling back
+func repeatedHour(t time.Time, loc *time.Location) bool {
+	wall := func(t time.Time) string { return t.In(loc).Format("2006-01-02 15") }
+
+	return wall(t.Add(-time.Hour)) == wall(t) || wall(t.Add(time.Hour)) == wall(t)
+}
+
+// the time column for a row. the hour repeated when clocks fall back gets
+// its zone, so the two are told apart, in place of the seconds, which the
+// low confidence marker also takes the room of.
+func rowTimeCell(req forecastRequest, r displayRow) string {
+	local := r.at.In(req.displayTimeZone)
+
+	layout := time.Stamp
+	if repeatedHour(r.at, req.displayTimeZone) {
+		layout = "Jan _2 15:04 MST"
+	}
+
+	if req.lowConfidenceAt(r) {
+		if layout == time.Stamp {
+			layout = "Jan _2 15:04"
+		}
+
+		return local.Format(layout) + lowConfidenceMarker
+	}
+
+	return local.Format(layout)
+}
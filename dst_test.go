@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return loc
+}
+
+// an hourly temperature of the hour's index, starting at start
+func hourlyTemperatures(start time.Time, hours int) map[string]series {
+	points := []weatherPoint{}
+	for i := 0; i < hours; i++ {
+		v := float64(i)
+		points = append(points, weatherPoint{
+			StartTime: start.Add(time.Duration(i) * time.Hour),
+			EndTime:   start.Add(time.Duration(i+1) * time.Hour),
+			Value:     &v,
+			Unit:      "wmoUnit:degC",
+		})
+	}
+
+	return map[string]series{"temperature": newSeries(points)}
+}
+
+func dstRequest(loc *time.Location, start, end time.Time) forecastRequest {
+	return forecastRequest{
+		properties:      []string{"temperature"},
+		start:           start,
+		end:             end,
+		displayTimeZone: loc,
+	}
+}
+
+func localClocks(rows []displayRow, loc *time.Location) []string {
+	clocks := []string{}
+	for _, r := range rows {
+		clocks = append(clocks, r.at.In(loc).Format("15:04 MST"))
+	}
+
+	return clocks
+}
+
+func TestBuildRowsCrossMidnight(t *testing.T) {
+	loc := mustLoadLocation(t, "America/Chicago")
+
+	start := time.Date(2024, 6, 1, 22, 0, 0, 0, loc)
+	req := dstRequest(loc, start, start.Add(4*time.Hour))
+
+	rows := buildRows(req, hourlyTemperatures(start.Add(-time.Hour), 8))
+
+	got := strings.Join(localClocks(rows, loc), ",")
+	want := "22:00 CDT,23:00 CDT,00:00 CDT,01:00 CDT,02:00 CDT"
+	if got != want {
+		t.Fatalf("got rows %s, want %s", got, want)
+	}
+
+	if rows[2].values[0] != "3 C" {
+		t.Fatalf("got %s at midnight, want 3 C", rows[2].values[0])
+	}
+}
+
+func TestBuildRowsSpringForward(t *testing.T) {
+	loc := mustLoadLocation(t, "America/Chicago")
+
+	// 02:00 on March 10 2024 never happens in Chicago
+	start := time.Date(2024, 3, 10, 0, 0, 0, 0, loc)
+	req := dstRequest(loc, start, start.Add(3*time.Hour))
+
+	rows := buildRows(req, hourlyTemperatures(start, 6))
+
+	got := strings.Join(localClocks(rows, loc), ",")
+	want := "00:00 CST,01:00 CST,03:00 CDT,04:00 CDT"
+	if got != want {
+		t.Fatalf("got rows %s, want %s", got, want)
+	}
+
+	for i := 1; i < len(rows); i++ {
+		if d := rows[i].at.Sub(rows[i-1].at); d != time.Hour {
+			t.Fatalf("rows %d and %d are %s apart, want 1h", i-1, i, d)
+		}
+	}
+
+	if change := clockChange(rows[1].at, rows[2].at, loc); change != "spring forward" {
+		t.Fatalf("got clock change %q, want spring forward", change)
+	}
+
+	var buf bytes.Buffer
+	render(&buf, req, rows)
+
+	if !strings.Contains(buf.String(), " spring forward ") {
+		t.Fatalf("no spring forward divider in\n%s", buf.String())
+	}
+
+	if strings.Contains(buf.String(), "CST") || strings.Contains(buf.String(), "CDT") {
+		t.Fatalf("no hour repeats, so none should show its zone, in\n%s", buf.String())
+	}
+}
+
+func TestBuildRowsFallBack(t *testing.T) {
+	loc := mustLoadLocation(t, "America/Chicago")
+
+	// 01:00 on November 3 2024 happens twice in Chicago, first in CDT
+	start := time.Date(2024, 11, 3, 0, 0, 0, 0, loc)
+	req := dstRequest(loc, start, start.Add(3*time.Hour))
+
+	rows := buildRows(req, hourlyTemperatures(start, 6))
+
+	got := strings.Join(localClocks(rows, loc), ",")
+	want := "00:00 CDT,01:00 CDT,01:00 CST,02:00 CST"
+	if got != want {
+		t.Fatalf("got rows %s, want %s", got, want)
+	}
+
+	if rows[1].values[0] == rows[2].values[0] {
+		t.Fatalf("both 01:00 rows show %s, want the values of different hours", rows[1].values[0])
+	}
+
+	for i, r := range rows {
+		if repeated := repeatedHour(r.at, loc); repeated != (i == 1 || i == 2) {
+			t.Fatalf("row %d repeatedHour = %v", i, repeated)
+		}
+	}
+
+	var buf bytes.Buffer
+	render(&buf, req, rows)
+
+	out := buf.String()
+	for _, s := range []string{" fall back ", "Nov  3 01:00 CDT", "Nov  3 01:00 CST", "Nov  3 02:00:00"} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("no %q in\n%s", s, out)
+		}
+	}
+}
+
+func TestRowTimeCellFallBackLowConfidence(t *testing.T) {
+	loc := mustLoadLocation(t, "America/Chicago")
+
+	// both 01:00s on November 3 2024 are more than a day after the forecast
+	start := time.Date(2024, 11, 3, 0, 0, 0, 0, loc)
+	req := dstRequest(loc, start, start.Add(3*time.Hour))
+	req.issuedAt = start.Add(-48 * time.Hour)
+	req.lowConfidence = 24 * time.Hour
+
+	rows := buildRows(req, hourlyTemperatures(start, 6))
+
+	for i, want := range []string{"Nov  3 00:00", "Nov  3 01:00 CDT", "Nov  3 01:00 CST", "Nov  3 02:00"} {
+		if got := rowTimeCell(req, rows[i]); got != want+lowConfidenceMarker {
+			t.Errorf("row %d is %q, want %q", i, got, want+lowConfidenceMarker)
+		}
+	}
+
+	req.lowConfidence = 0
+
+	if got := rowTimeCell(req, rows[1]); got != "Nov  3 01:00 CDT" {
+		t.Errorf("without -low-confidence-after the repeated hour is %q", got)
+	}
+}
+
+func TestLocalHour(t *testing.T) {
+	kolkata := mustLoadLocation(t, "Asia/Kolkata")
+	chicago := mustLoadLocation(t, "America/Chicago")
+
+	for _, c := range []struct {
+		name string
+		at   time.Time
+		loc  *time.Location
+		want time.Time
+	}{
+		{
+			name: "half hour offset",
+			at:   time.Date(2024, 6, 1, 10, 45, 12, 0, kolkata),
+			loc:  kolkata,
+			want: time.Date(2024, 6, 1, 10, 0, 0, 0, kolkata),
+		},
+		{
+			name: "second of the repeated hours",
+			at:   time.Date(2024, 11, 3, 7, 20, 0, 0, time.UTC),
+			loc:  chicago,
+			want: time.Date(2024, 11, 3, 7, 0, 0, 0, time.UTC),
+		},
+	} {
+		if got := localHour(c.at, c.loc); !got.Equal(c.want) {
+			t.Errorf("%s: got %s, want %s", c.name, got, c.want)
+		}
+	}
+}
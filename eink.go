@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// panels common in Raspberry Pi weather frames, in pixels
+var einkDisplays = map[string]image.Point{
+	"inky-phat":      {212, 104},
+	"inky-what":      {400, 300},
+	"waveshare-2.13": {250, 122},
+	"waveshare-2.9":  {296, 128},
+	"waveshare-4.2":  {400, 300},
+	"waveshare-7.5":  {800, 480},
+}
+
+func einkDisplayNames() []string {
+	names := []string{}
+	for name := range einkDisplays {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// a line of the layout, where scale multiplies the font
+type einkLine struct {
+	text  string
+	scale int
+}
+
+// the 5x7 font cell, with a pixel of space to the right and below
+const (
+	einkGlyphWidth  = 6
+	einkGlyphHeight = 8
+)
+
+// big panels get everything twice as large
+func einkScale(size image.Point) int {
+	if size.X >= 400 {
+		return 2
+	}
+
+	return 1
+}
+
+// every field has a fixed place and width, padded out with spaces, so from
+// one forecast to the next only the characters that changed are redrawn,
+// which is what keeps partial refreshes from ghosting
+func einkLayout(req forecastRequest, forecast gridForecast, size image.Point, now time.Time) []einkLine {
+	scale := einkScale(size)
+	columns := size.X / (einkGlyphWidth * scale)
+
+	value := func(property string, at time.Time) *float64 {
+		p, ok := findPointAt(forecast.properties[property], at)
+		if !ok {
+			return nil
+		}
+
+		p = req.liberate(p)
+
+		return p.Value
+	}
+
+	temperatureUnit := req.columnUnit(kindUnits[kindTemperature])
+
+	temperature := func(v *float64) string {
+		if v == nil {
+			return "--"
+		}
+
+		return formatNumber(*v, 0) + temperatureUnit
+	}
+
+	percent := func(v *float64) string {
+		if v == nil {
+			return "--"
+		}
+
+		return formatNumber(*v, 0) + "%"
+	}
+
+	fit := func(left, right string) string {
+		room := columns - len([]rune(right))
+		if room < 0 {
+			room = 0
+		}
+
+		runes := []rune(left)
+		if len(runes) > room {
+			runes = runes[:room]
+		}
+
+		return string(runes) + strings.Repeat(" ", room-len(runes)) + right
+	}
+
+	hour := now.Truncate(time.Hour)
+	today := weekDays(req, forecast)[0]
+
+	lines := []einkLine{
+		{fit(strings.ToUpper(req.address), now.In(req.displayTimeZone).Format("15:04")), scale},
+		{fit(temperature(value("temperature", hour)), ""), scale * 2},
+		{fit(strings.ToUpper(strings.ReplaceAll(condition(forecast, hour, hour.Add(3*time.Hour)), "-", " ")), ""), scale},
+		{fit(fmt.Sprintf("HI %-5s LO %-5s RAIN %s", temperature(today.high), temperature(today.low), percent(today.chance)), ""), scale},
+		{"", scale},
+	}
+
+	// as many of the coming hours as fit, five characters each
+	slots := columns / 5
+	if slots > 12 {
+		slots = 12
+	}
+
+	hours, temperatures, chances := "", "", ""
+	for i := 1; i <= slots; i++ {
+		at := hour.Add(time.Duration(i) * time.Hour)
+
+		hours += fmt.Sprintf("%5s", at.In(req.displayTimeZone).Format("15h"))
+		temperatures += fmt.Sprintf("%5s", temperature(value("temperature", at)))
+		chances += fmt.Sprintf("%5s", percent(value("probabilityOfPrecipitation", at)))
+	}
+
+	lines = append(lines, einkLine{fit(hours, ""), scale}, einkLine{fit(temperatures, ""), scale}, einkLine{fit(chances, ""), scale})
+
+	// whatever doesn't fit on a small panel is dropped from the bottom
+	height := 0
+	for i, l := range lines {
+		height += l.scale * einkGlyphHeight
+		if height > size.Y {
+			return lines[:i]
+		}
+	}
+
+	return lines
+}
+
+// plain text, one line per line of the layout, for frames that draw their
+// own type
+func writeEinkText(w io.Writer, lines []einkLine) {
+	for _, l := range lines {
+		fmt.Fprintln(w, l.text)
+	}
+}
+
+// black on white at exactly the panel's size, in the built in font
+func writeEinkPNG(w io.Writer, lines []einkLine, size image.Point) error {
+	img := image.NewPaletted(image.Rect(0, 0, size.X, size.Y), color.Palette{color.White, color.Black})
+
+	y := 0
+	for _, l := range lines {
+		for i, r := range []rune(strings.ToUpper(l.text)) {
+			glyph, ok := einkFont[r]
+			if !ok {
+				continue
+			}
+
+			for row, bits := range glyph {
+				for col, bit := range bits {
+					if bit != '#' {
+						continue
+					}
+
+					for dy := 0; dy < l.scale; dy++ {
+						for dx := 0; dx < l.scale; dx++ {
+							img.SetColorIndex((i*einkGlyphWidth+col)*l.scale+dx, y+row*l.scale+dy, 1)
+						}
+					}
+				}
+			}
+		}
+
+		y += einkGlyphHeight * l.scale
+	}
+
+	err := png.Encode(w, img)
+	if err != nil {
+		return fmt.Errorf("could not write e-ink image: %w", err)
+	}
+
+	return nil
+}
+
+func displayEink(w io.Writer, req forecastRequest, forecast gridForecast) error {
+	size := einkDisplays[req.einkDisplay]
+	lines := einkLayout(req, forecast, size, time.Now())
+
+	if req.format == "eink-png" {
+		return writeEinkPNG(w, lines, size)
+	}
+
+	writeEinkText(w, lines)
+
+	return nil
+}
+
+// 5x7, enough for what the layout prints
+var einkFont = map[rune][7]string{
+	'0':  {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1':  {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2':  {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3':  {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4':  {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5':  {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6':  {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7':  {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8':  {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9':  {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+	'A':  {".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'B':  {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
+	'C':  {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
+	'D':  {"###..", "#..#.", "#...#", "#...#", "#...#", "#..#.", "###.."},
+	'E':  {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+	'F':  {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
+	'G':  {".###.", "#...#", "#....", "#.###", "#...#", "#...#", ".####"},
+	'H':  {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'I':  {".###.", "..#..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'J':  {"..###", "...#.", "...#.", "...#.", "...#.", "#..#.", ".##.."},
+	'K':  {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
+	'L':  {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
+	'M':  {"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#"},
+	'N':  {"#...#", "#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#"},
+	'O':  {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'P':  {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
+	'Q':  {".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#"},
+	'R':  {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
+	'S':  {".####", "#....", "#....", ".###.", "....#", "....#", "####."},
+	'T':  {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	'U':  {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'V':  {"#...#", "#...#", "#...#", "#...#", "#...#", ".#.#.", "..#.."},
+	'W':  {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "#.#.#", ".#.#."},
+	'X':  {"#...#", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "#...#"},
+	'Y':  {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
+	'Z':  {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####"},
+	'%':  {"##...", "##..#", "...#.", "..#..", ".#...", "#..##", "...##"},
+	'-':  {".....", ".....", ".....", "#####", ".....", ".....", "....."},
+	'.':  {".....", ".....", ".....", ".....", ".....", ".##..", ".##.."},
+	',':  {".....", ".....", ".....", ".....", ".##..", "..#..", ".#..."},
+	':':  {".....", ".##..", ".##..", ".....", ".##..", ".##..", "....."},
+	'/':  {".....", "....#", "...#.", "..#..", ".#...", "#....", "....."},
+	'+':  {".....", "..#..", "..#..", "#####", "..#..", "..#..", "....."},
+	'\'': {"..#..", "..#..", ".#...", ".....", ".....", ".....", "....."},
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// -format env is for prompts and status bars that poll now and then, so
+// the variable names are as stable as the JSON schema: AGWC_ and the
+// column in upper snake case, with values as plain numbers and the unit
+// alongside
+func writeForecastEnv(w io.Writer, req forecastRequest, forecast gridForecast, rows []displayRow) {
+	var current *displayRow
+	for i, r := range rows {
+		if !r.observed && (current == nil || r.at.Before(current.at)) {
+			current = &rows[i]
+		}
+	}
+
+	if current == nil {
+		fmt.Fprintln(w, "# no hours match your criteria in the requested window")
+		quit(exitNoRows)
+	}
+
+	vars := [][2]string{
+		{"AGWC_TIME", current.at.In(req.displayTimeZone).Format(time.RFC3339)},
+		{"AGWC_CONDITION", condition(forecast, current.at, current.at.Add(time.Hour))},
+	}
+
+	if !forecast.updateTime.IsZero() {
+		vars = append(vars, [2]string{"AGWC_ISSUED", forecast.updateTime.In(req.displayTimeZone).Format(time.RFC3339)})
+	}
+
+	if req.precipType {
+		vars = append(vars, [2]string{"AGWC_PRECIP_TYPE", current.precipType})
+	}
+
+	columns := req.columns()
+
+	for _, i := range req.visibleColumns() {
+		name := "AGWC_" + envName(columns[i])
+
+		value := ""
+		if v := current.numbers[i]; v != nil {
+			value = formatNumber(*v, kindPrecision[propertyRegistry[columns[i]]])
+		}
+
+		vars = append(vars, [2]string{name, value}, [2]string{name + "_UNIT", strings.Trim(heatmapUnit(req, columns[i]), " ()")})
+	}
+
+	for _, v := range vars {
+		fmt.Fprintf(w, "%s=%s\n", v[0], shellQuote(v[1]))
+	}
+}
+
+// probabilityOfPrecipitation is PROBABILITY_OF_PRECIPITATION, and anything
+// that can't be in a variable name, like in a -derive name, is an underscore
+func envName(column string) string {
+	var b strings.Builder
+
+	for i, r := range column {
+		switch {
+		case r < unicode.MaxASCII && unicode.IsUpper(r) && i > 0:
+			b.WriteRune('_')
+			b.WriteRune(r)
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(unicode.ToUpper(r))
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	return b.String()
+}
+
+// only quoted when it has to be, so the common case reads cleanly
+func shellQuote(s string) string {
+	safe := strings.IndexFunc(s, func(r rune) bool {
+		return !(r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))) && !strings.ContainsRune("-_.:+/%", r)
+	}) < 0
+
+	if safe {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
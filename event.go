@@ -0,0 +1,370 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const eventStep = 15 * time.Minute
+
+var eventProperties = []string{
+	"temperature",
+	"relativeHumidity",
+	"windSpeed",
+	"windGust",
+	"windDirection",
+	"probabilityOfPrecipitation",
+}
+
+var clockMatcher = regexp.MustCompile(`^(?i)((?P<weekday>sun|mon|tue|wed|thu|fri|sat)[a-z]*\s+)?(?P<hour>\d{1,2}):(?P<minute>\d{2})$`)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// the next time the clock reads e.g. "7:00" or "sun 7:00" in the given zone
+func parseClockExpression(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	matches := clockMatcher.FindStringSubmatch(strings.TrimSpace(s))
+	if len(matches) == 0 {
+		return time.Time{}, fmt.Errorf("'%s' is not a valid time, expected something like 'sun 7:00' or '18:30'", s)
+	}
+
+	hour, _ := strconv.Atoi(matches[clockMatcher.SubexpIndex("hour")])
+	minute, _ := strconv.Atoi(matches[clockMatcher.SubexpIndex("minute")])
+
+	if hour > 23 || minute > 59 {
+		return time.Time{}, fmt.Errorf("'%s' is not a valid time of day", s)
+	}
+
+	local := now.In(loc)
+	t := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+
+	if weekday := matches[clockMatcher.SubexpIndex("weekday")]; weekday != "" {
+		days := (int(weekdays[strings.ToLower(weekday)]) - int(t.Weekday()) + 7) % 7
+		t = t.AddDate(0, 0, days)
+	}
+
+	if t.Before(now) {
+		if matches[clockMatcher.SubexpIndex("weekday")] != "" {
+			t = t.AddDate(0, 0, 7)
+		} else {
+			t = t.AddDate(0, 0, 1)
+		}
+	}
+
+	return t, nil
+}
+
+// grid values hold for a whole period, which makes for a jagged 15 minute
+// view, so blend linearly between the middles of neighboring periods
+func interpolateAt(points series, at time.Time) (weatherPoint, bool) {
+	i := points.IndexAt(at)
+	if i < 0 {
+		return weatherPoint{}, false
+	}
+
+	p := points.At(i)
+	if p.Value == nil {
+		return p, true
+	}
+
+	middle := p.StartTime.Add(p.EndTime.Sub(p.StartTime) / 2)
+
+	neighbor := i + 1
+	if at.Before(middle) {
+		neighbor = i - 1
+	}
+
+	if neighbor < 0 || neighbor >= points.Len() {
+		return p, true
+	}
+
+	n := points.At(neighbor)
+	if n.Value == nil {
+		return p, true
+	}
+
+	nMiddle := n.StartTime.Add(n.EndTime.Sub(n.StartTime) / 2)
+
+	fraction := float64(at.Sub(middle)) / float64(nMiddle.Sub(middle))
+	v := *p.Value + (*n.Value-*p.Value)*fraction
+
+	interpolated := p
+	interpolated.Value = &v
+
+	return interpolated, true
+}
+
+// Stull's empirical fit, good to about a degree between 5% and 99% humidity
+func wetBulbCelsius(temperature, humidity float64) float64 {
+	return temperature*math.Atan(0.151977*math.Sqrt(humidity+8.313659)) +
+		math.Atan(temperature+humidity) -
+		math.Atan(humidity-1.676331) +
+		0.00391838*math.Pow(humidity, 1.5)*math.Atan(0.023101*humidity) -
+		4.686035
+}
+
+// rough heat stress bands for strenuous exercise by wet bulb temperature
+func heatStress(wetBulb float64) string {
+	switch {
+	case wetBulb >= 28:
+		return "extreme"
+	case wetBulb >= 23:
+		return "high"
+	case wetBulb >= 18:
+		return "moderate"
+	default:
+		return "low"
+	}
+}
+
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+func compassDirection(degrees float64) string {
+	return compassPoints[int(math.Mod(degrees+11.25+360, 360)/22.5)%16]
+}
+
+type eventSample struct {
+	at          time.Time
+	temperature *float64
+	wetBulb     *float64
+	humidity    *float64
+	wind        *float64
+	gust        *float64
+	direction   *float64
+	precip      *float64
+}
+
+func sampleEvent(forecast gridForecast, start time.Time, duration time.Duration) []eventSample {
+	value := func(property string, at time.Time) *float64 {
+		p, ok := interpolateAt(forecast.properties[property], at)
+		if !ok {
+			return nil
+		}
+
+		return p.Value
+	}
+
+	samples := []eventSample{}
+
+	for at := start; !at.After(start.Add(duration)); at = at.Add(eventStep) {
+		s := eventSample{
+			at:          at,
+			temperature: value("temperature", at),
+			humidity:    value("relativeHumidity", at),
+			wind:        value("windSpeed", at),
+			gust:        value("windGust", at),
+			precip:      value("probabilityOfPrecipitation", at),
+		}
+
+		// interpolating directions goes wrong around north, so don't
+		if p, ok := findPointAt(forecast.properties["windDirection"], at); ok {
+			s.direction = p.Value
+		}
+
+		if s.temperature != nil && s.humidity != nil {
+			wb := wetBulbCelsius(*s.temperature, *s.humidity)
+			s.wetBulb = &wb
+		}
+
+		samples = append(samples, s)
+	}
+
+	return samples
+}
+
+func formatCelsius(v *float64, freedom bool) string {
+	return formatWeatherValue("temperature", weatherPoint{Value: v, Unit: "wmoUnit:degC"}, freedom)
+}
+
+func formatKph(v *float64, freedom bool) string {
+	return formatWeatherValue("windSpeed", weatherPoint{Value: v, Unit: "wmoUnit:km_h-1"}, freedom)
+}
+
+type seriesRange struct {
+	first, min, max, last *float64
+}
+
+func rangeOf(values []*float64) seriesRange {
+	r := seriesRange{}
+
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+
+		if r.first == nil {
+			r.first = v
+		}
+
+		if r.min == nil || *v < *r.min {
+			r.min = v
+		}
+
+		if r.max == nil || *v > *r.max {
+			r.max = v
+		}
+
+		r.last = v
+	}
+
+	return r
+}
+
+func eventBriefing(samples []eventSample, loc *time.Location, freedom bool) string {
+	if len(samples) == 0 {
+		return "No forecast data for this window."
+	}
+
+	collect := func(get func(eventSample) *float64) seriesRange {
+		values := []*float64{}
+		for _, s := range samples {
+			values = append(values, get(s))
+		}
+
+		return rangeOf(values)
+	}
+
+	temps := collect(func(s eventSample) *float64 { return s.temperature })
+	wetBulbs := collect(func(s eventSample) *float64 { return s.wetBulb })
+	winds := collect(func(s eventSample) *float64 { return s.wind })
+	gusts := collect(func(s eventSample) *float64 { return s.gust })
+	precip := collect(func(s eventSample) *float64 { return s.precip })
+
+	sentences := []string{
+		fmt.Sprintf("From %s to %s", samples[0].at.In(loc).Format("Mon 15:04"), samples[len(samples)-1].at.In(loc).Format("15:04")),
+	}
+
+	if temps.first != nil {
+		trend := "holding near"
+		switch {
+		case *temps.last-*temps.first >= 1:
+			trend = "rising to"
+		case *temps.first-*temps.last >= 1:
+			trend = "falling to"
+		}
+
+		sentences[0] += fmt.Sprintf(", temperatures start at %s %s %s", formatCelsius(temps.first, freedom), trend, formatCelsius(temps.last, freedom))
+	}
+
+	sentences[0] += "."
+
+	if wetBulbs.max != nil {
+		sentences = append(sentences, fmt.Sprintf("Wet bulb peaks at %s, %s heat stress.", formatCelsius(wetBulbs.max, freedom), heatStress(*wetBulbs.max)))
+	}
+
+	if winds.max != nil {
+		speeds := formatKph(winds.max, freedom)
+		if low := formatKph(winds.min, freedom); low != speeds {
+			speeds = low + " to " + speeds
+		}
+
+		wind := "Winds " + speeds
+		if samples[0].direction != nil {
+			wind = "Winds " + compassDirection(*samples[0].direction) + " " + speeds
+		}
+
+		if gusts.max != nil && *gusts.max > *winds.max {
+			wind += fmt.Sprintf(", gusting to %s", formatKph(gusts.max, freedom))
+		}
+
+		sentences = append(sentences, wind+".")
+	}
+
+	if precip.max != nil {
+		if *precip.max < precipChanceThreshold {
+			sentences = append(sentences, "Precipitation is unlikely.")
+		} else {
+			sentences = append(sentences, fmt.Sprintf("Precipitation chances reach %s%%.", formatNumber(*precip.max, 0)))
+		}
+	}
+
+	return strings.Join(sentences, " ")
+}
+
+func runEvent(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		at           string
+		duration     time.Duration
+		displaytz    string
+		freedom      bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address of the event")
+	flagset.StringVar(&at, "at", "", "when the event starts, e.g. 'sun 7:00' or '18:30' in the display timezone")
+	flagset.DurationVar(&duration, "duration", 2*time.Hour, "how long the event lasts")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone of -at and in which to display times")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		start, err := parseClockExpression(at, time.Now(), loc)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, eventProperties)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		samples := sampleEvent(forecast, start, duration)
+
+		widths := []int{9, 11, 11, 8, 15, 15, 6}
+
+		t := newTable(os.Stdout, widths, []string{"time", "temperature", "wet bulb", "humidity", "wind", "gust", "precip"})
+
+		for _, s := range samples {
+			wind := formatKph(s.wind, freedom)
+			if s.direction != nil && s.wind != nil {
+				wind = compassDirection(*s.direction) + " " + wind
+			}
+
+			t.row([]string{
+				s.at.In(loc).Format("Mon 15:04"),
+				formatCelsius(s.temperature, freedom),
+				formatCelsius(s.wetBulb, freedom),
+				formatWeatherValue("relativeHumidity", weatherPoint{Value: s.humidity, Unit: "wmoUnit:percent"}, freedom),
+				wind,
+				formatKph(s.gust, freedom),
+				formatWeatherValue("probabilityOfPrecipitation", weatherPoint{Value: s.precip, Unit: "wmoUnit:percent"}, freedom),
+			}, nil)
+		}
+
+		t.end()
+
+		fmt.Println()
+		fmt.Println(eventBriefing(samples, loc, freedom))
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// polls the active alerts for a point, asking upstream only whether
+// anything changed since last time and waiting as long as it says the
+// answer is good for
+type alertPoller struct {
+	url          string
+	interval     time.Duration
+	etag         string
+	lastModified string
+}
+
+// the alerts, whether they changed since the last poll, and how long to wait
+// before the next one
+func (p *alertPoller) poll() ([]alert, bool, time.Duration, error) {
+	req, err := http.NewRequest("GET", p.url, nil)
+	if err != nil {
+		return nil, false, p.interval, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/geo+json")
+
+	if p.etag != "" {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+
+	if p.lastModified != "" {
+		req.Header.Set("If-Modified-Since", p.lastModified)
+	}
+
+	res, err := httpClient.Do(fresh(req))
+	if err != nil {
+		return nil, false, p.interval, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	wait := p.wait(res.Header)
+
+	if res.StatusCode == http.StatusNotModified {
+		return nil, false, wait, nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, false, wait, fmt.Errorf("alerts request failed with %s", res.Status)
+	}
+
+	p.etag = res.Header.Get("ETag")
+	p.lastModified = res.Header.Get("Last-Modified")
+
+	alerts, err := parseAlerts(res.Body)
+	if err != nil {
+		return nil, false, wait, err
+	}
+
+	return alerts, true, wait, nil
+}
+
+// no sooner than the interval we were asked for, but no sooner than the
+// response says it's fresh for either
+func (p *alertPoller) wait(h http.Header) time.Duration {
+	wait := p.interval
+
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(directive)
+		if !strings.HasPrefix(directive, "max-age=") {
+			continue
+		}
+
+		seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+		if err == nil && time.Duration(seconds)*time.Second > wait {
+			wait = time.Duration(seconds) * time.Second
+		}
+	}
+
+	return wait
+}
+
+// what's changed between two polls, by alert ID, where an alert that's
+// been sent again with new details counts as updated
+func diffAlerts(before map[string]alert, after []alert) (added, updated, ended []alert) {
+	current := map[string]bool{}
+
+	for _, a := range after {
+		current[a.id] = true
+
+		previous, ok := before[a.id]
+		switch {
+		case !ok:
+			added = append(added, a)
+		case !previous.sent.Equal(a.sent):
+			updated = append(updated, a)
+		}
+	}
+
+	for id, a := range before {
+		if !current[id] {
+			ended = append(ended, a)
+		}
+	}
+
+	return added, updated, ended
+}
+
+func printAlertChange(req alertsRequest, change string, a alert) {
+	until := ""
+	if !a.ends.IsZero() {
+		until = " until " + a.ends.In(req.displayTimeZone).Format("Mon 15:04")
+	}
+
+	fmt.Printf(
+		"[%s] %s: %s (%s, %s)%s, %s\n",
+		time.Now().In(req.displayTimeZone).Format("15:04:05"), change, a.event, a.severity, a.urgency, until, a.areas,
+	)
+}
+
+func followAlerts(req alertsRequest, c coordinates) {
+	poller := &alertPoller{url: activeAlertsURL(c), interval: req.interval}
+
+	seen := map[string]alert{}
+
+	fmt.Printf("following alerts at %s, press ctrl-c to stop\n", c)
+
+	for {
+		alerts, changed, wait, err := poller.poll()
+		if err != nil {
+			// a blip upstream shouldn't end a long watch
+			fmt.Printf("[%s] could not check alerts: %s\n", time.Now().In(req.displayTimeZone).Format("15:04:05"), err)
+		}
+
+		if changed {
+			matching := []alert{}
+			for _, a := range alerts {
+				if req.wants(a) {
+					matching = append(matching, a)
+				}
+			}
+
+			sortAlerts(matching, req.sortBy)
+
+			added, updated, ended := diffAlerts(seen, matching)
+
+			for _, a := range added {
+				printAlertChange(req, "new", a)
+			}
+
+			for _, a := range updated {
+				printAlertChange(req, "updated", a)
+			}
+
+			for _, a := range ended {
+				printAlertChange(req, "ended", a)
+			}
+
+			seen = map[string]alert{}
+			for _, a := range matching {
+				seen[a.id] = a
+			}
+		}
+
+		time.Sleep(wait)
+	}
+}
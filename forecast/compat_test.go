@@ -0,0 +1,80 @@
+package forecast_test
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/packrat386/agwc/forecast"
+	"github.com/packrat386/agwc/nws"
+)
+
+// forecast's v1 API. Series is the type downstream code holds on to, so
+// its methods are the ones to be most careful with.
+var (
+	_ string = forecast.DefaultOpenMeteoURL
+
+	_ func([]forecast.Point) forecast.Series = forecast.NewSeries
+	_ func(nws.Layer) forecast.Series        = forecast.FromLayer
+
+	_ func(*forecast.Series, forecast.Point)                        = (*forecast.Series).Add
+	_ func(forecast.Series) int                                     = forecast.Series.Len
+	_ func(forecast.Series, int) forecast.Point                     = forecast.Series.At
+	_ func(forecast.Series, time.Time) int                          = forecast.Series.IndexAt
+	_ func(forecast.Series, time.Time, time.Time) int               = forecast.Series.IndexOverlapping
+	_ func(forecast.Series, time.Time, time.Duration) int           = forecast.Series.IndexNearest
+	_ func(io.Reader, []string) (map[string]forecast.Series, error) = forecast.ParseOpenMeteo
+
+	_ forecast.Provider = forecast.NWS{Client: &nws.Client{}}
+	_ forecast.Provider = forecast.OpenMeteo{HTTPClient: &http.Client{}, BaseURL: ""}
+
+	_ = forecast.Point{StartTime: time.Time{}, EndTime: time.Time{}, Value: (*float64)(nil), Unit: ""}
+	_ = forecast.Series{Unit: ""}
+
+	_ func(forecast.Provider, context.Context, float64, float64, []string) (map[string]forecast.Series, error) = forecast.Provider.Forecast
+)
+
+func TestOpenMeteoInNWSUnits(t *testing.T) {
+	body := `{"hourly": {
+		"time": [1714546800, 1714550400],
+		"temperature_2m": [12.5, null],
+		"pressure_msl": [1013.2, 1012.8],
+		"snowfall": [0.4, 0]
+	}}`
+
+	got, err := forecast.ParseOpenMeteo(strings.NewReader(body), []string{"temperature", "pressure", "snowfallAmount", "ceilingHeight"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := got["ceilingHeight"]; ok {
+		t.Errorf("open-meteo has a ceilingHeight")
+	}
+
+	temperature := got["temperature"]
+	if temperature.Unit != "wmoUnit:degC" || temperature.Len() != 2 {
+		t.Fatalf("temperature is %d points of %s", temperature.Len(), temperature.Unit)
+	}
+
+	first := temperature.At(0)
+	if first.Value == nil || *first.Value != 12.5 || !first.StartTime.Equal(time.Unix(1714546800, 0)) || first.EndTime.Sub(first.StartTime) != time.Hour {
+		t.Errorf("first temperature is %+v", first)
+	}
+
+	if temperature.At(1).Value != nil {
+		t.Errorf("a null temperature has a value")
+	}
+
+	if p := got["pressure"].At(0); got["pressure"].Unit != "wmoUnit:Pa" || math.Abs(*p.Value-101320) > 1e-6 {
+		t.Errorf("pressure is %g %s", *p.Value, got["pressure"].Unit)
+	}
+
+	// snowfall is for the hour before its timestamp, and in cm
+	if p := got["snowfallAmount"].At(0); !p.StartTime.Equal(time.Unix(1714546800-3600, 0)) || math.Abs(*p.Value-4) > 1e-9 {
+		t.Errorf("snowfall is %+v", p)
+	}
+}
@@ -0,0 +1,199 @@
+package forecast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/packrat386/agwc/nws"
+)
+
+// Provider is somewhere forecasts come from.
+type Provider interface {
+	// Forecast is a Series for each of properties, named the way NWS
+	// names its gridpoint layers, like "temperature". Properties the
+	// provider doesn't have are left out rather than an error.
+	Forecast(ctx context.Context, latitude, longitude float64, properties []string) (map[string]Series, error)
+}
+
+// NWS is the gridded forecast from api.weather.gov.
+type NWS struct {
+	Client *nws.Client
+}
+
+// Forecast looks up the grid cell the point is in and fetches its forecast.
+func (p NWS) Forecast(ctx context.Context, latitude, longitude float64, properties []string) (map[string]Series, error) {
+	point, err := p.Client.Point(ctx, latitude, longitude)
+	if err != nil {
+		return nil, err
+	}
+
+	grid, err := p.Client.Grid(ctx, point.ForecastGridData, properties)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]Series{}
+	for _, name := range properties {
+		if layer, ok := grid.Layers[name]; ok {
+			result[name] = FromLayer(layer)
+		}
+	}
+
+	return result, nil
+}
+
+// DefaultOpenMeteoURL is where Open-Meteo's forecast API lives.
+const DefaultOpenMeteoURL = "https://api.open-meteo.com/v1/forecast"
+
+// OpenMeteo is the hourly forecast from Open-Meteo, which covers the whole
+// world rather than only the US, and needs no key.
+type OpenMeteo struct {
+	// HTTPClient sends the requests, or http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	// BaseURL is DefaultOpenMeteoURL if empty.
+	BaseURL string
+}
+
+// how an Open-Meteo hourly variable maps onto an NWS property
+type openMeteoVariable struct {
+	name   string
+	unit   string
+	factor float64
+
+	// accumulations are for the hour before the timestamp, not after
+	preceding bool
+}
+
+var openMeteoVariables = map[string]openMeteoVariable{
+	"temperature":                {name: "temperature_2m", unit: "wmoUnit:degC", factor: 1},
+	"dewpoint":                   {name: "dew_point_2m", unit: "wmoUnit:degC", factor: 1},
+	"relativeHumidity":           {name: "relative_humidity_2m", unit: "wmoUnit:percent", factor: 1},
+	"probabilityOfPrecipitation": {name: "precipitation_probability", unit: "wmoUnit:percent", factor: 1},
+	"quantitativePrecipitation":  {name: "precipitation", unit: "wmoUnit:mm", factor: 1, preceding: true},
+	"snowfallAmount":             {name: "snowfall", unit: "wmoUnit:mm", factor: 10, preceding: true},
+	"skyCover":                   {name: "cloud_cover", unit: "wmoUnit:percent", factor: 1},
+	"windSpeed":                  {name: "wind_speed_10m", unit: "wmoUnit:km_h-1", factor: 1},
+	"windGust":                   {name: "wind_gusts_10m", unit: "wmoUnit:km_h-1", factor: 1},
+	"windDirection":              {name: "wind_direction_10m", unit: "wmoUnit:degree_(angle)", factor: 1},
+	"visibility":                 {name: "visibility", unit: "wmoUnit:m", factor: 1},
+	"pressure":                   {name: "pressure_msl", unit: "wmoUnit:Pa", factor: 100},
+}
+
+// Forecast fetches the last day and the next week, hourly.
+func (p OpenMeteo) Forecast(ctx context.Context, latitude, longitude float64, properties []string) (map[string]Series, error) {
+	names := []string{}
+	for _, name := range properties {
+		if v, ok := openMeteoVariables[name]; ok {
+			names = append(names, v.name)
+		}
+	}
+
+	baseURL := p.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultOpenMeteoURL
+	}
+
+	queryURL := baseURL + "?" + url.Values{
+		"latitude":      []string{fmt.Sprintf("%.4f", latitude)},
+		"longitude":     []string{fmt.Sprintf("%.4f", longitude)},
+		"hourly":        []string{strings.Join(names, ",")},
+		"timeformat":    []string{"unixtime"},
+		"past_days":     []string{"1"},
+		"forecast_days": []string{"7"},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", queryURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	return ParseOpenMeteo(res.Body, properties)
+}
+
+// ParseOpenMeteo reads an Open-Meteo forecast response with hourly
+// variables and unix timestamps, converting the variables to the NWS
+// properties and units they stand in for.
+func ParseOpenMeteo(r io.Reader, properties []string) (map[string]Series, error) {
+	body := struct {
+		Error  bool                       `json:"error"`
+		Reason string                     `json:"reason"`
+		Hourly map[string]json.RawMessage `json:"hourly"`
+	}{}
+
+	err := json.NewDecoder(r).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	if body.Error {
+		return nil, fmt.Errorf("open-meteo request failed: %s", body.Reason)
+	}
+
+	times := []int64{}
+
+	err = json.Unmarshal(body.Hourly["time"], &times)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse open-meteo times: %w", err)
+	}
+
+	result := map[string]Series{}
+
+	for _, name := range properties {
+		v, ok := openMeteoVariables[name]
+		if !ok {
+			continue
+		}
+
+		values := []*float64{}
+
+		err = json.Unmarshal(body.Hourly[v.name], &values)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse open-meteo %s: %w", v.name, err)
+		}
+
+		s := Series{Unit: v.unit}
+
+		for i, t := range times {
+			if i >= len(values) {
+				break
+			}
+
+			start := time.Unix(t, 0).UTC()
+			if v.preceding {
+				start = start.Add(-time.Hour)
+			}
+
+			p := Point{StartTime: start, EndTime: start.Add(time.Hour), Unit: v.unit}
+
+			if values[i] != nil {
+				converted := *values[i] * v.factor
+				p.Value = &converted
+			}
+
+			s.Add(p)
+		}
+
+		result[name] = s
+	}
+
+	return result, nil
+}
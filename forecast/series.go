@@ -0,0 +1,168 @@
+// Package forecast is what agwc makes of a forecast once it's fetched: a
+// Series of values over time for each property, and the Providers they can
+// come from.
+//
+// Every Series is in the units NWS uses, as WMO unit codes, so ones from
+// different providers can be compared directly, and converted with the
+// units package.
+package forecast
+
+import (
+	"math"
+	"time"
+
+	"github.com/packrat386/agwc/nws"
+	"github.com/packrat386/agwc/units"
+)
+
+// Point is a value for a span of time, where a nil Value means there's no
+// forecast for it.
+type Point struct {
+	StartTime time.Time
+	EndTime   time.Time
+	Value     *float64
+	Unit      string
+}
+
+// Series is the points for one property in order, kept as columns rather
+// than a slice of Point, since every point shares a unit and a week of
+// hourly data for a lot of properties adds up. The zero Series is empty and
+// ready to Add to.
+type Series struct {
+	// Unit is the WMO unit code of every point, like "wmoUnit:degC".
+	Unit string
+
+	starts []int64
+	ends   []int64
+	values []float64
+}
+
+// NewSeries is a Series of points, in the unit of the first.
+func NewSeries(points []Point) Series {
+	s := Series{
+		starts: make([]int64, 0, len(points)),
+		ends:   make([]int64, 0, len(points)),
+		values: make([]float64, 0, len(points)),
+	}
+
+	for _, p := range points {
+		s.Add(p)
+	}
+
+	return s
+}
+
+// FromLayer is a Series of a gridpoint layer, with its unit written the
+// usual way, so "unit:degC" is "wmoUnit:degC".
+func FromLayer(layer nws.Layer) Series {
+	unit := layer.Unit
+	if u, err := units.Parse(unit); err == nil {
+		unit = u.Canonical()
+	}
+
+	s := Series{Unit: unit}
+	for _, v := range layer.Values {
+		s.Add(Point{StartTime: v.Start, EndTime: v.End, Value: v.Value, Unit: unit})
+	}
+
+	return s
+}
+
+// Add puts p at the end, taking its unit if the Series has none yet. Times
+// are kept to the second.
+func (s *Series) Add(p Point) {
+	if s.Unit == "" {
+		s.Unit = p.Unit
+	}
+
+	v := math.NaN()
+	if p.Value != nil {
+		v = *p.Value
+	}
+
+	s.starts = append(s.starts, p.StartTime.Unix())
+	s.ends = append(s.ends, p.EndTime.Unix())
+	s.values = append(s.values, v)
+}
+
+// Len is how many points there are.
+func (s Series) Len() int {
+	return len(s.starts)
+}
+
+// At is the i'th point, in UTC.
+func (s Series) At(i int) Point {
+	p := Point{
+		StartTime: time.Unix(s.starts[i], 0).UTC(),
+		EndTime:   time.Unix(s.ends[i], 0).UTC(),
+		Unit:      s.Unit,
+	}
+
+	if v := s.values[i]; !math.IsNaN(v) {
+		p.Value = &v
+	}
+
+	return p
+}
+
+// IndexAt is the index of the point covering t, or -1.
+func (s Series) IndexAt(t time.Time) int {
+	unix := t.Unix()
+
+	for i := range s.starts {
+		if unix >= s.starts[i] && unix < s.ends[i] {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// IndexOverlapping is the index of the point covering the most of [start,
+// end), the earliest on a tie, or -1 if none touch it.
+func (s Series) IndexOverlapping(start, end time.Time) int {
+	from, to := start.Unix(), end.Unix()
+
+	best, most := -1, int64(0)
+	for i := range s.starts {
+		lo, hi := s.starts[i], s.ends[i]
+		if lo < from {
+			lo = from
+		}
+
+		if hi > to {
+			hi = to
+		}
+
+		if hi-lo > most {
+			best, most = i, hi-lo
+		}
+	}
+
+	return best
+}
+
+// IndexNearest is the index of the point closest to t, where one covering
+// t is no distance away, the earliest on a tie, or -1 if none are closer
+// than within.
+func (s Series) IndexNearest(t time.Time, within time.Duration) int {
+	unix := t.Unix()
+
+	best, closest := -1, int64(within/time.Second)
+	for i := range s.starts {
+		distance := int64(0)
+
+		switch {
+		case unix < s.starts[i]:
+			distance = s.starts[i] - unix
+		case unix >= s.ends[i]:
+			distance = unix - s.ends[i] + 1
+		}
+
+		if distance < closest {
+			best, closest = i, distance
+		}
+	}
+
+	return best
+}
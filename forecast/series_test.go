@@ -0,0 +1,79 @@
+package forecast
+
+import (
+	"testing"
+	"time"
+)
+
+var seriesStart = time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+
+// a series of the given spans from seriesStart, in minutes
+func minuteSeries(spans ...[2]int) Series {
+	s := Series{Unit: "wmoUnit:degC"}
+	for i, span := range spans {
+		v := float64(i)
+		s.Add(Point{
+			StartTime: seriesStart.Add(time.Duration(span[0]) * time.Minute),
+			EndTime:   seriesStart.Add(time.Duration(span[1]) * time.Minute),
+			Value:     &v,
+		})
+	}
+
+	return s
+}
+
+func TestIndexOverlapping(t *testing.T) {
+	// half hour points, then a gap, then one starting at :30
+	s := minuteSeries([2]int{0, 30}, [2]int{30, 60}, [2]int{60, 90}, [2]int{150, 210})
+
+	for _, tc := range []struct {
+		name       string
+		start, end int
+		want       int
+	}{
+		{"hour of half hours is a tie, so the first", 0, 60, 0},
+		{"hour starting at :30 is also a tie", 30, 90, 1},
+		{"mostly the second half hour", 20, 60, 1},
+		{"inside one half hour", 35, 50, 1},
+		{"hour starting at :30 after the gap", 150, 210, 3},
+		{"hour mostly in the :30 point", 120, 180, 3},
+		{"in the gap", 90, 150, -1},
+		{"before everything", -60, 0, -1},
+	} {
+		got := s.IndexOverlapping(seriesStart.Add(time.Duration(tc.start)*time.Minute), seriesStart.Add(time.Duration(tc.end)*time.Minute))
+		if got != tc.want {
+			t.Errorf("%s: got %d, wanted %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIndexNearest(t *testing.T) {
+	s := minuteSeries([2]int{0, 30}, [2]int{30, 60}, [2]int{150, 210})
+
+	for _, tc := range []struct {
+		name   string
+		at     int
+		within time.Duration
+		want   int
+	}{
+		{"covered at :30", 30, time.Hour, 1},
+		{"covered at :45", 45, time.Minute, 1},
+		{"just after the second", 70, time.Hour, 1},
+		{"nearer the end of the second", 104, time.Hour, 1},
+		{"nearer the :30 point", 130, time.Hour, 2},
+		{"gap longer than within", 105, 30 * time.Minute, -1},
+		{"before everything within", -20, 30 * time.Minute, 0},
+		{"after everything beyond within", 300, time.Hour, -1},
+	} {
+		got := s.IndexNearest(seriesStart.Add(time.Duration(tc.at)*time.Minute), tc.within)
+		if got != tc.want {
+			t.Errorf("%s: got %d, wanted %d", tc.name, got, tc.want)
+		}
+	}
+
+	// both cover :45, so it's a tie, and the first wins
+	overlapping := minuteSeries([2]int{0, 60}, [2]int{30, 90})
+	if got := overlapping.IndexNearest(seriesStart.Add(45*time.Minute), time.Hour); got != 0 {
+		t.Errorf("tie between overlapping points: got %d, wanted 0", got)
+	}
+}
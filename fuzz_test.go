@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func FuzzGeocoderResponse(f *testing.F) {
+	f.Add(`{"result":{"addressMatches":[{"coordinates":{"x":-87.6,"y":41.9}}]}}`)
+	f.Add(`{"result":{"addressMatches":[]}}`)
+	f.Add(`{"result":null}`)
+	f.Add(`[]`)
+
+	f.Fuzz(func(t *testing.T, s string) {
+		parseAddressCoordinates(strings.NewReader(s))
+	})
+}
+
+func FuzzGridPointResponse(f *testing.F) {
+	f.Add(`{"properties":{"gridId":"LOT","gridX":76,"gridY":73,"forecastGridData":"https://api.weather.gov/gridpoints/LOT/76,73"}}`)
+	f.Add(`{"properties":{"gridX":"76"}}`)
+	f.Add(`{}`)
+
+	f.Fuzz(func(t *testing.T, s string) {
+		parseGridPoint(strings.NewReader(s))
+	})
+}
+
+func FuzzGridpointData(f *testing.F) {
+	// a response cut off partway through, like a dropped connection
+	f.Add(benchFixture[:2048])
+	f.Add([]byte(`{"geometry":null,"properties":{"temperature":{"uom":"wmoUnit:degC","values":[{"validTime":"2024-05-01T06:00:00+00:00/PT1H","value":null}]}}}`))
+	f.Add([]byte(`{"properties":{"temperature":{"values":[{"validTime":"nonsense"}]}}}`))
+	f.Add([]byte(`{"properties":[]}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		forecast, err := parseWeatherData(bytes.NewReader(data), []string{"temperature"})
+		if err != nil {
+			return
+		}
+
+		points := forecast.properties["temperature"]
+		for i := 0; i < points.Len(); i++ {
+			p := points.At(i)
+			if p.EndTime.Before(p.StartTime) {
+				t.Fatalf("point %d ends before it starts: %+v", i, p)
+			}
+		}
+	})
+}
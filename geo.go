@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// a polygon is an outer ring followed by any holes, each ring a list of
+// coordinates, the same way GeoJSON lays them out
+type polygon [][]coordinates
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// flattens Polygon and MultiPolygon geometries into a list of polygons, and
+// anything else into nothing
+func (g geoJSONGeometry) polygons() ([]polygon, error) {
+	toPolygon := func(rings [][][2]float64) polygon {
+		p := polygon{}
+		for _, ring := range rings {
+			r := []coordinates{}
+			for _, c := range ring {
+				r = append(r, coordinates{latitude: c[1], longitude: c[0]})
+			}
+
+			p = append(p, r)
+		}
+
+		return p
+	}
+
+	switch g.Type {
+	case "Polygon":
+		rings := [][][2]float64{}
+
+		err := json.Unmarshal(g.Coordinates, &rings)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse polygon: %w", err)
+		}
+
+		return []polygon{toPolygon(rings)}, nil
+	case "MultiPolygon":
+		multi := [][][][2]float64{}
+
+		err := json.Unmarshal(g.Coordinates, &multi)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse multipolygon: %w", err)
+		}
+
+		polygons := []polygon{}
+		for _, rings := range multi {
+			polygons = append(polygons, toPolygon(rings))
+		}
+
+		return polygons, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (p polygon) contains(c coordinates) bool {
+	if len(p) == 0 || !ringContains(p[0], c) {
+		return false
+	}
+
+	for _, hole := range p[1:] {
+		if ringContains(hole, c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// standard ray casting, treating lat/lon as planar which is fine at the
+// scale of a forecast area
+func ringContains(ring []coordinates, c coordinates) bool {
+	inside := false
+
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+
+		if (a.latitude > c.latitude) != (b.latitude > c.latitude) {
+			crossing := (b.longitude-a.longitude)*(c.latitude-a.latitude)/(b.latitude-a.latitude) + a.longitude
+			if c.longitude < crossing {
+				inside = !inside
+			}
+		}
+	}
+
+	return inside
+}
+
+func anyContains(polygons []polygon, c coordinates) bool {
+	for _, p := range polygons {
+		if p.contains(c) {
+			return true
+		}
+	}
+
+	return false
+}
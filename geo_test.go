@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestPolygonContains(t *testing.T) {
+	// a square a degree on a side with a square hole in the middle, as
+	// GeoJSON lays it out, longitude first
+	body := `{"type": "MultiPolygon", "coordinates": [
+		[
+			[[-90, 40], [-89, 40], [-89, 41], [-90, 41], [-90, 40]],
+			[[-89.6, 40.4], [-89.4, 40.4], [-89.4, 40.6], [-89.6, 40.6], [-89.6, 40.4]]
+		],
+		[
+			[[-80, 30], [-79, 30], [-79.5, 31], [-80, 30]]
+		]
+	]}`
+
+	g := geoJSONGeometry{}
+
+	err := json.Unmarshal([]byte(body), &g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polygons, err := g.polygons()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(polygons) != 2 || len(polygons[0]) != 2 {
+		t.Fatalf("parsed %d polygons", len(polygons))
+	}
+
+	for _, tc := range []struct {
+		name   string
+		c      coordinates
+		inside bool
+	}{
+		{"in the square", coordinates{latitude: 40.2, longitude: -89.8}, true},
+		{"in the hole", coordinates{latitude: 40.5, longitude: -89.5}, false},
+		{"between the hole and the edge", coordinates{latitude: 40.5, longitude: -89.2}, true},
+		{"east of the square", coordinates{latitude: 40.5, longitude: -88.9}, false},
+		{"north of the square", coordinates{latitude: 41.1, longitude: -89.5}, false},
+		{"in the triangle", coordinates{latitude: 30.5, longitude: -79.5}, true},
+		{"beside the triangle's point", coordinates{latitude: 30.9, longitude: -79.9}, false},
+	} {
+		if got := anyContains(polygons, tc.c); got != tc.inside {
+			t.Errorf("%s: contains is %t", tc.name, got)
+		}
+	}
+}
+
+func TestGeometryWithoutPolygons(t *testing.T) {
+	for _, g := range []geoJSONGeometry{
+		{},
+		{Type: "Point", Coordinates: json.RawMessage(`[-89.5, 40.5]`)},
+	} {
+		polygons, err := g.polygons()
+		if err != nil || len(polygons) != 0 {
+			t.Errorf("%s: %v, %v", g.Type, polygons, err)
+		}
+	}
+
+	_, err := geoJSONGeometry{Type: "Polygon", Coordinates: json.RawMessage(`[1, 2]`)}.polygons()
+	if err == nil {
+		t.Errorf("a malformed polygon parsed")
+	}
+}
+
+func TestDistance(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		a, b coordinates
+		km   float64
+	}{
+		// the usual haversine example, on the same 6371km sphere
+		{"Big Ben to the Statue of Liberty", coordinates{latitude: 51.5007, longitude: -0.1246}, coordinates{latitude: 40.6892, longitude: -74.0445}, 5574.8},
+		{"a degree of the equator", coordinates{}, coordinates{longitude: 1}, 111.195},
+		{"a degree of a meridian", coordinates{latitude: 40, longitude: -105}, coordinates{latitude: 41, longitude: -105}, 111.195},
+		{"nowhere", coordinates{latitude: 40, longitude: -105}, coordinates{latitude: 40, longitude: -105}, 0},
+	} {
+		if got := distanceKm(tc.a, tc.b); math.Abs(got-tc.km) > 0.1 {
+			t.Errorf("%s: %.3fkm, wanted %.3fkm", tc.name, got, tc.km)
+		}
+	}
+}
+
+func TestDestination(t *testing.T) {
+	start := coordinates{latitude: 40, longitude: -105}
+
+	east := destination(coordinates{}, 90, 111.195)
+	if math.Abs(east.latitude) > 1e-6 || math.Abs(east.longitude-1) > 1e-3 {
+		t.Errorf("a degree east along the equator is %s", east)
+	}
+
+	north := destination(start, 0, 111.195)
+	if math.Abs(north.latitude-41) > 1e-3 || math.Abs(north.longitude+105) > 1e-9 {
+		t.Errorf("a degree north is %s", north)
+	}
+
+	// wherever it heads, the ring is as far away as asked
+	for _, c := range radiusRing(start, 40) {
+		if d := distanceKm(start, c); math.Abs(d-40) > 1e-6 {
+			t.Errorf("%s is %.6fkm away, not 40", c, d)
+		}
+	}
+}
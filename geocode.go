@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+var geocodeFormats = []string{"table", "json"}
+
+type geocoderConfig struct {
+	// like "Public_AR_ACS2024", from 'agwc geocode -benchmarks', for when
+	// the current one hasn't caught up with new construction
+	Benchmark string `json:"benchmark,omitempty"`
+
+	// like "Census2020_Current", from 'agwc geocode -vintages'
+	Vintage string `json:"vintage,omitempty"`
+}
+
+// the geocoder's defaults unless set, from the config and then the flags
+// to agwc geocode
+var geocodeBenchmark, geocodeVintage string
+
+func configureGeocoder(cfg config) {
+	geocodeBenchmark = cfg.Geocoder.Benchmark
+	geocodeVintage = cfg.Geocoder.Vintage
+}
+
+type geocodeRequest struct {
+	address    string
+	suggest    string
+	limit      int
+	format     string
+	benchmarks bool
+	vintages   bool
+}
+
+// everything about where an address ends up, short of the forecast
+type geocodeResult struct {
+	Address        string  `json:"address"`
+	MatchedAddress string  `json:"matchedAddress,omitempty"`
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+	County         string  `json:"county,omitempty"`
+
+	Office              string `json:"office"`
+	X                   int    `json:"x"`
+	Y                   int    `json:"y"`
+	ForecastZone        string `json:"forecastZone,omitempty"`
+	RadarStation        string `json:"radarStation,omitempty"`
+	ForecastGridDataURL string `json:"forecastGridDataURL"`
+}
+
+type geocodeSuggestion struct {
+	Address   string  `json:"address"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+func runGeocode(flagset *flag.FlagSet) func() {
+	req := geocodeRequest{}
+
+	flagset.StringVar(&req.address, "address", "", "address, coordinates or named location to look up")
+	flagset.StringVar(&req.suggest, "suggest", "", "list addresses that begin like this one instead, to find one that resolves")
+	flagset.IntVar(&req.limit, "limit", 5, "maximum number of addresses to suggest")
+	flagset.StringVar(&req.format, "format", "table", fmt.Sprintf("how to print the lookup, one of %v", geocodeFormats))
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+	flagset.StringVar(&geocodeBenchmark, "benchmark", geocodeBenchmark, "Census address data to look up in, instead of the config's or the current one")
+	flagset.StringVar(&geocodeVintage, "vintage", geocodeVintage, "Census geographies to find the county in, instead of the config's or the current ones")
+	flagset.BoolVar(&req.benchmarks, "benchmarks", false, "list the benchmarks the geocoder has instead")
+	flagset.BoolVar(&req.vintages, "vintages", false, "list the vintages the geocoder has for the benchmark instead")
+
+	return func() {
+		if indexOf(geocodeFormats, req.format) < 0 {
+			errorAndQuit(fmt.Errorf("format '%s' is not in %v", req.format, geocodeFormats))
+		}
+
+		if req.benchmarks || req.vintages {
+			listGeocoderData(req)
+			return
+		}
+
+		if (req.address == "") == (req.suggest == "") {
+			errorAndQuit(fmt.Errorf("need one of -address, -suggest, -benchmarks or -vintages"))
+		}
+
+		if req.suggest != "" {
+			suggestAddresses(req)
+			return
+		}
+
+		result, err := lookupAddress(req.address)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		if req.format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+
+			err = enc.Encode(result)
+			if err != nil {
+				errorAndQuit(fmt.Errorf("could not write geocode JSON: %w", err))
+			}
+
+			return
+		}
+
+		t := newTable(os.Stdout, []int{20, 60}, []string{"field", "value"})
+
+		for _, f := range [][2]string{
+			{"address", result.Address},
+			{"matched address", result.MatchedAddress},
+			{"location", coordinates{latitude: result.Latitude, longitude: result.Longitude}.String()},
+			{"county", result.County},
+			{"office", result.Office},
+			{"grid", strconv.Itoa(result.X) + "," + strconv.Itoa(result.Y)},
+			{"forecast zone", result.ForecastZone},
+			{"radar station", result.RadarStation},
+			{"grid data", result.ForecastGridDataURL},
+		} {
+			if f[1] != "" {
+				t.row(f[:], nil)
+			}
+		}
+
+		t.end()
+	}
+}
+
+// goes to the geocoder every time, skipping the cache, since this is for
+// finding out why an address lands where it does
+func lookupAddress(address string) (geocodeResult, error) {
+	place := address
+	if l, ok := namedLocations[address]; ok {
+		place = l.place()
+	}
+
+	result := geocodeResult{Address: address}
+
+	c, ok, err := parseCoordinates(place)
+	if err != nil {
+		return geocodeResult{}, err
+	}
+
+	if ok {
+		county, err := geocoder().County(context.Background(), c.latitude, c.longitude)
+		if err != nil {
+			return geocodeResult{}, fmt.Errorf("could not look up county: %w", err)
+		}
+
+		result.Latitude, result.Longitude, result.County = c.latitude, c.longitude, county
+	} else {
+		m, err := geocoder().Describe(context.Background(), place)
+		if err != nil {
+			return geocodeResult{}, fmt.Errorf("could not geocode address: %w", err)
+		}
+
+		c, err := matchCoordinates(m)
+		if err != nil {
+			return geocodeResult{}, err
+		}
+
+		result.Latitude, result.Longitude = c.latitude, c.longitude
+		result.MatchedAddress, result.County = m.Address, m.County
+	}
+
+	grid, err := getGridPoint(coordinates{latitude: result.Latitude, longitude: result.Longitude})
+	if err != nil {
+		return geocodeResult{}, err
+	}
+
+	result.Office, result.X, result.Y = grid.office, grid.x, grid.y
+	result.ForecastZone = grid.forecastZone
+	result.RadarStation = grid.radarStation
+	result.ForecastGridDataURL = grid.forecastGridDataURL
+
+	return result, nil
+}
+
+func suggestAddresses(req geocodeRequest) {
+	if req.limit < 1 {
+		errorAndQuit(fmt.Errorf("limit must be at least 1, got %d", req.limit))
+	}
+
+	suggestions, err := geocoder().Suggest(context.Background(), req.suggest, req.limit)
+	if err != nil {
+		errorAndQuit(fmt.Errorf("could not get suggestions: %w", err))
+	}
+
+	if req.format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		out := []geocodeSuggestion{}
+		for _, s := range suggestions {
+			out = append(out, geocodeSuggestion{Address: s.Address, Latitude: s.Latitude, Longitude: s.Longitude})
+		}
+
+		err = enc.Encode(out)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not write geocode JSON: %w", err))
+		}
+
+		return
+	}
+
+	if len(suggestions) == 0 {
+		fmt.Println("no addresses begin like that")
+		quit(exitNoRows)
+	}
+
+	t := newTable(os.Stdout, []int{50, 20}, []string{"address", "location"})
+
+	for _, s := range suggestions {
+		t.row([]string{s.Address, coordinates{latitude: s.Latitude, longitude: s.Longitude}.String()}, nil)
+	}
+
+	t.end()
+}
+
+type geocoderData struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+}
+
+// the benchmarks or, for the benchmark, the vintages -benchmark and
+// -vintage can be set to
+func listGeocoderData(req geocodeRequest) {
+	data := []geocoderData{}
+	kind := "benchmark"
+
+	if req.vintages {
+		kind = "vintage"
+
+		vintages, err := geocoder().Vintages(context.Background())
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not list vintages: %w", err))
+		}
+
+		for _, v := range vintages {
+			data = append(data, geocoderData{Name: v.Name, Description: v.Description, Default: v.Default})
+		}
+	} else {
+		benchmarks, err := geocoder().Benchmarks(context.Background())
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not list benchmarks: %w", err))
+		}
+
+		for _, b := range benchmarks {
+			data = append(data, geocoderData{Name: b.Name, Description: b.Description, Default: b.Default})
+		}
+	}
+
+	if req.format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		err := enc.Encode(data)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not write geocode JSON: %w", err))
+		}
+
+		return
+	}
+
+	t := newTable(os.Stdout, []int{25, 50, 8}, []string{kind, "description", "default"})
+
+	for _, d := range data {
+		def := ""
+		if d.Default {
+			def = "yes"
+		}
+
+		t.row([]string{d.Name, d.Description, def}, nil)
+	}
+
+	t.end()
+}
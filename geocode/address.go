@@ -0,0 +1,94 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrPOBox is returned for PO Box addresses, which are where the mail goes
+// rather than anywhere the geocoder can put on a map.
+var ErrPOBox = errors.New("a PO Box has no location to geocode, use the street address or the post office's instead")
+
+// Address is a one line address tidied up before it's sent anywhere.
+type Address struct {
+	// Line is what's sent, without any unit.
+	Line string
+
+	// Unit is the apartment, suite or other unit taken off, like "Apt 4B",
+	// since the geocoder only knows buildings.
+	Unit string
+
+	// Streets are the two streets of an intersection, like "Main St & 5th
+	// Ave", and empty for anything else.
+	Streets []string
+}
+
+var (
+	poBoxPattern = regexp.MustCompile(`(?i)\b(p\.?\s*o\.?\s*box|post\s+office\s+box)\b`)
+
+	// only with something that looks like a unit after it, so "Suite" or
+	// "Lot" in a street name stays put. "fl" is left out since it's also
+	// Florida.
+	unitPattern = regexp.MustCompile(`(?i)[,\s]*(\b(apt|apartment|unit|suite|ste|rm|room|bldg|building|lot|trlr)\.?\s*#?|#)\s*([a-z]?-?\d+[a-z]?|[a-z])\b`)
+
+	intersectionPattern = regexp.MustCompile(`(?i)\s*(&|@|/|\band\b|\bat\b)\s*`)
+
+	houseNumberPattern = regexp.MustCompile(`^\d+[a-zA-Z]?\s`)
+)
+
+// CleanAddress takes a unit off of address and notices whether it's an
+// intersection, or returns ErrPOBox for a PO Box.
+func CleanAddress(address string) (Address, error) {
+	address = strings.Join(strings.Fields(address), " ")
+
+	if poBoxPattern.MatchString(address) {
+		return Address{}, ErrPOBox
+	}
+
+	a := Address{Line: address}
+
+	if m := unitPattern.FindStringSubmatchIndex(address); m != nil {
+		a.Unit = strings.TrimSpace(address[m[2]:m[1]])
+		a.Line = strings.TrimSpace(address[:m[0]] + address[m[1]:])
+	}
+
+	// an intersection is in the street part, before the city, and has no
+	// house number, which would make it an address on a street with "and"
+	// in its name
+	street := a.Line
+	if i := strings.Index(street, ","); i >= 0 {
+		street = street[:i]
+	}
+
+	if !houseNumberPattern.MatchString(street) {
+		parts := intersectionPattern.Split(street, -1)
+		if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+			a.Streets = parts
+		}
+	}
+
+	return a, nil
+}
+
+// the Census geocoder needs a house number, so intersections go to Photon,
+// which finds one of the streets rather than the corner. that's well within
+// a forecast grid cell for any corner worth asking about.
+func (c *Client) locateIntersection(ctx context.Context, a Address) (Match, error) {
+	suggestions, err := c.Suggest(ctx, a.Line, 1)
+	if err != nil {
+		return Match{}, err
+	}
+
+	if len(suggestions) == 0 {
+		return Match{}, fmt.Errorf("%w, try an address near the corner of %s and %s", ErrNoMatch, a.Streets[0], a.Streets[1])
+	}
+
+	return Match{
+		Latitude:  suggestions[0].Latitude,
+		Longitude: suggestions[0].Longitude,
+		Address:   suggestions[0].Address,
+	}, nil
+}
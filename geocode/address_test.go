@@ -0,0 +1,71 @@
+package geocode_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/packrat386/agwc/geocode"
+)
+
+func TestCleanAddress(t *testing.T) {
+	for _, tc := range []struct {
+		address string
+		line    string
+		unit    string
+		streets []string
+	}{
+		{"1600 Pennsylvania Ave NW, Washington, DC 20500", "1600 Pennsylvania Ave NW, Washington, DC 20500", "", nil},
+		{"  123   Main St,  Springfield ", "123 Main St, Springfield", "", nil},
+		{"123 Main St Apt 4B, Springfield, IL", "123 Main St, Springfield, IL", "Apt 4B", nil},
+		{"123 Main St, Apt. 4B, Springfield, IL", "123 Main St, Springfield, IL", "Apt. 4B", nil},
+		{"123 Main St #12, Springfield, IL", "123 Main St, Springfield, IL", "#12", nil},
+		{"500 Oak Ave Suite 210, Springfield, IL", "500 Oak Ave, Springfield, IL", "Suite 210", nil},
+		{"500 Oak Ave Ste. B, Springfield, IL", "500 Oak Ave, Springfield, IL", "Ste. B", nil},
+		{"9 Elm Rd Unit C-3, Springfield, IL", "9 Elm Rd, Springfield, IL", "Unit C-3", nil},
+
+		// words that are only units with a number after them stay put
+		{"12 Suite Rd, Springfield, IL", "12 Suite Rd, Springfield, IL", "", nil},
+		{"40 Lot Ln, Springfield, IL", "40 Lot Ln, Springfield, IL", "", nil},
+
+		{"Main St & 5th Ave, Springfield, IL", "Main St & 5th Ave, Springfield, IL", "", []string{"Main St", "5th Ave"}},
+		{"Main St and 5th Ave", "Main St and 5th Ave", "", []string{"Main St", "5th Ave"}},
+		{"Main St at 5th Ave", "Main St at 5th Ave", "", []string{"Main St", "5th Ave"}},
+		{"Main St / 5th Ave", "Main St / 5th Ave", "", []string{"Main St", "5th Ave"}},
+		{"Main St @ 5th Ave, Springfield", "Main St @ 5th Ave, Springfield", "", []string{"Main St", "5th Ave"}},
+
+		// a house number means a street with "and" in its name
+		{"12 Bread and Butter Ln, Springfield", "12 Bread and Butter Ln, Springfield", "", nil},
+		{"Main St & 5th Ave & Elm St", "Main St & 5th Ave & Elm St", "", nil},
+		{"& 5th Ave", "& 5th Ave", "", nil},
+	} {
+		a, err := geocode.CleanAddress(tc.address)
+		if err != nil {
+			t.Errorf("%s: %s", tc.address, err)
+			continue
+		}
+
+		if a.Line != tc.line || a.Unit != tc.unit || strings.Join(a.Streets, "|") != strings.Join(tc.streets, "|") {
+			t.Errorf("%s: cleaned to %q, unit %q, streets %q", tc.address, a.Line, a.Unit, a.Streets)
+		}
+	}
+}
+
+func TestCleanAddressPOBox(t *testing.T) {
+	for _, address := range []string{
+		"PO Box 12, Springfield, IL",
+		"P.O. Box 12, Springfield, IL",
+		"p o box 12",
+		"Post Office Box 12, Springfield, IL",
+		"Jane Doe, POBox 12, Springfield",
+	} {
+		_, err := geocode.CleanAddress(address)
+		if err != geocode.ErrPOBox {
+			t.Errorf("%s: cleaned with %v", address, err)
+		}
+	}
+
+	_, err := geocode.CleanAddress("12 Boxwood Ln, Springfield, IL")
+	if err != nil {
+		t.Errorf("Boxwood is a PO Box: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Benchmark is a version of the Census address data, like
+// Public_AR_Current. An older one sometimes knows an address a newer one
+// hasn't caught up with yet, or the other way around.
+type Benchmark struct {
+	Name        string
+	Description string
+	Default     bool
+}
+
+// Vintage is a version of the geographies, like counties, for a benchmark.
+type Vintage struct {
+	Name        string
+	Description string
+	Default     bool
+}
+
+// Benchmarks lists the benchmarks the geocoder has, to set as
+// Client.Benchmark.
+func (c *Client) Benchmarks(ctx context.Context) ([]Benchmark, error) {
+	if c.UserAgent == "" {
+		return nil, ErrNoUserAgent
+	}
+
+	res, err := c.get(ctx, c.baseURL()+"/geocoder/benchmarks")
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status listing benchmarks: %s", res.Status)
+	}
+
+	return ParseBenchmarks(res.Body)
+}
+
+// Vintages lists the vintages the geocoder has for Client.Benchmark, to set
+// as Client.Vintage.
+func (c *Client) Vintages(ctx context.Context) ([]Vintage, error) {
+	if c.UserAgent == "" {
+		return nil, ErrNoUserAgent
+	}
+
+	res, err := c.get(ctx, c.baseURL()+"/geocoder/vintages?"+url.Values{
+		"benchmark": []string{c.benchmark()},
+	}.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status listing vintages for %s: %s", c.benchmark(), res.Status)
+	}
+
+	return ParseVintages(res.Body)
+}
+
+// ParseBenchmarks reads the geocoder's list of benchmarks.
+func ParseBenchmarks(r io.Reader) ([]Benchmark, error) {
+	body := struct {
+		Benchmarks []struct {
+			Name        string `json:"benchmarkName"`
+			Description string `json:"benchmarkDescription"`
+			Default     bool   `json:"isDefault"`
+		} `json:"benchmarks"`
+	}{}
+
+	err := json.NewDecoder(r).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	benchmarks := []Benchmark{}
+	for _, b := range body.Benchmarks {
+		benchmarks = append(benchmarks, Benchmark{Name: b.Name, Description: b.Description, Default: b.Default})
+	}
+
+	return benchmarks, nil
+}
+
+// ParseVintages reads the geocoder's list of vintages for a benchmark.
+func ParseVintages(r io.Reader) ([]Vintage, error) {
+	body := struct {
+		Vintages []struct {
+			Name        string `json:"vintageName"`
+			Description string `json:"vintageDescription"`
+			Default     bool   `json:"isDefault"`
+		} `json:"vintages"`
+	}{}
+
+	err := json.NewDecoder(r).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	vintages := []Vintage{}
+	for _, v := range body.Vintages {
+		vintages = append(vintages, Vintage{Name: v.Name, Description: v.Description, Default: v.Default})
+	}
+
+	return vintages, nil
+}
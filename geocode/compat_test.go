@@ -0,0 +1,74 @@
+package geocode_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/packrat386/agwc/geocode"
+)
+
+// geocode's v1 API. Match and Address are what callers keep around, so
+// their fields are listed in full.
+var (
+	_ string = geocode.DefaultBaseURL
+	_ string = geocode.DefaultBenchmark
+	_ string = geocode.DefaultVintage
+	_ string = geocode.DefaultSuggestURL
+	_ error  = geocode.ErrNoMatch
+	_ error  = geocode.ErrNoUserAgent
+	_ error  = geocode.ErrPOBox
+
+	_ func(string) *geocode.Client                                                      = geocode.NewClient
+	_ func(*geocode.Client, context.Context, string) (geocode.Match, error)             = (*geocode.Client).Locate
+	_ func(*geocode.Client, context.Context, string) (geocode.Match, error)             = (*geocode.Client).Describe
+	_ func(*geocode.Client, context.Context, float64, float64) (string, error)          = (*geocode.Client).County
+	_ func(*geocode.Client, context.Context, string, int) ([]geocode.Suggestion, error) = (*geocode.Client).Suggest
+	_ func(*geocode.Client, context.Context) ([]geocode.Benchmark, error)               = (*geocode.Client).Benchmarks
+	_ func(*geocode.Client, context.Context) ([]geocode.Vintage, error)                 = (*geocode.Client).Vintages
+
+	_ func(string) (geocode.Address, error)         = geocode.CleanAddress
+	_ func(io.Reader) (geocode.Match, error)        = geocode.ParseResponse
+	_ func(io.Reader) ([]geocode.Suggestion, error) = geocode.ParseSuggestions
+	_ func(io.Reader) ([]geocode.Benchmark, error)  = geocode.ParseBenchmarks
+	_ func(io.Reader) ([]geocode.Vintage, error)    = geocode.ParseVintages
+
+	_ = geocode.Client{HTTPClient: &http.Client{}, UserAgent: "", BaseURL: "", Benchmark: "", Vintage: "", SuggestURL: "", Retries: 0, Backoff: time.Second}
+	_ = geocode.Match{Latitude: 0, Longitude: 0, Address: "", County: ""}
+	_ = geocode.Address{Line: "", Unit: "", Streets: []string{}}
+	_ = geocode.Suggestion{Address: "", Latitude: 0, Longitude: 0}
+	_ = geocode.Benchmark{Name: "", Description: "", Default: false}
+	_ = geocode.Vintage{Name: "", Description: "", Default: false}
+)
+
+func TestCleanAddressAndNoUserAgent(t *testing.T) {
+	a, err := geocode.CleanAddress("123 Main St Apt 4B, Springfield, IL 62701")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Line != "123 Main St, Springfield, IL 62701" || a.Unit != "Apt 4B" || len(a.Streets) != 0 {
+		t.Errorf("cleaned to %+v", a)
+	}
+
+	a, err = geocode.CleanAddress("Main St & 5th Ave, Springfield, IL")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.Streets) != 2 || a.Streets[0] != "Main St" || a.Streets[1] != "5th Ave" {
+		t.Errorf("intersection cleaned to %+v", a)
+	}
+
+	_, err = geocode.CleanAddress("PO Box 12, Springfield, IL")
+	if err != geocode.ErrPOBox {
+		t.Errorf("PO Box cleaned with %v", err)
+	}
+
+	_, err = geocode.NewClient("").Locate(context.Background(), "1600 Pennsylvania Ave NW, Washington, DC")
+	if err != geocode.ErrNoUserAgent {
+		t.Errorf("a client without a User-Agent sent a request, got %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+// Package geocode turns US street addresses into coordinates with the
+// Census Bureau's geocoder.
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/packrat386/agwc/internal/retry"
+)
+
+// DefaultBaseURL is where the Census geocoder lives.
+const DefaultBaseURL = "https://geocoding.geo.census.gov"
+
+// DefaultBenchmark is the version of the Census address data to match
+// against.
+const DefaultBenchmark = "Public_AR_Current"
+
+// DefaultVintage is the version of the geographies, like counties, that
+// Describe and County report.
+const DefaultVintage = "Current_Current"
+
+// ErrNoMatch is returned when the geocoder doesn't know the address.
+var ErrNoMatch = errors.New("no matching coordinates for address")
+
+// ErrNoUserAgent is returned by every request from a Client without a
+// UserAgent.
+var ErrNoUserAgent = errors.New("geocode: a User-Agent is required, like 'myapp (me@example.com)'")
+
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// Client looks up addresses. The zero value is usable once UserAgent is
+// set, though it won't retry anything.
+type Client struct {
+	// HTTPClient sends the requests, or one with a 30 second timeout if
+	// nil.
+	HTTPClient *http.Client
+
+	// UserAgent identifies the caller, and is required.
+	UserAgent string
+
+	// BaseURL, Benchmark and Vintage are DefaultBaseURL,
+	// DefaultBenchmark and DefaultVintage if empty.
+	BaseURL   string
+	Benchmark string
+	Vintage   string
+
+	// SuggestURL is where Suggest looks, DefaultSuggestURL if empty.
+	SuggestURL string
+
+	// Retries is how many more times to try after a 5xx or 429, waiting
+	// Backoff before the first retry and twice as long before each after.
+	Retries int
+	Backoff time.Duration
+}
+
+// NewClient is a Client that retries three times starting a second apart.
+func NewClient(userAgent string) *Client {
+	return &Client{UserAgent: userAgent, Retries: 3, Backoff: time.Second}
+}
+
+// Match is where the geocoder put an address.
+type Match struct {
+	Latitude  float64
+	Longitude float64
+
+	// Address is the address as the geocoder matched it, which can be
+	// quite different from what was asked for.
+	Address string
+
+	// County is only filled in by Describe.
+	County string
+}
+
+// Locate finds the best match for a one line address, like "1600
+// Pennsylvania Ave NW, Washington, DC", after CleanAddress. An
+// intersection, like "Main St & 5th Ave, Springfield, IL", is found near
+// the corner.
+func (c *Client) Locate(ctx context.Context, address string) (Match, error) {
+	if c.UserAgent == "" {
+		return Match{}, ErrNoUserAgent
+	}
+
+	a, err := CleanAddress(address)
+	if err != nil {
+		return Match{}, err
+	}
+
+	if len(a.Streets) > 0 {
+		return c.locateIntersection(ctx, a)
+	}
+
+	res, err := c.get(ctx, c.baseURL()+"/geocoder/locations/onelineaddress?"+url.Values{
+		"format":    []string{"json"},
+		"benchmark": []string{c.benchmark()},
+		"address":   []string{a.Line},
+	}.Encode())
+	if err != nil {
+		return Match{}, err
+	}
+
+	defer res.Body.Close()
+
+	return ParseResponse(res.Body)
+}
+
+// Describe is Locate, but also finds the county the address is in, which
+// takes the geocoder a little longer.
+func (c *Client) Describe(ctx context.Context, address string) (Match, error) {
+	if c.UserAgent == "" {
+		return Match{}, ErrNoUserAgent
+	}
+
+	a, err := CleanAddress(address)
+	if err != nil {
+		return Match{}, err
+	}
+
+	if len(a.Streets) > 0 {
+		m, err := c.locateIntersection(ctx, a)
+		if err != nil {
+			return Match{}, err
+		}
+
+		m.County, err = c.County(ctx, m.Latitude, m.Longitude)
+		if err != nil {
+			return Match{}, err
+		}
+
+		return m, nil
+	}
+
+	res, err := c.get(ctx, c.baseURL()+"/geocoder/geographies/onelineaddress?"+url.Values{
+		"format":    []string{"json"},
+		"benchmark": []string{c.benchmark()},
+		"vintage":   []string{c.vintage()},
+		"layers":    []string{"Counties"},
+		"address":   []string{a.Line},
+	}.Encode())
+	if err != nil {
+		return Match{}, err
+	}
+
+	defer res.Body.Close()
+
+	return ParseResponse(res.Body)
+}
+
+// County finds the county a point is in, or "" if it isn't in one, like
+// out at sea.
+func (c *Client) County(ctx context.Context, latitude, longitude float64) (string, error) {
+	if c.UserAgent == "" {
+		return "", ErrNoUserAgent
+	}
+
+	res, err := c.get(ctx, c.baseURL()+"/geocoder/geographies/coordinates?"+url.Values{
+		"format":    []string{"json"},
+		"benchmark": []string{c.benchmark()},
+		"vintage":   []string{c.vintage()},
+		"layers":    []string{"Counties"},
+		"x":         []string{strconv.FormatFloat(longitude, 'f', -1, 64)},
+		"y":         []string{strconv.FormatFloat(latitude, 'f', -1, 64)},
+	}.Encode())
+	if err != nil {
+		return "", err
+	}
+
+	defer res.Body.Close()
+
+	body := struct {
+		Result struct {
+			Geographies geographies `json:"geographies"`
+		} `json:"result"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return "", fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	return body.Result.Geographies.county(), nil
+}
+
+func (c *Client) baseURL() string {
+	if c.BaseURL != "" {
+		return c.BaseURL
+	}
+
+	return DefaultBaseURL
+}
+
+func (c *Client) benchmark() string {
+	if c.Benchmark != "" {
+		return c.Benchmark
+	}
+
+	return DefaultBenchmark
+}
+
+func (c *Client) vintage() string {
+	if c.Vintage != "" {
+		return c.Vintage
+	}
+
+	return DefaultVintage
+}
+
+func (c *Client) get(ctx context.Context, queryURL string) (*http.Response, error) {
+	client := defaultHTTPClient
+	if c.HTTPClient != nil {
+		client = c.HTTPClient
+	}
+
+	header := http.Header{}
+	header.Set("User-Agent", c.UserAgent)
+
+	return retry.Get(ctx, client, queryURL, header, retry.Policy{Retries: c.Retries, Backoff: c.Backoff})
+}
+
+type geographies struct {
+	Counties []struct {
+		Name string `json:"NAME"`
+	} `json:"Counties"`
+}
+
+func (g geographies) county() string {
+	if len(g.Counties) == 0 {
+		return ""
+	}
+
+	return g.Counties[0].Name
+}
+
+// ParseResponse reads the geocoder's JSON response, from either Locate or
+// Describe, and returns its first match.
+func ParseResponse(r io.Reader) (Match, error) {
+	body := struct {
+		Result struct {
+			AddressMatches []struct {
+				MatchedAddress string `json:"matchedAddress"`
+				Coordinates    struct {
+					X float64 `json:"x"`
+					Y float64 `json:"y"`
+				} `json:"coordinates"`
+				Geographies geographies `json:"geographies"`
+			} `json:"addressMatches"`
+		} `json:"result"`
+	}{}
+
+	err := json.NewDecoder(r).Decode(&body)
+	if err != nil {
+		return Match{}, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	if len(body.Result.AddressMatches) == 0 {
+		return Match{}, ErrNoMatch
+	}
+
+	match := body.Result.AddressMatches[0]
+
+	return Match{
+		Latitude:  match.Coordinates.Y,
+		Longitude: match.Coordinates.X,
+		Address:   match.MatchedAddress,
+		County:    match.Geographies.county(),
+	}, nil
+}
@@ -0,0 +1,120 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultSuggestURL is Photon, an OpenStreetMap geocoder built for search
+// as you type, since the Census geocoder only answers for whole addresses.
+const DefaultSuggestURL = "https://photon.komoot.io"
+
+// Suggestion is an address that a partial one could be, and where it is.
+type Suggestion struct {
+	Address   string
+	Latitude  float64
+	Longitude float64
+}
+
+// Suggest lists up to limit addresses in the US that begin like partial,
+// like "1600 Penn", best first.
+func (c *Client) Suggest(ctx context.Context, partial string, limit int) ([]Suggestion, error) {
+	if c.UserAgent == "" {
+		return nil, ErrNoUserAgent
+	}
+
+	base := DefaultSuggestURL
+	if c.SuggestURL != "" {
+		base = c.SuggestURL
+	}
+
+	// Photon can't be limited to a country, so ask for extra to make up
+	// for the ones dropped
+	queryURL := base + "/api/?" + url.Values{
+		"q":     []string{partial},
+		"lang":  []string{"en"},
+		"limit": []string{strconv.Itoa(limit * 3)},
+	}.Encode()
+
+	res, err := c.get(ctx, queryURL)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	suggestions, err := ParseSuggestions(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	return suggestions, nil
+}
+
+// ParseSuggestions reads Photon's GeoJSON response and returns the places
+// in the US, the only ones NWS forecasts for.
+func ParseSuggestions(r io.Reader) ([]Suggestion, error) {
+	body := struct {
+		Features []struct {
+			Geometry struct {
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties struct {
+				Name        string `json:"name"`
+				HouseNumber string `json:"housenumber"`
+				Street      string `json:"street"`
+				City        string `json:"city"`
+				State       string `json:"state"`
+				Postcode    string `json:"postcode"`
+				CountryCode string `json:"countrycode"`
+			} `json:"properties"`
+		} `json:"features"`
+	}{}
+
+	err := json.NewDecoder(r).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	suggestions := []Suggestion{}
+	for _, f := range body.Features {
+		p := f.Properties
+		if !strings.EqualFold(p.CountryCode, "US") || len(f.Geometry.Coordinates) < 2 {
+			continue
+		}
+
+		// the same way the Census geocoder would want it back
+		parts := []string{}
+		switch {
+		case p.Street != "":
+			parts = append(parts, strings.TrimSpace(p.HouseNumber+" "+p.Street))
+		case p.Name != "":
+			parts = append(parts, p.Name)
+		}
+
+		if p.City != "" && p.City != p.Name {
+			parts = append(parts, p.City)
+		}
+
+		if state := strings.TrimSpace(p.State + " " + p.Postcode); state != "" {
+			parts = append(parts, state)
+		}
+
+		suggestions = append(suggestions, Suggestion{
+			Address:   strings.Join(parts, ", "),
+			Latitude:  f.Geometry.Coordinates[1],
+			Longitude: f.Geometry.Coordinates[0],
+		})
+	}
+
+	return suggestions, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// exit code for when the request was fine but there's nothing to print
+const exitNoData = 3
+
+type getRequest struct {
+	address  string
+	property string
+	at       string
+	format   string
+	freedom  bool
+}
+
+func runGet(flagset *flag.FlagSet) func() {
+	request := getFlags(flagset)
+
+	return func() {
+		req, err := request()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		coordinates, err := getAddressCoordinates(req.address)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, []string{req.property})
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		at, err := resolveTimeExpression(req.at, time.Now(), coordinates, time.Local)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		p, ok := findPointAt(forecast.properties[req.property], at)
+		if !ok || p.Value == nil {
+			fmt.Fprintf(os.Stderr, "no data for %s at %s\n", req.property, at.Format(time.RFC3339))
+			quit(exitNoData)
+		}
+
+		switch req.format {
+		case "raw":
+			if req.freedom {
+				p = liberate(p)
+			}
+
+			fmt.Println(formatNumber(*p.Value, kindPrecision[propertyRegistry[req.property]]))
+		case "text":
+			fmt.Println(formatWeatherValue(req.property, p, req.freedom))
+		}
+	}
+}
+
+func getFlags(flagset *flag.FlagSet) func() (getRequest, error) {
+	var (
+		queryAddress string
+		property     string
+		at           string
+		format       string
+		freedom      bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address at which to see the weather")
+	flagset.StringVar(&property, "property", "temperature", "weather property to print")
+	flagset.StringVar(&at, "at", "now", "time of the value, as 'now', an offset like '+3h', 'sunset', or RFC3339")
+	flagset.StringVar(&format, "format", "raw", "output format, 'raw' for a bare number or 'text' to include units")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+	flagset.BoolVar(&strictDecode, "strict-decode", false, "fail on unexpected or missing fields in upstream responses instead of warning")
+
+	return func() (getRequest, error) {
+		req := getRequest{
+			address:  queryAddress,
+			property: property,
+			at:       at,
+			format:   format,
+			freedom:  freedom,
+		}
+
+		if req.address == "" {
+			return getRequest{}, fmt.Errorf("address cannot be empty")
+		}
+
+		if _, ok := propertyRegistry[req.property]; !ok {
+			return getRequest{}, fmt.Errorf("requested property '%s' is not in %v", req.property, permittedProperties())
+		}
+
+		if !isTimeExpression(req.at) {
+			return getRequest{}, fmt.Errorf("'%s' is not a valid time, expected something like '+3h', 'sunset', or RFC3339", req.at)
+		}
+
+		if req.format != "raw" && req.format != "text" {
+			return getRequest{}, fmt.Errorf("format must be 'raw' or 'text', got '%s'", req.format)
+		}
+
+		return req, nil
+	}
+}
+
+// accepts "now", a signed offset from now like "+3h" or "-30m", or an RFC3339 timestamp
+func parseTimeExpression(s string, now time.Time) (time.Time, error) {
+	if s == "now" {
+		return now, nil
+	}
+
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not parse time offset '%s': %w", s, err)
+		}
+
+		return now.Add(d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse time '%s': %w", s, err)
+	}
+
+	return t, nil
+}
+
+func findPointAt(points series, at time.Time) (weatherPoint, bool) {
+	i := points.IndexAt(at)
+	if i < 0 {
+		return weatherPoint{}, false
+	}
+
+	return points.At(i), true
+}
@@ -1,3 +1,10 @@
 module github.com/packrat386/agwc
 
-go 1.16
+go 1.18
+
+require (
+	github.com/mattn/go-runewidth v0.0.15
+	rsc.io/qr v0.2.0
+)
+
+require github.com/rivo/uniseg v0.2.0 // indirect
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// from least to most, with a dot for hours there's no value for
+var heatmapShades = []string{" ", "░", "▒", "▓", "█"}
+
+const heatmapMissing = "·"
+
+// the first visible column as days down the side and hours across the top,
+// two characters to an hour so the cells come out about square
+func displayHeatmap(w io.Writer, req forecastRequest, rows []displayRow) {
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "no hours match your criteria in the requested window")
+		quit(exitNoRows)
+	}
+
+	columns := req.columns()
+	column := req.visibleColumns()[0]
+	name := columns[column]
+
+	// percentages have a natural scale, anything else is scaled to what's
+	// in the window
+	low, high := math.Inf(1), math.Inf(-1)
+	kind, known := propertyRegistry[name]
+
+	if known && (kind == kindProbability || kind == kindPercentage) {
+		low, high = 0, 100
+	} else {
+		for _, r := range rows {
+			if v := r.numbers[column]; v != nil {
+				low, high = math.Min(low, *v), math.Max(high, *v)
+			}
+		}
+	}
+
+	shade := func(v *float64) string {
+		if v == nil {
+			return heatmapMissing
+		}
+
+		if high <= low {
+			return heatmapShades[len(heatmapShades)-1]
+		}
+
+		i := int((*v - low) / (high - low) * float64(len(heatmapShades)))
+		if i >= len(heatmapShades) {
+			i = len(heatmapShades) - 1
+		}
+
+		if i < 0 {
+			i = 0
+		}
+
+		return heatmapShades[i]
+	}
+
+	unit := heatmapUnit(req, name)
+
+	fmt.Fprintf(w, "%s%s, %s\n", name, unit, req.displayTimeZone)
+
+	header := strings.Repeat(" ", 10)
+	for h := 0; h < 24; h += 3 {
+		header += fmt.Sprintf("%-6d", h)
+	}
+
+	fmt.Fprintln(w, strings.TrimRight(header, " "))
+
+	days := []string{}
+	cells := map[string][]string{}
+
+	for _, r := range rows {
+		at := r.at.In(req.displayTimeZone)
+		day := at.Format("Mon 01/02")
+
+		if _, ok := cells[day]; !ok {
+			days = append(days, day)
+			cells[day] = make([]string, 24)
+		}
+
+		cells[day][at.Hour()] = shade(r.numbers[column])
+	}
+
+	for _, day := range days {
+		line := day + " "
+
+		for _, c := range cells[day] {
+			// outside the window, which isn't the same as missing
+			if c == "" {
+				c = " "
+			}
+
+			line += c + c
+		}
+
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+
+	legend := []string{}
+	step := (high - low) / float64(len(heatmapShades))
+	precision := kindPrecision[kind]
+
+	for i, s := range heatmapShades {
+		from := low + step*float64(i)
+		legend = append(legend, fmt.Sprintf("[%s%s] %s+", s, s, formatNumber(from, precision)))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%s  [%s%s] no data\n", strings.Join(legend, "  "), heatmapMissing, heatmapMissing)
+}
+
+func heatmapUnit(req forecastRequest, name string) string {
+	for _, d := range req.derived {
+		if d.name == name && d.unit != "" {
+			return " (" + d.unit + ")"
+		}
+	}
+
+	unit, ok := kindUnits[propertyRegistry[name]]
+	if !ok {
+		return ""
+	}
+
+	return " (" + req.columnUnit(unit) + ")"
+}
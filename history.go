@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// what's been recorded, kept as one JSON record per line in a file per
+// station or grid cell, so it can be appended to cheaply and read with
+// anything
+const historyDirName = "history"
+
+func dataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "agwc"), nil
+	}
+
+	// which is %LocalAppData%, where data that shouldn't roam belongs
+	if runtime.GOOS == "windows" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("could not find data directory: %w", err)
+		}
+
+		return filepath.Join(dir, "agwc"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find data directory: %w", err)
+	}
+
+	return filepath.Join(home, ".local", "share", "agwc"), nil
+}
+
+func historyPath(kind, name string) (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, historyDirName, kind, name+".jsonl"), nil
+}
+
+type recordedValue struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+type recordedObservation struct {
+	Station string                   `json:"station"`
+	Time    time.Time                `json:"time"`
+	Values  map[string]recordedValue `json:"values"`
+}
+
+// one forecast hour, as it was forecast at the time it was issued
+type recordedForecast struct {
+	Office    string                   `json:"office"`
+	X         int                      `json:"x"`
+	Y         int                      `json:"y"`
+	IssuedAt  time.Time                `json:"issuedAt"`
+	Time      time.Time                `json:"time"`
+	LeadHours int                      `json:"leadHours"`
+	Values    map[string]recordedValue `json:"values"`
+}
+
+func gridHistoryName(office string, x, y int) string {
+	return fmt.Sprintf("%s-%d-%d", office, x, y)
+}
+
+// every record in a history file, where a missing file has none
+func readHistory(path string, each func(line []byte) error) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not open history: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		err := each(scanner.Bytes())
+		if err != nil {
+			return fmt.Errorf("could not parse history %s: %w", path, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// how long to wait for someone else's lock on a history file, and how old
+// a lock has to be before it's taken to be left over from a crash
+const (
+	historyLockWait  = 30 * time.Second
+	historyLockStale = 10 * time.Minute
+)
+
+// keeps record from appending to a history file while compaction is
+// rewriting it, since an append to the file being replaced would be lost.
+// it's a file next to the history rather than flock so it works the same
+// everywhere the daemon runs.
+func lockHistory(path string) (func(), error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("could not create history directory: %w", err)
+	}
+
+	lock := path + ".lock"
+	deadline := time.Now().Add(historyLockWait)
+
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("could not lock history: %w", err)
+		}
+
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > historyLockStale {
+			os.Remove(lock)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("history %s is still locked after %s, remove %s if nothing is recording or compacting", path, historyLockWait, lock)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// the caller holds the history's lock, from before it read what's already
+// there to check against, so nothing's appended twice
+func appendHistory(path string, records []interface{}) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not open history: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	for _, r := range records {
+		err := enc.Encode(r)
+		if err != nil {
+			return fmt.Errorf("could not write history: %w", err)
+		}
+	}
+
+	return w.Flush()
+}
+
+func readObservationHistory(stationID string) ([]recordedObservation, error) {
+	path, err := historyPath("observations", stationID)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []recordedObservation{}
+
+	err = readHistory(path, func(line []byte) error {
+		r := recordedObservation{}
+		err := json.Unmarshal(line, &r)
+		records = append(records, r)
+		return err
+	})
+
+	sort.Slice(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+
+	return records, err
+}
+
+// adds the observations that aren't already recorded, and says how many
+// that was
+func recordObservations(stationID string, observations []observation) (int, error) {
+	path, err := historyPath("observations", stationID)
+	if err != nil {
+		return 0, err
+	}
+
+	unlock, err := lockHistory(path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	existing, err := readObservationHistory(stationID)
+	if err != nil {
+		return 0, err
+	}
+
+	seen := map[int64]bool{}
+	for _, r := range existing {
+		seen[r.Time.Unix()] = true
+	}
+
+	records := []interface{}{}
+
+	for _, o := range observations {
+		if seen[o.timestamp.Unix()] {
+			continue
+		}
+
+		seen[o.timestamp.Unix()] = true
+
+		r := recordedObservation{Station: stationID, Time: o.timestamp.UTC(), Values: map[string]recordedValue{}}
+		for name, p := range o.values {
+			if p.Value != nil {
+				r.Values[name] = recordedValue{Value: *p.Value, Unit: p.Unit}
+			}
+		}
+
+		if len(r.Values) > 0 {
+			records = append(records, r)
+		}
+	}
+
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	return len(records), appendHistory(path, records)
+}
+
+func readForecastHistory(office string, x, y int) ([]recordedForecast, error) {
+	path, err := historyPath("forecasts", gridHistoryName(office, x, y))
+	if err != nil {
+		return nil, err
+	}
+
+	records := []recordedForecast{}
+
+	err = readHistory(path, func(line []byte) error {
+		r := recordedForecast{}
+		err := json.Unmarshal(line, &r)
+		records = append(records, r)
+		return err
+	})
+
+	return records, err
+}
+
+// records each hour of an issuance once, however many times it's fetched
+func recordForecast(grid gridPoint, forecast gridForecast, properties []string) (int, error) {
+	path, err := historyPath("forecasts", gridHistoryName(grid.office, grid.x, grid.y))
+	if err != nil {
+		return 0, err
+	}
+
+	unlock, err := lockHistory(path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	existing, err := readForecastHistory(grid.office, grid.x, grid.y)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, r := range existing {
+		if r.IssuedAt.Equal(forecast.updateTime) {
+			return 0, nil
+		}
+	}
+
+	issued := forecast.updateTime.UTC()
+	start := issued.Truncate(time.Hour)
+
+	records := []interface{}{}
+
+	for at := start; at.Before(start.Add(7 * 24 * time.Hour)); at = at.Add(time.Hour) {
+		r := recordedForecast{
+			Office:    grid.office,
+			X:         grid.x,
+			Y:         grid.y,
+			IssuedAt:  issued,
+			Time:      at,
+			LeadHours: int(at.Sub(start) / time.Hour),
+			Values:    map[string]recordedValue{},
+		}
+
+		for _, property := range properties {
+			p, ok := findPointAt(forecast.properties[property], at)
+			if ok && p.Value != nil {
+				r.Values[property] = recordedValue{Value: *p.Value, Unit: p.Unit}
+			}
+		}
+
+		if len(r.Values) > 0 {
+			records = append(records, r)
+		}
+	}
+
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	return len(records), appendHistory(path, records)
+}
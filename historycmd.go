@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var historyExportFormats = []string{"csv", "parquet"}
+
+// one recorded value, flattened so everything recorded fits in one table.
+// observations are their own issuance with no lead time.
+type historyRow struct {
+	kind      string
+	source    string
+	issuedAt  time.Time
+	time      time.Time
+	leadHours int
+	property  string
+	value     float64
+	unit      string
+}
+
+var historyRowHeader = []string{"kind", "source", "issued_at", "time", "lead_hours", "property", "value", "unit"}
+
+// every history file of a kind, by the station or grid cell it's for
+func historyFiles(kind string) (map[string]string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, historyDirName, kind, "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("could not list history: %w", err)
+	}
+
+	files := map[string]string{}
+	for _, p := range paths {
+		files[strings.TrimSuffix(filepath.Base(p), ".jsonl")] = p
+	}
+
+	return files, nil
+}
+
+func sortedKeys(m map[string]recordedValue) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func historyRows(since time.Time) ([]historyRow, error) {
+	rows := []historyRow{}
+
+	observations, err := historyFiles("observations")
+	if err != nil {
+		return nil, err
+	}
+
+	for name, path := range observations {
+		err := readHistory(path, func(line []byte) error {
+			r := recordedObservation{}
+
+			err := json.Unmarshal(line, &r)
+			if err != nil || r.Time.Before(since) {
+				return err
+			}
+
+			for _, property := range sortedKeys(r.Values) {
+				rows = append(rows, historyRow{
+					kind:     "observation",
+					source:   name,
+					issuedAt: r.Time,
+					time:     r.Time,
+					property: property,
+					value:    r.Values[property].Value,
+					unit:     r.Values[property].Unit,
+				})
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	forecasts, err := historyFiles("forecasts")
+	if err != nil {
+		return nil, err
+	}
+
+	for name, path := range forecasts {
+		err := readHistory(path, func(line []byte) error {
+			r := recordedForecast{}
+
+			err := json.Unmarshal(line, &r)
+			if err != nil || r.Time.Before(since) {
+				return err
+			}
+
+			for _, property := range sortedKeys(r.Values) {
+				rows = append(rows, historyRow{
+					kind:      "forecast",
+					source:    name,
+					issuedAt:  r.IssuedAt,
+					time:      r.Time,
+					leadHours: r.LeadHours,
+					property:  property,
+					value:     r.Values[property].Value,
+					unit:      r.Values[property].Unit,
+				})
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+
+		if a.source != b.source {
+			return a.source < b.source
+		}
+
+		if !a.issuedAt.Equal(b.issuedAt) {
+			return a.issuedAt.Before(b.issuedAt)
+		}
+
+		return a.time.Before(b.time)
+	})
+
+	return rows, nil
+}
+
+func writeHistoryCSV(w io.Writer, rows []historyRow) error {
+	c := csv.NewWriter(w)
+	c.Write(historyRowHeader)
+
+	for _, r := range rows {
+		c.Write([]string{
+			r.kind,
+			r.source,
+			r.issuedAt.UTC().Format(time.RFC3339),
+			r.time.UTC().Format(time.RFC3339),
+			strconv.Itoa(r.leadHours),
+			r.property,
+			strconv.FormatFloat(r.value, 'f', -1, 64),
+			r.unit,
+		})
+	}
+
+	c.Flush()
+
+	err := c.Error()
+	if err != nil {
+		return fmt.Errorf("could not write csv: %w", err)
+	}
+
+	return nil
+}
+
+func writeHistoryParquet(w io.Writer, rows []historyRow) error {
+	p := &parquetWriter{rows: int64(len(rows))}
+
+	kind := p.column("kind", parquetByteArray, parquetUTF8)
+	source := p.column("source", parquetByteArray, parquetUTF8)
+	issuedAt := p.column("issued_at", parquetInt64, parquetTimestampMillis)
+	at := p.column("time", parquetInt64, parquetTimestampMillis)
+	leadHours := p.column("lead_hours", parquetInt32, parquetNoConversion)
+	property := p.column("property", parquetByteArray, parquetUTF8)
+	value := p.column("value", parquetDouble, parquetNoConversion)
+	unit := p.column("unit", parquetByteArray, parquetUTF8)
+
+	for _, r := range rows {
+		kind.addString(r.kind)
+		source.addString(r.source)
+		issuedAt.addInt64(r.issuedAt.UnixNano() / int64(time.Millisecond))
+		at.addInt64(r.time.UnixNano() / int64(time.Millisecond))
+		leadHours.addInt32(int32(r.leadHours))
+		property.addString(r.property)
+		value.addDouble(r.value)
+		unit.addString(r.unit)
+	}
+
+	return p.writeTo(w)
+}
+
+func runHistoryExport(flagset *flag.FlagSet) func() {
+	var format, since, output string
+
+	flagset.StringVar(&format, "format", "csv", fmt.Sprintf("what to export as, one of %v", historyExportFormats))
+	flagset.StringVar(&since, "since", "", "only export what's for this date (YYYY-MM-DD) or later")
+	flagset.StringVar(&output, "o", "", "file to export to instead of stdout")
+
+	return func() {
+		if indexOf(historyExportFormats, format) < 0 {
+			errorAndQuit(fmt.Errorf("format '%s' is not in %v", format, historyExportFormats))
+		}
+
+		start := time.Time{}
+		if since != "" {
+			var err error
+
+			start, err = time.Parse("2006-01-02", since)
+			if err != nil {
+				errorAndQuit(fmt.Errorf("since must look like 2006-01-02: %w", err))
+			}
+		}
+
+		rows, err := historyRows(start)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		if len(rows) == 0 {
+			fmt.Fprintln(os.Stderr, "nothing recorded yet, see 'agwc record'")
+			quit(exitNoData)
+		}
+
+		w := io.Writer(os.Stdout)
+		if output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				errorAndQuit(fmt.Errorf("could not create %s: %w", output, err))
+			}
+			defer f.Close()
+
+			w = f
+		}
+
+		switch format {
+		case "parquet":
+			err = writeHistoryParquet(w, rows)
+		default:
+			err = writeHistoryCSV(w, rows)
+		}
+
+		if err != nil {
+			errorAndQuit(err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/packrat386/agwc/schema"
+)
+
+const hookProtocol = "agwc.hook/v1"
+
+// shell commands run around a forecast, for small extensions that don't
+// need a whole plugin, like archiving every forecast or pinging a phone
+type hooksConfig struct {
+	// before the forecast is downloaded, where failing stops the run
+	PreFetch string `json:"preFetch,omitempty"`
+
+	// once it's downloaded, and before and after it's printed, where only
+	// a failing preRender stops the run
+	PostFetch  string `json:"postFetch,omitempty"`
+	PreRender  string `json:"preRender,omitempty"`
+	PostRender string `json:"postRender,omitempty"`
+}
+
+var hooks hooksConfig
+
+func configureHooks(cfg config) {
+	hooks = cfg.Hooks
+}
+
+// what a hook gets on stdin, with the forecast for every hook after the
+// fetch in the same shape as -format json
+type hookPayload struct {
+	Protocol   string           `json:"protocol"`
+	Hook       string           `json:"hook"`
+	Address    string           `json:"address"`
+	Latitude   float64          `json:"latitude"`
+	Longitude  float64          `json:"longitude"`
+	Properties []string         `json:"properties"`
+	Forecast   *schema.Forecast `json:"forecast,omitempty"`
+}
+
+// hooks print to stderr, so they can't break -format json on stdout
+func runHook(name, command string, payload hookPayload) error {
+	if command == "" {
+		return nil
+	}
+
+	payload.Protocol = hookProtocol
+	payload.Hook = name
+
+	in, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not encode %s hook payload: %w", name, err)
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+
+	return nil
+}
+
+// a failed hook after the fact isn't worth failing a forecast that's
+// already been shown
+func runHookOrWarn(name, command string, payload hookPayload) {
+	err := runHook(name, command, payload)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func (req forecastRequest) hookPayload(c coordinates) hookPayload {
+	return hookPayload{Address: req.address, Latitude: c.latitude, Longitude: c.longitude, Properties: req.properties}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultBalancePoint = "65F"
+
+// indoor air much above this dewpoint feels muggy, so the outdoor dewpoint
+// beyond it is a decent stand in for how hard the AC works to dry the air
+const latentDewpointCelsius = 12.8
+
+// a temperature like "65F" or "18C", in C
+func parseTemperature(s string) (float64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return 0, fmt.Errorf("'%s' is not a temperature, expected something like '65F' or '18C'", s)
+	}
+
+	v, err := strconv.ParseFloat(s[:len(s)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a temperature, expected something like '65F' or '18C'", s)
+	}
+
+	switch s[len(s)-1] {
+	case 'C':
+		return v, nil
+	case 'F':
+		return (v - 32) * 5 / 9, nil
+	default:
+		return 0, fmt.Errorf("'%s' is not a temperature, expected something like '65F' or '18C'", s)
+	}
+}
+
+type hvacHour struct {
+	at          time.Time
+	temperature *float64
+	dewpoint    *float64
+	heating     float64
+	cooling     float64
+	latent      float64
+}
+
+// degree-hours either side of the balance point, where the house neither
+// needs heating nor cooling, all in C
+func hvacLoads(forecast gridForecast, start, end time.Time, balance float64) []hvacHour {
+	hours := []hvacHour{}
+
+	for at := start.Truncate(time.Hour); !at.After(end); at = at.Add(time.Hour) {
+		h := hvacHour{at: at}
+
+		if p, ok := findPointAt(forecast.properties["temperature"], at); ok && p.Value != nil {
+			h.temperature = p.Value
+
+			if *p.Value < balance {
+				h.heating = balance - *p.Value
+			} else {
+				h.cooling = *p.Value - balance
+			}
+		}
+
+		if p, ok := findPointAt(forecast.properties["dewpoint"], at); ok && p.Value != nil {
+			h.dewpoint = p.Value
+
+			if *p.Value > latentDewpointCelsius {
+				h.latent = *p.Value - latentDewpointCelsius
+			}
+		}
+
+		hours = append(hours, h)
+	}
+
+	return hours
+}
+
+// degree-hours are a temperature difference, so no offset when converting
+func formatDegreeHours(v float64, freedom bool) string {
+	unit := "C"
+	if freedom {
+		v, unit = activeUnitSystem.convertDifference(v, "wmoUnit:degC")
+	}
+
+	return formatNumber(v, 0) + " " + unit + "-hr"
+}
+
+func runHVAC(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		hours        int
+		balanceExpr  string
+		displaytz    string
+		freedom      bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address of the house")
+	flagset.IntVar(&hours, "hours", 24, "number of hours of predictions to total")
+	flagset.StringVar(&balanceExpr, "balance", "", "outdoor temperature at which the house needs neither heating nor cooling, like '65F' or '18C', remembered for next time")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display times")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		if balanceExpr != "" {
+			cfg.HVAC.BalancePoint = balanceExpr
+		}
+
+		if cfg.HVAC.BalancePoint == "" {
+			cfg.HVAC.BalancePoint = defaultBalancePoint
+		}
+
+		balance, err := parseTemperature(cfg.HVAC.BalancePoint)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		if balanceExpr != "" {
+			err = saveConfig(cfg)
+			if err != nil {
+				errorAndQuit(err)
+			}
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, []string{"temperature", "dewpoint"})
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		start := time.Now()
+		loads := hvacLoads(forecast, start, start.Add(time.Duration(hours)*time.Hour), balance)
+
+		widths := []int{15, 11, 11, 11, 11, 11}
+
+		t := newTable(os.Stdout, widths, []string{"time", "temperature", "dewpoint", "heating", "cooling", "latent"})
+
+		var heating, cooling, latent float64
+
+		for _, h := range loads {
+			heating += h.heating
+			cooling += h.cooling
+			latent += h.latent
+
+			t.row([]string{
+				h.at.In(loc).Format(time.Stamp),
+				formatCelsius(h.temperature, freedom),
+				formatCelsius(h.dewpoint, freedom),
+				formatDegreeHours(h.heating, freedom),
+				formatDegreeHours(h.cooling, freedom),
+				formatDegreeHours(h.latent, freedom),
+			}, nil)
+		}
+
+		t.rule()
+		t.row([]string{
+			"total", "", "",
+			formatDegreeHours(heating, freedom),
+			formatDegreeHours(cooling, freedom),
+			formatDegreeHours(latent, freedom),
+		}, nil)
+
+		t.end()
+
+		scale := 1.0
+		if freedom {
+			scale = 9.0 / 5.0
+		}
+
+		threshold := latentDewpointCelsius
+
+		fmt.Println()
+		fmt.Printf(
+			"Balance point %s: %s heating and %s cooling degree-days, with %s of dewpoint above %s\n",
+			cfg.HVAC.BalancePoint,
+			formatNumber(heating*scale/24, 1),
+			formatNumber(cooling*scale/24, 1),
+			formatDegreeHours(latent, freedom),
+			formatCelsius(&threshold, freedom),
+		)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the start of a UGC line, like "ILZ003>006-008-INZ001-021200-", which can
+// wrap over several lines and ends with the expiration time
+var ugcStart = regexp.MustCompile(`^[A-Z]{2}[CZ]\d{3}[-0-9>A-Z]*-$`)
+var ugcEnd = regexp.MustCompile(`\d{6}-$`)
+
+// whether a UGC line lists the zone, expanding ranges like "003>006"
+func ugcCovers(ugc string, zone string) bool {
+	prefix := ""
+
+	for _, token := range strings.Split(strings.TrimSuffix(ugc, "-"), "-") {
+		if len(token) >= 6 && token[2] >= 'A' && token[2] <= 'Z' {
+			prefix = token[:3]
+			token = token[3:]
+		}
+
+		if prefix == "" || len(token) == 6 {
+			continue
+		}
+
+		low, high := token, token
+		if split := strings.Split(token, ">"); len(split) == 2 {
+			low, high = split[0], split[1]
+		}
+
+		from, err1 := strconv.Atoi(low)
+		to, err2 := strconv.Atoi(high)
+		if err1 != nil || err2 != nil || !strings.HasPrefix(zone, prefix) {
+			continue
+		}
+
+		n, err := strconv.Atoi(strings.TrimPrefix(zone, prefix))
+		if err == nil && n >= from && n <= to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// an outlook can have a segment for each group of zones, each starting with
+// the zones it's for and ending with "$$"
+func hwoSegmentFor(text string, zone string) (string, bool) {
+	segments := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "$$")
+
+	for _, segment := range segments {
+		ugc := ""
+		collecting := false
+
+		for _, line := range strings.Split(segment, "\n") {
+			line = strings.TrimSpace(line)
+
+			if !collecting && ugcStart.MatchString(line) {
+				collecting = true
+			}
+
+			if collecting {
+				ugc += line
+
+				if ugcEnd.MatchString(line) {
+					break
+				}
+			}
+		}
+
+		if ugc != "" && ugcCovers(ugc, zone) {
+			return segment, true
+		}
+	}
+
+	return "", false
+}
+
+type hwoSummary struct {
+	office  string
+	issued  time.Time
+	later   string
+	spotter string
+}
+
+func compactText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// quiet outlooks say so in so many words
+func (h hwoSummary) active() bool {
+	quietLater := h.later == "" || strings.Contains(strings.ToLower(h.later), "no hazardous weather is expected")
+	quietSpotters := h.spotter == "" || strings.Contains(strings.ToLower(h.spotter), "not expected")
+
+	return !quietLater || !quietSpotters
+}
+
+func summarizeHWO(p textProduct, zone string) (hwoSummary, bool) {
+	segment, ok := hwoSegmentFor(p.text, zone)
+	if !ok {
+		return hwoSummary{}, false
+	}
+
+	h := hwoSummary{office: p.office, issued: p.issuanceTime}
+
+	for _, s := range splitDiscussion(segment) {
+		title := strings.ToUpper(s.title)
+
+		switch {
+		case strings.HasPrefix(title, "DAYS TWO THROUGH SEVEN"):
+			h.later = compactText(s.body)
+		case strings.HasPrefix(title, "SPOTTER INFORMATION"):
+			for _, sentence := range strings.SplitAfter(compactText(s.body), ". ") {
+				if strings.Contains(strings.ToLower(sentence), "spotter") {
+					h.spotter = strings.TrimSpace(sentence)
+					break
+				}
+			}
+		}
+	}
+
+	return h, true
+}
+
+func hazardousWeatherOutlook(grid gridPoint, loc *time.Location) ([]string, error) {
+	if grid.forecastZone == "" {
+		return nil, nil
+	}
+
+	p, err := getLatestProduct("HWO", grid.office)
+	if err != nil {
+		return nil, err
+	}
+
+	h, ok := summarizeHWO(p, grid.forecastZone)
+	if !ok || !h.active() {
+		return nil, nil
+	}
+
+	lines := []string{fmt.Sprintf("hazardous weather outlook from %s, issued %s", h.office, h.issued.In(loc).Format(time.Stamp))}
+
+	if h.later != "" {
+		lines = append(lines, "days 2-7: "+h.later)
+	}
+
+	if h.spotter != "" {
+		lines = append(lines, "spotters: "+h.spotter)
+	}
+
+	return lines, nil
+}
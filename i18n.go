@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// the languages serve mode can answer ?lang= in, the first the default.
+// agwc is written in english, so only the others need a table.
+var servedLanguages = []string{"en", "es"}
+
+// text serve mode shows people, keyed by the english, which is what's used
+// for anything missing. property names are here as well as the phrases
+// around them, so a client can label a column without its own table.
+var translations = map[string]map[string]string{
+	"es": {
+		"dewpoint":                   "punto de rocío",
+		"heatIndex":                  "índice de calor",
+		"maxTemperature":             "temperatura máxima",
+		"minTemperature":             "temperatura mínima",
+		"pressure":                   "presión",
+		"probabilityOfPrecipitation": "probabilidad de precipitación",
+		"probabilityOfThunder":       "probabilidad de tormenta",
+		"quantitativePrecipitation":  "precipitación",
+		"relativeHumidity":           "humedad relativa",
+		"skyCover":                   "nubosidad",
+		"snowfallAmount":             "nevada",
+		"temperature":                "temperatura",
+		"visibility":                 "visibilidad",
+		"waveDirection":              "dirección del oleaje",
+		"waveHeight":                 "altura del oleaje",
+		"wavePeriod":                 "período del oleaje",
+		"windChill":                  "sensación térmica por viento",
+		"windDirection":              "dirección del viento",
+		"windGust":                   "ráfagas de viento",
+		"windSpeed":                  "velocidad del viento",
+
+		"thunder":       "tormenta",
+		"snow":          "nieve",
+		"rain":          "lluvia",
+		"cloudy":        "nublado",
+		"partly-cloudy": "parcialmente nublado",
+		"clear":         "despejado",
+
+		"agwc forecast for %s":                             "pronóstico de agwc para %s",
+		"daily forecast for %s, in %s":                     "pronóstico diario para %s, en %s",
+		"high %s, low %s":                                  "máxima %s, mínima %s",
+		"%s%% chance of precipitation":                     "%s%% de probabilidad de precipitación",
+		"%s expected":                                      "%s previstos",
+		"%s on %s, %s":                                     "%s el %s, %s",
+		"agwc forecast skill":                              "precisión de los pronósticos de agwc",
+		"forecast skill":                                   "precisión de los pronósticos",
+		"grid %s, observed at %s":                          "celda %s, observada en %s",
+		"nothing to compare yet, see 'agwc record'":        "nada que comparar todavía, ver 'agwc record'",
+		"%d-%dh ahead: mae %.2f, bias %+.2f, %d forecasts": "%d-%dh antes: eam %.2f, sesgo %+.2f, %d pronósticos",
+		"mean absolute error of recorded forecasts against what was observed, by how far ahead they were made. bias is forecast minus observed.": "error absoluto medio de los pronósticos registrados frente a lo observado, según la antelación con que se hicieron. el sesgo es lo pronosticado menos lo observado.",
+	},
+}
+
+// english in lang, or the english if lang is english, empty, or has no
+// translation for it
+func translate(lang, english string) string {
+	if s, ok := translations[lang][english]; ok {
+		return s
+	}
+
+	return english
+}
+
+// names go's time formatting only knows in english
+var (
+	weekdayNames = map[string][7]string{
+		"es": {"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+	}
+
+	monthNames = map[string][12]string{
+		"es": {"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+	}
+)
+
+// a day like "Mon Jan 2", or with long, "Monday, January 2", the way lang
+// writes it
+func formatDay(lang string, t time.Time, long bool) string {
+	weekdays, ok := weekdayNames[lang]
+	if !ok {
+		if long {
+			return t.Format("Monday, January 2")
+		}
+
+		return t.Format("Mon Jan 2")
+	}
+
+	weekday, month := weekdays[t.Weekday()], monthNames[lang][t.Month()-1]
+	if long {
+		return fmt.Sprintf("%s %d de %s", weekday, t.Day(), month)
+	}
+
+	return fmt.Sprintf("%.3s %d %.3s", weekday, t.Day(), month)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranslationsCoverProperties(t *testing.T) {
+	for _, lang := range servedLanguages[1:] {
+		for property := range propertyRegistry {
+			if _, ok := translations[lang][property]; !ok {
+				t.Errorf("no %s for %s", lang, property)
+			}
+		}
+	}
+}
+
+func TestRequestLanguage(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  string
+		ok    bool
+	}{
+		{"", "en", true},
+		{"?lang=en-US", "en", true},
+		{"?lang=es", "es", true},
+		{"?lang=ES_mx", "es", true},
+		{"?lang=fr", "", false},
+	} {
+		got, err := requestLanguage(httptest.NewRequest("GET", "/api/skill"+tc.query, nil))
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("%s: got '%s', %v", tc.query, got, err)
+		}
+	}
+}
+
+func TestFormatDay(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		lang string
+		long bool
+		want string
+	}{
+		{"", false, "Wed May 1"},
+		{"en", true, "Wednesday, May 1"},
+		{"es", false, "mié 1 may"},
+		{"es", true, "miércoles 1 de mayo"},
+	} {
+		if got := formatDay(tc.lang, day, tc.long); got != tc.want {
+			t.Errorf("%s: got '%s', wanted '%s'", tc.lang, got, tc.want)
+		}
+	}
+}
+
+func TestDashboardInSpanish(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	serveDashboard(w, "es", []locationSkill{{
+		Name:    "home",
+		Grid:    "LWX-97-71",
+		Station: "KDCA",
+		Properties: []propertySkill{
+			{Property: "temperature", Unit: "C", Leads: []leadSkill{{LeadHours: 0, MAE: 1.5, Count: 3}}},
+		},
+	}})
+
+	body := w.Body.String()
+	for _, want := range []string{`<html lang="es">`, "precisión de los pronósticos", "celda LWX-97-71, observada en KDCA", "temperatura (C)", "0-6h antes"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("dashboard is missing '%s':\n%s", want, body)
+		}
+	}
+}
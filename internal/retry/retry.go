@@ -0,0 +1,86 @@
+// Package retry sends GET requests, trying again with backoff when upstream
+// is having trouble or asks us to slow down.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Policy is how many times to try again after the first attempt, and how
+// long to wait before the first retry, doubling after each one.
+type Policy struct {
+	Retries int
+	Backoff time.Duration
+}
+
+// worth trying again: upstream errors, and being told to slow down
+func retryable(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests
+}
+
+// Get sends a GET with the given headers. Responses that are still 5xx or
+// 429 after the last retry are returned as they are, for the caller to
+// report.
+func Get(ctx context.Context, client *http.Client, url string, header http.Header, p Policy) (*http.Response, error) {
+	wait := p.Backoff
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not initialize HTTP request: %w", err)
+		}
+
+		for name, values := range header {
+			req.Header[name] = values
+		}
+
+		res, err := client.Do(req)
+
+		last := attempt >= p.Retries
+		if err == nil && (!retryable(res.StatusCode) || last) {
+			return res, nil
+		}
+
+		if err != nil && (last || ctx.Err() != nil) {
+			return nil, fmt.Errorf("could not execute HTTP request: %w", err)
+		}
+
+		delay := wait
+		if err == nil {
+			if after := retryAfter(res.Header.Get("Retry-After")); after > delay {
+				delay = after
+			}
+
+			res.Body.Close()
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		wait *= 2
+	}
+}
+
+// either a number of seconds or an HTTP date
+func retryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(v); err == nil {
+		return time.Until(at)
+	}
+
+	return 0
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/packrat386/agwc/units"
+)
+
+type irrigationConfig struct {
+	// mm a day of evapotranspiration the normal watering schedule is set up
+	// for, 5 if unset, which is about a midsummer day in most of the US
+	BaselineET0 float64 `json:"baselineET0,omitempty"`
+
+	// mm of rain in the last or next day that skips watering outright, 6 if
+	// unset
+	SkipRain float64 `json:"skipRain,omitempty"`
+
+	// days of observed rain and forecast rain to count, 2 and 1 if unset
+	DaysBack  int `json:"daysBack,omitempty"`
+	DaysAhead int `json:"daysAhead,omitempty"`
+}
+
+// watering a lawn at or below this just ices it, in C
+const irrigationFreeze = 2
+
+// controllers scale their programs by a percent, and most cap it at 200
+const maxIrrigationPercent = 200
+
+func (c irrigationConfig) withDefaults() irrigationConfig {
+	if c.BaselineET0 == 0 {
+		c.BaselineET0 = 5
+	}
+
+	if c.SkipRain == 0 {
+		c.SkipRain = 6
+	}
+
+	if c.DaysBack == 0 {
+		c.DaysBack = 2
+	}
+
+	if c.DaysAhead == 0 {
+		c.DaysAhead = 1
+	}
+
+	return c
+}
+
+type irrigationDecision struct {
+	Location     string    `json:"location"`
+	Generated    time.Time `json:"generated"`
+	Decision     string    `json:"decision"`
+	Percent      int       `json:"percent"`
+	Reason       string    `json:"reason"`
+	RecentRain   float64   `json:"recentRainMm"`
+	ForecastRain float64   `json:"forecastRainMm"`
+	ET0          float64   `json:"et0Mm"`
+	DaysBack     int       `json:"daysBack"`
+	DaysAhead    int       `json:"daysAhead"`
+}
+
+// the FAO-56 extraterrestrial radiation, in MJ per square meter per day,
+// which is all the sunshine the Hargreaves equation knows about
+func extraterrestrialRadiation(latitude float64, day time.Time) float64 {
+	j := float64(day.YearDay())
+	phi := toRadians(latitude)
+
+	dr := 1 + 0.033*math.Cos(2*math.Pi*j/365)
+	delta := 0.409 * math.Sin(2*math.Pi*j/365-1.39)
+	omega := math.Acos(math.Max(-1, math.Min(1, -math.Tan(phi)*math.Tan(delta))))
+
+	return 24 * 60 / math.Pi * 0.0820 * dr * (omega*math.Sin(phi)*math.Sin(delta) + math.Cos(phi)*math.Cos(delta)*math.Sin(omega))
+}
+
+// reference evapotranspiration in mm for a day, from just its high and low,
+// which is less exact than Penman-Monteith but doesn't need radiation or
+// humidity measurements
+func hargreavesET0(latitude float64, day time.Time, high, low float64) float64 {
+	ra := 0.408 * extraterrestrialRadiation(latitude, day)
+	mean := (high + low) / 2
+
+	return math.Max(0, 0.0023*ra*(mean+17.8)*math.Sqrt(math.Max(0, high-low)))
+}
+
+// observations come more than hourly when the weather's interesting, each
+// with the rain since the top of the last hour, so the most any says for an
+// hour is what fell in it
+func observedRain(observations []observation) float64 {
+	hourly := map[time.Time]float64{}
+
+	for _, o := range observations {
+		p, ok := o.values["precipitationLastHour"]
+		if !ok || p.Value == nil {
+			continue
+		}
+
+		mm, err := units.Convert(*p.Value, units.MustParse(p.Unit), units.MustParse("wmoUnit:mm"))
+		if err != nil {
+			continue
+		}
+
+		hour := o.timestamp.Truncate(time.Hour)
+		hourly[hour] = math.Max(hourly[hour], mm)
+	}
+
+	total := 0.0
+	for _, mm := range hourly {
+		total += mm
+	}
+
+	return total
+}
+
+// the days are the 24 hour stretches back and ahead of now, so the answer
+// doesn't jump at midnight
+func decideIrrigation(cfg irrigationConfig, latitude float64, observations []observation, forecast gridForecast, now time.Time) irrigationDecision {
+	d := irrigationDecision{Generated: now.UTC(), DaysBack: cfg.DaysBack, DaysAhead: cfg.DaysAhead}
+
+	observedTemperatures := observationSeries(observations, []string{"temperature"})["temperature"]
+
+	for i := -cfg.DaysBack; i < cfg.DaysAhead; i++ {
+		start := now.Add(time.Duration(i) * 24 * time.Hour)
+
+		points := forecast.properties["temperature"]
+		if i < 0 {
+			points = observedTemperatures
+		}
+
+		temperatures := []*float64{}
+		for at := start; at.Before(start.Add(24 * time.Hour)); at = at.Add(time.Hour) {
+			if p, ok := findPointAt(points, at); ok {
+				temperatures = append(temperatures, p.Value)
+			}
+		}
+
+		r := rangeOf(temperatures)
+		if r.min != nil {
+			d.ET0 += hargreavesET0(latitude, start, *r.max, *r.min)
+		}
+	}
+
+	d.RecentRain = observedRain(observations)
+	d.ForecastRain, _ = totalOver(forecast.properties["quantitativePrecipitation"], now, now.Add(time.Duration(cfg.DaysAhead)*24*time.Hour))
+
+	lastDay := observedRain(observationsSince(observations, now.Add(-24*time.Hour)))
+	nextDay, _ := totalOver(forecast.properties["quantitativePrecipitation"], now, now.Add(24*time.Hour))
+
+	temperatures := []*float64{}
+	for at := now; at.Before(now.Add(24 * time.Hour)); at = at.Add(time.Hour) {
+		if p, ok := findPointAt(forecast.properties["temperature"], at); ok {
+			temperatures = append(temperatures, p.Value)
+		}
+	}
+
+	d.ET0 = math.Round(d.ET0*10) / 10
+	d.RecentRain = math.Round(d.RecentRain*10) / 10
+	d.ForecastRain = math.Round(d.ForecastRain*10) / 10
+
+	switch low := rangeOf(temperatures).min; {
+	case low != nil && *low <= irrigationFreeze:
+		d.Decision, d.Reason = "skip", fmt.Sprintf("freezing, down to %s C in the next day", formatNumber(*low, 0))
+	case lastDay >= cfg.SkipRain:
+		d.Decision, d.Reason = "skip", fmt.Sprintf("%s mm of rain in the last day", formatNumber(lastDay, 1))
+	case nextDay >= cfg.SkipRain:
+		d.Decision, d.Reason = "skip", fmt.Sprintf("%s mm of rain forecast in the next day", formatNumber(nextDay, 1))
+	default:
+		need := d.ET0 - d.RecentRain - d.ForecastRain
+		normal := cfg.BaselineET0 * float64(cfg.DaysBack+cfg.DaysAhead)
+
+		d.Percent = int(math.Round(math.Max(0, math.Min(maxIrrigationPercent, 100*need/normal))))
+		d.Decision, d.Reason = "run", fmt.Sprintf("%s mm evapotranspiration against %s mm of rain", formatNumber(d.ET0, 1), formatNumber(d.RecentRain+d.ForecastRain, 1))
+
+		if d.Percent == 0 {
+			d.Decision = "skip"
+		}
+	}
+
+	return d
+}
+
+func observationsSince(observations []observation, since time.Time) []observation {
+	recent := []observation{}
+
+	for _, o := range observations {
+		if !o.timestamp.Before(since) {
+			recent = append(recent, o)
+		}
+	}
+
+	return recent
+}
+
+func irrigationFor(name string, c coordinates, grid gridPoint, s station) (irrigationDecision, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return irrigationDecision{}, err
+	}
+
+	irrigation := cfg.Irrigation.withDefaults()
+
+	now := time.Now()
+
+	observations, err := getObservationHistory(s, now.Add(-time.Duration(irrigation.DaysBack)*24*time.Hour), now)
+	if err != nil {
+		return irrigationDecision{}, err
+	}
+
+	forecast, err := getWeatherData(grid.forecastGridDataURL, []string{"temperature", "quantitativePrecipitation"})
+	if err != nil {
+		return irrigationDecision{}, err
+	}
+
+	d := decideIrrigation(irrigation, c.latitude, observations, forecast, now)
+	d.Location = name
+
+	return d, nil
+}
+
+// the serve mode version, for controllers to poll, one location at a time
+// with ?location= or the only one there is
+func irrigationHandler(locations []skillLocation) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("location")
+		if name == "" && len(locations) == 1 {
+			name = locations[0].name
+		}
+
+		key, limited := requestKey(r)
+
+		for _, l := range locations {
+			if l.name != name || (limited && !key.allows(l.name)) {
+				continue
+			}
+
+			// the station's close enough to the address for the sun
+			d, err := irrigationFor(l.name, l.station.location, l.grid, l.station)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, d)
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("no location '%s'", name), http.StatusNotFound)
+	}
+}
+
+func runIrrigate(flagset *flag.FlagSet) func() {
+	var queryAddress, pinned string
+
+	flagset.StringVar(&queryAddress, "address", "", "address or named location being watered")
+	flagset.StringVar(&pinned, "station", "", "observation station to count rain at instead of the nearest one")
+
+	return func() {
+		grid, s, err := recordTarget(queryAddress, pinned)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		d, err := irrigationFor(queryAddress, coordinates, grid, s)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		err = enc.Encode(d)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not write irrigation decision: %w", err))
+		}
+	}
+}
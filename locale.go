@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaults for -unit-system and -displaytz, from the config if it says, or
+// else whatever the environment suggests
+var (
+	defaultUnitSystem = "metric"
+	defaultDisplayTZ  = "UTC"
+)
+
+// the few places that still measure in something other than metric
+var territoryUnitSystems = map[string]string{
+	"US": "us",
+	"LR": "us",
+	"MM": "us",
+	"GB": "uk",
+}
+
+// the locale measurements are in, by the usual precedence, where LC_ALL
+// overrides LC_MEASUREMENT overrides LANG
+func measurementLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MEASUREMENT", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// en_US.UTF-8 and en_US@euro and the like are all in the US
+func localeTerritory(locale string) string {
+	locale = strings.SplitN(locale, ".", 2)[0]
+	locale = strings.SplitN(locale, "@", 2)[0]
+
+	split := strings.SplitN(locale, "_", 2)
+	if len(split) != 2 {
+		return ""
+	}
+
+	return strings.ToUpper(split[1])
+}
+
+func configureLocale(cfg config) error {
+	switch cfg.Units {
+	case "freedom":
+		defaultUnitSystem = "us"
+	case "":
+		if system, ok := territoryUnitSystems[localeTerritory(measurementLocale())]; ok {
+			defaultUnitSystem = system
+		}
+	default:
+		if _, ok := unitSystems[cfg.Units]; !ok {
+			return fmt.Errorf("units '%s' is not freedom or one of %v", cfg.Units, unitSystemNames())
+		}
+
+		defaultUnitSystem = cfg.Units
+	}
+
+	if cfg.TimeZone != "" {
+		_, err := time.LoadLocation(cfg.TimeZone)
+		if err != nil {
+			return fmt.Errorf("could not load time zone: %w", err)
+		}
+
+		defaultDisplayTZ = cfg.TimeZone
+
+		return nil
+	}
+
+	// TZ can have a leading colon, and isn't worth failing over if it's
+	// something we don't know
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" {
+		if _, err := time.LoadLocation(tz); err == nil {
+			defaultDisplayTZ = tz
+		}
+	}
+
+	return nil
+}
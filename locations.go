@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a place by a short name, like "home", either an address or coordinates,
+// along with how it's usually looked at
+type locationConfig struct {
+	Address   string   `json:"address,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+
+	// defaults for -properties, -displaytz and -unit-system when the
+	// location is picked with -location
+	Properties string `json:"properties,omitempty"`
+	TimeZone   string `json:"timeZone,omitempty"`
+	Units      string `json:"units,omitempty"`
+}
+
+// locations used to be just addresses, and a location that's only an
+// address is still written that way
+func (l *locationConfig) UnmarshalJSON(data []byte) error {
+	var address string
+	if json.Unmarshal(data, &address) == nil {
+		*l = locationConfig{Address: address}
+		return nil
+	}
+
+	type plain locationConfig
+
+	return json.Unmarshal(data, (*plain)(l))
+}
+
+func (l locationConfig) MarshalJSON() ([]byte, error) {
+	if l == (locationConfig{Address: l.Address}) {
+		return json.Marshal(l.Address)
+	}
+
+	type plain locationConfig
+
+	return json.Marshal(plain(l))
+}
+
+// what to look up for the location, coordinates if it has them since
+// they don't need geocoding
+func (l locationConfig) place() string {
+	if l.Latitude != nil && l.Longitude != nil {
+		return coordinates{latitude: *l.Latitude, longitude: *l.Longitude}.String()
+	}
+
+	return l.Address
+}
+
+func (l locationConfig) validate() error {
+	if (l.Latitude == nil) != (l.Longitude == nil) {
+		return fmt.Errorf("needs both latitude and longitude, or neither")
+	}
+
+	if l.place() == "" {
+		return fmt.Errorf("needs an address or coordinates")
+	}
+
+	if l.Latitude != nil {
+		_, err := validCoordinates(*l.Latitude, *l.Longitude)
+		if err != nil {
+			return err
+		}
+	}
+
+	if l.TimeZone != "" {
+		_, err := time.LoadLocation(l.TimeZone)
+		if err != nil {
+			return fmt.Errorf("could not load time zone: %w", err)
+		}
+	}
+
+	if l.Units != "" && l.Units != "freedom" {
+		if _, ok := unitSystems[l.Units]; !ok {
+			return fmt.Errorf("units '%s' is not freedom or one of %v", l.Units, unitSystemNames())
+		}
+	}
+
+	return nil
+}
+
+// the "locations" from the config, set by configureLocations
+var namedLocations = map[string]locationConfig{}
+
+func configureLocations(cfg config) error {
+	for name, l := range cfg.Locations {
+		err := l.validate()
+		if err != nil {
+			return fmt.Errorf("location '%s' %w", name, err)
+		}
+	}
+
+	namedLocations = cfg.Locations
+
+	return nil
+}
+
+func locationNames() []string {
+	names := []string{}
+	for name := range namedLocations {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// the US and its territories, roughly, since NWS forecasts nowhere else
+var nwsCoverage = []struct {
+	name                     string
+	south, north, west, east float64
+}{
+	{"the lower 48", 24, 50, -125.5, -66.5},
+	{"alaska", 51, 72, -180, -129},
+	{"the aleutians", 51, 55, 172, 180},
+	{"hawaii", 18.5, 22.5, -161, -154.5},
+	{"puerto rico and the virgin islands", 17.5, 18.7, -68, -64.5},
+	{"guam and the northern marianas", 13, 21, 144, 146.5},
+	{"american samoa", -14.6, -11, -171.2, -168},
+}
+
+func nwsCovers(latitude, longitude float64) bool {
+	for _, r := range nwsCoverage {
+		if latitude >= r.south && latitude <= r.north && longitude >= r.west && longitude <= r.east {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checks the bounds, so a mistake is caught here rather than as whatever
+// /points makes of it, and rounds to the four places /points takes, which
+// is about 10 meters.
+func validCoordinates(latitude, longitude float64) (coordinates, error) {
+	if math.IsNaN(latitude) || math.IsInf(latitude, 0) || latitude < -90 || latitude > 90 {
+		return coordinates{}, fmt.Errorf("latitude must be between -90 and 90, got %g", latitude)
+	}
+
+	if math.IsNaN(longitude) || math.IsInf(longitude, 0) || longitude < -180 || longitude > 180 {
+		return coordinates{}, fmt.Errorf("longitude must be between -180 and 180, got %g", longitude)
+	}
+
+	c := coordinates{
+		latitude:  math.Round(latitude*10000) / 10000,
+		longitude: math.Round(longitude*10000) / 10000,
+	}
+
+	return c, nil
+}
+
+// "41.8781,-87.6298" is already where it is, so there's nothing to geocode.
+// two numbers that aren't a place on earth are an error rather than an
+// address. somewhere NWS doesn't forecast is only warned about, since the
+// coverage here is rough, and only now that it's being looked up, so a
+// named location nobody is using doesn't warn on every run.
+func parseCoordinates(s string) (coordinates, bool, error) {
+	lat, lon, ok := strings.Cut(s, ",")
+	if !ok {
+		return coordinates{}, false, nil
+	}
+
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	if err != nil {
+		return coordinates{}, false, nil
+	}
+
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(lon), 64)
+	if err != nil {
+		return coordinates{}, false, nil
+	}
+
+	c, err := validCoordinates(latitude, longitude)
+	if err != nil {
+		return coordinates{}, true, err
+	}
+
+	if !nwsCovers(c.latitude, c.longitude) {
+		fmt.Fprintf(os.Stderr, "warning: %s looks to be outside the US and its territories, where NWS forecasts, is it latitude,longitude?\n", c)
+	}
+
+	return c, true, nil
+}
@@ -1,303 +1,1500 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
-	"net/url"
 	"os"
-	"regexp"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/packrat386/agwc/geocode"
+	"github.com/packrat386/agwc/nws"
+	"github.com/packrat386/agwc/units"
+)
+
+type propertyKind int
+
+const (
+	kindGeneric propertyKind = iota
+	kindTemperature
+	kindPrecipitation
+	kindProbability
+	kindPercentage
+	kindSpeed
+	kindDirection
+	kindPressure
+	kindDistance
+	kindHeight
 )
 
-var permittedProperties = []string{
-	"dewpoint",
-	"heatIndex",
-	"maxTemperature",
-	"minTemperature",
-	"pressure",
-	"probabilityOfPrecipitation",
-	"probabilityOfThunder",
-	"quantitativePrecipitation",
-	"relativeHumidity",
-	"skyCover",
-	"temperature",
-	"windChill",
-	"windDirection",
-	"windSpeed",
+var propertyRegistry = map[string]propertyKind{
+	"dewpoint":                   kindTemperature,
+	"heatIndex":                  kindTemperature,
+	"maxTemperature":             kindTemperature,
+	"minTemperature":             kindTemperature,
+	"pressure":                   kindPressure,
+	"probabilityOfPrecipitation": kindProbability,
+	"probabilityOfThunder":       kindProbability,
+	"quantitativePrecipitation":  kindPrecipitation,
+	"relativeHumidity":           kindPercentage,
+	"skyCover":                   kindPercentage,
+	"snowfallAmount":             kindPrecipitation,
+	"temperature":                kindTemperature,
+	"visibility":                 kindDistance,
+	"waveDirection":              kindDirection,
+	"waveHeight":                 kindHeight,
+	"wavePeriod":                 kindGeneric,
+	"windChill":                  kindTemperature,
+	"windDirection":              kindDirection,
+	"windGust":                   kindSpeed,
+	"windSpeed":                  kindSpeed,
+}
+
+// how many decimal places to show for each kind of property
+var kindPrecision = map[propertyKind]int{
+	kindGeneric:       2,
+	kindTemperature:   0,
+	kindPrecipitation: 2,
+	kindProbability:   0,
+	kindPercentage:    0,
+	kindSpeed:         0,
+	kindDirection:     0,
+	kindPressure:      0,
+	kindDistance:      0,
+	kindHeight:        1,
+}
+
+func permittedProperties() []string {
+	names := make([]string, 0, len(propertyRegistry))
+	for name := range propertyRegistry {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func main() {
+	configureConsole()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	err = configureHTTPClient(cfg)
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	err = checkSecretReferences(cfg)
+	if err != nil {
+		errorAndQuit(fmt.Errorf("invalid notify rules in config: %w", err))
+	}
+
+	err = configureCache(cfg)
+	if err != nil {
+		errorAndQuit(fmt.Errorf("invalid cache config: %w", err))
+	}
+
+	if cfg.Style != "" {
+		err = setTableStyle(cfg.Style)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("invalid style in config: %w", err))
+		}
+	}
+
+	configureGeocoder(cfg)
+
+	err = configureLocale(cfg)
+	if err != nil {
+		errorAndQuit(fmt.Errorf("invalid locale in config: %w", err))
+	}
+
+	err = configureLocations(cfg)
+	if err != nil {
+		errorAndQuit(fmt.Errorf("invalid locations in config: %w", err))
+	}
+
+	err = configurePlugins(cfg)
+	if err != nil {
+		errorAndQuit(fmt.Errorf("invalid plugins in config: %w", err))
+	}
+
+	err = configureSeasons(cfg)
+	if err != nil {
+		errorAndQuit(fmt.Errorf("invalid seasons in config: %w", err))
+	}
+
+	configureHooks(cfg)
+
+	c, args := resolveCommand(commandTree(), os.Args)
+	runCommand(c, args)
+}
+
+// what agwc does without a subcommand
+func runRoot(flagset *flag.FlagSet) func() {
+	request := forecastFlags(flagset)
+
+	return func() {
+		req, err := request()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		showForecast(req)
+	}
+}
+
+// the forecast for args, as if they were given to agwc itself
+func runForecast(args []string) {
+	req, err := getForecastRequest(args)
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	showForecast(req)
+}
+
+func showForecast(req forecastRequest) {
+	strictDecode = req.strictDecode
+
+	if req.outputPath != "" || req.copy {
+		finish, err := redirectStdout(req.outputPath, req.copy)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		defer func() {
+			err := finish()
+			if err != nil {
+				errorAndQuit(err)
+			}
+		}()
+	}
+
+	if len(req.addresses) > 1 {
+		runMultiForecast(req)
+		return
+	}
+
+	var providers []string
+	if req.consensus {
+		var err error
+		providers, err = consensusProviders()
+		if err != nil {
+			errorAndQuit(err)
+		}
+	}
+
+	coordinates, err := getAddressCoordinates(req.address)
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	// json and csv go to stdout on their own, so scripts can parse them, and
+	// the other formats are their own layouts
+	table := req.format == "table"
+
+	if table {
+		fmt.Println("lat: ", coordinates.latitude)
+		fmt.Println("long: ", coordinates.longitude)
+	}
+
+	req, err = req.resolveWindow(coordinates)
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	grid, err := getGridPoint(coordinates)
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	if table {
+		fmt.Println("forecastGridDataURL: ", grid.forecastGridDataURL)
+	}
+
+	if req.mode == "periods" {
+		fmt.Println()
+		displayPeriods(req, grid)
+		return
+	}
+
+	if req.watch {
+		watchForecast(req, grid)
+		return
+	}
+
+	err = runHook("preFetch", hooks.PreFetch, req.hookPayload(coordinates))
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	forecast, err := getWeatherData(grid.forecastGridDataURL, req.fetchProperties())
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	if banner := cacheBanner(forecast, req.displayTimeZone); banner != "" {
+		if table {
+			fmt.Println(banner)
+		} else {
+			fmt.Fprintln(os.Stderr, banner)
+		}
+	}
+
+	if table && len(forecast.cell) > 0 {
+		fmt.Println("gridCell: ", formatCell(forecast.cell))
+		fmt.Println("gridCellMap: ", openStreetMapLink(cellCenter(forecast.cell)))
+		fmt.Println("gridCellGoogleMap: ", googleMapsLink(cellCenter(forecast.cell)))
+	}
+
+	if table && req.verbose {
+		fmt.Println()
+		displayCoverage(req, forecast)
+	}
+
+	req.issuedAt = forecast.updateTime
+
+	rows := buildRows(req, forecast.properties)
+
+	missing := notices{}
+
+	if req.delta > 0 {
+		err = applyDeltas(req, grid, coordinates, forecast, rows)
+		if err != nil {
+			printNotice(table, missing.add(req, "-delta changes", err))
+		}
+	}
+
+	if req.past > 0 {
+		observed, err := getObservedRows(req, grid, coordinates)
+		if err != nil {
+			printNotice(table, missing.add(req, "-past observations", err))
+		}
+
+		rows = append(observed, rows...)
+	}
+
+	if req.sortProperty != "" {
+		sortRows(rows, indexOf(req.columns(), req.sortProperty), req.sortDescending)
+	}
+
+	var (
+		alerts        []alert
+		hwoLines      []string
+		recordStation station
+		recordLines   []string
+		consensus     []map[string]series
+		neighborCells []gridForecast
+		radiusCells   []gridForecast
+	)
+
+	sections := []section{}
+	if req.alerts {
+		sections = append(sections, section{name: "alerts", fetch: func(ctx context.Context) (func(), error) {
+			a, err := forecastAlerts(ctx, req, coordinates)
+			return func() { alerts = a }, err
+		}})
+	}
+
+	// the rest only show up under the table
+	if table && req.hwo {
+		sections = append(sections, section{name: "the hazardous weather outlook", fetch: func(context.Context) (func(), error) {
+			lines, err := hazardousWeatherOutlook(grid, req.displayTimeZone)
+			return func() { hwoLines = lines }, err
+		}})
+	}
+
+	if table && req.records {
+		sections = append(sections, section{name: "records", fetch: func(context.Context) (func(), error) {
+			s, notes, err := getRecords(req, grid, coordinates, forecast)
+			return func() { recordStation, recordLines = s, notes }, err
+		}})
+	}
+
+	if table && req.consensus {
+		sections = append(sections, section{name: "the consensus", fetch: func(context.Context) (func(), error) {
+			f, err := getConsensusForecasts(req, coordinates, providers)
+			return func() { consensus = f }, err
+		}})
+	}
+
+	if table && req.neighbors {
+		sections = append(sections, section{name: "neighboring cells", fetch: func(ctx context.Context) (func(), error) {
+			cells, err := getNeighborWeatherData(ctx, grid, req.properties)
+			return func() { neighborCells = cells }, err
+		}})
+	}
+
+	if table && req.radiusKm > 0 {
+		sections = append(sections, section{name: "the radius", fetch: func(ctx context.Context) (func(), error) {
+			cells, err := getRadiusWeatherData(ctx, grid, coordinates, req.radiusKm, req.properties)
+			return func() { radiusCells = cells }, err
+		}})
+	}
+
+	// notices for the table wait for where the section would have been
+	sectionNotices := map[string]string{}
+	for _, c := range composeSections(sections, req.sectionTimeout) {
+		if c.err != nil {
+			sectionNotices[c.name] = missing.add(req, c.name, c.err)
+			if !table {
+				printNotice(table, sectionNotices[c.name])
+			}
+
+			if !partial(c.err) {
+				continue
+			}
+		}
+
+		c.finish()
+	}
+
+	payload := req.hookPayload(coordinates)
+	if hooks.PostFetch != "" || hooks.PreRender != "" || hooks.PostRender != "" {
+		doc := newForecastDocument(req, coordinates, grid, forecast, rows)
+		doc.Notices = missing
+		payload.Forecast = &doc
+	}
+
+	runHookOrWarn("postFetch", hooks.PostFetch, payload)
+
+	err = runHook("preRender", hooks.PreRender, payload)
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	defer runHookOrWarn("postRender", hooks.PostRender, payload)
+
+	if !table {
+		switch req.format {
+		case "heatmap":
+			displayHeatmap(os.Stdout, req, rows)
+		case "chart":
+			displayCharts(os.Stdout, req, rows)
+		case "env":
+			writeForecastEnv(os.Stdout, req, forecast, rows)
+		case "week":
+			displayWeek(os.Stdout, req, forecast)
+		case "windrose":
+			displayWindRose(os.Stdout, req, forecast)
+		case "strip":
+			displayStrip(os.Stdout, req, forecast)
+		case "rss":
+			err = writeForecastRSS(os.Stdout, newForecastFeed(req, coordinates, forecast))
+			if err != nil {
+				errorAndQuit(err)
+			}
+		case "eink", "eink-png":
+			err = displayEink(os.Stdout, req, forecast)
+			if err != nil {
+				errorAndQuit(err)
+			}
+		case "waybar":
+			err = writeForecastWaybar(os.Stdout, req, forecast, rows)
+			if err != nil {
+				errorAndQuit(err)
+			}
+		case "alfred":
+			err = writeForecastAlfred(os.Stdout, req, coordinates, forecast, rows)
+			if err != nil {
+				errorAndQuit(err)
+			}
+		case "shortcuts":
+			err = writeForecastShortcuts(os.Stdout, req, forecast, rows)
+			if err != nil {
+				errorAndQuit(err)
+			}
+		case "template", "conky", "xmobar":
+			err = writeForecastTemplate(os.Stdout, req, forecast, rows)
+			if err != nil {
+				errorAndQuit(err)
+			}
+		case "csv":
+			err = writeForecastCSV(os.Stdout, newForecastDocument(req, coordinates, grid, forecast, rows))
+			if err != nil {
+				errorAndQuit(err)
+			}
+		default:
+			doc := newForecastDocument(req, coordinates, grid, forecast, rows)
+			if req.alerts {
+				doc.Alerts = alertDocuments(alerts)
+			}
+
+			doc.Notices = missing
+
+			// output plugins get the same document json would print
+			if p, ok := outputPlugins[req.format]; ok {
+				err = runPlugin(p, doc, os.Stdout)
+			} else {
+				err = writeForecastDocument(os.Stdout, doc)
+			}
+
+			if err != nil {
+				errorAndQuit(err)
+			}
+		}
+
+		if req.reportPath != "" {
+			err = writeReport(req.reportPath, newRunReport(req, coordinates, grid, forecast, rows))
+			if err != nil {
+				errorAndQuit(err)
+			}
+		}
+
+		return
+	}
+
+	display(req, rows)
+
+	fmt.Println()
+	fmt.Println(precipitationSummary(forecast.properties["probabilityOfPrecipitation"], time.Now(), req.displayTimeZone))
+
+	if req.strip {
+		fmt.Println()
+		displayStrip(os.Stdout, req, forecast)
+	}
+
+	if req.hwo {
+		if n, ok := sectionNotices["the hazardous weather outlook"]; ok {
+			fmt.Println()
+			printNotice(table, n)
+		}
+
+		if len(hwoLines) > 0 {
+			fmt.Println()
+			for _, line := range hwoLines {
+				fmt.Println(line)
+			}
+		}
+	}
+
+	if req.alerts {
+		fmt.Println()
+		if n, ok := sectionNotices["alerts"]; ok {
+			printNotice(table, n)
+		} else {
+			displayAlertHeadlines(os.Stdout, alerts, req.displayTimeZone)
+		}
+	}
+
+	if req.plantingDate != "" {
+		err = rememberPlantingDate(req.plantingDate)
+		if err != nil {
+			errorAndQuit(err)
+		}
+	}
+
+	if req.profile != "" {
+		fmt.Println()
+		for _, line := range profiles[req.profile].summary(req, grid, coordinates, forecast) {
+			fmt.Println(line)
+		}
+	}
+
+	if req.records {
+		if n, ok := sectionNotices["records"]; ok {
+			fmt.Println()
+			printNotice(table, n)
+		} else {
+			displayRecords(recordStation, recordLines)
+		}
+	}
+
+	if req.consensus {
+		if n, ok := sectionNotices["the consensus"]; ok {
+			fmt.Println()
+			printNotice(table, n)
+		} else {
+			displayConsensus(req, providers, consensus)
+		}
+	}
+
+	if req.neighbors {
+		if n, ok := sectionNotices["neighboring cells"]; ok {
+			fmt.Println()
+			printNotice(table, n)
+		}
+
+		if neighborCells != nil {
+			displayNeighborSpread(req, append([]gridForecast{forecast}, neighborCells...), rows)
+		}
+	}
+
+	if req.radiusKm > 0 {
+		if n, ok := sectionNotices["the radius"]; ok {
+			fmt.Println()
+			printNotice(table, n)
+		}
+
+		if radiusCells != nil {
+			displayRadiusSpread(req, append([]gridForecast{forecast}, radiusCells...), rows)
+		}
+	}
+
+	if req.attribution {
+		fmt.Println()
+		displayAttribution(os.Stdout, req, grid, providers)
+	}
+
+	if req.qr {
+		link := req.qrURL
+		if link == "" {
+			link = forecastPageURL(coordinates)
+		}
+
+		fmt.Println()
+		fmt.Println(link)
+
+		err = writeQR(os.Stdout, link)
+		if err != nil {
+			errorAndQuit(err)
+		}
+	}
+
+	if req.reportPath != "" {
+		err = writeReport(req.reportPath, newRunReport(req, coordinates, grid, forecast, rows))
+		if err != nil {
+			errorAndQuit(err)
+		}
+	}
+}
+
+type forecastRequest struct {
+	address           string
+	properties        []string
+	start             time.Time
+	end               time.Time
+	displayTimeZone   *time.Location
+	freedom           bool
+	system            *unitSystem
+	lang              string
+	highlightExtremes bool
+	sortProperty      string
+	sortDescending    bool
+	hidden            []string
+	derived           derivedColumns
+	reportPath        string
+	neighbors         bool
+	radius            string
+	radiusKm          float64
+	past              time.Duration
+	station           string
+	records           bool
+	startExpr         string
+	endExpr           string
+	length            time.Duration
+	profile           string
+	plantingDate      string
+	strictDecode      bool
+	verbose           bool
+	format            string
+	delta             time.Duration
+	deltaProperties   []string
+	consensus         bool
+	hwo               bool
+	outputPath        string
+	copy              bool
+	qr                bool
+	qrURL             string
+	alerts            bool
+	alertSeverity     *levelFilter
+	issuedAt          time.Time
+	lowConfidence     time.Duration
+	watch             bool
+	watchInterval     time.Duration
+	watchDiff         bool
+	mode              string
+	chartHeight       int
+	addresses         []string
+	layout            string
+	parallel          int
+	precipType        bool
+	strip             bool
+	einkDisplay       string
+	template          string
+	align             string
+	attribution       bool
+	strict            bool
+	sectionTimeout    time.Duration
+}
+
+// -start and -end can depend on where we are, so they have to wait until
+// we've found the coordinates
+func (req forecastRequest) resolveWindow(c coordinates) (forecastRequest, error) {
+	now := time.Now()
+
+	if req.startExpr != "" {
+		start, err := resolveTimeExpression(req.startExpr, now, c, req.displayTimeZone)
+		if err != nil {
+			return forecastRequest{}, err
+		}
+
+		req.start = start
+		req.end = start.Add(req.length)
+	}
+
+	if req.endExpr != "" {
+		end, err := resolveTimeExpression(req.endExpr, now, c, req.displayTimeZone)
+		if err != nil {
+			return forecastRequest{}, err
+		}
+
+		req.end = end
+	}
+
+	if localHour(req.start, req.displayTimeZone).After(localHour(req.end, req.displayTimeZone)) {
+		return forecastRequest{}, fmt.Errorf("window starts at %s which is after it ends at %s", req.start.In(req.displayTimeZone).Format(time.Stamp), req.end.In(req.displayTimeZone).Format(time.Stamp))
+	}
+
+	return req, nil
+}
+
+// properties we need from upstream, which can include some that other
+// features depend on even if they weren't asked for
+func (req forecastRequest) fetchProperties() []string {
+	properties := append([]string{}, req.properties...)
+
+	if req.records && indexOf(properties, "temperature") < 0 {
+		properties = append(properties, "temperature")
+	}
+
+	if req.profile != "" {
+		for _, p := range profiles[req.profile].properties {
+			if indexOf(properties, p) < 0 {
+				properties = append(properties, p)
+			}
+		}
+	}
+
+	if req.precipType {
+		for _, p := range precipTypeProperties {
+			if indexOf(properties, p) < 0 {
+				properties = append(properties, p)
+			}
+		}
+	}
+
+	// env, the strip, rss, waybar, alfred, shortcuts, templates and the e-ink
+	// layouts give the condition too, which takes the same properties
+	if req.format == "week" || req.format == "env" || req.format == "strip" || req.format == "rss" || req.format == "eink" || req.format == "eink-png" || req.format == "waybar" || req.format == "alfred" || req.format == "shortcuts" || templatePresets[req.format].escape != nil || req.strip {
+		for _, p := range weekProperties {
+			if indexOf(properties, p) < 0 {
+				properties = append(properties, p)
+			}
+		}
+	}
+
+	if req.format == "windrose" {
+		for _, p := range windroseProperties {
+			if indexOf(properties, p) < 0 {
+				properties = append(properties, p)
+			}
+		}
+	}
+
+	if indexOf(properties, "probabilityOfPrecipitation") < 0 {
+		properties = append(properties, "probabilityOfPrecipitation")
+	}
+
+	return properties
+}
+
+// every column in the table, including ones that might be hidden
+func (req forecastRequest) columns() []string {
+	columns := append([]string{}, req.properties...)
+	for _, d := range req.derived {
+		columns = append(columns, d.name)
+	}
+
+	return columns
+}
+
+func getForecastRequest(args []string) (forecastRequest, error) {
+	flagset := flag.NewFlagSet(args[0], flag.ExitOnError)
+	request := forecastFlags(flagset)
+
+	parseFlags(flagset, args[1:])
+
+	return request()
+}
+
+// defines the forecast's flags, returning what makes a request of them once
+// they're parsed
+func forecastFlags(flagset *flag.FlagSet) func() (forecastRequest, error) {
+	var (
+		addresses    stringList
+		addressFile  string
+		location     string
+		latitude     float64
+		longitude    float64
+		layout       string
+		parallel     int
+		properties   string
+		hours        int
+		offset       int
+		displaytz    string
+		freedom      bool
+		highlight    bool
+		sortBy       string
+		hide         string
+		derived      derivedColumns
+		report       string
+		neighbors    bool
+		radius       string
+		past         time.Duration
+		pinned       string
+		records      bool
+		startExpr    string
+		endExpr      string
+		profileName  string
+		plantingDate string
+		strict       bool
+		verbose      bool
+		format       string
+		delta        time.Duration
+		deltaProps   string
+		consensus    bool
+		hwo          bool
+		outputPath   string
+		copyOutput   bool
+		showQR       bool
+		qrURL        string
+		showAlerts   bool
+		alertLevel   string
+		lowConf      time.Duration
+		noCache      bool
+		maxStale     time.Duration
+		chart        bool
+		chartHeight  int
+		watch        bool
+		watchEvery   time.Duration
+		watchDiff    bool
+		mode         string
+		precipType   bool
+		strip        bool
+		einkDisplay  string
+		templateText string
+		align        string
+		attribution  bool
+		failHard     bool
+		sectionWait  time.Duration
+	)
+
+	flagset.Var(&addresses, "address", "address at which to see the weather, may be repeated to compare several")
+	flagset.StringVar(&location, "location", "", "named location from the config to see the weather at, with its default properties, time zone and units")
+	flagset.Float64Var(&latitude, "lat", 0, "latitude to see the weather at instead of an address, with -lon")
+	flagset.Float64Var(&longitude, "lon", 0, "longitude to see the weather at instead of an address, with -lat")
+	flagset.StringVar(&addressFile, "address-file", "", "also see the weather at each address in this file, one per line")
+	flagset.StringVar(&layout, "layout", "", fmt.Sprintf("how to show several addresses, one of %v, by default compare for a single property and stack otherwise", multiLayouts))
+	flagset.IntVar(&parallel, "parallel", 4, "how many addresses to look up at once")
+	flagset.StringVar(&properties, "properties", "temperature", "weather properties to display in a comma separated string")
+	flagset.IntVar(&hours, "hours", 12, "number of hours of predictions to show")
+	flagset.IntVar(&offset, "offset", 0, "start predictions this many hours from now")
+	flagset.StringVar(&startExpr, "start", "", "start predictions at this time instead, e.g. '+3h', 'sunrise', 'sunset-1h', or RFC3339")
+	flagset.StringVar(&endExpr, "end", "", "end predictions at this time instead of after -hours, same format as -start")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display predictions")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+	flagset.BoolVar(&highlight, "highlight-extremes", false, "highlight the highest and lowest value of each property")
+	flagset.StringVar(&sortBy, "sort", "", "sort rows by a property instead of time, as property[:asc|desc]")
+	flagset.StringVar(&hide, "hide", "", "requested properties to fetch but not display in a comma separated string")
+	flagset.StringVar(&report, "report", "", "write a JSON record of the run, its inputs and its outputs to this file")
+	flagset.BoolVar(&neighbors, "neighbors", false, "also show the spread of values across the surrounding grid cells")
+	flagset.StringVar(&radius, "radius", "", "also show the range of values across a ring of points this far around the location, like 25mi or 40km")
+	flagset.DurationVar(&past, "past", 0, "also show this much observed history from the nearest station before the forecast")
+	flagset.StringVar(&pinned, "station", "", "observation station to use with -past instead of the nearest one")
+	flagset.BoolVar(&records, "records", false, "note days where the forecast comes near or beats the nearest station's records")
+	flagset.Var(&derived, "derive", fmt.Sprintf("computed column as name[unit]=expression over requested properties, name[unit]=plugin:<metric plugin>, or name=comfort:<index> for one of %v, may be repeated", comfortIndexNames()))
+	flagset.StringVar(&profileName, "profile", "", fmt.Sprintf("bundle of properties and a summary for a use case, one of %v", profileNames()))
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+	flagset.BoolVar(&verbose, "verbose", false, "also show how far each property's data extends and where it has gaps")
+	flagset.BoolVar(&failHard, "strict", false, "fail if alerts, observations, records or any other part of the output can't be fetched, instead of leaving it out with a notice")
+	flagset.DurationVar(&sectionWait, "section-timeout", 20*time.Second, "how long to wait for alerts, records and the other parts of the output fetched alongside the forecast before leaving them out")
+	flagset.BoolVar(&strict, "strict-decode", false, "fail on unexpected or missing fields in upstream responses instead of warning")
+	flagset.DurationVar(&delta, "delta", 0, "also show how much each hour changed from this long before, e.g. 24h for the same hour yesterday")
+	flagset.StringVar(&deltaProps, "delta-properties", "temperature", "requested properties to show -delta for in a comma separated string")
+	flagset.BoolVar(&consensus, "consensus", false, "also compare each hour across the forecast providers in the config")
+	flagset.BoolVar(&showAlerts, "alerts", false, "below the forecast, list active alerts for the address with their headlines, and include them with -format json")
+	flagset.StringVar(&alertLevel, "alert-severity", "", fmt.Sprintf("only list -alerts of this severity, or worse with a trailing '+', from %v", severities))
+	flagset.DurationVar(&lowConf, "low-confidence-after", 72*time.Hour, "mark hours this far past when the forecast was issued as low confidence, or 0 not to")
+	flagset.DurationVar(&maxStale, "max-stale", 0, "the oldest a cached forecast can be and still be shown, even when upstream can't be reached (default 24h or the config's)")
+	flagset.BoolVar(&noCache, "no-cache", false, "look the address and grid up again and download the forecast even if a recent copy is cached")
+	flagset.BoolVar(&watch, "watch", false, "keep checking for a new forecast and draw the table again from it when one is issued")
+	flagset.DurationVar(&watchEvery, "interval", 5*time.Minute, "how often to check with -watch")
+	flagset.BoolVar(&watchDiff, "diff", false, "with -watch, list which displayed hours changed in each new forecast")
+	flagset.BoolVar(&precipType, "precip-type", false, "also show whether precipitation will be rain, snow, sleet or freezing rain, judged from temperature, humidity and the precipitation, snow and ice amounts")
+	flagset.BoolVar(&strip, "strip", false, "below the forecast, add a line for each day with a glyph for each hour's sky and precipitation, like -format strip on its own")
+	flagset.BoolVar(&hwo, "hwo", false, "below the forecast, summarize the hazardous weather outlook when it calls for active weather")
+	flagset.StringVar(&mode, "mode", "grid", fmt.Sprintf("what to show, one of %v, where grid is each hour's -properties and periods is the forecast NWS writes for each day and night", forecastModes))
+	flagset.StringVar(&format, "format", "table", fmt.Sprintf("how to print the forecast, one of %v, where json follows the documented schema package, csv has a line per hour and property, heatmap shades the first property by day and hour, week lays out the next seven days, chart plots each property over the window, windrose counts the hours of wind by direction and speed, strip is a line of sky and precipitation glyphs for each day, rss is a feed with an item for each day, eink and eink-png are a large type monochrome layout for an -eink-display, waybar is the JSON Waybar and i3blocks modules read, alfred is script filter JSON for Alfred and Raycast, shortcuts is flat JSON of display strings for iOS Shortcuts, template fills in -template, conky and xmobar are templates for those bars, and env prints the coming hour as shell variables like AGWC_TEMPERATURE", outputFormats))
+	flagset.StringVar(&einkDisplay, "eink-display", "waveshare-7.5", fmt.Sprintf("panel to lay -format eink and eink-png out for, one of %v", einkDisplayNames()))
+	flagset.StringVar(&align, "align", "instant", fmt.Sprintf("how to match hours to values that don't line up with them, one of %v, where instant takes the value in effect at the top of the hour, overlap the one covering most of the hour, and nearest the closest one within an hour", alignPolicies))
+	flagset.BoolVar(&attribution, "attribution", false, "also list where each column comes from and how it's calculated, for sharing the table")
+	flagset.StringVar(&templateText, "template", "", "Go text/template for -format template, or to use instead of the conky or xmobar preset, over .Time, .Condition, .Glyph, .Issued, .Columns, .Primary, .Values and .Hours")
+	flagset.BoolVar(&chart, "chart", false, "same as -format chart")
+	flagset.IntVar(&chartHeight, "chart-height", 6, "how many lines tall each -chart is, where 1 is a sparkline")
+	flagset.StringVar(&format, "output", "table", "same as -format")
+	flagset.StringVar(&outputPath, "o", "", "write the output to this file instead of stdout, in any format")
+	flagset.BoolVar(&copyOutput, "copy", false, "also put the output on the clipboard, without any color")
+	flagset.BoolVar(&showQR, "qr", false, "below the forecast, show a QR code linking to the official forecast for the point")
+	flagset.StringVar(&qrURL, "qr-url", "", "link the -qr code here instead, like to where 'agwc serve' is running")
+	flagset.StringVar(&plantingDate, "planting-date", "", "remember this YYYY-MM-DD planting date for growing degree days with -profile agri")
+
+	return func() (forecastRequest, error) {
+		if noCache {
+			useCache = false
+		}
+
+		if chart {
+			format = "chart"
+		}
+
+		if maxStale < 0 {
+			return forecastRequest{}, fmt.Errorf("max stale can't be negative, got %s", maxStale)
+		}
+
+		if maxStale > 0 {
+			forecastMaxStale = maxStale
+		}
+
+		explicit := map[string]bool{}
+		flagset.Visit(func(f *flag.Flag) {
+			explicit[f.Name] = true
+		})
+
+		if explicit["lat"] != explicit["lon"] {
+			return forecastRequest{}, fmt.Errorf("-lat and -lon go together")
+		}
+
+		if explicit["lat"] {
+			c, err := validCoordinates(latitude, longitude)
+			if err != nil {
+				return forecastRequest{}, err
+			}
+
+			addresses = append(stringList{c.String()}, addresses...)
+		}
+
+		// the season's bundle stands in for the default, and a location's or a
+		// profile's for that
+		if s, ok := currentSeason(time.Now()); ok && !explicit["properties"] {
+			properties = s.properties
+		}
+
+		if location != "" {
+			l, ok := namedLocations[location]
+			if !ok {
+				return forecastRequest{}, fmt.Errorf("location '%s' is not in %v", location, locationNames())
+			}
+
+			addresses = append(stringList{l.place()}, addresses...)
+
+			// the location's defaults stand in for the usual ones, but anything
+			// asked for explicitly wins
+			if l.Properties != "" && !explicit["properties"] {
+				properties = l.Properties
+			}
+
+			if l.TimeZone != "" && !explicit["displaytz"] {
+				displaytz = l.TimeZone
+			}
+
+			if l.Units != "" && !explicit["unit-system"] && !explicit["freedom"] {
+				system := l.Units
+				if system == "freedom" {
+					system = "us"
+				}
+
+				err := selectUnitSystem(system, &freedom)
+				if err != nil {
+					return forecastRequest{}, err
+				}
+			}
+		}
+
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			return forecastRequest{}, fmt.Errorf("could not load display timezone: %w", err)
+		}
+
+		start := time.Now().Add(time.Duration(offset) * time.Hour)
+		end := start.Add(time.Duration(hours) * time.Hour)
+
+		if addressFile != "" {
+			more, err := readAddressFile(addressFile)
+			if err != nil {
+				return forecastRequest{}, err
+			}
+
+			addresses = append(addresses, more...)
+		}
+
+		req := forecastRequest{
+			mode:              mode,
+			chartHeight:       chartHeight,
+			addresses:         addresses,
+			layout:            layout,
+			parallel:          parallel,
+			properties:        strings.Split(properties, ","),
+			start:             start,
+			end:               end,
+			displayTimeZone:   loc,
+			freedom:           freedom,
+			highlightExtremes: highlight,
+			derived:           derived,
+			reportPath:        report,
+			neighbors:         neighbors,
+			radius:            radius,
+			past:              past,
+			station:           pinned,
+			records:           records,
+			startExpr:         startExpr,
+			endExpr:           endExpr,
+			length:            time.Duration(hours) * time.Hour,
+			profile:           profileName,
+			plantingDate:      plantingDate,
+			strictDecode:      strict,
+			verbose:           verbose,
+			format:            format,
+			delta:             delta,
+			consensus:         consensus,
+			hwo:               hwo,
+			outputPath:        outputPath,
+			copy:              copyOutput,
+			qr:                showQR || qrURL != "",
+			qrURL:             qrURL,
+			alerts:            showAlerts || alertLevel != "",
+			lowConfidence:     lowConf,
+			watch:             watch || watchDiff,
+			watchInterval:     watchEvery,
+			watchDiff:         watchDiff,
+			precipType:        precipType,
+			strip:             strip,
+			einkDisplay:       einkDisplay,
+			template:          templateText,
+			align:             align,
+			attribution:       attribution,
+			strict:            failHard,
+			sectionTimeout:    sectionWait,
+		}
+
+		if len(req.addresses) == 0 || req.addresses[0] == "" {
+			return forecastRequest{}, fmt.Errorf("address cannot be empty")
+		}
+
+		req.address = req.addresses[0]
+
+		if alertLevel != "" {
+			f, err := parseLevelFilter(alertLevel, severities, "alert severity")
+			if err != nil {
+				return forecastRequest{}, err
+			}
+
+			req.alertSeverity = &f
+		}
+
+		if indexOf(outputFormats, req.format) < 0 {
+			return forecastRequest{}, fmt.Errorf("format '%s' is not in %v", req.format, outputFormats)
+		}
+
+		if indexOf(forecastModes, req.mode) < 0 {
+			return forecastRequest{}, fmt.Errorf("mode '%s' is not in %v", req.mode, forecastModes)
+		}
+
+		if indexOf(alignPolicies, req.align) < 0 {
+			return forecastRequest{}, fmt.Errorf("align '%s' is not in %v", req.align, alignPolicies)
+		}
+
+		if _, ok := templatePresets[req.format]; ok {
+			_, err := parseForecastTemplate(req.format, req.template)
+			if err != nil {
+				return forecastRequest{}, err
+			}
+		}
+
+		if _, ok := einkDisplays[req.einkDisplay]; !ok {
+			return forecastRequest{}, fmt.Errorf("e-ink display '%s' is not in %v", req.einkDisplay, einkDisplayNames())
+		}
+
+		if req.sectionTimeout <= 0 {
+			return forecastRequest{}, fmt.Errorf("section timeout must be positive, got %s", req.sectionTimeout)
+		}
+
+		if req.chartHeight < 1 {
+			return forecastRequest{}, fmt.Errorf("chart height must be at least 1, got %d", req.chartHeight)
+		}
+
+		if req.mode == "periods" && req.format != "table" {
+			return forecastRequest{}, fmt.Errorf("periods mode only draws tables, not format '%s'", req.format)
+		}
+
+		if req.watch {
+			if req.format != "table" {
+				return forecastRequest{}, fmt.Errorf("-watch only draws tables, not format '%s'", req.format)
+			}
+
+			if req.mode != "grid" {
+				return forecastRequest{}, fmt.Errorf("-watch only redraws the grid, not %s", req.mode)
+			}
+
+			if req.watchInterval < time.Minute {
+				return forecastRequest{}, fmt.Errorf("watch interval must be at least a minute, got %s", req.watchInterval)
+			}
+		}
+
+		if req.profile != "" {
+			p, ok := profiles[req.profile]
+			if !ok {
+				return forecastRequest{}, fmt.Errorf("profile '%s' is not in %v", req.profile, profileNames())
+			}
+
+			// the profile's bundle stands in for the default, but anything asked
+			// for explicitly wins
+			if !explicit["properties"] {
+				req.properties = append([]string{}, p.properties...)
+			}
+		}
+
+		if req.radius != "" {
+			req.radiusKm, err = parseRadius(req.radius)
+			if err != nil {
+				return forecastRequest{}, err
+			}
+		}
+
+		if req.plantingDate != "" {
+			_, err := time.Parse("2006-01-02", req.plantingDate)
+			if err != nil {
+				return forecastRequest{}, fmt.Errorf("planting date must look like 2024-04-15, got '%s'", req.plantingDate)
+			}
+		}
+
+		for _, p := range req.properties {
+			if _, ok := propertyRegistry[p]; !ok {
+				return forecastRequest{}, fmt.Errorf("requested property '%s' is not in %v", p, permittedProperties())
+			}
+		}
+
+		if req.delta > 0 {
+			req.deltaProperties = strings.Split(deltaProps, ",")
+
+			for _, p := range req.deltaProperties {
+				if indexOf(req.properties, p) < 0 {
+					return forecastRequest{}, fmt.Errorf("delta property '%s' is not in requested properties %v", p, req.properties)
+				}
+			}
+		}
+
+		for _, d := range req.derived {
+			if indexOf(req.properties, d.name) >= 0 {
+				return forecastRequest{}, fmt.Errorf("derived column '%s' has the same name as a requested property", d.name)
+			}
+
+			for _, v := range d.expr.variables() {
+				if indexOf(req.properties, v) < 0 {
+					return forecastRequest{}, fmt.Errorf("derived column '%s' uses '%s' which is not in requested properties %v", d.name, v, req.properties)
+				}
+			}
+		}
+
+		for _, expr := range []string{req.startExpr, req.endExpr} {
+			if expr != "" && !isTimeExpression(expr) {
+				return forecastRequest{}, fmt.Errorf("'%s' is not a valid time, expected something like '+3h', 'sunrise+1h', or RFC3339", expr)
+			}
+		}
+
+		if req.past != 0 && req.past < time.Hour {
+			return forecastRequest{}, fmt.Errorf("past must be at least an hour, got %s", req.past)
+		}
+
+		if sortBy != "" {
+			split := strings.SplitN(sortBy, ":", 2)
+
+			req.sortProperty = split[0]
+			if len(split) == 2 {
+				switch split[1] {
+				case "asc":
+					req.sortDescending = false
+				case "desc":
+					req.sortDescending = true
+				default:
+					return forecastRequest{}, fmt.Errorf("sort direction must be 'asc' or 'desc', got '%s'", split[1])
+				}
+			}
+
+			if indexOf(req.columns(), req.sortProperty) < 0 {
+				return forecastRequest{}, fmt.Errorf("sort property '%s' is not in requested columns %v", req.sortProperty, req.columns())
+			}
+		}
+
+		if hide != "" {
+			req.hidden = strings.Split(hide, ",")
+
+			for _, p := range req.hidden {
+				if indexOf(req.columns(), p) < 0 {
+					return forecastRequest{}, fmt.Errorf("hidden column '%s' is not in requested columns %v", p, req.columns())
+				}
+			}
+		}
+
+		if len(req.addresses) > 1 {
+			err := req.validateMultiLocation()
+			if err != nil {
+				return forecastRequest{}, err
+			}
+
+			if req.layout == "" {
+				req.layout = "stack"
+				if len(req.visibleColumns()) == 1 {
+					req.layout = "compare"
+				}
+			}
+		}
+
+		return req, nil
+	}
+}
+
+// -1 if needle isn't present
+func indexOf(haystack []string, needle string) int {
+	for i, v := range haystack {
+		if needle == v {
+			return i
+		}
+	}
+
+	return -1
 }
 
-func main() {
-	req, err := getForecastRequest(os.Args)
-	if err != nil {
-		errorAndQuit(err)
+func errorAndQuit(err error) {
+	fmt.Fprintln(terminal, "agcw encountered an error: ", redact(err.Error()))
+	quit(1)
+}
+
+// in the repl a command ending shouldn't end the session, so it unwinds back
+// to the prompt instead
+func quit(code int) {
+	if inREPL {
+		panic(replExit{code: code})
 	}
 
-	coordinates, err := getAddressCoordinates(req.address)
-	if err != nil {
-		errorAndQuit(err)
+	os.Exit(code)
+}
+
+type coordinates struct {
+	latitude  float64
+	longitude float64
+}
+
+func getAddressCoordinates(queryAddress string) (coordinates, error) {
+	if l, ok := namedLocations[queryAddress]; ok {
+		queryAddress = l.place()
 	}
 
-	fmt.Println("lat: ", coordinates.latitude)
-	fmt.Println("long: ", coordinates.longitude)
+	if c, ok, err := parseCoordinates(queryAddress); ok {
+		return c, err
+	}
 
-	forecastGridDataURL, err := getForecastGridDataURL(coordinates)
-	if err != nil {
-		errorAndQuit(err)
+	cached := cachedCoordinates{}
+	if readCache("geocode", geocodeCacheKey(queryAddress), &cached) {
+		return coordinates{latitude: cached.Latitude, longitude: cached.Longitude}, nil
 	}
 
-	fmt.Println("forecastGridDataURL: ", forecastGridDataURL)
+	m, err := geocoder().Locate(context.Background(), queryAddress)
+	if errors.Is(err, geocode.ErrNoMatch) {
+		return coordinates{}, fmt.Errorf("%w '%s', check the spelling and that it has a city and state or a zip, or try 'agwc geocode -suggest'", err, queryAddress)
+	}
 
-	weatherData, err := getWeatherData(forecastGridDataURL, req.properties)
 	if err != nil {
-		errorAndQuit(err)
+		return coordinates{}, err
 	}
 
-	display(req, weatherData)
-}
-
-type forecastRequest struct {
-	address         string
-	properties      []string
-	start           time.Time
-	end             time.Time
-	displayTimeZone *time.Location
-	freedom         bool
-}
+	c, err := matchCoordinates(m)
+	if err != nil {
+		return coordinates{}, err
+	}
 
-func getForecastRequest(args []string) (forecastRequest, error) {
-	flagset := flag.NewFlagSet(args[0], flag.ExitOnError)
+	writeCache("geocode", geocodeCacheKey(queryAddress), cachedCoordinates{Latitude: c.latitude, Longitude: c.longitude})
 
-	var (
-		queryAddress string
-		properties   string
-		hours        int
-		offset       int
-		displaytz    string
-		freedom      bool
-	)
+	return c, nil
+}
 
-	flagset.StringVar(&queryAddress, "address", "", "address at which to see the weather")
-	flagset.StringVar(&properties, "properties", "temperature", "weather properties to display in a comma separated string")
-	flagset.IntVar(&hours, "hours", 12, "number of hours of predictions to show")
-	flagset.IntVar(&offset, "offset", 0, "start predictions this many hours from now")
-	flagset.StringVar(&displaytz, "displaytz", "UTC", "time zone in which to display predictions")
-	flagset.BoolVar(&freedom, "freedom", false, "use freedom units")
+func parseAddressCoordinates(r io.Reader) (coordinates, error) {
+	m, err := geocode.ParseResponse(r)
+	if err != nil {
+		return coordinates{}, err
+	}
 
-	flagset.Parse(args[1:])
+	return matchCoordinates(m)
+}
 
-	loc, err := time.LoadLocation(displaytz)
+func matchCoordinates(m geocode.Match) (coordinates, error) {
+	err := checkRequired("geocoder match", requiredField{"coordinates", m.Latitude != 0 || m.Longitude != 0})
 	if err != nil {
-		return forecastRequest{}, fmt.Errorf("could not load display timezone: %w", err)
+		return coordinates{}, err
 	}
 
-	start := time.Now().Add(time.Duration(offset) * time.Hour)
-	end := start.Add(time.Duration(hours) * time.Hour)
+	return coordinates{latitude: m.Latitude, longitude: m.Longitude}, nil
+}
 
-	req := forecastRequest{
-		address:         queryAddress,
-		properties:      strings.Split(properties, ","),
-		start:           start,
-		end:             end,
-		displayTimeZone: loc,
-		freedom:         freedom,
-	}
+func (c coordinates) String() string {
+	return fmt.Sprintf("%.4f,%.4f", c.latitude, c.longitude)
+}
 
-	if req.address == "" {
-		return forecastRequest{}, fmt.Errorf("address cannot be empty")
+func formatCell(cell []coordinates) string {
+	corners := []string{}
+	for _, c := range cell {
+		corners = append(corners, c.String())
 	}
 
-	for _, p := range req.properties {
-		if !containsString(permittedProperties, p) {
-			return forecastRequest{}, fmt.Errorf("requested property '%s' is not in %v", p, permittedProperties)
-		}
+	return strings.Join(corners, " ")
+}
+
+func cellCenter(cell []coordinates) coordinates {
+	center := coordinates{}
+	for _, c := range cell {
+		center.latitude += c.latitude / float64(len(cell))
+		center.longitude += c.longitude / float64(len(cell))
 	}
 
-	return req, nil
+	return center
 }
 
-func containsString(haystack []string, needle string) bool {
-	for _, v := range haystack {
-		if needle == v {
-			return true
-		}
-	}
+// grid cells are about 2.5km on a side, so zoom 15 shows the whole thing
+func openStreetMapLink(c coordinates) string {
+	return fmt.Sprintf("https://www.openstreetmap.org/?mlat=%.4f&mlon=%.4f#map=15/%.4f/%.4f", c.latitude, c.longitude, c.latitude, c.longitude)
+}
 
-	return false
+func googleMapsLink(c coordinates) string {
+	return fmt.Sprintf("https://www.google.com/maps/search/?api=1&query=%.4f,%.4f", c.latitude, c.longitude)
 }
 
-func errorAndQuit(err error) {
-	fmt.Println("agcw encountered an error: ", err.Error())
-	os.Exit(1)
+type gridPoint struct {
+	office              string
+	x                   int
+	y                   int
+	forecastGridDataURL string
+	forecastURL         string
+	observationStations string
+	radarStation        string
+
+	// the public forecast zone, like ILZ014, that text products are issued for
+	forecastZone string
 }
 
-type coordinates struct {
-	latitude  float64
-	longitude float64
+func getGridPoint(c coordinates) (gridPoint, error) {
+	return getGridPointContext(context.Background(), c)
 }
 
-func getAddressCoordinates(queryAddress string) (coordinates, error) {
-	queryURL := &url.URL{
-		Scheme: "https",
-		Host:   "geocoding.geo.census.gov",
-		Path:   "/geocoder/locations/onelineaddress",
-		RawQuery: url.Values{
-			"format":    []string{"json"},
-			"benchmark": []string{"Public_AR_Current"},
-			"address":   []string{queryAddress},
-		}.Encode(),
-	}
-
-	req, err := http.NewRequest("GET", queryURL.String(), nil)
+func getGridPointContext(ctx context.Context, c coordinates) (gridPoint, error) {
+	cached := cachedGridPoint{}
+	if readCache("points", c.String(), &cached) {
+		return cached.gridPoint(), nil
+	}
+
+	p, err := nwsClient().Point(ctx, c.latitude, c.longitude)
 	if err != nil {
-		return coordinates{}, fmt.Errorf("could not initialize HTTP request: %w", err)
+		return gridPoint{}, err
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	grid, err := newGridPoint(p)
 	if err != nil {
-		return coordinates{}, fmt.Errorf("could not execute HTTP request: %w", err)
+		return gridPoint{}, err
 	}
 
-	body := struct {
-		Result struct {
-			AddressMatches []struct {
-				Coordinates struct {
-					X float64 `json:"x"`
-					Y float64 `json:"y"`
-				} `json:"coordinates"`
-			} `json:"address_matches'`
-		} `json: "result"`
-	}{}
+	writeCache("points", c.String(), newCachedGridPoint(grid))
 
-	err = json.NewDecoder(res.Body).Decode(&body)
+	return grid, nil
+}
+
+func parseGridPoint(r io.Reader) (gridPoint, error) {
+	p, err := nws.ParsePoint(r)
+	if err != nil {
+		return gridPoint{}, err
+	}
+
+	return newGridPoint(p)
+}
+
+func newGridPoint(p nws.Point) (gridPoint, error) {
+	err := checkRequired(
+		"points response",
+		requiredField{"gridId", p.GridID != ""},
+		requiredField{"observationStations", p.ObservationStations != ""},
+	)
 	if err != nil {
-		return coordinates{}, fmt.Errorf("could not parse HTTP response body: %w", err)
+		return gridPoint{}, err
 	}
 
-	if len(body.Result.AddressMatches) == 0 {
-		return coordinates{}, fmt.Errorf("no matching coordinates for address")
+	err = nws.ValidateGridDataURL(p.ForecastGridData)
+	if err != nil {
+		return gridPoint{}, err
+	}
+
+	zone := ""
+	if p.ForecastZone != "" {
+		zone = path.Base(p.ForecastZone)
 	}
 
-	return coordinates{
-		latitude:  body.Result.AddressMatches[0].Coordinates.Y,
-		longitude: body.Result.AddressMatches[0].Coordinates.X,
+	return gridPoint{
+		office:              p.GridID,
+		x:                   p.GridX,
+		y:                   p.GridY,
+		forecastGridDataURL: p.ForecastGridData,
+		forecastURL:         p.Forecast,
+		observationStations: p.ObservationStations,
+		radarStation:        p.RadarStation,
+		forecastZone:        zone,
 	}, nil
 }
 
-func getForecastGridDataURL(c coordinates) (string, error) {
-	queryURL := &url.URL{
-		Scheme: "https",
-		Host:   "api.weather.gov",
-		Path:   fmt.Sprintf("/points/%f,%f", c.latitude, c.longitude),
+type gridForecast struct {
+	updateTime time.Time
+	cell       []coordinates
+	properties map[string]series
+
+	// in meters, if the grid said
+	elevation *float64
+
+	// when the forecast was fetched, if it came out of the cache without
+	// asking upstream, and whether that's because upstream couldn't be
+	// reached
+	cachedAt time.Time
+	offline  bool
+}
+
+// the grid's forecast from the cache while it's fresh, and after that only
+// downloaded again if upstream says it's changed
+func getWeatherData(forecastGridDataURL string, requestedProperties []string) (gridForecast, error) {
+	return getWeatherDataContext(context.Background(), forecastGridDataURL, requestedProperties)
+}
+
+func getWeatherDataContext(ctx context.Context, forecastGridDataURL string, requestedProperties []string) (gridForecast, error) {
+	cached := cachedGrid{}
+	hit := readCache("gridpoints", forecastGridDataURL, &cached) && cached.has(requestedProperties)
+
+	if hit && cached.fresh() {
+		return newGridForecast(cached.grid(), requestedProperties, cached.FetchedAt, false)
 	}
 
-	req, err := http.NewRequest("GET", queryURL.String(), nil)
-	if err != nil {
-		return "", fmt.Errorf("could not initialize HTTP request: %w", err)
+	header := http.Header{}
+
+	// a 304 only helps if the cache has everything asked for
+	if hit && cached.ETag != "" {
+		header.Set("If-None-Match", cached.ETag)
 	}
 
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("could not execute HTTP request: %w", err)
+	if hit && cached.LastModified != "" {
+		header.Set("If-Modified-Since", cached.LastModified)
+	}
+
+	res, err := nwsClient().Get(streamed(ctx), forecastGridDataURL, header)
+
+	// retries give back the last 5xx or 429 rather than an error, and that's
+	// how an outage usually looks, so it's as much a reason to fall back
+	if err == nil && res.StatusCode != http.StatusOK && !(hit && res.StatusCode == http.StatusNotModified) {
+		res.Body.Close()
+		err = fmt.Errorf("gridpoint request failed with %s", res.Status)
 	}
 
-	body := struct {
-		Properties struct {
-			ForecastGridData string `json:forecast_grid_data"`
-		} `json:"properties"`
-	}{}
+	if err != nil && hit && cached.usable() {
+		return newGridForecast(cached.grid(), requestedProperties, cached.FetchedAt, true)
+	}
 
-	err = json.NewDecoder(res.Body).Decode(&body)
 	if err != nil {
-		return "", fmt.Errorf("could not parse HTTP response body: %w", err)
+		return gridForecast{}, err
 	}
 
-	return body.Properties.ForecastGridData, nil
-}
+	defer res.Body.Close()
 
-type weatherPoint struct {
-	StartTime time.Time
-	EndTime   time.Time
-	Value     *float64
-	Unit      string
-}
+	if hit && res.StatusCode == http.StatusNotModified {
+		cached.FetchedAt = time.Now()
+		writeCache("gridpoints", forecastGridDataURL, cached)
 
-func getWeatherData(forecastGridDataURL string, requestedProperties []string) (map[string][]weatherPoint, error) {
-	req, err := http.NewRequest("GET", forecastGridDataURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("could not initialize HTTP request: %w", err)
+		return newGridForecast(cached.grid(), requestedProperties, time.Time{}, false)
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	// decoding stops after the last layer it wants, so the rest of the
+	// response is never read
+	grid, err := nws.GridDecoder{Layers: cached.layersWith(requestedProperties), Strict: strictDecode}.Decode(res.Body)
 	if err != nil {
-		return nil, fmt.Errorf("could not execute HTTP request: %w", err)
+		return gridForecast{}, err
 	}
 
-	body := struct {
-		Properties map[string]json.RawMessage `json:"properties"`
-	}{}
+	writeCache("gridpoints", forecastGridDataURL, newCachedGrid(grid, res))
 
-	err = json.NewDecoder(res.Body).Decode(&body)
+	return newGridForecast(grid, requestedProperties, time.Time{}, false)
+}
+
+func parseWeatherData(r io.Reader, requestedProperties []string) (gridForecast, error) {
+	grid, err := nws.GridDecoder{Layers: requestedProperties, Strict: strictDecode}.Decode(r)
 	if err != nil {
-		return nil, fmt.Errorf("could not parse HTTP response body: %w", err)
+		return gridForecast{}, err
 	}
 
-	properties := map[string][]weatherPoint{}
+	return newGridForecast(grid, requestedProperties, time.Time{}, false)
+}
 
-	for _, name := range requestedProperties {
-		raw := struct {
-			UnitOfMeasurement string `json:"uom"`
-			Values            []struct {
-				ValidTime string   `json:"validTime"`
-				Value     *float64 `json:"value"`
-			} `json:"values"`
-		}{}
+// the forecast for requestedProperties from a decoded grid, cached at
+// cachedAt or zero if it just came from upstream, and offline if upstream
+// couldn't be reached
+func newGridForecast(grid nws.Grid, requestedProperties []string, cachedAt time.Time, offline bool) (gridForecast, error) {
+	cell := []coordinates{}
+	for _, corner := range grid.Cell {
+		cell = append(cell, coordinates{latitude: corner[1], longitude: corner[0]})
+	}
 
-		data := body.Properties[name]
-		if data == nil {
-			return nil, fmt.Errorf("no data for requested property: %s", name)
-		}
+	properties := map[string]series{}
+
+	for _, name := range requestedProperties {
+		layer := grid.Layers[name]
 
-		err := json.Unmarshal(data, &raw)
+		err := checkRequired(fmt.Sprintf("gridpoint property '%s'", name), requiredField{"uom", layer.Unit != ""})
 		if err != nil {
-			return nil, fmt.Errorf("error parsing requested property '%s': %w", name, err)
+			return gridForecast{}, err
 		}
 
+		unit := normalizeUnit(layer.Unit)
 		points := []weatherPoint{}
 
-		for _, v := range raw.Values {
-			start, end, err := parseTimeRange(v.ValidTime)
-			if err != nil {
-				return nil, fmt.Errorf("error parsing time range: %w", err)
-			}
-
+		for _, v := range layer.Values {
 			points = append(points, weatherPoint{
-				Unit:      raw.UnitOfMeasurement,
-				StartTime: start,
-				EndTime:   end,
+				Unit:      unit,
+				StartTime: v.Start,
+				EndTime:   v.End,
 				Value:     v.Value,
 			})
 		}
 
-		// I don't know that the API is always guaranteed to return in order
-		sort.Slice(points, func(i, j int) bool { return points[i].StartTime.Before(points[j].EndTime) })
-
-		properties[name] = points
+		properties[name] = newSeries(points)
 	}
 
-	return properties, nil
+	return gridForecast{updateTime: grid.UpdateTime, cell: cell, properties: properties, elevation: grid.Elevation, cachedAt: cachedAt, offline: offline}, nil
 }
 
 type displayRow struct {
-	at     time.Time
-	values []string
+	at       time.Time
+	values   []string
+	numbers  []*float64
+	observed bool
+
+	// with -precip-type, what the forecast precipitation will fall as
+	precipType string
 }
 
-func formatWeatherValue(p weatherPoint, freedom bool) string {
+func formatWeatherValue(property string, p weatherPoint, freedom bool) string {
 	if freedom {
 		p = liberate(p)
 	}
@@ -306,39 +1503,60 @@ func formatWeatherValue(p weatherPoint, freedom bool) string {
 		return "No Data"
 	}
 
-	return fmt.Sprintf("%5.5g %s", *p.Value, displayUnit(p.Unit))
+	kind := propertyRegistry[property]
+	value := formatNumber(*p.Value, kindPrecision[kind])
+	unit := displayUnit(p.Unit)
+
+	if kind == kindProbability || unit == "%" {
+		return value + "%"
+	}
+
+	return value + " " + unit
 }
 
-func liberate(p weatherPoint) weatherPoint {
-	if p.Value == nil {
-		return p
+// rounds to the given number of decimal places, dropping trailing zeros and
+// making sure that values too small to show don't come out as "-0"
+func formatNumber(v float64, precision int) string {
+	if math.Abs(v) < 0.5*math.Pow(10, -float64(precision)) {
+		v = 0
 	}
 
-	f := weatherPoint{StartTime: p.StartTime, EndTime: p.EndTime}
+	s := strconv.FormatFloat(v, 'f', precision, 64)
 
-	switch p.Unit {
-	case "wmoUnit:degC":
-		v := ((*p.Value * 9.0) / 5.0) + 32
-		f.Value = &v
-		f.Unit = "F"
-	case "wmoUnit:km_h-1":
-		v := *p.Value * 0.621371
-		f.Value = &v
-		f.Unit = "mph"
-	case "wmoUnit:mm":
-		v := *p.Value * 0.0393701
-		f.Value = &v
-		f.Unit = "in"
-	case "wmoUnit:m":
-		v := *p.Value * 3.28084
-		f.Value = &v
-		f.Unit = "ft"
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+
+	if s == "-0" {
+		s = "0"
+	}
+
+	return s
+}
+
+// converts out of metric into whatever -unit-system asked for
+func liberate(p weatherPoint) weatherPoint {
+	return activeUnitSystem.convert(p)
+}
+
+// converts for display if the request is converting at all, into the unit
+// system a serve request asked for or else -unit-system's, since requests
+// are served side by side
+func (req forecastRequest) liberate(p weatherPoint) weatherPoint {
+	switch {
+	case !req.freedom:
+		return p
+	case req.system != nil:
+		return req.system.convert(p)
 	default:
-		f.Value = p.Value
-		f.Unit = p.Unit
+		return liberate(p)
 	}
+}
 
-	return f
+func (req forecastRequest) columnUnit(unit string) string {
+	zero := 0.0
+	return displayUnit(req.liberate(weatherPoint{Value: &zero, Unit: unit}).Unit)
 }
 
 func displayUnit(unit string) string {
@@ -353,21 +1571,35 @@ func displayUnit(unit string) string {
 		return "mm"
 	case "wmoUnit:m":
 		return "m"
+	case "wmoUnit:km":
+		return "km"
 	case "wmoUnit:degree_(angle)":
 		return "deg"
+	case "wmoUnit:Pa":
+		return "Pa"
 	default:
+		// something we haven't needed before, which is still better as km/h
+		// than as wmoUnit:km_h-1
+		if u, err := units.Parse(unit); err == nil {
+			return u.Symbol()
+		}
+
 		return unit
 	}
 }
 
-func display(req forecastRequest, weatherData map[string][]weatherPoint) {
+// some layers have values for half hours, or that start at half past, so
+// the top of the hour isn't always the best one to show for it
+var alignPolicies = []string{"instant", "overlap", "nearest"}
+
+func buildRows(req forecastRequest, weatherData map[string]series) []displayRow {
 	idx := map[string]int{}
 	for _, p := range req.properties {
 		idx[p] = 0
 	}
 
-	start := req.start.Truncate(time.Hour)
-	end := req.end.Truncate(time.Hour)
+	start := localHour(req.start, req.displayTimeZone)
+	end := localHour(req.end, req.displayTimeZone)
 
 	if start.After(end) {
 		panic("display start time after end time")
@@ -376,207 +1608,358 @@ func display(req forecastRequest, weatherData map[string][]weatherPoint) {
 	rows := []displayRow{}
 
 	for curr := start; !curr.After(end); curr = curr.Add(time.Hour) {
-		// fmt.Println("DEBUG: TIME: ", curr)
-
 		row := displayRow{
-			at:     curr,
-			values: []string{},
+			at:      curr,
+			values:  []string{},
+			numbers: []*float64{},
 		}
 
+		// as the API sent them, for the comfort indexes
+		metric := map[string]*float64{}
+
 		for _, property := range req.properties {
-			// fmt.Println("DEBUG: PROP: ", property)
 			points := weatherData[property]
-			for idx[property] < len(points) {
-				p := points[idx[property]]
-				cmp := compareTimeToRange(curr, p.StartTime, p.EndTime)
 
-				// fmt.Println("p: ", p)
-				// fmt.Println("idx: ", idx[property])
-				// fmt.Println("cmp: ", cmp)
+			var match *weatherPoint
 
-				if cmp == 0 {
-					row.values = append(row.values, formatWeatherValue(p, req.freedom))
-					break
+			switch req.align {
+			case "overlap":
+				if i := points.IndexOverlapping(curr, curr.Add(time.Hour)); i >= 0 {
+					p := points.At(i)
+					match = &p
+				}
+			case "nearest":
+				if i := points.IndexNearest(curr, time.Hour); i >= 0 {
+					p := points.At(i)
+					match = &p
 				}
+			default:
+				for idx[property] < points.Len() {
+					p := points.At(idx[property])
+					cmp := compareTimeToRange(curr, p.StartTime, p.EndTime)
 
-				if cmp < 0 {
-					row.values = append(row.values, "No Data")
-					break
+					if cmp == 0 {
+						match = &p
+						break
+					}
+
+					if cmp < 0 {
+						break
+					}
+
+					idx[property]++
 				}
+			}
+
+			if match == nil {
+				row.values = append(row.values, "No Data")
+				row.numbers = append(row.numbers, nil)
+				continue
+			}
+
+			converted := req.liberate(*match)
+
+			row.values = append(row.values, formatWeatherValue(property, converted, false))
+			row.numbers = append(row.numbers, converted.Value)
+			metric[property] = match.Value
+		}
+
+		// derived columns work on the values as displayed, so that the
+		// expression is in the same units the user is looking at
+		values := map[string]*float64{}
+		for i, property := range req.properties {
+			values[property] = row.numbers[i]
+		}
+
+		for _, d := range req.derived {
+			if c, ok := d.expr.(comfortExpr); ok {
+				p := c.point(metric)
+
+				converted := req.liberate(p)
 
-				idx[property]++
+				row.values = append(row.values, formatWeatherValue("temperature", converted, false))
+				row.numbers = append(row.numbers, converted.Value)
+
+				continue
 			}
+
+			v := d.expr.eval(values)
+
+			row.values = append(row.values, d.format(v))
+			row.numbers = append(row.numbers, v)
 		}
 
 		rows = append(rows, row)
 	}
 
-	fmtstr, bar := getFormatString(req.properties)
+	if req.precipType {
+		applyPrecipTypes(weatherData, rows)
+	}
+
+	applyMetricPlugins(req, rows)
 
-	fmt.Printf(fmtstr, append([]interface{}{"time"}, toiface(req.properties)...)...)
-	fmt.Println(bar)
+	return rows
+}
 
-	for _, r := range rows {
-		fmt.Printf(fmtstr, append([]interface{}{r.at.In(req.displayTimeZone).Format(time.Stamp)}, toiface(r.values)...)...)
+// indexes into req.columns() of the columns that aren't hidden
+func (req forecastRequest) visibleColumns() []int {
+	visible := []int{}
+	for i, c := range req.columns() {
+		if indexOf(req.hidden, c) < 0 {
+			visible = append(visible, i)
+		}
 	}
+
+	return visible
 }
 
-func toiface(ss []string) []interface{} {
-	is := make([]interface{}, len(ss))
-	for i, v := range ss {
-		is[i] = v
+// distinct from errors, so scripts can tell "nothing matched" from "broken"
+const exitNoRows = 4
+
+func display(req forecastRequest, rows []displayRow) {
+	if len(rows) == 0 {
+		fmt.Println("no hours match your criteria in the requested window")
+		quit(exitNoRows)
 	}
 
-	return is
+	render(os.Stdout, req, rows)
 }
 
-func getFormatString(properties []string) (string, string) {
-	fmtstr := " %15.15s"
+func render(w io.Writer, req forecastRequest, rows []displayRow) {
+	columns := req.columns()
+	visible := req.visibleColumns()
 
-	totwidth := 16
+	var extremes []columnExtremes
+	if req.highlightExtremes {
+		extremes = findExtremes(rows, len(columns))
+	}
 
-	for _, p := range properties {
-		fmtstr += " | "
+	header := []string{"time"}
+	for _, i := range visible {
+		header = append(header, columns[i])
+	}
 
-		width := len(p)
-		if width < 15 {
-			width = 15
+	if req.precipType {
+		header = append(header, "precipType")
+	}
+
+	widths := getColumnWidths(header[1:])
+
+	times := []string{}
+	for _, r := range rows {
+		cell := rowTimeCell(req, r)
+		if width := runewidth.StringWidth(cell); width > widths[0] {
+			widths[0] = width
 		}
 
-		fmtstr += fmt.Sprint("%", width, ".", width, "s")
-		totwidth += 3 + width
+		times = append(times, cell)
 	}
 
-	fmtstr += "\n"
+	t := newTable(w, widths, header)
 
-	return fmtstr, strings.Repeat("-", totwidth)
-}
+	marked := false
 
-// negative if test is before start
-// positive if test is after or equal to end
-// zero if test is within the range
-func compareTimeToRange(test, start, end time.Time) int {
-	// we don't care about monotonic clock readings for this purpose
-	test = test.Round(0)
-	start = start.Round(0)
-	end = end.Round(0)
+	for i, r := range rows {
+		if i > 0 && rows[i-1].observed && !r.observed {
+			t.divider(" now ")
+		}
 
-	if test.Before(start) {
-		return -1
-	}
+		// only between hours in order, since sorting puts any two together
+		if i > 0 && r.at.Sub(rows[i-1].at) == time.Hour {
+			if change := clockChange(rows[i-1].at, r.at, req.displayTimeZone); change != "" {
+				t.divider(" " + change + " ")
+			}
+		}
 
-	if test.Equal(end) || test.After(end) {
-		return 1
-	}
+		cells := []string{times[i]}
+		styles := []string{""}
 
-	return 0
-}
+		if strings.HasSuffix(times[i], lowConfidenceMarker) {
+			marked = true
+		}
 
-func parseTimeRange(validTime string) (time.Time, time.Time, error) {
-	split := strings.Split(validTime, "/")
+		for _, i := range visible {
+			cells = append(cells, r.values[i])
 
-	if len(split) != 2 {
-		return time.Time{}, time.Time{}, fmt.Errorf("malformed time + duration: %s", validTime)
+			if extremes != nil {
+				styles = append(styles, extremes[i].style(r.numbers[i]))
+			} else {
+				styles = append(styles, "")
+			}
+		}
+
+		if req.precipType {
+			switch {
+			case r.observed:
+				cells = append(cells, "")
+			case r.precipType == "":
+				cells = append(cells, "No Data")
+			default:
+				cells = append(cells, r.precipType)
+			}
+
+			styles = append(styles, "")
+		}
+
+		t.row(cells, styles)
 	}
 
-	start, err := time.Parse(time.RFC3339, split[0])
-	if err != nil {
-		return time.Time{}, time.Time{}, fmt.Errorf("could not parse time '%s': %w", split[0], err)
+	t.end()
+
+	if marked {
+		fmt.Fprintf(w, "%s more than %s after the forecast was issued, low confidence\n", strings.TrimSpace(lowConfidenceMarker), formatLead(req.lowConfidence))
 	}
+}
 
-	dur, err := parseISO8601Duration(split[1])
-	if err != nil {
-		return time.Time{}, time.Time{}, fmt.Errorf("could not parse duration '%s' : %w", split[1], err)
+const lowConfidenceMarker = "  ~"
+
+// hours from when the forecast was issued, or nil for observed hours and
+// forecasts that didn't say
+func (req forecastRequest) leadHours(r displayRow) *int {
+	if r.observed || req.issuedAt.IsZero() {
+		return nil
 	}
 
-	return start, applyISO8601Duration(start, dur), nil
-}
+	lead := int(r.at.Sub(req.issuedAt) / time.Hour)
 
-type iso8601Duration struct {
-	years   int64
-	months  int64
-	days    int64
-	hours   int64
-	minutes int64
-	seconds int64
+	return &lead
 }
 
-var iso8601DurationMatcher = regexp.MustCompile(`^P((?P<numYears>\d+)Y)?((?P<numMonths>\d+)M)?((?P<numDays>\d+)D)?(T((?P<numHours>\d+)H)?((?P<numMinutes>\d+)M)?((?P<numSeconds>\d+)S)?)?$|^P(?P<numWeeks>\d+)W$`)
+func (req forecastRequest) lowConfidenceAt(r displayRow) bool {
+	return req.lowConfidence > 0 && !r.observed && !req.issuedAt.IsZero() && r.at.Sub(req.issuedAt) > req.lowConfidence
+}
 
-func parseISO8601Duration(s string) (iso8601Duration, error) {
-	matches := iso8601DurationMatcher.FindStringSubmatch(s)
+// 72h reads better than 72h0m0s
+func formatLead(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
 
-	if len(matches) == 0 {
-		return iso8601Duration{}, fmt.Errorf("'%s' is not a valid iso8601 duration", s)
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
 	}
 
-	yearstr := matches[iso8601DurationMatcher.SubexpIndex("numYears")]
-	monthstr := matches[iso8601DurationMatcher.SubexpIndex("numMonths")]
-	daystr := matches[iso8601DurationMatcher.SubexpIndex("numDays")]
-	hourstr := matches[iso8601DurationMatcher.SubexpIndex("numHours")]
-	minutestr := matches[iso8601DurationMatcher.SubexpIndex("numMinutes")]
-	secondstr := matches[iso8601DurationMatcher.SubexpIndex("numSeconds")]
+	return s
+}
 
-	weekstr := matches[iso8601DurationMatcher.SubexpIndex("numWeeks")]
+func centerText(text string, width int, fill rune) string {
+	textWidth := runewidth.StringWidth(text)
+	if textWidth >= width {
+		return text
+	}
 
-	if weekstr != "" {
-		weeks, err := strconv.ParseInt(weekstr, 10, 64)
-		if err != nil {
-			return iso8601Duration{}, fmt.Errorf("could not parse week value '%s' to int: %w", weekstr, err)
-		}
+	left := (width - textWidth) / 2
+	right := width - textWidth - left
 
-		return iso8601Duration{days: 7 * weeks}, nil
-	}
+	return strings.Repeat(string(fill), left) + text + strings.Repeat(string(fill), right)
+}
 
-	duration := iso8601Duration{}
-	var err error
+// rows without data for the column always sort to the bottom
+func sortRows(rows []displayRow, column int, descending bool) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i].numbers[column], rows[j].numbers[column]
 
-	if yearstr != "" {
-		duration.years, err = strconv.ParseInt(yearstr, 10, 64)
-		if err != nil {
-			return iso8601Duration{}, fmt.Errorf("could not parse year value '%s' to int: %w", yearstr, err)
+		if a == nil || b == nil {
+			return a != nil && b == nil
 		}
-	}
 
-	if monthstr != "" {
-		duration.months, err = strconv.ParseInt(monthstr, 10, 64)
-		if err != nil {
-			return iso8601Duration{}, fmt.Errorf("could not parse month value '%s' to int: %w", monthstr, err)
+		if descending {
+			return *a > *b
 		}
-	}
 
-	if daystr != "" {
+		return *a < *b
+	})
+}
 
-		duration.days, err = strconv.ParseInt(daystr, 10, 64)
-		if err != nil {
-			return iso8601Duration{}, fmt.Errorf("could not parse day value '%s' to int: %w", daystr, err)
+const (
+	styleMax   = "\x1b[1;31m"
+	styleMin   = "\x1b[1;34m"
+	styleReset = "\x1b[0m"
+)
+
+type columnExtremes struct {
+	min *float64
+	max *float64
+}
+
+func findExtremes(rows []displayRow, columns int) []columnExtremes {
+	extremes := make([]columnExtremes, columns)
+
+	for _, r := range rows {
+		for i, n := range r.numbers {
+			if n == nil {
+				continue
+			}
+
+			if extremes[i].min == nil || *n < *extremes[i].min {
+				extremes[i].min = n
+			}
+
+			if extremes[i].max == nil || *n > *extremes[i].max {
+				extremes[i].max = n
+			}
 		}
 	}
 
-	if hourstr != "" {
-		duration.hours, err = strconv.ParseInt(hourstr, 10, 64)
-		if err != nil {
-			return iso8601Duration{}, fmt.Errorf("could not parse hour value '%s' to int: %w", hourstr, err)
-		}
+	return extremes
+}
+
+func (e columnExtremes) style(n *float64) string {
+	// a flat column has no interesting high or low
+	if n == nil || e.min == nil || *e.min == *e.max {
+		return ""
 	}
 
-	if minutestr != "" {
-		duration.minutes, err = strconv.ParseInt(minutestr, 10, 64)
-		if err != nil {
-			return iso8601Duration{}, fmt.Errorf("could not parse minute value '%s' to int: %w", minutestr, err)
-		}
+	switch *n {
+	case *e.max:
+		return styleMax
+	case *e.min:
+		return styleMin
+	default:
+		return ""
 	}
+}
 
-	if secondstr != "" {
-		duration.seconds, err = strconv.ParseInt(secondstr, 10, 64)
-		if err != nil {
-			return iso8601Duration{}, fmt.Errorf("could not parse second value '%s' to int: %w", secondstr, err)
+// the first column is always the timestamp
+func getColumnWidths(properties []string) []int {
+	widths := []int{15}
+
+	for _, p := range properties {
+		width := runewidth.StringWidth(p)
+		if width < 15 {
+			width = 15
 		}
+
+		widths = append(widths, width)
 	}
 
-	return duration, nil
+	return widths
 }
 
-func applyISO8601Duration(t time.Time, d iso8601Duration) time.Time {
-	return t.AddDate(int(d.years), int(d.months), int(d.days)).Add(time.Duration(d.hours) * time.Hour).Add(time.Duration(d.minutes) * time.Minute).Add(time.Duration(d.seconds) * time.Second)
+// right aligns the cell and cuts it off at the given width, counting by how
+// wide things look in a terminal rather than bytes, since glyphs and
+// accented place names would otherwise throw the columns off
+func padCell(cell string, width int) string {
+	return runewidth.FillLeft(runewidth.Truncate(cell, width, ""), width)
+}
+
+// negative if test is before start
+// positive if test is after or equal to end
+// zero if test is within the range
+func compareTimeToRange(test, start, end time.Time) int {
+	// we don't care about monotonic clock readings for this purpose
+	test = test.Round(0)
+	start = start.Round(0)
+	end = end.Round(0)
+
+	if test.Before(start) {
+		return -1
+	}
+
+	if test.Equal(end) || test.After(end) {
+		return 1
+	}
+
+	return 0
 }
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var multiLayouts = []string{"compare", "stack"}
+
+// a place in a multi-location run, with everything needed to draw it
+type locationForecast struct {
+	address string
+	req     forecastRequest
+	rows    []displayRow
+	err     error
+}
+
+// one address per line, skipping blanks and #comments, since addresses
+// have commas of their own
+func readAddressFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open address file: %w", err)
+	}
+
+	defer f.Close()
+
+	addresses := []string{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		addresses = append(addresses, line)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("could not read address file: %w", err)
+	}
+
+	return addresses, nil
+}
+
+// the extras built around a single place, which don't mean anything when
+// comparing several
+func (req forecastRequest) singleLocationFlags() []string {
+	set := []string{}
+
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"-past", req.past > 0},
+		{"-delta", req.delta > 0},
+		{"-records", req.records},
+		{"-neighbors", req.neighbors},
+		{"-radius", req.radius != ""},
+		{"-consensus", req.consensus},
+		{"-hwo", req.hwo},
+		{"-alerts", req.alerts},
+		{"-qr", req.qr},
+		{"-report", req.reportPath != ""},
+		{"-watch", req.watch},
+		{"-mode periods", req.mode == "periods"},
+		{"-verbose", req.verbose},
+		{"-planting-date", req.plantingDate != ""},
+	} {
+		if f.set {
+			set = append(set, f.name)
+		}
+	}
+
+	return set
+}
+
+func (req forecastRequest) validateMultiLocation() error {
+	for _, a := range req.addresses {
+		if a == "" {
+			return fmt.Errorf("address cannot be empty")
+		}
+	}
+
+	if req.format != "table" {
+		return fmt.Errorf("several addresses can only be shown as a table, not format '%s'", req.format)
+	}
+
+	if flags := req.singleLocationFlags(); len(flags) > 0 {
+		return fmt.Errorf("%s only work with a single address", strings.Join(flags, ", "))
+	}
+
+	if req.layout != "" && indexOf(multiLayouts, req.layout) < 0 {
+		return fmt.Errorf("layout '%s' is not in %v", req.layout, multiLayouts)
+	}
+
+	if req.layout == "compare" && len(req.visibleColumns()) != 1 {
+		return fmt.Errorf("compare layout shows a single property, but %d are visible", len(req.visibleColumns()))
+	}
+
+	if req.parallel < 1 {
+		return fmt.Errorf("parallel must be at least 1, got %d", req.parallel)
+	}
+
+	return nil
+}
+
+// geocodes and fetches every address at once, up to req.parallel at a
+// time, since each one is three round trips one after the other
+func getLocationForecasts(req forecastRequest) []locationForecast {
+	results := make([]locationForecast, len(req.addresses))
+	slots := make(chan struct{}, req.parallel)
+
+	var wg sync.WaitGroup
+
+	for i, address := range req.addresses {
+		wg.Add(1)
+
+		go func(i int, address string) {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			results[i] = getLocationForecast(req, address)
+		}(i, address)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func getLocationForecast(req forecastRequest, address string) locationForecast {
+	req.address = address
+
+	coordinates, err := getAddressCoordinates(address)
+	if err != nil {
+		return locationForecast{address: address, err: err}
+	}
+
+	req, err = req.resolveWindow(coordinates)
+	if err != nil {
+		return locationForecast{address: address, err: err}
+	}
+
+	grid, err := getGridPoint(coordinates)
+	if err != nil {
+		return locationForecast{address: address, err: err}
+	}
+
+	forecast, err := getWeatherData(grid.forecastGridDataURL, req.fetchProperties())
+	if err != nil {
+		return locationForecast{address: address, err: err}
+	}
+
+	req.issuedAt = forecast.updateTime
+
+	rows := buildRows(req, forecast.properties)
+	if req.sortProperty != "" {
+		sortRows(rows, indexOf(req.columns(), req.sortProperty), req.sortDescending)
+	}
+
+	return locationForecast{address: address, req: req, rows: rows}
+}
+
+func runMultiForecast(req forecastRequest) {
+	locations := getLocationForecasts(req)
+
+	for _, l := range locations {
+		if l.err != nil {
+			errorAndQuit(fmt.Errorf("could not get forecast for '%s': %w", l.address, l.err))
+		}
+	}
+
+	empty := true
+	for _, l := range locations {
+		if len(l.rows) > 0 {
+			empty = false
+		}
+	}
+
+	if empty {
+		fmt.Println("no hours match your criteria in the requested window")
+		quit(exitNoRows)
+	}
+
+	if req.layout == "compare" {
+		displayComparison(req, locations)
+		return
+	}
+
+	for i, l := range locations {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		fmt.Println(l.address)
+
+		if len(l.rows) == 0 {
+			fmt.Println("no hours match your criteria in the requested window")
+			continue
+		}
+
+		render(os.Stdout, l.req, l.rows)
+	}
+}
+
+// a column per address for the one property being shown, lined up by hour
+func displayComparison(req forecastRequest, locations []locationForecast) {
+	column := req.visibleColumns()[0]
+
+	fmt.Println(req.columns()[column])
+
+	byHour := map[int64][]string{}
+	hours := []time.Time{}
+
+	for i, l := range locations {
+		for _, r := range l.rows {
+			key := r.at.Unix()
+			if _, ok := byHour[key]; !ok {
+				byHour[key] = make([]string, len(locations))
+				hours = append(hours, r.at)
+			}
+
+			byHour[key][i] = r.values[column]
+		}
+	}
+
+	sort.Slice(hours, func(i, j int) bool { return hours[i].Before(hours[j]) })
+
+	header := []string{"time"}
+	for _, l := range locations {
+		header = append(header, l.address)
+	}
+
+	t := newTable(os.Stdout, getColumnWidths(header[1:]), header)
+
+	for _, at := range hours {
+		cells := append([]string{at.In(req.displayTimeZone).Format(time.Stamp)}, byHour[at.Unix()]...)
+		t.row(cells, nil)
+	}
+
+	t.end()
+}
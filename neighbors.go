@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the eight cells surrounding the given one, some of which may not exist if
+// we're on the edge of an office's grid
+func neighborGridPoints(center gridPoint) []gridPoint {
+	neighbors := []gridPoint{}
+
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+
+			x, y := center.x+dx, center.y+dy
+			if x < 0 || y < 0 {
+				continue
+			}
+
+			neighbors = append(neighbors, gridPoint{
+				office:              center.office,
+				x:                   x,
+				y:                   y,
+				forecastGridDataURL: fmt.Sprintf("https://api.weather.gov/gridpoints/%s/%d,%d", center.office, x, y),
+			})
+		}
+	}
+
+	return neighbors
+}
+
+// the forecasts for the cells around center, and which of them couldn't be
+// fetched
+func getNeighborWeatherData(ctx context.Context, center gridPoint, properties []string) ([]gridForecast, error) {
+	neighbors := neighborGridPoints(center)
+	results := make([]*gridForecast, len(neighbors))
+	errs := make([]error, len(neighbors))
+
+	var wg sync.WaitGroup
+
+	for i, n := range neighbors {
+		wg.Add(1)
+
+		go func(i int, n gridPoint) {
+			defer wg.Done()
+
+			forecast, err := getWeatherDataContext(ctx, n.forecastGridDataURL, properties)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s/%d,%d: %w", n.office, n.x, n.y, err)
+				return
+			}
+
+			results[i] = &forecast
+		}(i, n)
+	}
+
+	wg.Wait()
+
+	forecasts := []gridForecast{}
+	for _, r := range results {
+		if r != nil {
+			forecasts = append(forecasts, *r)
+		}
+	}
+
+	return forecasts, skipped("cells", errs)
+}
+
+// why whatever of a spread failed did, as a partial error if any of the
+// rest are left to show, or nil if none failed
+func skipped(what string, errs []error) error {
+	failed := []string{}
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	err := fmt.Errorf("skipped %d of %d %s: %s", len(failed), len(errs), what, strings.Join(failed, "; "))
+	if len(failed) == len(errs) {
+		return err
+	}
+
+	return partialError{err}
+}
+
+// shows the lowest and highest value of each property across the given cells,
+// so it's obvious when the location sits on a sharp gradient
+func displayNeighborSpread(req forecastRequest, forecasts []gridForecast, rows []displayRow) {
+	displaySpread(req, fmt.Sprintf("spread across %d grid cells", len(forecasts)), forecasts, rows)
+}
+
+func displaySpread(req forecastRequest, title string, forecasts []gridForecast, rows []displayRow) {
+	properties := req.visibleProperties()
+
+	header := append([]string{"time"}, properties...)
+	widths := getColumnWidths(properties)
+
+	fmt.Println()
+	fmt.Println(title)
+	t := newTable(os.Stdout, widths, header)
+
+	for _, r := range rows {
+		cells := append([]string{r.at.In(req.displayTimeZone).Format(time.Stamp)}, spreadCells(req, properties, forecasts, []time.Time{r.at})...)
+		t.row(cells, nil)
+	}
+
+	t.end()
+}
+
+// the fetched properties that aren't hidden, leaving out derived columns
+// since only the main cell has those
+func (req forecastRequest) visibleProperties() []string {
+	properties := []string{}
+	for _, p := range req.properties {
+		if indexOf(req.hidden, p) < 0 {
+			properties = append(properties, p)
+		}
+	}
+
+	return properties
+}
+
+// the lowest and highest value of each property across the given cells at
+// any of the given times
+func spreadCells(req forecastRequest, properties []string, forecasts []gridForecast, times []time.Time) []string {
+	values := []string{}
+
+	for _, property := range properties {
+		var lo, hi *float64
+		unit := ""
+
+		for _, f := range forecasts {
+			for _, at := range times {
+				p, ok := findPointAt(f.properties[property], at)
+				if !ok || p.Value == nil {
+					continue
+				}
+
+				p = req.liberate(p)
+
+				unit = displayUnit(p.Unit)
+
+				if lo == nil || *p.Value < *lo {
+					lo = p.Value
+				}
+
+				if hi == nil || *p.Value > *hi {
+					hi = p.Value
+				}
+			}
+		}
+
+		if lo == nil {
+			values = append(values, "No Data")
+			continue
+		}
+
+		precision := kindPrecision[propertyRegistry[property]]
+		spread := formatNumber(*lo, precision) + "-" + formatNumber(*hi, precision)
+
+		if unit == "%" {
+			values = append(values, spread+unit)
+		} else {
+			values = append(values, spread+" "+unit)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpreadCellsRequestUnits(t *testing.T) {
+	start := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+
+	cell := func(v float64) gridForecast {
+		return gridForecast{properties: map[string]series{"temperature": newSeries([]weatherPoint{
+			{StartTime: start, EndTime: start.Add(time.Hour), Value: &v, Unit: "wmoUnit:degC"},
+		})}}
+	}
+
+	forecasts := []gridForecast{cell(20), cell(25)}
+
+	defer func(s unitSystem) { activeUnitSystem = s }(activeUnitSystem)
+	activeUnitSystem = unitSystems["us"]
+
+	// a serve request in metric, while the command line's last was in us
+	metric := unitSystems["metric"]
+	req := forecastRequest{freedom: true, system: &metric}
+
+	if got := spreadCells(req, []string{"temperature"}, forecasts, []time.Time{start}); got[0] != "20-25 C" {
+		t.Errorf("metric request spread is %q", got[0])
+	}
+
+	req.system = nil
+
+	if got := spreadCells(req, []string{"temperature"}, forecasts, []time.Time{start}); got[0] != "68-77 F" {
+		t.Errorf("us spread is %q", got[0])
+	}
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type notifyConfig struct {
+	Rules []notifyRule `json:"rules,omitempty"`
+}
+
+// what to watch for and where, and how often it's allowed to bother you
+type notifyRule struct {
+	Name     string   `json:"name"`
+	Address  string   `json:"address"`
+	Severity string   `json:"severity,omitempty"`
+	Urgency  string   `json:"urgency,omitempty"`
+	Events   []string `json:"events,omitempty"`
+
+	// no more than one notification per cooldown, an hour if unset, except
+	// for alerts of at least quietSeverity
+	Cooldown string `json:"cooldown,omitempty"`
+
+	// e.g. "22:00-07:00", in the rule's time zone, during which only alerts
+	// of at least quietSeverity get through, "severe+" if unset
+	QuietHours    string `json:"quietHours,omitempty"`
+	QuietSeverity string `json:"quietSeverity,omitempty"`
+	TimeZone      string `json:"timeZone,omitempty"`
+
+	// where to send notifications, stdout if there are none
+	Transports []transportConfig `json:"transports,omitempty"`
+}
+
+const defaultNotifyCooldown = time.Hour
+
+// minutes after midnight, where an end before the start wraps past midnight
+type quietHours struct {
+	start, end int
+}
+
+func parseQuietHours(s string) (quietHours, error) {
+	split := strings.Split(s, "-")
+	if len(split) != 2 {
+		return quietHours{}, fmt.Errorf("quiet hours '%s' should look like '22:00-07:00'", s)
+	}
+
+	minutes := []int{}
+	for _, part := range split {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return quietHours{}, fmt.Errorf("quiet hours '%s' should look like '22:00-07:00': %w", s, err)
+		}
+
+		minutes = append(minutes, t.Hour()*60+t.Minute())
+	}
+
+	return quietHours{start: minutes[0], end: minutes[1]}, nil
+}
+
+func (q quietHours) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+
+	if q.start <= q.end {
+		return m >= q.start && m < q.end
+	}
+
+	return m >= q.start || m < q.end
+}
+
+// a rule as it runs, remembering what it's already said
+type ruleWatch struct {
+	rule        notifyRule
+	filter      alertsRequest
+	loc         *time.Location
+	cooldown    time.Duration
+	quiet       *quietHours
+	quietFilter levelFilter
+	notifiers   []notifier
+
+	lastSent time.Time
+	notified map[string]bool
+
+	// the events sent in the hour starting at sentHour, since an update to
+	// an alert comes with a new id
+	sentHour   time.Time
+	sentEvents map[string]bool
+}
+
+func newRuleWatch(r notifyRule) (*ruleWatch, error) {
+	w := &ruleWatch{
+		rule:     r,
+		filter:   alertsRequest{events: r.Events},
+		loc:      time.Local,
+		cooldown: defaultNotifyCooldown,
+		notified: map[string]bool{},
+	}
+
+	if r.Address == "" {
+		return nil, fmt.Errorf("rule '%s' has no address", r.Name)
+	}
+
+	if r.Severity != "" {
+		f, err := parseLevelFilter(r.Severity, severities, "severity")
+		if err != nil {
+			return nil, fmt.Errorf("rule '%s': %w", r.Name, err)
+		}
+
+		w.filter.severity = &f
+	}
+
+	if r.Urgency != "" {
+		f, err := parseLevelFilter(r.Urgency, urgencies, "urgency")
+		if err != nil {
+			return nil, fmt.Errorf("rule '%s': %w", r.Name, err)
+		}
+
+		w.filter.urgency = &f
+	}
+
+	if r.Cooldown != "" {
+		d, err := time.ParseDuration(r.Cooldown)
+		if err != nil {
+			return nil, fmt.Errorf("rule '%s' has a bad cooldown: %w", r.Name, err)
+		}
+
+		w.cooldown = d
+	}
+
+	if r.TimeZone != "" {
+		loc, err := time.LoadLocation(r.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("rule '%s' has a bad time zone: %w", r.Name, err)
+		}
+
+		w.loc = loc
+	}
+
+	if r.QuietHours != "" {
+		q, err := parseQuietHours(r.QuietHours)
+		if err != nil {
+			return nil, fmt.Errorf("rule '%s': %w", r.Name, err)
+		}
+
+		w.quiet = &q
+	}
+
+	quietSeverity := r.QuietSeverity
+	if quietSeverity == "" {
+		quietSeverity = "severe+"
+	}
+
+	f, err := parseLevelFilter(quietSeverity, severities, "quiet severity")
+	if err != nil {
+		return nil, fmt.Errorf("rule '%s': %w", r.Name, err)
+	}
+
+	w.quietFilter = f
+
+	for _, t := range r.Transports {
+		n, err := newNotifier(t)
+		if err != nil {
+			return nil, fmt.Errorf("rule '%s': %w", r.Name, err)
+		}
+
+		w.notifiers = append(w.notifiers, n)
+	}
+
+	if len(w.notifiers) == 0 {
+		w.notifiers = []notifier{stdoutNotifier{}}
+	}
+
+	return w, nil
+}
+
+// the alerts worth a notification right now. anything held back by quiet
+// hours, the cooldown or the same event having gone out this hour is still
+// pending, and goes out later if it's still active, and nothing counts as
+// notified until it's been sent, so nothing is lost, just batched. alerts
+// of at least the quiet severity skip the cooldown, so a warning isn't held
+// up behind an advisory.
+func (w *ruleWatch) due(alerts []alert, now time.Time) []alert {
+	active := map[string]bool{}
+	pending := []alert{}
+
+	cooledDown := now.Sub(w.lastSent) >= w.cooldown
+	quiet := w.quiet != nil && w.quiet.contains(now.In(w.loc))
+	thisHour := w.sentHour.Equal(now.Truncate(time.Hour))
+
+	for _, a := range alerts {
+		active[a.id] = true
+
+		if w.notified[a.id] || !w.filter.wants(a) {
+			continue
+		}
+
+		urgent := w.quietFilter.matches(a.severity)
+		if (quiet || !cooledDown) && !urgent {
+			continue
+		}
+
+		if thisHour && w.sentEvents[a.event] {
+			continue
+		}
+
+		pending = append(pending, a)
+	}
+
+	// nothing to dedup against once an alert is gone
+	for id := range w.notified {
+		if !active[id] {
+			delete(w.notified, id)
+		}
+	}
+
+	return pending
+}
+
+// marks alerts as notified, once at least one transport has taken them
+func (w *ruleWatch) sent(alerts []alert, now time.Time) {
+	if hour := now.Truncate(time.Hour); !w.sentHour.Equal(hour) {
+		w.sentHour = hour
+		w.sentEvents = map[string]bool{}
+	}
+
+	for _, a := range alerts {
+		w.notified[a.id] = true
+		w.sentEvents[a.event] = true
+	}
+
+	w.lastSent = now
+}
+
+func notifyMessage(alerts []alert, loc *time.Location) string {
+	lines := []string{}
+
+	for _, a := range alerts {
+		until := ""
+		if !a.ends.IsZero() {
+			until = " until " + a.ends.In(loc).Format("Mon 15:04")
+		}
+
+		lines = append(lines, fmt.Sprintf("%s (%s, %s)%s, %s", a.event, a.severity, a.urgency, until, a.areas))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func runNotify(flagset *flag.FlagSet) func() {
+	var (
+		only     stringList
+		interval time.Duration
+	)
+
+	flagset.Var(&only, "rule", "only run the rule with this name, may be repeated")
+	flagset.DurationVar(&interval, "interval", time.Minute, "how often to check, or longer if upstream asks")
+
+	return func() {
+		if interval < 10*time.Second {
+			errorAndQuit(fmt.Errorf("interval must be at least 10s to go easy on upstream, got %s", interval))
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		err = watchRules(cfg.Notify.Rules, only, interval)
+		if err != nil {
+			errorAndQuit(err)
+		}
+	}
+}
+
+// runs until something's wrong with the rules themselves, upstream trouble
+// is only reported
+func watchRules(rules []notifyRule, only []string, interval time.Duration) error {
+	watches := []*ruleWatch{}
+	for _, r := range rules {
+		if len(only) > 0 && !containsFold(only, r.Name) {
+			continue
+		}
+
+		w, err := newRuleWatch(r)
+		if err != nil {
+			return err
+		}
+
+		watches = append(watches, w)
+	}
+
+	if len(watches) == 0 {
+		path, _ := configPath()
+		return fmt.Errorf("no notify rules to run, add some under \"notify\" in %s", path)
+	}
+
+	// rules at the same address share one poll
+	pollers := map[string]*alertPoller{}
+	current := map[string][]alert{}
+
+	points := map[string]coordinates{}
+
+	for _, w := range watches {
+		c, ok := points[w.rule.Address]
+		if !ok {
+			var err error
+
+			c, err = getAddressCoordinates(w.rule.Address)
+			if err != nil {
+				return err
+			}
+
+			points[w.rule.Address] = c
+			pollers[w.rule.Address] = &alertPoller{url: activeAlertsURL(c), interval: interval}
+		}
+
+		w.filter.point = &c
+	}
+
+	fmt.Printf("watching %d rules, press ctrl-c to stop\n", len(watches))
+
+	for {
+		wait := time.Duration(0)
+
+		for address, p := range pollers {
+			alerts, changed, next, err := p.poll()
+			if err != nil {
+				fmt.Printf("[%s] could not check alerts at %s: %s\n", time.Now().Format("15:04:05"), address, err)
+			}
+
+			if changed {
+				current[address] = alerts
+			}
+
+			if next > wait {
+				wait = next
+			}
+		}
+
+		now := time.Now()
+
+		for _, w := range watches {
+			due := w.due(current[w.rule.Address], now)
+			if len(due) == 0 {
+				continue
+			}
+
+			n := newNotification(w.rule.Name, due, w.loc)
+
+			// one transport being down shouldn't keep the others quiet, and
+			// if they all are the alerts are still due next time
+			delivered := false
+			for _, notifier := range w.notifiers {
+				err := notifier.notify(n)
+				if err != nil {
+					fmt.Printf("[%s] could not notify for %s: %s\n", now.Format("15:04:05"), w.rule.Name, redact(err.Error()))
+					continue
+				}
+
+				delivered = true
+			}
+
+			if delivered {
+				w.sent(due, now)
+			}
+		}
+
+		time.Sleep(wait)
+	}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+
+	return false
+}
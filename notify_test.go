@@ -0,0 +1,168 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	for _, tc := range []struct {
+		hours string
+		at    string
+		quiet bool
+	}{
+		{"22:00-07:00", "21:59", false},
+		{"22:00-07:00", "22:00", true},
+		{"22:00-07:00", "23:30", true},
+		{"22:00-07:00", "00:00", true},
+		{"22:00-07:00", "06:59", true},
+		{"22:00-07:00", "07:00", false},
+		{"22:00-07:00", "12:00", false},
+		{"13:00-14:30", "12:59", false},
+		{"13:00-14:30", "13:00", true},
+		{"13:00-14:30", "14:29", true},
+		{"13:00-14:30", "14:30", false},
+		{" 22:00 - 07:00 ", "23:00", true},
+	} {
+		q, err := parseQuietHours(tc.hours)
+		if err != nil {
+			t.Errorf("%s: %s", tc.hours, err)
+			continue
+		}
+
+		at, err := time.Parse("15:04", tc.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := q.contains(at); got != tc.quiet {
+			t.Errorf("%s at %s: quiet %t, wanted %t", tc.hours, tc.at, got, tc.quiet)
+		}
+	}
+
+	for _, bad := range []string{"", "22:00", "22:00-07:00-08:00", "10pm-7am", "25:00-07:00"} {
+		_, err := parseQuietHours(bad)
+		if err == nil {
+			t.Errorf("'%s' parsed", bad)
+		}
+	}
+}
+
+func testAlert(id, event, severity string) alert {
+	return alert{id: id, event: event, severity: severity, urgency: "Expected"}
+}
+
+func alertIDs(alerts []alert) []string {
+	ids := []string{}
+	for _, a := range alerts {
+		ids = append(ids, a.id)
+	}
+
+	return ids
+}
+
+func TestRuleWatchDue(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	advisory := testAlert("a1", "Wind Advisory", "Minor")
+	advisoryUpdate := testAlert("a2", "Wind Advisory", "Minor")
+	watch := testAlert("w1", "Flood Watch", "Moderate")
+	warning := testAlert("t1", "Tornado Warning", "Extreme")
+
+	type step struct {
+		at     time.Duration
+		alerts []alert
+		sent   bool
+		want   []string
+	}
+
+	for _, tc := range []struct {
+		name  string
+		rule  notifyRule
+		steps []step
+	}{
+		{
+			name: "an alert already sent isn't sent again",
+			rule: notifyRule{Name: "r", Address: "x"},
+			steps: []step{
+				{0, []alert{advisory}, true, []string{"a1"}},
+				{2 * time.Hour, []alert{advisory}, true, []string{}},
+			},
+		},
+		{
+			name: "the cooldown holds back a new moderate alert",
+			rule: notifyRule{Name: "r", Address: "x"},
+			steps: []step{
+				{0, []alert{advisory}, true, []string{"a1"}},
+				{10 * time.Minute, []alert{advisory, watch}, true, []string{}},
+				{61 * time.Minute, []alert{advisory, watch}, true, []string{"w1"}},
+			},
+		},
+		{
+			name: "a severe alert skips the cooldown",
+			rule: notifyRule{Name: "r", Address: "x"},
+			steps: []step{
+				{0, []alert{advisory}, true, []string{"a1"}},
+				{10 * time.Minute, []alert{advisory, watch, warning}, true, []string{"t1"}},
+			},
+		},
+		{
+			name: "an update to an event sent this hour waits for the next",
+			rule: notifyRule{Name: "r", Address: "x", Cooldown: "1m"},
+			steps: []step{
+				{0, []alert{advisory}, true, []string{"a1"}},
+				{30 * time.Minute, []alert{advisoryUpdate}, true, []string{}},
+				{time.Hour, []alert{advisoryUpdate}, true, []string{"a2"}},
+			},
+		},
+		{
+			name: "nothing is marked when every transport fails",
+			rule: notifyRule{Name: "r", Address: "x"},
+			steps: []step{
+				{0, []alert{advisory}, false, []string{"a1"}},
+				{time.Minute, []alert{advisory}, true, []string{"a1"}},
+			},
+		},
+		{
+			name: "quiet hours only let severe alerts through",
+			rule: notifyRule{Name: "r", Address: "x", QuietHours: "11:00-13:00"},
+			steps: []step{
+				{0, []alert{advisory}, true, []string{}},
+				{time.Minute, []alert{advisory, warning}, true, []string{"t1"}},
+				{62 * time.Minute, []alert{advisory, warning}, true, []string{"a1"}},
+			},
+		},
+		{
+			name: "a gone alert is forgotten",
+			rule: notifyRule{Name: "r", Address: "x", Cooldown: "1m"},
+			steps: []step{
+				{0, []alert{watch}, true, []string{"w1"}},
+				{2 * time.Hour, []alert{}, true, []string{}},
+				{3 * time.Hour, []alert{watch}, true, []string{"w1"}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := newRuleWatch(tc.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w.loc = time.UTC
+
+			for i, s := range tc.steps {
+				now := start.Add(s.at)
+
+				due := w.due(s.alerts, now)
+				if got := alertIDs(due); strings.Join(got, ",") != strings.Join(s.want, ",") {
+					t.Fatalf("step %d: due %v, wanted %v", i, got, s.want)
+				}
+
+				if s.sent && len(due) > 0 {
+					w.sent(due, now)
+				}
+			}
+		})
+	}
+}
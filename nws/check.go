@@ -0,0 +1,251 @@
+package nws
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/packrat386/agwc/units"
+)
+
+// WarningKind is what sort of surprise a Warning is about.
+type WarningKind string
+
+const (
+	// MissingLayer is a layer that was asked for and isn't there.
+	MissingLayer WarningKind = "missing layer"
+
+	// MissingUnit is a layer without a uom.
+	MissingUnit WarningKind = "missing unit"
+
+	// UnknownUnit is a uom the units package can't parse, so its values
+	// can't be converted.
+	UnknownUnit WarningKind = "unknown unit"
+
+	// UnknownField is a field nothing reads, at the top of the response,
+	// in its properties, in a layer or in one of a layer's values. Any
+	// property with values or a uom is taken to be a layer, not an unknown
+	// field.
+	UnknownField WarningKind = "unknown field"
+
+	// MissingField is a field decoding expects, like updateTime.
+	MissingField WarningKind = "missing field"
+
+	// BadValue is a field that isn't the type it should be, like a value
+	// that isn't a number.
+	BadValue WarningKind = "bad value"
+
+	// BadTime is a validTime ParseValidTime can't read.
+	BadTime WarningKind = "bad time"
+
+	// NullValue is a value with no forecast in it.
+	NullValue WarningKind = "null value"
+
+	// Overlap is a value that starts before the one before it ends, so
+	// some hours have two forecasts.
+	Overlap WarningKind = "overlap"
+)
+
+// Warning is something about a gridpoint response that Decode gets past,
+// or without Strict wouldn't mention, but that says upstream has changed
+// or is having trouble.
+type Warning struct {
+	Kind WarningKind `json:"kind"`
+
+	// Layer is the layer it's in, or "" for the response as a whole.
+	Layer string `json:"layer,omitempty"`
+
+	// Start is when the value it's about starts, or zero if it isn't about
+	// a value.
+	Start time.Time `json:"start"`
+
+	Detail string `json:"detail"`
+}
+
+func (w Warning) String() string {
+	s := string(w.Kind)
+	if w.Layer != "" {
+		s += " in " + w.Layer
+	}
+
+	if !w.Start.IsZero() {
+		s += " at " + w.Start.Format(time.RFC3339)
+	}
+
+	return s + ": " + w.Detail
+}
+
+// the fields of the response, its properties other than layers, a layer
+// and its values that gridpoint responses have, whether Decode reads them
+// or not
+var (
+	knownTopFields      = map[string]bool{"@context": true, "id": true, "type": true, "geometry": true, "properties": true}
+	knownPropertyFields = map[string]bool{"@id": true, "@type": true, "updateTime": true, "validTimes": true, "elevation": true, "forecastOffice": true, "gridId": true, "gridX": true, "gridY": true}
+	knownLayerFields    = map[string]bool{"uom": true, "values": true}
+	knownValueFields    = map[string]bool{"validTime": true, "value": true}
+)
+
+// Check reads a gridpoint response the way Decode would, but lists what's
+// surprising about it instead of stopping at the first problem or getting
+// past it quietly, for keeping an eye on upstream. It checks Layers, or
+// every layer with a unit if there are none. Only a body that isn't a JSON
+// object is an error.
+func (d GridDecoder) Check(r io.Reader) ([]Warning, error) {
+	top := map[string]json.RawMessage{}
+
+	err := json.NewDecoder(r).Decode(&top)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	warnings := []Warning{}
+	warn := func(kind WarningKind, layer string, start time.Time, format string, args ...interface{}) {
+		warnings = append(warnings, Warning{Kind: kind, Layer: layer, Start: start, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	for _, f := range sortedKeys(top) {
+		if !knownTopFields[f] {
+			warn(UnknownField, "", time.Time{}, "'%s'", f)
+		}
+	}
+
+	if top["geometry"] == nil {
+		warn(MissingField, "", time.Time{}, "no geometry, so no grid cell")
+	}
+
+	properties := map[string]json.RawMessage{}
+	if top["properties"] == nil {
+		warn(MissingField, "", time.Time{}, "no properties, so no forecast")
+	} else if json.Unmarshal(top["properties"], &properties) != nil {
+		warn(BadValue, "", time.Time{}, "properties is not an object")
+	}
+
+	for _, f := range sortedKeys(properties) {
+		fields := map[string]json.RawMessage{}
+		if knownPropertyFields[f] || json.Unmarshal(properties[f], &fields) == nil && (fields["values"] != nil || fields["uom"] != nil) {
+			continue
+		}
+
+		warn(UnknownField, "", time.Time{}, "'%s' in properties", f)
+	}
+
+	if raw, ok := properties["updateTime"]; !ok {
+		warn(MissingField, "", time.Time{}, "no updateTime, so no way to tell how old the forecast is")
+	} else {
+		var t time.Time
+		if json.Unmarshal(raw, &t) != nil {
+			warn(BadValue, "", time.Time{}, "updateTime %s is not a time", raw)
+		}
+	}
+
+	layers := d.Layers
+	if len(layers) == 0 {
+		for name, raw := range properties {
+			fields := map[string]json.RawMessage{}
+			if json.Unmarshal(raw, &fields) == nil && fields["uom"] != nil {
+				layers = append(layers, name)
+			}
+		}
+
+		sort.Strings(layers)
+	}
+
+	for _, name := range layers {
+		raw, ok := properties[name]
+		if !ok {
+			warn(MissingLayer, name, time.Time{}, "not in the response")
+			continue
+		}
+
+		fields := map[string]json.RawMessage{}
+		if json.Unmarshal(raw, &fields) != nil {
+			warn(BadValue, name, time.Time{}, "not an object")
+			continue
+		}
+
+		for _, f := range sortedKeys(fields) {
+			if !knownLayerFields[f] {
+				warn(UnknownField, name, time.Time{}, "'%s'", f)
+			}
+		}
+
+		var uom string
+		if fields["uom"] == nil || json.Unmarshal(fields["uom"], &uom) != nil || uom == "" {
+			warn(MissingUnit, name, time.Time{}, "no uom, so its values can't be converted")
+		} else if _, err := units.Parse(uom); err != nil {
+			warn(UnknownUnit, name, time.Time{}, "%s", err.Error())
+		}
+
+		if fields["values"] == nil {
+			warn(MissingField, name, time.Time{}, "no values")
+			continue
+		}
+
+		values := []map[string]json.RawMessage{}
+		if json.Unmarshal(fields["values"], &values) != nil {
+			warn(BadValue, name, time.Time{}, "values is not a list of objects")
+			continue
+		}
+
+		spans := []Value{}
+		for i, v := range values {
+			for _, f := range sortedKeys(v) {
+				if !knownValueFields[f] {
+					warn(UnknownField, name, time.Time{}, "'%s' in value %d", f, i)
+				}
+			}
+
+			var validTime string
+			if json.Unmarshal(v["validTime"], &validTime) != nil {
+				warn(BadTime, name, time.Time{}, "value %d has no validTime", i)
+				continue
+			}
+
+			start, end, err := ParseValidTime(validTime)
+			if err != nil {
+				warn(BadTime, name, time.Time{}, "value %d: %s", i, err.Error())
+				continue
+			}
+
+			if v["value"] == nil {
+				warn(MissingField, name, start, "no value")
+				continue
+			}
+
+			var n *float64
+			if json.Unmarshal(v["value"], &n) != nil {
+				warn(BadValue, name, start, "%s is not a number", v["value"])
+				continue
+			}
+
+			if n == nil {
+				warn(NullValue, name, start, "no forecast until %s", end.Format(time.RFC3339))
+			}
+
+			spans = append(spans, Value{Start: start, End: end, Value: n})
+		}
+
+		sort.SliceStable(spans, func(i, j int) bool { return spans[i].Start.Before(spans[j].Start) })
+
+		for i := 1; i < len(spans); i++ {
+			if spans[i].Start.Before(spans[i-1].End) {
+				warn(Overlap, name, spans[i].Start, "starts before the value from %s ends at %s", spans[i-1].Start.Format(time.RFC3339), spans[i-1].End.Format(time.RFC3339))
+			}
+		}
+	}
+
+	return warnings, nil
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
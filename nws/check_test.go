@@ -0,0 +1,149 @@
+package nws
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	// a response with nothing surprising in it, but for the layer each
+	// case puts in
+	wrap := func(layer string) string {
+		return `{"@context": [], "id": "x", "type": "Feature", "geometry": {},
+			"properties": {"updateTime": "2024-05-01T06:00:00+00:00", "temperature": ` + layer + `}}`
+	}
+
+	for _, tc := range []struct {
+		name   string
+		layers []string
+		body   string
+		kind   WarningKind
+		want   string
+	}{
+		{
+			name: "unknown uom",
+			body: wrap(`{"uom": "wmoUnit:furlong", "values": []}`),
+			kind: UnknownUnit,
+			want: "unknown unit in temperature",
+		},
+		{
+			name:   "missing uom",
+			layers: []string{"temperature"},
+			body:   wrap(`{"values": []}`),
+			kind:   MissingUnit,
+			want:   "missing unit in temperature",
+		},
+		{
+			name: "overlap",
+			body: wrap(`{"uom": "wmoUnit:degC", "values": [
+				{"validTime": "2024-05-01T06:00:00+00:00/PT3H", "value": 20},
+				{"validTime": "2024-05-01T08:00:00+00:00/PT1H", "value": 21}
+			]}`),
+			kind: Overlap,
+			want: "overlap in temperature at 2024-05-01T08:00:00Z: starts before the value from 2024-05-01T06:00:00Z ends at 2024-05-01T09:00:00Z",
+		},
+		{
+			name: "null",
+			body: wrap(`{"uom": "wmoUnit:degC", "values": [{"validTime": "2024-05-01T06:00:00+00:00/PT1H", "value": null}]}`),
+			kind: NullValue,
+			want: "null value in temperature at 2024-05-01T06:00:00Z: no forecast until 2024-05-01T07:00:00Z",
+		},
+		{
+			name: "missing values",
+			body: wrap(`{"uom": "wmoUnit:degC"}`),
+			kind: MissingField,
+			want: "missing field in temperature: no values",
+		},
+		{
+			name: "values not a list",
+			body: wrap(`{"uom": "wmoUnit:degC", "values": 20}`),
+			kind: BadValue,
+			want: "bad value in temperature: values is not a list of objects",
+		},
+		{
+			name: "bad validTime",
+			body: wrap(`{"uom": "wmoUnit:degC", "values": [{"validTime": "2024-05-01T06:00:00+00:00/PT1Q", "value": 20}]}`),
+			kind: BadTime,
+			want: "bad time in temperature: value 0:",
+		},
+		{
+			name: "value not a number",
+			body: wrap(`{"uom": "wmoUnit:degC", "values": [{"validTime": "2024-05-01T06:00:00+00:00/PT1H", "value": "warm"}]}`),
+			kind: BadValue,
+			want: `bad value in temperature at 2024-05-01T06:00:00Z: "warm" is not a number`,
+		},
+		{
+			name: "unknown field in a value",
+			body: wrap(`{"uom": "wmoUnit:degC", "values": [{"validTime": "2024-05-01T06:00:00+00:00/PT1H", "value": 20, "confidence": 0.9}]}`),
+			kind: UnknownField,
+			want: "unknown field in temperature: 'confidence' in value 0",
+		},
+		{
+			name: "unknown field in a layer",
+			body: wrap(`{"uom": "wmoUnit:degC", "values": [], "source": "nbm"}`),
+			kind: UnknownField,
+			want: "unknown field in temperature: 'source'",
+		},
+		{
+			name: "unknown field in properties",
+			body: `{"geometry": {}, "properties": {"updateTime": "2024-05-01T06:00:00+00:00", "model": "nbm"}}`,
+			kind: UnknownField,
+			want: "unknown field: 'model' in properties",
+		},
+		{
+			name: "unknown field at the top",
+			body: `{"geometry": {}, "properties": {"updateTime": "2024-05-01T06:00:00+00:00"}, "generatedAt": "now"}`,
+			kind: UnknownField,
+			want: "unknown field: 'generatedAt'",
+		},
+		{
+			name: "missing updateTime",
+			body: `{"geometry": {}, "properties": {}}`,
+			kind: MissingField,
+			want: "missing field: no updateTime",
+		},
+		{
+			name:   "missing layer",
+			layers: []string{"temperature", "dewpoint"},
+			body:   wrap(`{"uom": "wmoUnit:degC", "values": []}`),
+			kind:   MissingLayer,
+			want:   "missing layer in dewpoint: not in the response",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			warnings, err := GridDecoder{Layers: tc.layers}.Check(strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(warnings) != 1 {
+				t.Fatalf("wanted one warning, got %v", warnings)
+			}
+
+			if warnings[0].Kind != tc.kind || !strings.HasPrefix(warnings[0].String(), tc.want) {
+				t.Errorf("wanted %s '%s...', got %s '%s'", tc.kind, tc.want, warnings[0].Kind, warnings[0])
+			}
+		})
+	}
+}
+
+func TestCheckClean(t *testing.T) {
+	warnings, err := GridDecoder{}.Check(strings.NewReader(`{"@context": [], "id": "x", "type": "Feature", "geometry": {},
+		"properties": {"@id": "x", "@type": "wx:Gridpoint", "updateTime": "2024-05-01T06:00:00+00:00", "gridId": "LOT",
+			"weather": {"values": [{"validTime": "2024-05-01T06:00:00+00:00/PT1H", "value": [{"weather": "rain"}]}]},
+			"temperature": {"uom": "wmoUnit:degC", "values": [{"validTime": "2024-05-01T06:00:00+00:00/PT1H", "value": 20}]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(warnings) != 0 {
+		t.Errorf("wanted no warnings, got %v", warnings)
+	}
+}
+
+func TestCheckNotAnObject(t *testing.T) {
+	_, err := GridDecoder{}.Check(strings.NewReader(`[]`))
+	if err == nil {
+		t.Errorf("a list checked without an error")
+	}
+}
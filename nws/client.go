@@ -0,0 +1,93 @@
+// Package nws reads the parts of api.weather.gov that agwc is built on:
+// which forecast grid cell a point falls in, and the gridded forecast for
+// that cell, along with the ISO 8601 intervals its values are given for.
+//
+// The API asks every caller to identify itself with a User-Agent and turns
+// away requests that don't with a 403 now and then, so a Client won't send
+// anything without one.
+package nws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/packrat386/agwc/internal/retry"
+)
+
+// DefaultBaseURL is where the API lives.
+const DefaultBaseURL = "https://api.weather.gov"
+
+// ErrNoUserAgent is returned by every request from a Client without a
+// UserAgent.
+var ErrNoUserAgent = errors.New("nws: a User-Agent is required, like 'myapp (me@example.com)'")
+
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// Client talks to the API. The zero value is usable once UserAgent is set,
+// though it won't retry anything.
+type Client struct {
+	// HTTPClient sends the requests, or one with a 30 second timeout if
+	// nil.
+	HTTPClient *http.Client
+
+	// UserAgent identifies the caller to NWS, and is required.
+	UserAgent string
+
+	// BaseURL is DefaultBaseURL if empty.
+	BaseURL string
+
+	// Retries is how many more times to try after a 5xx or 429, waiting
+	// Backoff before the first retry and twice as long before each after,
+	// or longer if upstream says to with Retry-After.
+	Retries int
+	Backoff time.Duration
+
+	// Strict makes unknown fields in gridpoint layers, and layers without
+	// a unit, errors rather than something to carry on past.
+	Strict bool
+}
+
+// NewClient is a Client that retries three times starting a second apart.
+func NewClient(userAgent string) *Client {
+	return &Client{UserAgent: userAgent, Retries: 3, Backoff: time.Second}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+
+	return defaultHTTPClient
+}
+
+func (c *Client) baseURL() string {
+	if c.BaseURL != "" {
+		return c.BaseURL
+	}
+
+	return DefaultBaseURL
+}
+
+// Get sends a GET for any API URL with the client's User-Agent and
+// retries, and any headers given, like If-None-Match for a conditional
+// request. The caller closes the body.
+func (c *Client) Get(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	return c.get(ctx, c.httpClient(), url, header)
+}
+
+func (c *Client) get(ctx context.Context, client *http.Client, url string, header http.Header) (*http.Response, error) {
+	if c.UserAgent == "" {
+		return nil, ErrNoUserAgent
+	}
+
+	h := http.Header{}
+	for name, values := range header {
+		h[name] = values
+	}
+
+	h.Set("User-Agent", c.UserAgent)
+
+	return retry.Get(ctx, client, url, h, retry.Policy{Retries: c.Retries, Backoff: c.Backoff})
+}
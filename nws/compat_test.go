@@ -0,0 +1,77 @@
+package nws_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/packrat386/agwc/nws"
+)
+
+// nws's v1 API, including the Client fields, since callers set those
+// directly rather than through options.
+var (
+	_ string = nws.DefaultBaseURL
+	_ error  = nws.ErrNoUserAgent
+
+	_ func(string) *nws.Client                                                        = nws.NewClient
+	_ func(*nws.Client, context.Context, string, http.Header) (*http.Response, error) = (*nws.Client).Get
+	_ func(*nws.Client, context.Context, string, []string) (nws.Grid, error)          = (*nws.Client).Grid
+	_ func(*nws.Client, context.Context, string, string) ([]nws.Period, error)        = (*nws.Client).Periods
+	_ func(*nws.Client, context.Context, float64, float64) (nws.Point, error)         = (*nws.Client).Point
+
+	_ func(string) (nws.Duration, error)                      = nws.ParseDuration
+	_ func(nws.Duration, time.Time) time.Time                 = nws.Duration.AddTo
+	_ func(string) (time.Time, time.Time, error)              = nws.ParseValidTime
+	_ func(string) error                                      = nws.ValidateGridDataURL
+	_ func(io.Reader) ([]nws.Period, error)                   = nws.ParsePeriods
+	_ func(io.Reader) (nws.Point, error)                      = nws.ParsePoint
+	_ func(nws.GridDecoder, io.Reader) (nws.Grid, error)      = nws.GridDecoder.Decode
+	_ func(nws.GridDecoder, io.Reader) ([]nws.Warning, error) = nws.GridDecoder.Check
+	_ func(nws.Warning) string                                = nws.Warning.String
+
+	_ = nws.Client{HTTPClient: &http.Client{}, UserAgent: "", BaseURL: "", Retries: 0, Backoff: time.Second, Strict: false}
+	_ = nws.Duration{Years: 0, Months: 0, Days: 0, Hours: 0, Minutes: 0, Seconds: 0}
+	_ = nws.Grid{UpdateTime: time.Time{}, Cell: [][2]float64{}, Elevation: (*float64)(nil), Layers: map[string]nws.Layer{}}
+	_ = nws.GridDecoder{Layers: []string{}, Strict: false}
+	_ = nws.Layer{Unit: "", Values: []nws.Value{}}
+	_ = nws.Value{Start: time.Time{}, End: time.Time{}, Value: (*float64)(nil)}
+	_ = nws.Point{GridID: "", GridX: 0, GridY: 0, ForecastGridData: "", ObservationStations: "", RadarStation: "", Forecast: "", ForecastHourly: "", ForecastZone: ""}
+	_ = nws.Warning{Kind: nws.MissingLayer, Layer: "", Start: time.Time{}, Detail: ""}
+
+	_ = nws.Period{
+		Number: 0, Name: "", Start: time.Time{}, End: time.Time{}, IsDaytime: false,
+		Temperature: (*float64)(nil), TemperatureUnit: "", ProbabilityOfPrecipitation: (*float64)(nil),
+		WindSpeed: "", WindDirection: "", ShortForecast: "", DetailedForecast: "",
+	}
+
+	_ = []nws.WarningKind{nws.MissingLayer, nws.MissingUnit, nws.UnknownUnit, nws.UnknownField, nws.MissingField, nws.BadValue, nws.BadTime, nws.NullValue, nws.Overlap}
+)
+
+func TestDurationsAndNoUserAgent(t *testing.T) {
+	d, err := nws.ParseDuration("P1DT12H")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	if got := d.AddTo(start); !got.Equal(start.Add(36 * time.Hour)) {
+		t.Errorf("P1DT12H from %s is %s", start, got)
+	}
+
+	from, to, err := nws.ParseValidTime("2024-05-01T06:00:00+00:00/PT3H")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !from.Equal(start) || !to.Equal(start.Add(3*time.Hour)) {
+		t.Errorf("validTime parsed to %s/%s", from, to)
+	}
+
+	_, err = nws.NewClient("").Point(context.Background(), 41.8781, -87.6298)
+	if err != nws.ErrNoUserAgent {
+		t.Errorf("a client without a User-Agent sent a request, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package nws
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is an ISO 8601 duration like "P1DT12H". Years, months, and days
+// are kept apart from the rest because how long they are depends on when
+// they start.
+type Duration struct {
+	Years   int64
+	Months  int64
+	Days    int64
+	Hours   int64
+	Minutes int64
+	Seconds int64
+}
+
+var durationMatcher = regexp.MustCompile(`^P((?P<numYears>\d+)Y)?((?P<numMonths>\d+)M)?((?P<numDays>\d+)D)?(T((?P<numHours>\d+)H)?((?P<numMinutes>\d+)M)?((?P<numSeconds>\d+)S)?)?$|^P(?P<numWeeks>\d+)W$`)
+
+// ParseDuration reads an ISO 8601 duration, including the "P2W" form for
+// weeks.
+func ParseDuration(s string) (Duration, error) {
+	matches := durationMatcher.FindStringSubmatch(s)
+
+	if len(matches) == 0 {
+		return Duration{}, fmt.Errorf("'%s' is not a valid iso8601 duration", s)
+	}
+
+	field := func(name string) string {
+		return matches[durationMatcher.SubexpIndex(name)]
+	}
+
+	if weekstr := field("numWeeks"); weekstr != "" {
+		weeks, err := strconv.ParseInt(weekstr, 10, 64)
+		if err != nil {
+			return Duration{}, fmt.Errorf("could not parse week value '%s' to int: %w", weekstr, err)
+		}
+
+		return Duration{Days: 7 * weeks}, nil
+	}
+
+	d := Duration{}
+
+	for _, f := range []struct {
+		name string
+		unit string
+		into *int64
+	}{
+		{"numYears", "year", &d.Years},
+		{"numMonths", "month", &d.Months},
+		{"numDays", "day", &d.Days},
+		{"numHours", "hour", &d.Hours},
+		{"numMinutes", "minute", &d.Minutes},
+		{"numSeconds", "second", &d.Seconds},
+	} {
+		s := field(f.name)
+		if s == "" {
+			continue
+		}
+
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return Duration{}, fmt.Errorf("could not parse %s value '%s' to int: %w", f.unit, s, err)
+		}
+
+		*f.into = v
+	}
+
+	return d, nil
+}
+
+// AddTo is t plus the duration, calendar parts first.
+func (d Duration) AddTo(t time.Time) time.Time {
+	return t.AddDate(int(d.Years), int(d.Months), int(d.Days)).Add(time.Duration(d.Hours) * time.Hour).Add(time.Duration(d.Minutes) * time.Minute).Add(time.Duration(d.Seconds) * time.Second)
+}
+
+// ParseValidTime reads the "start/duration" intervals gridpoint values are
+// given for, like "2024-05-01T06:00:00+00:00/PT3H", into when the value
+// starts and ends.
+func ParseValidTime(validTime string) (time.Time, time.Time, error) {
+	split := strings.Split(validTime, "/")
+
+	if len(split) != 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("malformed time + duration: %s", validTime)
+	}
+
+	start, err := parseTimestamp(split[0])
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse time '%s': %w", split[0], err)
+	}
+
+	dur, err := ParseDuration(split[1])
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("could not parse duration '%s' : %w", split[1], err)
+	}
+
+	end := dur.AddTo(start)
+
+	// absurdly long durations overflow and wrap around
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("duration '%s' is too long", split[1])
+	}
+
+	return start, end, nil
+}
+
+// RFC 3339 allows a leap second, like 23:59:60, which Go's time has no
+// room for, so it's read as the first second after
+func parseTimestamp(s string) (time.Time, error) {
+	if len(s) > 19 && s[16:19] == ":60" {
+		t, err := time.Parse(time.RFC3339, s[:16]+":59"+s[19:])
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return t.Add(time.Second), nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,45 @@
+package nws
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseValidTimeLeapSecond(t *testing.T) {
+	for _, tc := range []struct {
+		validTime string
+		start     time.Time
+		end       time.Time
+	}{
+		{
+			validTime: "2016-12-31T23:59:60Z/PT1H",
+			start:     time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2017, 1, 1, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			validTime: "2016-12-31T18:59:60-05:00/PT30M",
+			start:     time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2017, 1, 1, 0, 30, 0, 0, time.UTC),
+		},
+		{
+			validTime: "2024-05-01T06:30:00+00:00/PT30M",
+			start:     time.Date(2024, 5, 1, 6, 30, 0, 0, time.UTC),
+			end:       time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC),
+		},
+	} {
+		start, end, err := ParseValidTime(tc.validTime)
+		if err != nil {
+			t.Errorf("%s: %s", tc.validTime, err)
+			continue
+		}
+
+		if !start.Equal(tc.start) || !end.Equal(tc.end) {
+			t.Errorf("%s parsed to %s/%s, wanted %s/%s", tc.validTime, start, end, tc.start, tc.end)
+		}
+	}
+
+	_, err := parseTimestamp("2016-12-31T23:59:61Z")
+	if err == nil {
+		t.Errorf("a 61st second parsed")
+	}
+}
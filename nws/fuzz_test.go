@@ -0,0 +1,47 @@
+package nws
+
+import "testing"
+
+func FuzzISO8601Duration(f *testing.F) {
+	for _, seed := range []string{"PT1H", "P1D", "P7DT1H", "P1Y2M3DT4H5M6S", "P2W", "PT", "P", "", "PT-1H", "P99999999999999999999D"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		d, err := ParseDuration(s)
+		if err != nil {
+			return
+		}
+
+		for _, v := range []int64{d.Years, d.Months, d.Days, d.Hours, d.Minutes, d.Seconds} {
+			if v < 0 {
+				t.Fatalf("'%s' parsed to a negative duration %+v", s, d)
+			}
+		}
+	})
+}
+
+func FuzzTimeRange(f *testing.F) {
+	for _, seed := range []string{
+		"2024-05-01T06:00:00+00:00/PT3H",
+		"2024-05-01T06:00:00-05:00/P1DT12H",
+		"2024-05-01T06:00:00+00:00/P2W",
+		"2024-05-01T06:00:00+00:00",
+		"/PT1H",
+		"2024-05-01T06:00:00+00:00/PT1H/PT1H",
+		"2024-05-01T06:00:00+00:00/P9223372036854775807Y",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		start, end, err := ParseValidTime(s)
+		if err != nil {
+			return
+		}
+
+		if end.Before(start) {
+			t.Fatalf("'%s' ends at %s before it starts at %s", s, end, start)
+		}
+	})
+}
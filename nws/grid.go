@@ -0,0 +1,279 @@
+package nws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Grid is the gridded forecast for one cell, with only the layers that were
+// asked for.
+type Grid struct {
+	// UpdateTime is when NWS last updated the forecast, and zero if the
+	// response didn't say.
+	UpdateTime time.Time
+
+	// Cell is the corners of the grid cell as [longitude, latitude], the
+	// way GeoJSON has them, without the first corner repeated at the end.
+	Cell [][2]float64
+
+	// Elevation is the grid cell's average height in meters, which is what
+	// its temperatures are for, and nil if the response didn't say.
+	Elevation *float64
+
+	Layers map[string]Layer
+}
+
+// Layer is one forecast property, like "temperature".
+type Layer struct {
+	// Unit is the WMO unit code, like "wmoUnit:degC".
+	Unit string
+
+	// Values are in order of when they start.
+	Values []Value
+}
+
+// Value is what's forecast for a span of time, where a nil Value means
+// there's no forecast for it.
+type Value struct {
+	Start time.Time
+	End   time.Time
+	Value *float64
+}
+
+// GridDecoder reads gridpoint responses, keeping only Layers.
+type GridDecoder struct {
+	Layers []string
+
+	// Strict makes unknown fields in layers, and layers without a unit,
+	// errors.
+	Strict bool
+}
+
+// Grid fetches and decodes the gridded forecast at a Point's
+// ForecastGridData URL.
+func (c *Client) Grid(ctx context.Context, forecastGridDataURL string, layers []string) (Grid, error) {
+	res, err := c.Get(ctx, forecastGridDataURL, nil)
+	if err != nil {
+		return Grid{}, err
+	}
+
+	defer res.Body.Close()
+
+	return GridDecoder{Layers: layers, Strict: c.Strict}.Decode(res.Body)
+}
+
+// Decode reads a gridpoint response body.
+func (d GridDecoder) Decode(r io.Reader) (Grid, error) {
+	body, err := d.scan(r)
+	if err != nil {
+		return Grid{}, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	g := Grid{Cell: [][2]float64{}, Layers: map[string]Layer{}}
+
+	// GeoJSON polygons are lists of rings where the last point repeats the
+	// first, and the grid cell only ever has the outer ring
+	if body.Geometry.Type == "Polygon" && len(body.Geometry.Coordinates) > 0 {
+		ring := body.Geometry.Coordinates[0]
+		if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+			ring = ring[:len(ring)-1]
+		}
+
+		g.Cell = append(g.Cell, ring...)
+	}
+
+	if raw, ok := body.Properties["updateTime"]; ok {
+		err = json.Unmarshal(raw, &g.UpdateTime)
+		if err != nil {
+			return Grid{}, fmt.Errorf("could not parse update time: %w", err)
+		}
+	}
+
+	if raw, ok := body.Properties["elevation"]; ok {
+		elevation := struct {
+			UnitCode string   `json:"unitCode"`
+			Value    *float64 `json:"value"`
+		}{}
+
+		err = json.Unmarshal(raw, &elevation)
+		if err != nil {
+			return Grid{}, fmt.Errorf("could not parse elevation: %w", err)
+		}
+
+		if elevation.UnitCode == "wmoUnit:m" {
+			g.Elevation = elevation.Value
+		}
+	}
+
+	for _, name := range d.Layers {
+		raw := struct {
+			UnitOfMeasurement string `json:"uom"`
+			Values            []struct {
+				ValidTime string   `json:"validTime"`
+				Value     *float64 `json:"value"`
+			} `json:"values"`
+		}{}
+
+		data := body.Properties[name]
+		if data == nil {
+			return Grid{}, fmt.Errorf("no data for requested property: %s", name)
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(data))
+		if d.Strict {
+			dec.DisallowUnknownFields()
+		}
+
+		err := dec.Decode(&raw)
+		if err != nil {
+			return Grid{}, fmt.Errorf("error parsing requested property '%s': %w", name, err)
+		}
+
+		if d.Strict && raw.UnitOfMeasurement == "" {
+			return Grid{}, fmt.Errorf("gridpoint property '%s' is missing uom", name)
+		}
+
+		layer := Layer{Unit: raw.UnitOfMeasurement, Values: []Value{}}
+
+		for _, v := range raw.Values {
+			start, end, err := ParseValidTime(v.ValidTime)
+			if err != nil {
+				return Grid{}, fmt.Errorf("error parsing time range: %w", err)
+			}
+
+			layer.Values = append(layer.Values, Value{Start: start, End: end, Value: v.Value})
+		}
+
+		// I don't know that the API is always guaranteed to return in order
+		sort.SliceStable(layer.Values, func(i, j int) bool {
+			a, b := layer.Values[i], layer.Values[j]
+			if !a.Start.Equal(b.Start) {
+				return a.Start.Before(b.Start)
+			}
+
+			return a.End.Before(b.End)
+		})
+
+		g.Layers[name] = layer
+	}
+
+	return g, nil
+}
+
+type gridData struct {
+	Geometry struct {
+		Type        string         `json:"type"`
+		Coordinates [][][2]float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+// the gridpoint endpoint has no way to ask for only some layers, and it
+// sends dozens of them, so walk the response and only keep the ones we
+// asked for, stopping as soon as we have them all
+func (d GridDecoder) scan(r io.Reader) (gridData, error) {
+	data := gridData{Properties: map[string]json.RawMessage{}}
+
+	wanted := map[string]bool{"updateTime": true, "elevation": true}
+	for _, name := range d.Layers {
+		wanted[name] = true
+	}
+
+	dec := json.NewDecoder(r)
+
+	expectDelim := func(delim json.Delim) error {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		if t != delim {
+			return fmt.Errorf("expected '%s' but got '%v'", delim, t)
+		}
+
+		return nil
+	}
+
+	skip := func() error {
+		var raw json.RawMessage
+		return dec.Decode(&raw)
+	}
+
+	err := expectDelim('{')
+	if err != nil {
+		return gridData{}, err
+	}
+
+	seenGeometry := false
+
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return gridData{}, err
+		}
+
+		switch t {
+		case "geometry":
+			err = dec.Decode(&data.Geometry)
+			if err != nil {
+				return gridData{}, fmt.Errorf("could not parse geometry: %w", err)
+			}
+
+			seenGeometry = true
+		case "properties":
+			err = expectDelim('{')
+			if err != nil {
+				return gridData{}, err
+			}
+
+			for dec.More() {
+				t, err := dec.Token()
+				if err != nil {
+					return gridData{}, err
+				}
+
+				name, _ := t.(string)
+				if !wanted[name] {
+					err = skip()
+					if err != nil {
+						return gridData{}, err
+					}
+
+					continue
+				}
+
+				var raw json.RawMessage
+
+				err = dec.Decode(&raw)
+				if err != nil {
+					return gridData{}, err
+				}
+
+				data.Properties[name] = raw
+
+				// geometry comes before properties in practice, so this is
+				// usually where we get to stop reading
+				if seenGeometry && len(data.Properties) == len(wanted) {
+					return data, nil
+				}
+			}
+
+			err = expectDelim('}')
+			if err != nil {
+				return gridData{}, err
+			}
+		default:
+			err = skip()
+			if err != nil {
+				return gridData{}, err
+			}
+		}
+	}
+
+	return data, nil
+}
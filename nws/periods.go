@@ -0,0 +1,107 @@
+package nws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+)
+
+// Period is one stretch of a text forecast, like "Tonight" or "Saturday",
+// the way a forecaster would read it out.
+type Period struct {
+	Number    int
+	Name      string
+	Start     time.Time
+	End       time.Time
+	IsDaytime bool
+
+	// Temperature is in TemperatureUnit, "F" or "C" depending on the units
+	// asked for.
+	Temperature     *float64
+	TemperatureUnit string
+
+	// ProbabilityOfPrecipitation is a percentage, and nil when NWS doesn't
+	// give one.
+	ProbabilityOfPrecipitation *float64
+
+	// WindSpeed is text, like "10 to 15 mph".
+	WindSpeed     string
+	WindDirection string
+
+	ShortForecast    string
+	DetailedForecast string
+}
+
+// Periods fetches the text forecast at a Point's Forecast or
+// ForecastHourly URL, in "us" or "si" units.
+func (c *Client) Periods(ctx context.Context, forecastURL string, units string) ([]Period, error) {
+	u, err := url.Parse(forecastURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse forecast URL '%s': %w", forecastURL, err)
+	}
+
+	q := u.Query()
+	q.Set("units", units)
+	u.RawQuery = q.Encode()
+
+	res, err := c.Get(ctx, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	return ParsePeriods(res.Body)
+}
+
+// ParsePeriods reads a /forecast or /forecast/hourly response body.
+func ParsePeriods(r io.Reader) ([]Period, error) {
+	body := struct {
+		Properties struct {
+			Periods []struct {
+				Number                     int       `json:"number"`
+				Name                       string    `json:"name"`
+				StartTime                  time.Time `json:"startTime"`
+				EndTime                    time.Time `json:"endTime"`
+				IsDaytime                  bool      `json:"isDaytime"`
+				Temperature                *float64  `json:"temperature"`
+				TemperatureUnit            string    `json:"temperatureUnit"`
+				WindSpeed                  string    `json:"windSpeed"`
+				WindDirection              string    `json:"windDirection"`
+				ShortForecast              string    `json:"shortForecast"`
+				DetailedForecast           string    `json:"detailedForecast"`
+				ProbabilityOfPrecipitation struct {
+					Value *float64 `json:"value"`
+				} `json:"probabilityOfPrecipitation"`
+			} `json:"periods"`
+		} `json:"properties"`
+	}{}
+
+	err := json.NewDecoder(r).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	periods := []Period{}
+	for _, p := range body.Properties.Periods {
+		periods = append(periods, Period{
+			Number:                     p.Number,
+			Name:                       p.Name,
+			Start:                      p.StartTime,
+			End:                        p.EndTime,
+			IsDaytime:                  p.IsDaytime,
+			Temperature:                p.Temperature,
+			TemperatureUnit:            p.TemperatureUnit,
+			ProbabilityOfPrecipitation: p.ProbabilityOfPrecipitation.Value,
+			WindSpeed:                  p.WindSpeed,
+			WindDirection:              p.WindDirection,
+			ShortForecast:              p.ShortForecast,
+			DetailedForecast:           p.DetailedForecast,
+		})
+	}
+
+	return periods, nil
+}
@@ -0,0 +1,133 @@
+package nws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Point is what /points says about a location: the forecast office and grid
+// cell it's in, and where to find more about it.
+type Point struct {
+	GridID string
+	GridX  int
+	GridY  int
+
+	// ForecastGridData is the URL of the cell's gridded forecast, for
+	// Client.Grid.
+	ForecastGridData    string
+	ObservationStations string
+	RadarStation        string
+
+	// Forecast and ForecastHourly are the URLs of the cell's text
+	// forecasts, for Client.Periods.
+	Forecast       string
+	ForecastHourly string
+
+	// ForecastZone is the URL of the public forecast zone text products
+	// are issued for.
+	ForecastZone string
+}
+
+// NWS redirects /points to a canonical URL when the coordinates have more
+// precision than it wants, so we ask with 4 decimal places to begin with
+// and follow any redirect ourselves rather than trusting the client to
+const maxPointRedirects = 3
+
+// Point looks up the location at the given latitude and longitude.
+func (c *Client) Point(ctx context.Context, latitude, longitude float64) (Point, error) {
+	where := fmt.Sprintf("%.4f,%.4f", latitude, longitude)
+	queryURL := c.baseURL() + "/points/" + where
+
+	client := *c.httpClient()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	for redirects := 0; ; redirects++ {
+		res, err := c.get(ctx, &client, queryURL, nil)
+		if err != nil {
+			return Point{}, err
+		}
+
+		switch res.StatusCode {
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+			res.Body.Close()
+
+			if redirects >= maxPointRedirects {
+				return Point{}, fmt.Errorf("too many redirects looking up point %s", where)
+			}
+
+			location, err := res.Location()
+			if err != nil {
+				return Point{}, fmt.Errorf("could not follow redirect for point %s: %w", where, err)
+			}
+
+			queryURL = location.String()
+
+			continue
+		}
+
+		defer res.Body.Close()
+
+		return ParsePoint(res.Body)
+	}
+}
+
+// ParsePoint reads a /points response body.
+func ParsePoint(r io.Reader) (Point, error) {
+	body := struct {
+		Properties struct {
+			GridID              string `json:"gridId"`
+			GridX               int    `json:"gridX"`
+			GridY               int    `json:"gridY"`
+			ForecastGridData    string `json:"forecastGridData"`
+			Forecast            string `json:"forecast"`
+			ForecastHourly      string `json:"forecastHourly"`
+			ObservationStations string `json:"observationStations"`
+			RadarStation        string `json:"radarStation"`
+			ForecastZone        string `json:"forecastZone"`
+		} `json:"properties"`
+	}{}
+
+	err := json.NewDecoder(r).Decode(&body)
+	if err != nil {
+		return Point{}, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	return Point{
+		GridID:              body.Properties.GridID,
+		GridX:               body.Properties.GridX,
+		GridY:               body.Properties.GridY,
+		ForecastGridData:    body.Properties.ForecastGridData,
+		Forecast:            body.Properties.Forecast,
+		ForecastHourly:      body.Properties.ForecastHourly,
+		ObservationStations: body.Properties.ObservationStations,
+		RadarStation:        body.Properties.RadarStation,
+		ForecastZone:        body.Properties.ForecastZone,
+	}, nil
+}
+
+// ValidateGridDataURL checks that a ForecastGridData URL is one the API
+// would give for a point it forecasts. An empty or odd URL would otherwise
+// turn into a confusing failure somewhere further down, and usually means
+// NWS doesn't forecast there.
+func ValidateGridDataURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("no forecast grid data for this point, it may be outside NWS coverage")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("could not parse forecast grid data URL '%s': %w", raw, err)
+	}
+
+	if u.Scheme != "https" || u.Host != "api.weather.gov" {
+		return fmt.Errorf("forecast grid data URL '%s' is not on api.weather.gov, the point may be outside NWS coverage", raw)
+	}
+
+	return nil
+}
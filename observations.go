@@ -0,0 +1,575 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+type station struct {
+	id          string
+	name        string
+	location    coordinates
+	distanceKm  float64
+	observation string
+}
+
+// observation layers use the same names as the grid, except for pressure
+var observationPropertyNames = map[string]string{
+	"temperature":      "temperature",
+	"dewpoint":         "dewpoint",
+	"relativeHumidity": "relativeHumidity",
+	"windDirection":    "windDirection",
+	"windSpeed":        "windSpeed",
+	"windChill":        "windChill",
+	"heatIndex":        "heatIndex",
+	"pressure":         "barometricPressure",
+}
+
+func observableProperties() []string {
+	names := []string{}
+	for name := range observationPropertyNames {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// stations come back from the API roughly in order of distance, but we sort
+// them ourselves since that's what we tell the user
+func getStations(grid gridPoint, from coordinates) ([]station, error) {
+	if grid.observationStations == "" {
+		return nil, fmt.Errorf("no observation stations for this point")
+	}
+
+	req, err := http.NewRequest("GET", grid.observationStations, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	body := struct {
+		Features []struct {
+			ID       string `json:"id"`
+			Geometry struct {
+				Coordinates [2]float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties struct {
+				StationIdentifier string `json:"stationIdentifier"`
+				Name              string `json:"name"`
+			} `json:"properties"`
+		} `json:"features"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	stations := []station{}
+
+	for _, f := range body.Features {
+		location := coordinates{
+			latitude:  f.Geometry.Coordinates[1],
+			longitude: f.Geometry.Coordinates[0],
+		}
+
+		stations = append(stations, station{
+			id:          f.Properties.StationIdentifier,
+			name:        f.Properties.Name,
+			location:    location,
+			distanceKm:  distanceKm(from, location),
+			observation: f.ID + "/observations",
+		})
+	}
+
+	sort.SliceStable(stations, func(i, j int) bool { return stations[i].distanceKm < stations[j].distanceKm })
+
+	return stations, nil
+}
+
+// great circle distance using the haversine formula
+func distanceKm(a, b coordinates) float64 {
+	const earthRadiusKm = 6371.0
+
+	dlat := toRadians(b.latitude - a.latitude)
+	dlon := toRadians(b.longitude - a.longitude)
+
+	h := math.Pow(math.Sin(dlat/2), 2) + math.Cos(toRadians(a.latitude))*math.Cos(toRadians(b.latitude))*math.Pow(math.Sin(dlon/2), 2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// picks the pinned station if there is one, otherwise the nearest
+func selectStation(stations []station, pinned string) (station, error) {
+	if len(stations) == 0 {
+		return station{}, fmt.Errorf("no observation stations for this point")
+	}
+
+	if pinned == "" {
+		return stations[0], nil
+	}
+
+	for _, s := range stations {
+		if strings.EqualFold(s.id, pinned) {
+			return s, nil
+		}
+	}
+
+	return station{}, fmt.Errorf("station '%s' is not near this point, run 'stations' to see the options", pinned)
+}
+
+type observation struct {
+	station     string
+	timestamp   time.Time
+	description string
+	values      map[string]weatherPoint
+}
+
+type observationValue struct {
+	UnitCode string   `json:"unitCode"`
+	Value    *float64 `json:"value"`
+}
+
+func parseObservation(stationID string, raw json.RawMessage) (observation, error) {
+	body := struct {
+		Timestamp       time.Time `json:"timestamp"`
+		TextDescription string    `json:"textDescription"`
+	}{}
+
+	err := json.Unmarshal(raw, &body)
+	if err != nil {
+		return observation{}, fmt.Errorf("could not parse observation: %w", err)
+	}
+
+	layers := map[string]json.RawMessage{}
+
+	err = json.Unmarshal(raw, &layers)
+	if err != nil {
+		return observation{}, fmt.Errorf("could not parse observation: %w", err)
+	}
+
+	o := observation{
+		station:     stationID,
+		timestamp:   body.Timestamp,
+		description: body.TextDescription,
+		values:      map[string]weatherPoint{},
+	}
+
+	for name, layer := range observationPropertyNames {
+		data, ok := layers[layer]
+		if !ok {
+			continue
+		}
+
+		v := observationValue{}
+
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return observation{}, fmt.Errorf("could not parse observed '%s': %w", layer, err)
+		}
+
+		o.values[name] = weatherPoint{
+			StartTime: body.Timestamp,
+			EndTime:   body.Timestamp,
+			Value:     v.Value,
+			Unit:      normalizeUnit(v.UnitCode),
+		}
+	}
+
+	// not something the grid forecasts by that name, but irrigation adds up
+	// what's fallen
+	if data, ok := layers["precipitationLastHour"]; ok {
+		v := observationValue{}
+
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return observation{}, fmt.Errorf("could not parse observed 'precipitationLastHour': %w", err)
+		}
+
+		o.values["precipitationLastHour"] = weatherPoint{
+			StartTime: body.Timestamp.Add(-time.Hour),
+			EndTime:   body.Timestamp,
+			Value:     v.Value,
+			Unit:      normalizeUnit(v.UnitCode),
+		}
+	}
+
+	return o, nil
+}
+
+func getLatestObservation(s station) (observation, error) {
+	req, err := http.NewRequest("GET", s.observation+"/latest", nil)
+	if err != nil {
+		return observation{}, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return observation{}, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	body := struct {
+		Properties json.RawMessage `json:"properties"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return observation{}, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	return parseObservation(s.id, body.Properties)
+}
+
+type stationsRequest struct {
+	address string
+	limit   int
+	freedom bool
+}
+
+func runStations(flagset *flag.FlagSet) func() {
+	req := stationsRequest{}
+
+	flagset.StringVar(&req.address, "address", "", "address near which to list observation stations")
+	flagset.IntVar(&req.limit, "limit", 10, "maximum number of stations to list")
+	unitSystemFlags(flagset, &req.freedom, defaultUnitSystem)
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+
+	return func() {
+		if req.address == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		coordinates, err := getAddressCoordinates(req.address)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		stations, err := getStations(grid, coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		if req.limit > 0 && len(stations) > req.limit {
+			stations = stations[:req.limit]
+		}
+
+		widths := []int{7, 40, 17, 10}
+
+		t := newTable(os.Stdout, widths, []string{"station", "name", "location", "distance"})
+
+		for _, s := range stations {
+			p := weatherPoint{Value: &s.distanceKm, Unit: "wmoUnit:km"}
+			if req.freedom {
+				p = liberate(p)
+			}
+
+			distance := formatNumber(*p.Value, 1) + " " + displayUnit(p.Unit)
+
+			t.row([]string{s.id, s.name, s.location.String(), distance}, nil)
+		}
+
+		t.end()
+	}
+}
+
+type nowRequest struct {
+	address    string
+	station    string
+	properties []string
+	displayTZ  *time.Location
+	freedom    bool
+}
+
+func runNow(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		pinned       string
+		properties   string
+		displaytz    string
+		freedom      bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address at which to see the weather")
+	flagset.StringVar(&pinned, "station", "", "observation station to use instead of the nearest one")
+	flagset.StringVar(&properties, "properties", strings.Join(observableProperties(), ","), "observed properties to display in a comma separated string")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display the observation time")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		req := nowRequest{
+			address:    queryAddress,
+			station:    pinned,
+			properties: strings.Split(properties, ","),
+			displayTZ:  loc,
+			freedom:    freedom,
+		}
+
+		if req.address == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		for _, p := range req.properties {
+			if _, ok := observationPropertyNames[p]; !ok {
+				errorAndQuit(fmt.Errorf("requested property '%s' is not in %v", p, observableProperties()))
+			}
+		}
+
+		coordinates, err := getAddressCoordinates(req.address)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		stations, err := getStations(grid, coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		s, err := selectStation(stations, req.station)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		o, err := getLatestObservation(s)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		fmt.Printf("%s (%s), observed %s\n", s.name, s.id, o.timestamp.In(req.displayTZ).Format(time.Stamp))
+
+		if o.description != "" {
+			fmt.Println(o.description)
+		}
+
+		for _, p := range req.properties {
+			point, ok := o.values[p]
+			if !ok {
+				point = weatherPoint{}
+			}
+
+			fmt.Printf("%s: %s\n", padCell(p, 28), formatWeatherValue(p, point, req.freedom))
+		}
+	}
+}
+
+func getObservationHistory(s station, start, end time.Time) ([]observation, error) {
+	queryURL, err := url.Parse(s.observation)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse observation URL: %w", err)
+	}
+
+	queryURL.RawQuery = url.Values{
+		"start": []string{start.UTC().Format(time.RFC3339)},
+		"end":   []string{end.UTC().Format(time.RFC3339)},
+	}.Encode()
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	body := struct {
+		Features []struct {
+			Properties json.RawMessage `json:"properties"`
+		} `json:"features"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	observations := []observation{}
+
+	for _, f := range body.Features {
+		o, err := parseObservation(s.id, f.Properties)
+		if err != nil {
+			return nil, err
+		}
+
+		observations = append(observations, o)
+	}
+
+	// newest comes first from the API
+	sort.Slice(observations, func(i, j int) bool { return observations[i].timestamp.Before(observations[j].timestamp) })
+
+	return observations, nil
+}
+
+// turns point-in-time observations into series the table can use, where each
+// observation holds until the next one comes in
+func observationSeries(observations []observation, properties []string) map[string]series {
+	observed := map[string]series{}
+
+	for _, property := range properties {
+		points := series{}
+
+		for i, o := range observations {
+			p, ok := o.values[property]
+			if !ok {
+				continue
+			}
+
+			p.EndTime = p.StartTime.Add(time.Hour)
+			if i+1 < len(observations) {
+				p.EndTime = observations[i+1].timestamp
+			}
+
+			points.Add(p)
+		}
+
+		observed[property] = points
+	}
+
+	return observed
+}
+
+func runObs(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		pinned       string
+		properties   string
+		past         time.Duration
+		displaytz    string
+		freedom      bool
+		highlight    bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address at which to see the weather")
+	flagset.StringVar(&pinned, "station", "", "observation station to use instead of the nearest one")
+	flagset.StringVar(&properties, "properties", "temperature", "observed properties to display in a comma separated string")
+	flagset.DurationVar(&past, "past", 12*time.Hour, "how far back to show observations")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display observations")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+	flagset.BoolVar(&highlight, "highlight-extremes", false, "highlight the highest and lowest value of each property")
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		end := time.Now()
+
+		req := forecastRequest{
+			address:           queryAddress,
+			properties:        strings.Split(properties, ","),
+			start:             end.Add(-past),
+			end:               end,
+			displayTimeZone:   loc,
+			freedom:           freedom,
+			highlightExtremes: highlight,
+		}
+
+		if req.address == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		for _, p := range req.properties {
+			if _, ok := observationPropertyNames[p]; !ok {
+				errorAndQuit(fmt.Errorf("requested property '%s' is not in %v", p, observableProperties()))
+			}
+		}
+
+		coordinates, err := getAddressCoordinates(req.address)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		stations, err := getStations(grid, coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		s, err := selectStation(stations, pinned)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		observations, err := getObservationHistory(s, req.start, req.end)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		fmt.Printf("observed at %s (%s)\n", s.name, s.id)
+
+		display(req, buildRows(req, observationSeries(observations, req.properties)))
+	}
+}
+
+// rows for the hours leading up to now, filled in from what was actually
+// observed instead of what was forecast
+func getObservedRows(req forecastRequest, grid gridPoint, c coordinates) ([]displayRow, error) {
+	stations, err := getStations(grid, c)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := selectStation(stations, req.station)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	observedReq := req
+	observedReq.start = now.Add(-req.past)
+	observedReq.end = now.Truncate(time.Hour).Add(-time.Hour)
+
+	observations, err := getObservationHistory(s, observedReq.start, now)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := buildRows(observedReq, observationSeries(observations, req.properties))
+	for i := range rows {
+		rows[i].observed = true
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+type outlookRisk struct {
+	day         int
+	level       int
+	label       string
+	description string
+	valid       time.Time
+	expires     time.Time
+}
+
+// the Storm Prediction Center only issues categorical outlooks out to day 3
+const outlookDays = 3
+
+// SPC timestamps look like 202405011200, always in UTC
+const spcTimeLayout = "200601021504"
+
+func getOutlookRisk(day int, c coordinates) (outlookRisk, error) {
+	outlookURL := fmt.Sprintf("https://www.spc.noaa.gov/products/outlook/day%dotlk_cat.nolyr.geojson", day)
+
+	req, err := http.NewRequest("GET", outlookURL, nil)
+	if err != nil {
+		return outlookRisk{}, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return outlookRisk{}, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	body := struct {
+		Features []struct {
+			Geometry   geoJSONGeometry `json:"geometry"`
+			Properties struct {
+				DN     int    `json:"DN"`
+				Label  string `json:"LABEL"`
+				Label2 string `json:"LABEL2"`
+				Valid  string `json:"VALID"`
+				Expire string `json:"EXPIRE"`
+			} `json:"properties"`
+		} `json:"features"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return outlookRisk{}, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	risk := outlookRisk{day: day, label: "NONE", description: "No Thunderstorm Risk"}
+
+	for _, f := range body.Features {
+		if risk.valid.IsZero() {
+			risk.valid, _ = time.Parse(spcTimeLayout, f.Properties.Valid)
+			risk.expires, _ = time.Parse(spcTimeLayout, f.Properties.Expire)
+		}
+
+		if f.Properties.DN <= risk.level {
+			continue
+		}
+
+		polygons, err := f.Geometry.polygons()
+		if err != nil {
+			return outlookRisk{}, fmt.Errorf("could not parse day %d outlook: %w", day, err)
+		}
+
+		if anyContains(polygons, c) {
+			risk.level = f.Properties.DN
+			risk.label = f.Properties.Label
+			risk.description = f.Properties.Label2
+		}
+	}
+
+	return risk, nil
+}
+
+func runOutlook(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		displaytz    string
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address at which to see the severe weather outlook")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display outlook times")
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		widths := []int{3, 4, 28, 15, 15}
+
+		t := newTable(os.Stdout, widths, []string{"day", "risk", "description", "valid", "expires"})
+
+		for day := 1; day <= outlookDays; day++ {
+			risk, err := getOutlookRisk(day, coordinates)
+			if err != nil {
+				errorAndQuit(err)
+			}
+
+			t.row([]string{
+				fmt.Sprint(risk.day),
+				risk.label,
+				risk.description,
+				risk.valid.In(loc).Format(time.Stamp),
+				risk.expires.In(loc).Format(time.Stamp),
+			}, nil)
+		}
+
+		t.end()
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/packrat386/agwc/schema"
+)
+
+var outputFormats = []string{"table", "json", "csv", "heatmap", "week", "chart", "env", "windrose", "strip", "rss", "eink", "eink-png", "waybar", "alfred", "shortcuts", "template", "conky", "xmobar"}
+
+func newForecastDocument(req forecastRequest, c coordinates, grid gridPoint, forecast gridForecast, rows []displayRow) schema.Forecast {
+	doc := schema.Forecast{
+		Schema:      schema.Version,
+		GeneratedAt: time.Now().UTC(),
+		Location: schema.Location{
+			Address:   req.address,
+			Latitude:  c.latitude,
+			Longitude: c.longitude,
+		},
+		Grid: schema.Grid{
+			Office:              grid.office,
+			X:                   grid.x,
+			Y:                   grid.y,
+			ForecastGridDataURL: grid.forecastGridDataURL,
+			IssuedAt:            forecast.updateTime,
+		},
+		Window: schema.Window{
+			Start:    req.start,
+			End:      req.end,
+			TimeZone: req.displayTimeZone.String(),
+		},
+		Columns: []schema.Column{},
+		Hours:   []schema.Hour{},
+	}
+
+	visible := req.visibleColumns()
+
+	for _, i := range visible {
+		if i < len(req.properties) {
+			property := req.properties[i]
+			c := schema.Column{
+				Name: property,
+				Unit: req.columnUnit(forecast.properties[property].Unit),
+			}
+
+			if req.lang != "" {
+				c.Label = translate(req.lang, property)
+			}
+
+			doc.Columns = append(doc.Columns, c)
+		} else {
+			d := req.derived[i-len(req.properties)]
+
+			unit := d.unit
+			if _, ok := d.expr.(comfortExpr); ok {
+				unit = req.columnUnit(kindUnits[kindTemperature])
+			}
+
+			doc.Columns = append(doc.Columns, schema.Column{Name: d.name, Unit: unit, Derived: true})
+		}
+	}
+
+	for _, r := range rows {
+		h := schema.Hour{
+			Time:          r.at.In(req.displayTimeZone),
+			Observed:      r.observed,
+			Values:        []*float64{},
+			LeadHours:     req.leadHours(r),
+			LowConfidence: req.lowConfidenceAt(r),
+			PrecipType:    r.precipType,
+		}
+
+		for _, i := range visible {
+			h.Values = append(h.Values, r.numbers[i])
+		}
+
+		doc.Hours = append(doc.Hours, h)
+	}
+
+	for i, s := range req.columnSources(grid) {
+		doc.Columns[i].Source = s.source
+		doc.Columns[i].Computation = s.computation
+	}
+
+	for i := range doc.Columns {
+		doc.Columns[i].Coverage = columnCoverage(doc.Hours, i)
+	}
+
+	return doc
+}
+
+// with no hours there's nothing to be missing, so that counts as full
+func columnCoverage(hours []schema.Hour, column int) *float64 {
+	coverage := 1.0
+
+	if len(hours) > 0 {
+		present := 0
+		for _, h := range hours {
+			if h.Values[column] != nil {
+				present++
+			}
+		}
+
+		coverage = float64(present) / float64(len(hours))
+	}
+
+	return &coverage
+}
+
+func writeForecastDocument(w io.Writer, doc schema.Forecast) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("could not write forecast JSON: %w", err)
+	}
+
+	return nil
+}
+
+// one line per hour and column, so scripts don't need to know the columns
+// ahead of time
+func writeForecastCSV(w io.Writer, doc schema.Forecast) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write([]string{"timestamp", "property", "value", "unit"})
+	if err != nil {
+		return fmt.Errorf("could not write forecast CSV: %w", err)
+	}
+
+	for _, h := range doc.Hours {
+		for i, c := range doc.Columns {
+			value := ""
+			if v := h.Values[i]; v != nil {
+				value = strconv.FormatFloat(*v, 'f', -1, 64)
+			}
+
+			err = cw.Write([]string{h.Time.Format(time.RFC3339), c.Name, value, c.Unit})
+			if err != nil {
+				return fmt.Errorf("could not write forecast CSV: %w", err)
+			}
+		}
+
+		if h.PrecipType != "" {
+			err = cw.Write([]string{h.Time.Format(time.RFC3339), "precipType", h.PrecipType, ""})
+			if err != nil {
+				return fmt.Errorf("could not write forecast CSV: %w", err)
+			}
+		}
+	}
+
+	cw.Flush()
+
+	err = cw.Error()
+	if err != nil {
+		return fmt.Errorf("could not write forecast CSV: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// just enough of Parquet to write one row group of flat, required columns
+// with plain encoding and no compression, which every reader understands
+// and keeps us from pulling in a whole columnar library for an export
+
+const (
+	parquetBoolean   = 0
+	parquetInt32     = 1
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetUTF8            = 0
+	parquetTimestampMillis = 9
+	parquetNoConversion    = -1
+)
+
+type parquetColumn struct {
+	name      string
+	kind      int32
+	converted int32
+	values    bytes.Buffer
+}
+
+type parquetWriter struct {
+	columns []*parquetColumn
+	rows    int64
+}
+
+func (p *parquetWriter) column(name string, kind, converted int32) *parquetColumn {
+	c := &parquetColumn{name: name, kind: kind, converted: converted}
+	p.columns = append(p.columns, c)
+
+	return c
+}
+
+func (c *parquetColumn) addString(s string) {
+	binary.Write(&c.values, binary.LittleEndian, uint32(len(s)))
+	c.values.WriteString(s)
+}
+
+func (c *parquetColumn) addInt32(v int32) {
+	binary.Write(&c.values, binary.LittleEndian, v)
+}
+
+func (c *parquetColumn) addInt64(v int64) {
+	binary.Write(&c.values, binary.LittleEndian, v)
+}
+
+func (c *parquetColumn) addDouble(v float64) {
+	binary.Write(&c.values, binary.LittleEndian, math.Float64bits(v))
+}
+
+func (p *parquetWriter) writeTo(w io.Writer) error {
+	out := &bytes.Buffer{}
+	out.WriteString("PAR1")
+
+	type chunk struct {
+		offset int64
+		size   int64
+	}
+
+	chunks := []chunk{}
+
+	for _, c := range p.columns {
+		offset := int64(out.Len())
+
+		header := &thriftWriter{}
+		header.i32(1, 0) // data page
+		header.i32(2, int32(c.values.Len()))
+		header.i32(3, int32(c.values.Len()))
+		header.structField(5, func() {
+			header.i32(1, int32(p.rows))
+			header.i32(2, 0) // plain
+			header.i32(3, 3) // rle, though required columns have no levels
+			header.i32(4, 3)
+		})
+		header.stop()
+
+		out.Write(header.buf.Bytes())
+		out.Write(c.values.Bytes())
+
+		chunks = append(chunks, chunk{offset: offset, size: int64(out.Len()) - offset})
+	}
+
+	meta := &thriftWriter{}
+	meta.i32(1, 1)
+	meta.listOfStructs(2, len(p.columns)+1, func(i int) {
+		if i == 0 {
+			meta.binary(4, "schema")
+			meta.i32(5, int32(len(p.columns)))
+			return
+		}
+
+		c := p.columns[i-1]
+		meta.i32(1, c.kind)
+		meta.i32(3, 0) // required
+		meta.binary(4, c.name)
+
+		if c.converted != parquetNoConversion {
+			meta.i32(6, c.converted)
+		}
+	})
+	meta.i64(3, p.rows)
+
+	total := int64(0)
+	for _, c := range chunks {
+		total += c.size
+	}
+
+	meta.listOfStructs(4, 1, func(int) {
+		meta.listOfStructs(1, len(p.columns), func(i int) {
+			c := p.columns[i]
+
+			meta.i64(2, chunks[i].offset)
+			meta.structField(3, func() {
+				meta.i32(1, c.kind)
+				meta.listI32(2, []int32{0})
+				meta.listString(3, []string{c.name})
+				meta.i32(4, 0) // uncompressed
+				meta.i64(5, p.rows)
+				meta.i64(6, chunks[i].size)
+				meta.i64(7, chunks[i].size)
+				meta.i64(9, chunks[i].offset)
+			})
+		})
+		meta.i64(2, total)
+		meta.i64(3, p.rows)
+	})
+	meta.binary(6, "agwc")
+	meta.stop()
+
+	out.Write(meta.buf.Bytes())
+	binary.Write(out, binary.LittleEndian, uint32(meta.buf.Len()))
+	out.WriteString("PAR1")
+
+	_, err := w.Write(out.Bytes())
+	if err != nil {
+		return fmt.Errorf("could not write parquet: %w", err)
+	}
+
+	return nil
+}
+
+// the thrift compact protocol, which is what parquet metadata is written in
+type thriftWriter struct {
+	buf    bytes.Buffer
+	lastID int16
+}
+
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+func (t *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	t.buf.Write(b[:n])
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func (t *thriftWriter) field(id int16, kind byte) {
+	delta := id - t.lastID
+	if delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | kind)
+	} else {
+		t.buf.WriteByte(kind)
+		t.varint(zigzag(int64(id)))
+	}
+
+	t.lastID = id
+}
+
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(0)
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.rawString(s)
+}
+
+func (t *thriftWriter) rawString(s string) {
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) listHeader(n int, kind byte) {
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | kind)
+		return
+	}
+
+	t.buf.WriteByte(0xf0 | kind)
+	t.varint(uint64(n))
+}
+
+// a struct starts its own field numbering, and the caller's picks up where
+// it left off afterwards
+func (t *thriftWriter) inStruct(body func()) {
+	saved := t.lastID
+	t.lastID = 0
+
+	body()
+	t.stop()
+
+	t.lastID = saved
+}
+
+func (t *thriftWriter) structField(id int16, body func()) {
+	t.field(id, thriftStruct)
+	t.inStruct(body)
+}
+
+func (t *thriftWriter) listOfStructs(id int16, n int, each func(i int)) {
+	t.field(id, thriftList)
+	t.listHeader(n, thriftStruct)
+
+	for i := 0; i < n; i++ {
+		t.inStruct(func() { each(i) })
+	}
+}
+
+func (t *thriftWriter) listI32(id int16, values []int32) {
+	t.field(id, thriftList)
+	t.listHeader(len(values), thriftI32)
+
+	for _, v := range values {
+		t.varint(zigzag(int64(v)))
+	}
+}
+
+func (t *thriftWriter) listString(id int16, values []string) {
+	t.field(id, thriftList)
+	t.listHeader(len(values), thriftBinary)
+
+	for _, v := range values {
+		t.rawString(v)
+	}
+}
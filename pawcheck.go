@@ -0,0 +1,204 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+var pawProperties = []string{"temperature", "heatIndex", "skyCover"}
+
+// asphalt in full sun runs about 25 C hotter than the air, less as the sun
+// gets lower or clouds get in the way. sun is the sine of its altitude, so
+// 1 overhead and 0 at or below the horizon. it's a -derive expression, so
+// -pavement can swap in a better one over the same variables.
+const defaultPavementExpr = "temperature + 25 * sun * (100 - skyCover) / 100"
+
+var pavementVariables = []string{"temperature", "heatIndex", "skyCover", "sun"}
+
+// in C. the seven second rule is that pavement too hot to keep the back
+// of a hand on is too hot for paws, which is around 45, and a minute on
+// 52 is enough to burn them. heat indexes are where vets start to worry
+// about heatstroke.
+const (
+	pavementCaution  = 45
+	pavementDanger   = 52
+	heatIndexCaution = 32
+	heatIndexDanger  = 39
+)
+
+type pawHour struct {
+	at        time.Time
+	air       *float64
+	heatIndex *float64
+	skyCover  *float64
+	pavement  *float64
+	verdict   string
+}
+
+func pawVerdict(pavement, heatIndex *float64) string {
+	switch {
+	case pavement != nil && *pavement >= pavementDanger, heatIndex != nil && *heatIndex >= heatIndexDanger:
+		return "danger"
+	case pavement != nil && *pavement >= pavementCaution, heatIndex != nil && *heatIndex >= heatIndexCaution:
+		return "caution"
+	default:
+		return "ok"
+	}
+}
+
+// the heat index is only forecast when it's warm, so below that it's the
+// temperature. values are metric, whatever's displayed.
+func pawHours(forecast gridForecast, c coordinates, pavement expression, start time.Time, hours int) []pawHour {
+	paws := []pawHour{}
+
+	for at := start; at.Before(start.Add(time.Duration(hours) * time.Hour)); at = at.Add(time.Hour) {
+		value := func(property string) *float64 {
+			p, ok := findPointAt(forecast.properties[property], at)
+			if !ok {
+				return nil
+			}
+
+			return p.Value
+		}
+
+		h := pawHour{at: at, air: value("temperature"), heatIndex: value("heatIndex"), skyCover: value("skyCover")}
+		if h.heatIndex == nil {
+			h.heatIndex = h.air
+		}
+
+		sun := math.Max(0, math.Sin(toRadians(sunAltitude(at, c))))
+
+		h.pavement = pavement.eval(map[string]*float64{
+			"temperature": h.air,
+			"heatIndex":   h.heatIndex,
+			"skyCover":    h.skyCover,
+			"sun":         &sun,
+		})
+
+		h.verdict = pawVerdict(h.pavement, h.heatIndex)
+		paws = append(paws, h)
+	}
+
+	return paws
+}
+
+// runs of hours that aren't ok, like "Sat 13:00-19:00"
+func riskySpans(paws []pawHour, loc *time.Location) []string {
+	spans := []string{}
+
+	for i := 0; i < len(paws); i++ {
+		if paws[i].verdict == "ok" {
+			continue
+		}
+
+		j := i
+		for j+1 < len(paws) && paws[j+1].verdict != "ok" {
+			j++
+		}
+
+		span := paws[i].at.In(loc).Format("Mon 15:04")
+		if j > i {
+			span += "-" + paws[j].at.Add(time.Hour).In(loc).Format("15:04")
+		}
+
+		spans = append(spans, span)
+		i = j
+	}
+
+	return spans
+}
+
+func runPawCheck(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		hours        int
+		pavementExpr string
+		displaytz    string
+		freedom      bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address or named location of the walk")
+	flagset.IntVar(&hours, "hours", 24, "number of hours to check")
+	flagset.StringVar(&pavementExpr, "pavement", defaultPavementExpr, fmt.Sprintf("expression estimating pavement temperature in C over %v", pavementVariables))
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display hours")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		pavement, err := parseExpression(pavementExpr)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not parse pavement expression: %w", err))
+		}
+
+		for _, v := range pavement.variables() {
+			if indexOf(pavementVariables, v) < 0 {
+				errorAndQuit(fmt.Errorf("pavement expression uses '%s', which is not in %v", v, pavementVariables))
+			}
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, pawProperties)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		paws := pawHours(forecast, coordinates, pavement, time.Now().Truncate(time.Hour), hours)
+
+		t := newTable(os.Stdout, []int{15, 11, 11, 8, 11, 8}, []string{"time", "air", "heat index", "sky", "pavement", "walk"})
+
+		for _, h := range paws {
+			styles := []string{"", "", "", "", "", ""}
+
+			switch h.verdict {
+			case "danger":
+				styles[5] = styleMax
+			case "ok":
+				styles[5] = styleMin
+			}
+
+			t.row([]string{
+				h.at.In(loc).Format(time.Stamp),
+				formatCelsius(h.air, freedom),
+				formatCelsius(h.heatIndex, freedom),
+				formatWeatherValue("skyCover", weatherPoint{Value: h.skyCover, Unit: "wmoUnit:percent"}, freedom),
+				formatCelsius(h.pavement, freedom),
+				h.verdict,
+			}, styles)
+		}
+
+		t.end()
+
+		fmt.Println()
+
+		risky := riskySpans(paws, loc)
+		if len(risky) == 0 {
+			fmt.Printf("paws are fine for the next %d hours\n", hours)
+			return
+		}
+
+		fmt.Printf("too hot for a comfortable walk at %s\n", strings.Join(risky, ", "))
+		fmt.Println("walk early or late, stick to grass and shade, and bring water")
+	}
+}
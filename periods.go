@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/packrat386/agwc/nws"
+)
+
+// what the forecast shows: the gridded values for each hour, or the text
+// forecast NWS writes for each day and night
+var forecastModes = []string{"grid", "periods"}
+
+// the text forecast for the periods that overlap the window, in metric so
+// they go through the same conversions as everything else
+func getPeriods(req forecastRequest, grid gridPoint) ([]nws.Period, error) {
+	// cached grid points from before the text forecast was used don't have
+	// its URL, but it's always next to the grid data
+	forecastURL := grid.forecastURL
+	if forecastURL == "" {
+		forecastURL = grid.forecastGridDataURL + "/forecast"
+	}
+
+	periods, err := nwsClient().Periods(context.Background(), forecastURL, "si")
+	if err != nil {
+		return nil, fmt.Errorf("could not get text forecast: %w", err)
+	}
+
+	inWindow := []nws.Period{}
+	for _, p := range periods {
+		if p.End.After(req.start) && p.Start.Before(req.end) {
+			inWindow = append(inWindow, p)
+		}
+	}
+
+	return inWindow, nil
+}
+
+func displayPeriods(req forecastRequest, grid gridPoint) {
+	periods, err := getPeriods(req, grid)
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	if len(periods) == 0 {
+		fmt.Println("no periods in the requested window")
+		quit(exitNoRows)
+	}
+
+	t := newTable(os.Stdout, []int{15, 12, 6, 6, 16, 40}, []string{"period", "starts", "temp", "precip", "wind", "forecast"})
+
+	for _, p := range periods {
+		temperature := formatWeatherValue("temperature", req.liberate(weatherPoint{Value: p.Temperature, Unit: "wmoUnit:degC"}), false)
+
+		precipitation := ""
+		if p.ProbabilityOfPrecipitation != nil {
+			precipitation = formatWeatherValue("probabilityOfPrecipitation", req.liberate(weatherPoint{Value: p.ProbabilityOfPrecipitation, Unit: "wmoUnit:percent"}), false)
+		}
+
+		t.row([]string{
+			p.Name,
+			p.Start.In(req.displayTimeZone).Format("Mon 15:04"),
+			temperature,
+			precipitation,
+			strings.TrimSpace(p.WindDirection + " " + formatWindText(req, p.WindSpeed)),
+			p.ShortForecast,
+		}, nil)
+	}
+
+	t.end()
+
+	for _, p := range periods {
+		if p.DetailedForecast != "" {
+			fmt.Println()
+			fmt.Printf("%s: %s\n", p.Name, p.DetailedForecast)
+		}
+	}
+}
+
+// wind comes as text, like "10 to 15 km/h", so each number in it is
+// converted on its own
+func formatWindText(req forecastRequest, s string) string {
+	words := strings.Fields(s)
+	if len(words) == 0 || words[len(words)-1] != "km/h" {
+		return s
+	}
+
+	for i, w := range words[:len(words)-1] {
+		v, err := strconv.ParseFloat(w, 64)
+		if err != nil {
+			continue
+		}
+
+		p := req.liberate(weatherPoint{Value: &v, Unit: "wmoUnit:km_h-1"})
+
+		words[i] = formatNumber(*p.Value, 0)
+	}
+
+	words[len(words)-1] = req.columnUnit("wmoUnit:km_h-1")
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// sun altitudes bounding the photographers' golden and blue hours
+const (
+	goldenHourHigh = 6.0
+	goldenHourLow  = -4.0
+	blueHourLow    = -6.0
+)
+
+type lightWindow struct {
+	name  string
+	start time.Time
+	end   time.Time
+}
+
+func lightWindows(day time.Time, c coordinates) []lightWindow {
+	windows := []lightWindow{}
+
+	goldenRise, goldenSet, goldenOK := sunTimes(day, c, goldenHourHigh)
+	twilightRise, twilightSet, twilightOK := sunTimes(day, c, goldenHourLow)
+	blueRise, blueSet, blueOK := sunTimes(day, c, blueHourLow)
+
+	if blueOK && twilightOK {
+		windows = append(windows, lightWindow{name: "morning blue", start: blueRise, end: twilightRise})
+	}
+
+	if twilightOK && goldenOK {
+		windows = append(windows, lightWindow{name: "morning golden", start: twilightRise, end: goldenRise})
+		windows = append(windows, lightWindow{name: "evening golden", start: goldenSet, end: twilightSet})
+	}
+
+	if blueOK && twilightOK {
+		windows = append(windows, lightWindow{name: "evening blue", start: twilightSet, end: blueSet})
+	}
+
+	return windows
+}
+
+// some cloud makes for the best color, total overcast or fog kills it
+func rateLight(skyCover, visibilityMeters *float64) string {
+	if skyCover == nil {
+		return "unknown"
+	}
+
+	if visibilityMeters != nil && *visibilityMeters < 1600 {
+		return "poor"
+	}
+
+	switch {
+	case *skyCover >= 90:
+		return "poor"
+	case *skyCover >= 20 && *skyCover <= 70:
+		return "good"
+	default:
+		return "fair"
+	}
+}
+
+func runPhoto(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		days         int
+		displaytz    string
+		freedom      bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address at which to plan a shoot")
+	flagset.IntVar(&days, "days", 3, "number of days to plan")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display times")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, []string{"skyCover", "visibility"})
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		widths := []int{10, 14, 13, 15, 15, 7}
+
+		t := newTable(os.Stdout, widths, []string{"day", "light", "time", "skyCover", "visibility", "outlook"})
+
+		now := time.Now()
+		today := now.In(loc)
+
+		for d := 0; d < days; d++ {
+			day := today.AddDate(0, 0, d)
+
+			for _, w := range lightWindows(day, coordinates) {
+				if w.end.Before(now) {
+					continue
+				}
+
+				middle := w.start.Add(w.end.Sub(w.start) / 2)
+
+				sky, _ := findPointAt(forecast.properties["skyCover"], middle)
+				visibility, _ := findPointAt(forecast.properties["visibility"], middle)
+
+				t.row([]string{
+					day.Format("Mon Jan 02"),
+					w.name,
+					w.start.In(loc).Format("15:04") + "-" + w.end.In(loc).Format("15:04"),
+					formatWeatherValue("skyCover", sky, freedom),
+					formatWeatherValue("visibility", visibility, freedom),
+					rateLight(sky.Value, visibility.Value),
+				}, nil)
+			}
+		}
+
+		t.end()
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// what every plugin request says it is, so a plugin can tell when agwc
+// starts speaking something it doesn't understand
+const pluginProtocol = "agwc.plugin/v1"
+
+var pluginKinds = []string{"provider", "metric", "output"}
+
+// a program that extends agwc, run once per use with a JSON request on
+// stdin and answering on stdout, see the Plugins section of the README
+type pluginConfig struct {
+	Name string `json:"name"`
+
+	// provider adds a forecast source for -consensus, metric adds a
+	// -derive name=plugin:<name> column, and output adds a -format
+	Kind string `json:"kind"`
+
+	// the program and its arguments
+	Command []string `json:"command"`
+
+	// how long it gets before it's killed, 30s if unset
+	Timeout string `json:"timeout,omitempty"`
+}
+
+var (
+	metricPlugins = map[string]pluginConfig{}
+	outputPlugins = map[string]pluginConfig{}
+)
+
+func configurePlugins(cfg config) error {
+	for _, p := range cfg.Plugins {
+		if p.Name == "" {
+			return fmt.Errorf("plugin name cannot be empty")
+		}
+
+		if len(p.Command) == 0 {
+			return fmt.Errorf("plugin '%s' needs a command", p.Name)
+		}
+
+		if p.Timeout != "" {
+			_, err := time.ParseDuration(p.Timeout)
+			if err != nil {
+				return fmt.Errorf("could not parse timeout for plugin '%s': %w", p.Name, err)
+			}
+		}
+
+		switch p.Kind {
+		case "provider":
+			if _, ok := forecastProviders[p.Name]; ok {
+				return fmt.Errorf("provider plugin '%s' has the same name as a provider in %v", p.Name, providerNames())
+			}
+
+			forecastProviders[p.Name] = pluginProvider(p)
+		case "metric":
+			if _, ok := metricPlugins[p.Name]; ok {
+				return fmt.Errorf("there's more than one metric plugin named '%s'", p.Name)
+			}
+
+			metricPlugins[p.Name] = p
+		case "output":
+			if indexOf(outputFormats, p.Name) >= 0 {
+				return fmt.Errorf("output plugin '%s' has the same name as a format in %v", p.Name, outputFormats)
+			}
+
+			outputFormats = append(outputFormats, p.Name)
+			outputPlugins[p.Name] = p
+		default:
+			return fmt.Errorf("kind '%s' of plugin '%s' is not in %v", p.Kind, p.Name, pluginKinds)
+		}
+	}
+
+	return nil
+}
+
+// sends the request and hands whatever the plugin prints to out, where
+// anything it says on stderr is passed along as is
+func runPlugin(p pluginConfig, request interface{}, out io.Writer) error {
+	timeout := 30 * time.Second
+	if p.Timeout != "" {
+		timeout, _ = time.ParseDuration(p.Timeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	in, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("could not encode request for plugin '%s': %w", p.Name, err)
+	}
+
+	cmd := exec.CommandContext(ctx, p.Command[0], p.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "AGWC_PLUGIN_PROTOCOL="+pluginProtocol)
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return fmt.Errorf("plugin '%s' took longer than %s", p.Name, timeout)
+	}
+
+	if err != nil {
+		return fmt.Errorf("plugin '%s' failed: %w", p.Name, err)
+	}
+
+	return nil
+}
+
+// runs the plugin and decodes what it prints into response
+func callPlugin(p pluginConfig, request interface{}, response interface{}) error {
+	var out bytes.Buffer
+
+	err := runPlugin(p, request, &out)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(out.Bytes(), response)
+	if err != nil {
+		return fmt.Errorf("could not parse response from plugin '%s': %w", p.Name, err)
+	}
+
+	return nil
+}
+
+type pluginProviderRequest struct {
+	Protocol   string   `json:"protocol"`
+	Kind       string   `json:"kind"`
+	Latitude   float64  `json:"latitude"`
+	Longitude  float64  `json:"longitude"`
+	Properties []string `json:"properties"`
+}
+
+// the same shape as an NWS gridpoint layer, with a WMO unit code and
+// values that each cover a span of time
+type pluginLayer struct {
+	Unit   string `json:"unit"`
+	Values []struct {
+		Start time.Time `json:"start"`
+		End   time.Time `json:"end"`
+		Value *float64  `json:"value"`
+	} `json:"values"`
+}
+
+func pluginProvider(p pluginConfig) forecastProvider {
+	return func(c coordinates, properties []string) (map[string]series, error) {
+		response := struct {
+			Properties map[string]pluginLayer `json:"properties"`
+		}{}
+
+		err := callPlugin(p, pluginProviderRequest{
+			Protocol:   pluginProtocol,
+			Kind:       "provider",
+			Latitude:   c.latitude,
+			Longitude:  c.longitude,
+			Properties: properties,
+		}, &response)
+		if err != nil {
+			return nil, err
+		}
+
+		forecast := map[string]series{}
+		for name, layer := range response.Properties {
+			unit := normalizeUnit(layer.Unit)
+
+			points := []weatherPoint{}
+			for _, v := range layer.Values {
+				points = append(points, weatherPoint{StartTime: v.Start, EndTime: v.End, Value: v.Value, Unit: unit})
+			}
+
+			forecast[name] = newSeries(points)
+		}
+
+		return forecast, nil
+	}
+}
+
+type pluginMetricRequest struct {
+	Protocol   string            `json:"protocol"`
+	Kind       string            `json:"kind"`
+	Column     string            `json:"column"`
+	Properties []string          `json:"properties"`
+	Units      []string          `json:"units"`
+	Hours      []pluginMetricRow `json:"hours"`
+}
+
+type pluginMetricRow struct {
+	Time   time.Time  `json:"time"`
+	Values []*float64 `json:"values"`
+}
+
+// a -derive column whose values come from a metric plugin, all at once
+// after the rows are built rather than an hour at a time
+type pluginExpr string
+
+func (pluginExpr) eval(map[string]*float64) *float64 { return nil }
+
+func (pluginExpr) variables() []string { return nil }
+
+// fills in the -derive columns that come from metric plugins, which see
+// every requested property as displayed
+func applyMetricPlugins(req forecastRequest, rows []displayRow) {
+	for i, d := range req.derived {
+		name, ok := d.expr.(pluginExpr)
+		if !ok {
+			continue
+		}
+
+		column := len(req.properties) + i
+
+		request := pluginMetricRequest{
+			Protocol:   pluginProtocol,
+			Kind:       "metric",
+			Column:     d.name,
+			Properties: req.properties,
+			Hours:      []pluginMetricRow{},
+		}
+
+		for _, p := range req.properties {
+			request.Units = append(request.Units, req.columnUnit(kindUnits[propertyRegistry[p]]))
+		}
+
+		for _, r := range rows {
+			request.Hours = append(request.Hours, pluginMetricRow{Time: r.at, Values: r.numbers[:len(req.properties)]})
+		}
+
+		response := struct {
+			Values []*float64 `json:"values"`
+		}{}
+
+		err := callPlugin(metricPlugins[string(name)], request, &response)
+		if err == nil && len(response.Values) != len(rows) {
+			err = fmt.Errorf("plugin '%s' gave %d values for %d hours", name, len(response.Values), len(rows))
+		}
+
+		// like any other missing data, it shouldn't sink the whole run
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipping derived column '%s': %s\n", d.name, err)
+			continue
+		}
+
+		for j, v := range response.Values {
+			rows[j].values[column] = d.format(v)
+			rows[j].numbers[column] = v
+		}
+	}
+}
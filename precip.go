@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// NWS wording: 30-50% is a chance, 60% and up is likely
+const (
+	precipChanceThreshold = 30.0
+	precipLikelyThreshold = 60.0
+)
+
+type precipOnset struct {
+	at          time.Time
+	probability float64
+}
+
+// the first period at or after now with a meaningful chance of precipitation
+func nextPrecipitation(points series, now time.Time) (precipOnset, bool) {
+	for i := 0; i < points.Len(); i++ {
+		p := points.At(i)
+		if p.Value == nil || !p.EndTime.After(now) {
+			continue
+		}
+
+		if *p.Value >= precipChanceThreshold {
+			at := p.StartTime
+			if at.Before(now) {
+				at = now
+			}
+
+			return precipOnset{at: at, probability: *p.Value}, true
+		}
+	}
+
+	return precipOnset{}, false
+}
+
+func precipitationSummary(points series, now time.Time, loc *time.Location) string {
+	onset, ok := nextPrecipitation(points, now)
+	if !ok {
+		if points.Len() == 0 {
+			return "No precipitation forecast available"
+		}
+
+		return fmt.Sprintf("No precipitation expected through %s", points.At(points.Len()-1).EndTime.In(loc).Format("Mon 15:04"))
+	}
+
+	chance := "possible"
+	if onset.probability >= precipLikelyThreshold {
+		chance = "likely"
+	}
+
+	probability := formatNumber(onset.probability, kindPrecision[kindProbability]) + "%"
+
+	if !onset.at.After(now) {
+		return fmt.Sprintf("Precipitation %s now (%s)", chance, probability)
+	}
+
+	hours := math.Round(onset.at.Sub(now).Hours())
+	if hours < 1 {
+		return fmt.Sprintf("Precipitation %s within the hour (%s, %s)", chance, onset.at.In(loc).Format("Mon 15:04"), probability)
+	}
+
+	return fmt.Sprintf("Precipitation %s starting in ~%.0fh (%s, %s)", chance, hours, onset.at.In(loc).Format("Mon 15:04"), probability)
+}
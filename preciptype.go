@@ -0,0 +1,104 @@
+package main
+
+import (
+	"time"
+)
+
+// what -precip-type looks at, none of which says outright whether it'll
+// be rain or snow
+var precipTypeProperties = []string{"temperature", "relativeHumidity", "quantitativePrecipitation", "snowfallAmount", "iceAccumulation"}
+
+const (
+	precipNone         = "none"
+	precipRain         = "rain"
+	precipSnow         = "snow"
+	precipSleet        = "sleet"
+	precipFreezingRain = "freezing rain"
+)
+
+// snow that melts down to less water than this is wet enough to be mixed
+// with something, since fresh snow is usually more like 10:1
+const sleetSnowRatio = 5
+
+// a rough call from surface conditions, since what happens aloft isn't in
+// the grid. the amounts are for the period covering the hour, in mm, and
+// an empty string means there's not enough to go on.
+func precipType(temperature, humidity, qpf, snow, ice *float64) string {
+	if qpf == nil && snow == nil && ice == nil {
+		return ""
+	}
+
+	amount := func(v *float64) float64 {
+		if v == nil {
+			return 0
+		}
+
+		return *v
+	}
+
+	// ice accumulation is freezing rain by definition
+	if amount(ice) > 0 {
+		return precipFreezingRain
+	}
+
+	// melting falls off with the wet bulb rather than the air temperature,
+	// so dry air can still get snow to the ground a few degrees above
+	// freezing
+	var wetBulb *float64
+	if temperature != nil && humidity != nil {
+		wb := wetBulbCelsius(*temperature, *humidity)
+		wetBulb = &wb
+	}
+
+	if amount(snow) > 0 {
+		if wetBulb != nil && *wetBulb > 1 {
+			return precipSleet
+		}
+
+		if amount(qpf) > 0 && amount(snow)/amount(qpf) < sleetSnowRatio {
+			return precipSleet
+		}
+
+		return precipSnow
+	}
+
+	if amount(qpf) <= 0 {
+		return precipNone
+	}
+
+	if temperature != nil && *temperature <= 0 {
+		return precipFreezingRain
+	}
+
+	if wetBulb != nil && *wetBulb <= 0 {
+		return precipSleet
+	}
+
+	return precipRain
+}
+
+// fills in each forecast hour's precipitation type
+func applyPrecipTypes(weatherData map[string]series, rows []displayRow) {
+	value := func(property string, at time.Time, unit string) *float64 {
+		p, ok := findPointAt(weatherData[property], at)
+		if !ok || p.Unit != unit {
+			return nil
+		}
+
+		return p.Value
+	}
+
+	for i, r := range rows {
+		if r.observed {
+			continue
+		}
+
+		rows[i].precipType = precipType(
+			value("temperature", r.at, "wmoUnit:degC"),
+			value("relativeHumidity", r.at, "wmoUnit:percent"),
+			value("quantitativePrecipitation", r.at, "wmoUnit:mm"),
+			value("snowfallAmount", r.at, "wmoUnit:mm"),
+			value("iceAccumulation", r.at, "wmoUnit:mm"),
+		)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWetBulb(t *testing.T) {
+	// Stull's worked example
+	if got := wetBulbCelsius(20, 50); math.Abs(got-13.7) > 0.05 {
+		t.Errorf("wet bulb at 20C and 50%% is %.2f, wanted 13.7", got)
+	}
+}
+
+func TestPrecipType(t *testing.T) {
+	v := floatPtr
+
+	for _, tc := range []struct {
+		name                                  string
+		temperature, humidity, qpf, snow, ice *float64
+		want                                  string
+	}{
+		{"no amounts at all", v(5), v(90), nil, nil, nil, ""},
+		{"nothing falling", v(5), v(90), v(0), v(0), v(0), precipNone},
+		{"ice is freezing rain, whatever the temperature", v(5), v(90), v(2), v(0), v(0.5), precipFreezingRain},
+		{"cold and snowing", v(-5), v(90), v(1), v(10), nil, precipSnow},
+		{"snow without a qpf", v(-5), v(90), nil, v(10), nil, precipSnow},
+		{"snow with a wet bulb above 1C", v(3), v(90), v(1), v(10), nil, precipSleet},
+		{"snow that's mostly water", v(-2), v(90), v(2), v(4), nil, precipSleet},
+		{"rain below freezing", v(-1), v(90), v(2), v(0), nil, precipFreezingRain},
+		{"rain into dry air near freezing", v(2), v(30), v(2), v(0), nil, precipSleet},
+		{"rain", v(5), v(90), v(2), v(0), nil, precipRain},
+		{"rain without a temperature", nil, nil, v(2), nil, nil, precipRain},
+	} {
+		if got := precipType(tc.temperature, tc.humidity, tc.qpf, tc.snow, tc.ice); got != tc.want {
+			t.Errorf("%s: '%s', wanted '%s'", tc.name, got, tc.want)
+		}
+	}
+}
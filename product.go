@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+type textProduct struct {
+	id           string
+	code         string
+	name         string
+	office       string
+	issuanceTime time.Time
+	text         string
+}
+
+// a product as it appears in a listing, without its text
+type productSummary struct {
+	id           string
+	code         string
+	name         string
+	office       string
+	issuanceTime time.Time
+}
+
+// newest first, as NWS returns them
+func getProductList(productType, office string) ([]productSummary, error) {
+	queryURL := &url.URL{
+		Scheme: "https",
+		Host:   "api.weather.gov",
+		Path:   "/products/types/" + productType + "/locations/" + office,
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	return parseProductList(res.Body)
+}
+
+func parseProductList(r io.Reader) ([]productSummary, error) {
+	body := struct {
+		Graph []struct {
+			ID            string    `json:"id"`
+			IssuingOffice string    `json:"issuingOffice"`
+			IssuanceTime  time.Time `json:"issuanceTime"`
+			ProductCode   string    `json:"productCode"`
+			ProductName   string    `json:"productName"`
+		} `json:"@graph"`
+	}{}
+
+	err := json.NewDecoder(r).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	products := []productSummary{}
+	for _, p := range body.Graph {
+		products = append(products, productSummary{
+			id:           p.ID,
+			code:         p.ProductCode,
+			name:         p.ProductName,
+			office:       p.IssuingOffice,
+			issuanceTime: p.IssuanceTime,
+		})
+	}
+
+	return products, nil
+}
+
+func getLatestProduct(productType, office string) (textProduct, error) {
+	products, err := getProductList(productType, office)
+	if err != nil {
+		return textProduct{}, err
+	}
+
+	if len(products) == 0 {
+		return textProduct{}, fmt.Errorf("%s has not issued any %s recently", office, productType)
+	}
+
+	return getProduct(products[0].id)
+}
+
+func getProduct(id string) (textProduct, error) {
+	queryURL := &url.URL{
+		Scheme: "https",
+		Host:   "api.weather.gov",
+		Path:   "/products/" + id,
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return textProduct{}, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return textProduct{}, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return textProduct{}, fmt.Errorf("no product with id '%s'", id)
+	}
+
+	return parseProduct(res.Body)
+}
+
+func parseProduct(r io.Reader) (textProduct, error) {
+	body := struct {
+		ID            string    `json:"id"`
+		IssuingOffice string    `json:"issuingOffice"`
+		IssuanceTime  time.Time `json:"issuanceTime"`
+		ProductCode   string    `json:"productCode"`
+		ProductName   string    `json:"productName"`
+		ProductText   string    `json:"productText"`
+	}{}
+
+	err := json.NewDecoder(r).Decode(&body)
+	if err != nil {
+		return textProduct{}, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	return textProduct{
+		id:           body.ID,
+		code:         body.ProductCode,
+		name:         body.ProductName,
+		office:       body.IssuingOffice,
+		issuanceTime: body.IssuanceTime,
+		text:         body.ProductText,
+	}, nil
+}
+
+func runProduct(flagset *flag.FlagSet) func() {
+	var (
+		productType  string
+		office       string
+		queryAddress string
+		id           string
+		list         bool
+		displaytz    string
+	)
+
+	flagset.StringVar(&productType, "type", "", "product code, e.g. AFD for the forecast discussion, HWO for the hazardous weather outlook, or NOW for the short term forecast")
+	flagset.StringVar(&office, "office", "", "forecast office that issued it, e.g. LOT")
+	flagset.StringVar(&queryAddress, "address", "", "use the forecast office for this address instead of -office")
+	flagset.StringVar(&id, "id", "", "show this product, as listed by -list, instead of the latest")
+	flagset.BoolVar(&list, "list", false, "list recent products of the type instead of showing the latest")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display when products were issued")
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the -list table, one of %v", tableStyleNames()))
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		var product textProduct
+
+		if id != "" {
+			product, err = getProduct(id)
+			if err != nil {
+				errorAndQuit(err)
+			}
+
+			displayProduct(product, loc)
+			return
+		}
+
+		if productType == "" {
+			errorAndQuit(fmt.Errorf("type cannot be empty without -id"))
+		}
+
+		productType = strings.ToUpper(productType)
+
+		if queryAddress != "" {
+			coordinates, err := getAddressCoordinates(queryAddress)
+			if err != nil {
+				errorAndQuit(err)
+			}
+
+			grid, err := getGridPoint(coordinates)
+			if err != nil {
+				errorAndQuit(err)
+			}
+
+			office = grid.office
+		}
+
+		if office == "" {
+			errorAndQuit(fmt.Errorf("one of office or address is needed to find products"))
+		}
+
+		office = strings.ToUpper(office)
+
+		if list {
+			products, err := getProductList(productType, office)
+			if err != nil {
+				errorAndQuit(err)
+			}
+
+			if len(products) == 0 {
+				fmt.Printf("%s has not issued any %s recently\n", office, productType)
+				return
+			}
+
+			t := newTable(os.Stdout, []int{15, 36}, []string{"issued", "id"})
+			for _, p := range products {
+				t.row([]string{p.issuanceTime.In(loc).Format(time.Stamp), p.id}, nil)
+			}
+
+			t.end()
+
+			return
+		}
+
+		product, err = getLatestProduct(productType, office)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		displayProduct(product, loc)
+	}
+}
+
+func displayProduct(p textProduct, loc *time.Location) {
+	fmt.Printf("%s from %s, issued %s\n", p.name, p.office, p.issuanceTime.In(loc).Format(time.Stamp))
+	fmt.Println()
+	fmt.Println(strings.TrimSpace(p.text))
+}
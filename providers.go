@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	"github.com/packrat386/agwc/forecast"
+)
+
+// somewhere forecasts come from, giving back series in the same units the
+// NWS uses so they can be compared directly
+type forecastProvider func(c coordinates, properties []string) (map[string]series, error)
+
+var forecastProviders = map[string]forecastProvider{
+	"nws":        nwsForecast,
+	"open-meteo": openMeteoForecast,
+}
+
+func providerNames() []string {
+	names := []string{}
+	for name := range forecastProviders {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func nwsForecast(c coordinates, properties []string) (map[string]series, error) {
+	grid, err := getGridPoint(c)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := getWeatherData(grid.forecastGridDataURL, properties)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.properties, nil
+}
+
+func openMeteoForecast(c coordinates, properties []string) (map[string]series, error) {
+	return forecast.OpenMeteo{HTTPClient: httpClient}.Forecast(context.Background(), c.latitude, c.longitude, properties)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+// the official point forecast, for opening on a phone
+func forecastPageURL(c coordinates) string {
+	return fmt.Sprintf("https://forecast.weather.gov/MapClick.php?lat=%.4f&lon=%.4f", c.latitude, c.longitude)
+}
+
+// scanners want a few modules of light around the code
+const qrQuietZone = 2
+
+// two modules to a character with half blocks, so the code comes out about
+// square. light modules are drawn, since most terminals are dark, which
+// makes the code the right way around.
+func writeQR(w io.Writer, text string) error {
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return fmt.Errorf("could not encode QR code: %w", err)
+	}
+
+	light := func(x, y int) bool {
+		return !code.Black(x-qrQuietZone, y-qrQuietZone)
+	}
+
+	size := code.Size + 2*qrQuietZone
+
+	for y := 0; y < size; y += 2 {
+		var line strings.Builder
+
+		for x := 0; x < size; x++ {
+			top := light(x, y)
+			bottom := y+1 < size && light(x, y+1)
+
+			switch {
+			case top && bottom:
+				line.WriteString("█")
+			case top:
+				line.WriteString("▀")
+			case bottom:
+				line.WriteString("▄")
+			default:
+				line.WriteString(" ")
+			}
+		}
+
+		fmt.Fprintln(w, line.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+type radarLinks struct {
+	station string
+	page    string
+	loop    string
+	latest  string
+}
+
+func getRadarLinks(grid gridPoint) (radarLinks, error) {
+	if grid.radarStation == "" {
+		return radarLinks{}, fmt.Errorf("no radar station for this point")
+	}
+
+	station := strings.ToUpper(grid.radarStation)
+
+	return radarLinks{
+		station: station,
+		page:    fmt.Sprintf("https://radar.weather.gov/station/%s/standard", strings.ToLower(station)),
+		loop:    fmt.Sprintf("https://radar.weather.gov/ridge/standard/%s_loop.gif", station),
+		latest:  fmt.Sprintf("https://radar.weather.gov/ridge/standard/%s_0.gif", station),
+	}, nil
+}
+
+func runRadar(flagset *flag.FlagSet) func() {
+	var queryAddress string
+
+	flagset.StringVar(&queryAddress, "address", "", "address at which to see the radar")
+
+	return func() {
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		links, err := getRadarLinks(grid)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		fmt.Println("radarStation: ", links.station)
+		fmt.Println("radarPage: ", links.page)
+		fmt.Println("radarLoop: ", links.loop)
+		fmt.Println("radarLatest: ", links.latest)
+	}
+}
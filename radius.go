@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// points sampled around the ring for -radius, one every 45 degrees
+const radiusBearings = 8
+
+// "25mi" or "40km" into kilometers
+func parseRadius(s string) (float64, error) {
+	factor := 0.0
+	number := s
+
+	switch {
+	case strings.HasSuffix(s, "mi"):
+		factor, number = 1.609344, strings.TrimSuffix(s, "mi")
+	case strings.HasSuffix(s, "km"):
+		factor, number = 1, strings.TrimSuffix(s, "km")
+	default:
+		return 0, fmt.Errorf("radius must be a distance in mi or km like 25mi, got '%s'", s)
+	}
+
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("radius must be a positive distance like 25mi, got '%s'", s)
+	}
+
+	return v * factor, nil
+}
+
+// the point km away from c in the given direction, on a sphere
+func destination(c coordinates, bearingDegrees, km float64) coordinates {
+	const earthRadiusKm = 6371.0
+
+	d := km / earthRadiusKm
+	bearing := toRadians(bearingDegrees)
+	lat := toRadians(c.latitude)
+	lon := toRadians(c.longitude)
+
+	lat2 := math.Asin(math.Sin(lat)*math.Cos(d) + math.Cos(lat)*math.Sin(d)*math.Cos(bearing))
+	lon2 := lon + math.Atan2(math.Sin(bearing)*math.Sin(d)*math.Cos(lat), math.Cos(d)-math.Sin(lat)*math.Sin(lat2))
+
+	return coordinates{latitude: lat2 * 180 / math.Pi, longitude: lon2 * 180 / math.Pi}
+}
+
+func radiusRing(center coordinates, km float64) []coordinates {
+	ring := []coordinates{}
+	for i := 0; i < radiusBearings; i++ {
+		ring = append(ring, destination(center, float64(i)*360/radiusBearings, km))
+	}
+
+	return ring
+}
+
+// the forecasts for the cells under the ring around center, leaving out the
+// center's own cell and any cell two points land in, and why any points
+// were skipped. points over water or outside the NWS's coverage have no
+// cell, so a ring on a coast usually loses some.
+func getRadiusWeatherData(ctx context.Context, center gridPoint, c coordinates, km float64, properties []string) ([]gridForecast, error) {
+	ring := radiusRing(c, km)
+	results := make([]*gridForecast, len(ring))
+	urls := make([]string, len(ring))
+	errs := make([]error, len(ring))
+
+	var wg sync.WaitGroup
+
+	for i, p := range ring {
+		wg.Add(1)
+
+		go func(i int, p coordinates) {
+			defer wg.Done()
+
+			grid, err := getGridPointContext(ctx, p)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", p, err)
+				return
+			}
+
+			urls[i] = grid.forecastGridDataURL
+			if grid.forecastGridDataURL == center.forecastGridDataURL {
+				return
+			}
+
+			forecast, err := getWeatherDataContext(ctx, grid.forecastGridDataURL, properties)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", p, err)
+				return
+			}
+
+			results[i] = &forecast
+		}(i, p)
+	}
+
+	wg.Wait()
+
+	seen := map[string]bool{center.forecastGridDataURL: true}
+	forecasts := []gridForecast{}
+	for i, r := range results {
+		if r != nil && !seen[urls[i]] {
+			seen[urls[i]] = true
+			forecasts = append(forecasts, *r)
+		}
+	}
+
+	return forecasts, skipped("points", errs)
+}
+
+// the hour by hour spread across the area, then a line per property over
+// the whole window, like "temperature 68-75 F across 25mi"
+func displayRadiusSpread(req forecastRequest, forecasts []gridForecast, rows []displayRow) {
+	displaySpread(req, fmt.Sprintf("spread across %d grid cells within %s", len(forecasts), req.radius), forecasts, rows)
+
+	times := []time.Time{}
+	for _, r := range rows {
+		times = append(times, r.at)
+	}
+
+	properties := req.visibleProperties()
+	spreads := spreadCells(req, properties, forecasts, times)
+
+	fmt.Println()
+	for i, p := range properties {
+		fmt.Printf("%s %s across %s\n", p, spreads[i], req.radius)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+// where to record for, resolved once for all of record's subcommands
+func recordTarget(queryAddress, pinned string) (gridPoint, station, error) {
+	if queryAddress == "" {
+		return gridPoint{}, station{}, fmt.Errorf("address cannot be empty")
+	}
+
+	coordinates, err := getAddressCoordinates(queryAddress)
+	if err != nil {
+		return gridPoint{}, station{}, err
+	}
+
+	return recordTargetAt(coordinates, pinned)
+}
+
+// recordTarget for somewhere already geocoded
+func recordTargetAt(coordinates coordinates, pinned string) (gridPoint, station, error) {
+	grid, err := getGridPoint(coordinates)
+	if err != nil {
+		return gridPoint{}, station{}, err
+	}
+
+	stations, err := getStations(grid, coordinates)
+	if err != nil {
+		return gridPoint{}, station{}, err
+	}
+
+	s, err := selectStation(stations, pinned)
+	if err != nil {
+		return gridPoint{}, station{}, err
+	}
+
+	return grid, s, nil
+}
+
+// records the current forecast and the last day of observations, meant to
+// be run regularly, like from the daemon
+func runRecord(flagset *flag.FlagSet) func() {
+	var queryAddress, pinned string
+
+	flagset.StringVar(&queryAddress, "address", "", "address to record the forecast and observations for")
+	flagset.StringVar(&pinned, "station", "", "observation station to record instead of the nearest one")
+
+	return func() {
+		grid, s, err := recordTarget(queryAddress, pinned)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, observableProperties())
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		hours, err := recordForecast(grid, forecast, observableProperties())
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		now := time.Now()
+
+		observations, err := getObservationHistory(s, now.Add(-24*time.Hour), now)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		observed, err := recordObservations(s.id, observations)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		if hours == 0 {
+			fmt.Printf("forecast issued %s for %s was already recorded\n", forecast.updateTime.Format(time.Stamp), gridHistoryName(grid.office, grid.x, grid.y))
+		} else {
+			fmt.Printf("recorded %d hours of the forecast issued %s for %s\n", hours, forecast.updateTime.Format(time.Stamp), gridHistoryName(grid.office, grid.x, grid.y))
+		}
+
+		fmt.Printf("recorded %d new observations from %s\n", observed, s.id)
+	}
+}
+
+// seeds the history with what the station has already seen, so comparing
+// forecasts against it doesn't have to wait weeks
+func runRecordBackfill(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		pinned       string
+		days         int
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address whose nearest station to backfill from")
+	flagset.StringVar(&pinned, "station", "", "observation station to backfill instead of the nearest one")
+	flagset.IntVar(&days, "days", 30, "how many days back to fetch")
+
+	return func() {
+		if days < 1 {
+			errorAndQuit(fmt.Errorf("days must be at least 1, got %d", days))
+		}
+
+		_, s, err := recordTarget(queryAddress, pinned)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		end := time.Now()
+		start := end.AddDate(0, 0, -days)
+
+		// a day at a time keeps each response well under the API's page size
+		fetched := []observation{}
+		for from := start; from.Before(end); from = from.Add(24 * time.Hour) {
+			to := from.Add(24 * time.Hour)
+			if to.After(end) {
+				to = end
+			}
+
+			observations, err := getObservationHistory(s, from, to)
+			if err != nil {
+				errorAndQuit(err)
+			}
+
+			fetched = append(fetched, observations...)
+		}
+
+		recorded, err := recordObservations(s.id, fetched)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		fmt.Printf("fetched %d observations from %s, %d of them new\n", len(fetched), s.id, recorded)
+
+		if len(fetched) > 0 && fetched[0].timestamp.After(start.Add(24*time.Hour)) {
+			fmt.Printf("the API only had observations back to %s\n", fetched[0].timestamp.Format(time.Stamp))
+		}
+	}
+}
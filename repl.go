@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// set while the repl is running, so commands unwind back to the prompt
+// rather than exiting
+var inREPL bool
+
+type replExit struct {
+	code int
+}
+
+// what carries over between commands, so it doesn't have to be typed again
+type replSession struct {
+	locations  map[string]locationConfig
+	address    string
+	properties string
+	hours      int
+	units      string
+	displaytz  string
+}
+
+func (s *replSession) status() {
+	address := s.address
+	if address == "" {
+		address = "(none, try 'use <address>')"
+	}
+
+	fmt.Printf("address: %s\nproperties: %s\nhours: %d\nunits: %s\ntime zone: %s\n", address, s.properties, s.hours, s.units, s.displaytz)
+}
+
+// like a shell, words are split on spaces except in quotes
+func splitWords(line string) ([]string, error) {
+	words := []string{}
+
+	var (
+		word    strings.Builder
+		inWord  bool
+		quoteBy rune
+	)
+
+	for _, r := range line {
+		switch {
+		case quoteBy != 0 && r == quoteBy:
+			quoteBy = 0
+		case quoteBy != 0:
+			word.WriteRune(r)
+		case r == '"' || r == '\'':
+			quoteBy = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quoteBy != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quoteBy)
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+var replDuration = regexp.MustCompile(`^(\d+)([hd])$`)
+
+// show [properties] [hours like 24h or 3d]
+func (s *replSession) show(words []string) ([]string, error) {
+	if s.address == "" {
+		return nil, fmt.Errorf("no address yet, try 'use <address>'")
+	}
+
+	properties, hours := s.properties, s.hours
+
+	for _, w := range words {
+		if m := replDuration.FindStringSubmatch(w); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			if m[2] == "d" {
+				n *= 24
+			}
+
+			hours = n
+			continue
+		}
+
+		properties = w
+	}
+
+	return []string{"agwc", "-address", s.address, "-properties", properties, "-hours", strconv.Itoa(hours), "-unit-system", s.units, "-displaytz", s.displaytz}, nil
+}
+
+// any other command, with the session's address and units filled in where
+// it takes them and they weren't given
+func (s *replSession) passthrough(c *command, words []string) []string {
+	args := []string{c.name}
+
+	flagset := flagsOf(c)
+	given := strings.Join(words, " ")
+
+	if flagset != nil {
+		if flagset.Lookup("address") != nil && s.address != "" && !strings.Contains(given, "-address") {
+			args = append(args, "-address", s.address)
+		}
+
+		if flagset.Lookup("unit-system") != nil && !strings.Contains(given, "-freedom") && !strings.Contains(given, "-unit-system") {
+			args = append(args, "-unit-system", s.units)
+		}
+
+		if flagset.Lookup("displaytz") != nil && !strings.Contains(given, "-displaytz") {
+			args = append(args, "-displaytz", s.displaytz)
+		}
+	}
+
+	return append(args, words...)
+}
+
+func (s *replSession) run(c *command, args []string) {
+	defer func() {
+		r := recover()
+		if e, ok := r.(replExit); ok {
+			if e.code != 0 {
+				fmt.Printf("(exit %d)\n", e.code)
+			}
+
+			return
+		}
+
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	runCommand(c, args)
+}
+
+const replHelp = `commands:
+  use <address or location>   set the address, by name if it's under "locations" in the config
+  show [properties] [24h|3d]  show the forecast, remembering the properties
+  units <system>|F|C          switch unit systems, F and C being us and metric
+  tz <zone>                   display times in this time zone
+  status                      show the session's settings
+  refresh                     forget what's been fetched, to see upstream changes
+  <command> [flags]           run any other command, like 'alerts' or 'now', with the session's address
+  help [command]              this, or help for a command
+  quit                        leave`
+
+func runREPL(flagset *flag.FlagSet) func() {
+	var queryAddress string
+
+	flagset.StringVar(&queryAddress, "address", "", "address or named location to start with")
+
+	return func() {
+		cfg, err := loadConfig()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		s := &replSession{
+			locations:  cfg.Locations,
+			properties: "temperature",
+			hours:      12,
+			units:      defaultUnitSystem,
+			displaytz:  defaultDisplayTZ,
+		}
+
+		if queryAddress != "" {
+			s.use(queryAddress)
+		}
+
+		root := commandTree()
+		inREPL = true
+
+		fmt.Println("agwc repl, 'help' for commands, 'quit' to leave")
+
+		in := bufio.NewScanner(os.Stdin)
+
+		for {
+			fmt.Print("agwc> ")
+
+			if !in.Scan() {
+				fmt.Println()
+				return
+			}
+
+			words, err := splitWords(in.Text())
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+
+			if len(words) == 0 {
+				continue
+			}
+
+			switch words[0] {
+			case "quit", "exit":
+				return
+			case "help", "?":
+				if len(words) == 1 {
+					fmt.Println(replHelp)
+					continue
+				}
+
+				s.run(root.find("help"), words)
+			case "use":
+				if len(words) < 2 {
+					fmt.Println("use needs an address or location name")
+					continue
+				}
+
+				s.use(strings.Join(words[1:], " "))
+			case "units":
+				system := strings.ToLower(strings.Join(words[1:], ""))
+				switch system {
+				case "f", "freedom":
+					system = "us"
+				case "c":
+					system = "metric"
+				}
+
+				if _, ok := unitSystems[system]; !ok {
+					fmt.Printf("units must be F, C or one of %v\n", unitSystemNames())
+					continue
+				}
+
+				s.units = system
+			case "tz":
+				if len(words) != 2 {
+					fmt.Println("tz needs a time zone, like America/Chicago")
+					continue
+				}
+
+				_, err := time.LoadLocation(words[1])
+				if err != nil {
+					fmt.Printf("could not load time zone: %s\n", err)
+					continue
+				}
+
+				s.displaytz = words[1]
+			case "status":
+				s.status()
+			case "refresh":
+				forgetFetches()
+			case "show":
+				args, err := s.show(words[1:])
+				if err != nil {
+					fmt.Println(err)
+					continue
+				}
+
+				if len(words) > 1 {
+					s.properties = args[4]
+				}
+
+				s.run(root, args)
+			default:
+				c := root.find(words[0])
+				if c == nil || c.name == "repl" {
+					fmt.Printf("no command '%s', 'help' for commands\n", words[0])
+					continue
+				}
+
+				c, rest := resolveCommand(c, words)
+				s.run(c, s.passthrough(c, rest[1:]))
+			}
+		}
+	}
+}
+
+func (s *replSession) use(name string) {
+	if l, ok := s.locations[name]; ok {
+		s.address = l.place()
+
+		if l.Properties != "" {
+			s.properties = l.Properties
+		}
+
+		if l.TimeZone != "" {
+			s.displaytz = l.TimeZone
+		}
+
+		if l.Units == "freedom" {
+			s.units = "us"
+		} else if l.Units != "" {
+			s.units = l.Units
+		}
+	} else {
+		s.address = name
+	}
+
+	fmt.Printf("using %s\n", s.address)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// everything that went into and came out of a run, so that someone acting on
+// the output can go back later and see exactly what it was based on
+type runReport struct {
+	GeneratedAt time.Time                `json:"generatedAt"`
+	Request     reportRequest            `json:"request"`
+	Coordinates reportCoordinates        `json:"coordinates"`
+	Grid        reportGrid               `json:"grid"`
+	Provider    string                   `json:"provider"`
+	IssuedAt    time.Time                `json:"issuedAt"`
+	Series      map[string][]reportPoint `json:"series"`
+	Rendered    reportTable              `json:"rendered"`
+}
+
+type reportRequest struct {
+	Address         string    `json:"address"`
+	Properties      []string  `json:"properties"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DisplayTimeZone string    `json:"displayTimeZone"`
+	Freedom         bool      `json:"freedom"`
+	Derived         []string  `json:"derived,omitempty"`
+	Sort            string    `json:"sort,omitempty"`
+	SortDescending  bool      `json:"sortDescending,omitempty"`
+	Hidden          []string  `json:"hidden,omitempty"`
+}
+
+type reportCoordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type reportGrid struct {
+	Office              string              `json:"office"`
+	X                   int                 `json:"x"`
+	Y                   int                 `json:"y"`
+	ForecastGridDataURL string              `json:"forecastGridDataURL"`
+	Cell                []reportCoordinates `json:"cell,omitempty"`
+	MapURL              string              `json:"mapURL,omitempty"`
+}
+
+type reportPoint struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Value     *float64  `json:"value"`
+	Unit      string    `json:"unit"`
+}
+
+type reportTable struct {
+	Columns []string    `json:"columns"`
+	Rows    []reportRow `json:"rows"`
+}
+
+type reportRow struct {
+	Time     time.Time `json:"time"`
+	Observed bool      `json:"observed,omitempty"`
+	Values   []string  `json:"values"`
+}
+
+func newRunReport(req forecastRequest, c coordinates, grid gridPoint, forecast gridForecast, rows []displayRow) runReport {
+	report := runReport{
+		GeneratedAt: time.Now(),
+		Request: reportRequest{
+			Address:         req.address,
+			Properties:      req.properties,
+			Start:           req.start,
+			End:             req.end,
+			DisplayTimeZone: req.displayTimeZone.String(),
+			Freedom:         req.freedom,
+			Sort:            req.sortProperty,
+			SortDescending:  req.sortDescending,
+			Hidden:          req.hidden,
+		},
+		Coordinates: reportCoordinates{
+			Latitude:  c.latitude,
+			Longitude: c.longitude,
+		},
+		Grid: reportGrid{
+			Office:              grid.office,
+			X:                   grid.x,
+			Y:                   grid.y,
+			ForecastGridDataURL: grid.forecastGridDataURL,
+		},
+		Provider: "api.weather.gov",
+		IssuedAt: forecast.updateTime,
+		Series:   map[string][]reportPoint{},
+	}
+
+	for _, corner := range forecast.cell {
+		report.Grid.Cell = append(report.Grid.Cell, reportCoordinates{
+			Latitude:  corner.latitude,
+			Longitude: corner.longitude,
+		})
+	}
+
+	if len(forecast.cell) > 0 {
+		report.Grid.MapURL = openStreetMapLink(cellCenter(forecast.cell))
+	}
+
+	for _, d := range req.derived {
+		report.Request.Derived = append(report.Request.Derived, d.source)
+	}
+
+	for name, points := range forecast.properties {
+		series := []reportPoint{}
+		for i := 0; i < points.Len(); i++ {
+			p := points.At(i)
+			series = append(series, reportPoint{
+				StartTime: p.StartTime,
+				EndTime:   p.EndTime,
+				Value:     p.Value,
+				Unit:      p.Unit,
+			})
+		}
+
+		report.Series[name] = series
+	}
+
+	columns := req.columns()
+	visible := req.visibleColumns()
+
+	for _, i := range visible {
+		report.Rendered.Columns = append(report.Rendered.Columns, columns[i])
+	}
+
+	for _, r := range rows {
+		row := reportRow{Time: r.at, Observed: r.observed, Values: []string{}}
+		for _, i := range visible {
+			row.Values = append(row.Values, r.values[i])
+		}
+
+		report.Rendered.Rows = append(report.Rendered.Rows, row)
+	}
+
+	return report
+}
+
+func writeReport(path string, report runReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create report file: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+
+	err = enc.Encode(report)
+	if err != nil {
+		return fmt.Errorf("could not write report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RSS 2.0, which is what the feed readers and e-ink frames that only speak
+// feeds all understand
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	TTL           int       `xml:"ttl"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// minutes readers should wait between checks, about as often as the NWS
+// issues a new forecast
+const rssTTL = 60
+
+// an item per day of the week layout, where a day's guid stays the same as
+// the forecast for it is updated, so readers show the change instead of
+// another item. days past the end of the forecast are left out.
+func newForecastFeed(req forecastRequest, c coordinates, forecast gridForecast) rssFeed {
+	link := forecastPageURL(c)
+
+	issued := forecast.updateTime
+	if issued.IsZero() {
+		issued = time.Now()
+	}
+
+	temperatureUnit := req.columnUnit(kindUnits[kindTemperature])
+	precipUnit := req.columnUnit(kindUnits[kindPrecipitation])
+
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         fmt.Sprintf(translate(req.lang, "agwc forecast for %s"), req.address),
+			Link:          link,
+			Description:   fmt.Sprintf(translate(req.lang, "daily forecast for %s, in %s"), req.address, req.displayTimeZone),
+			LastBuildDate: issued.Format(time.RFC1123Z),
+			TTL:           rssTTL,
+			Items:         []rssItem{},
+		},
+	}
+
+	for _, d := range weekDays(req, forecast) {
+		if d.high == nil && d.chance == nil {
+			continue
+		}
+
+		parts := []string{}
+
+		if d.high != nil {
+			parts = append(parts, fmt.Sprintf(translate(req.lang, "high %s, low %s"), formatNumber(*d.high, kindPrecision[kindTemperature])+temperatureUnit, formatNumber(*d.low, kindPrecision[kindTemperature])+temperatureUnit))
+		}
+
+		if d.chance != nil {
+			parts = append(parts, fmt.Sprintf(translate(req.lang, "%s%% chance of precipitation"), formatNumber(*d.chance, kindPrecision[kindProbability])))
+		}
+
+		if d.precip != nil && *d.precip > 0 {
+			parts = append(parts, fmt.Sprintf(translate(req.lang, "%s expected"), formatNumber(*d.precip, kindPrecision[kindPrecipitation])+precipUnit))
+		}
+
+		summary := strings.Join(parts, ", ")
+
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       fmt.Sprintf("%s %s: %s", formatDay(req.lang, d.day, false), d.glyph, summary),
+			Link:        link,
+			Description: fmt.Sprintf(translate(req.lang, "%s on %s, %s"), strings.ReplaceAll(translate(req.lang, condition(forecast, d.day, d.day.AddDate(0, 0, 1))), "-", " "), formatDay(req.lang, d.day, true), summary),
+			GUID:        rssGUID{Value: fmt.Sprintf("agwc:%s:%s", req.address, d.day.Format("2006-01-02"))},
+			PubDate:     issued.Format(time.RFC1123Z),
+		})
+	}
+
+	return feed
+}
+
+func writeForecastRSS(w io.Writer, feed rssFeed) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return fmt.Errorf("could not write forecast feed: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	err = enc.Encode(feed)
+	if err != nil {
+		return fmt.Errorf("could not write forecast feed: %w", err)
+	}
+
+	_, err = io.WriteString(w, "\n")
+	if err != nil {
+		return fmt.Errorf("could not write forecast feed: %w", err)
+	}
+
+	return nil
+}
+
+// the serve mode version, a feed per location with ?location= or the only
+// one there is, in ?units= or the units agwc defaults to here, and the time
+// zone it does
+func feedHandler(locations []skillLocation, freedom bool, loc *time.Location) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("location")
+		if name == "" && len(locations) == 1 {
+			name = locations[0].name
+		}
+
+		system, err := requestUnitSystem(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		lang, err := requestLanguage(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Language", lang)
+
+		key, limited := requestKey(r)
+
+		for _, l := range locations {
+			if l.name != name || (limited && !key.allows(l.name)) {
+				continue
+			}
+
+			req := forecastRequest{
+				address:         l.name,
+				format:          "rss",
+				start:           time.Now(),
+				displayTimeZone: loc,
+				freedom:         freedom || system != nil,
+				system:          system,
+				lang:            lang,
+			}
+
+			forecast, err := getWeatherData(l.grid.forecastGridDataURL, req.fetchProperties())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+			writeForecastRSS(w, newForecastFeed(req, l.coordinates, forecast))
+
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("no location '%s'", name), http.StatusNotFound)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+var sailProperties = []string{"windSpeed", "windGust", "windDirection", "probabilityOfThunder"}
+
+// only grid cells on the water or near it have these
+var waveProperties = []string{"waveHeight", "wavePeriod"}
+
+// a condition worth knowing about before heading out, as a -flag
+// assertion without a window
+type sailFlag struct {
+	label     string
+	assertion assertion
+}
+
+// roughly where small craft advisories and gale warnings start, in metric:
+// 21 kt sustained, 34 kt gusts and 4 ft seas. local offices set their own,
+// so these are only a starting point.
+var defaultSailFlags = []struct {
+	label, assertion string
+}{
+	{"small craft", "windSpeed>=39"},
+	{"gale gusts", "windGust>=63"},
+	{"rough", "waveHeight>=1.2"},
+	{"thunder", "probabilityOfThunder>=20"},
+}
+
+// defaults are kept in metric, so they're converted into whatever units
+// are displayed, where -flag thresholds already are
+func sailFlags(extra []string, freedom bool) ([]sailFlag, error) {
+	flags := []sailFlag{}
+
+	for _, d := range defaultSailFlags {
+		a, err := parseAssertion(d.assertion)
+		if err != nil {
+			panic(err)
+		}
+
+		if freedom {
+			threshold := a.threshold
+			a.threshold = *liberate(weatherPoint{Value: &threshold, Unit: kindUnits[propertyRegistry[a.property]]}).Value
+		}
+
+		flags = append(flags, sailFlag{label: d.label, assertion: a})
+	}
+
+	for _, s := range extra {
+		a, err := parseAssertion(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if a.window != 0 || a.until != "" {
+			return nil, fmt.Errorf("flag '%s' is checked every hour, so it can't have a window", s)
+		}
+
+		if indexOf(sailProperties, a.property) < 0 && indexOf(waveProperties, a.property) < 0 {
+			return nil, fmt.Errorf("flag property '%s' is not in %v", a.property, append(append([]string{}, sailProperties...), waveProperties...))
+		}
+
+		flags = append(flags, sailFlag{label: strings.TrimSpace(s), assertion: a})
+	}
+
+	return flags, nil
+}
+
+// which flags are up at the hour, where missing data doesn't raise any
+func raisedFlags(flags []sailFlag, forecast gridForecast, at time.Time, freedom bool) []string {
+	raised := []string{}
+
+	for _, f := range flags {
+		p, ok := findPointAt(forecast.properties[f.assertion.property], at)
+		if !ok || p.Value == nil {
+			continue
+		}
+
+		if freedom {
+			p = liberate(p)
+		}
+
+		if f.assertion.holds(*p.Value) {
+			raised = append(raised, f.label)
+		}
+	}
+
+	return raised
+}
+
+func runSail(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		hours        int
+		displaytz    string
+		freedom      bool
+		extra        stringList
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address or named location on the water, like a marina")
+	flagset.IntVar(&hours, "hours", 12, "number of hours to show")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display hours")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+	flagset.Var(&extra, "flag", fmt.Sprintf("also flag hours where this holds, like 'windGust>=20' in the displayed units, over %v, may be repeated", append(append([]string{}, sailProperties...), waveProperties...)))
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		flags, err := sailFlags(extra, freedom)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, sailProperties)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		// the grid is cached by now, so asking again for the waves is free, and
+		// inland cells not having them isn't worth failing over
+		waves, err := getWeatherData(grid.forecastGridDataURL, waveProperties)
+
+		hasWaves := err == nil && waves.properties["waveHeight"].Len() > 0
+		if hasWaves {
+			for _, p := range waveProperties {
+				forecast.properties[p] = waves.properties[p]
+			}
+		}
+
+		t := newTable(os.Stdout, []int{15, 15, 15, 11, 8, 8, 30}, []string{"time", "wind", "gust", "waves", "period", "thunder", "flags"})
+
+		start := time.Now().Truncate(time.Hour)
+		flagged := 0
+
+		for at := start; at.Before(start.Add(time.Duration(hours) * time.Hour)); at = at.Add(time.Hour) {
+			value := func(property string) weatherPoint {
+				p, _ := findPointAt(forecast.properties[property], at)
+				return p
+			}
+
+			wind := formatWeatherValue("windSpeed", value("windSpeed"), freedom)
+			if d := value("windDirection"); d.Value != nil && value("windSpeed").Value != nil {
+				wind += " " + compassDirection(*d.Value)
+			}
+
+			waveHeight, period := "", ""
+			if hasWaves {
+				waveHeight = formatWeatherValue("waveHeight", value("waveHeight"), freedom)
+				period = formatWeatherValue("wavePeriod", value("wavePeriod"), freedom)
+			}
+
+			raised := raisedFlags(flags, forecast, at, freedom)
+			if len(raised) > 0 {
+				flagged++
+			}
+
+			styles := []string{"", "", "", "", "", "", ""}
+			if len(raised) > 0 {
+				styles[6] = styleMax
+			}
+
+			t.row([]string{
+				at.In(loc).Format(time.Stamp),
+				wind,
+				formatWeatherValue("windGust", value("windGust"), freedom),
+				waveHeight,
+				period,
+				formatWeatherValue("probabilityOfThunder", value("probabilityOfThunder"), freedom),
+				strings.Join(raised, ", "),
+			}, styles)
+		}
+
+		t.end()
+
+		fmt.Println()
+
+		if !hasWaves {
+			fmt.Println("no wave forecast here, it may be too far from open water")
+		}
+
+		if flagged == 0 {
+			fmt.Printf("nothing flagged in the next %d hours\n", hours)
+		} else {
+			fmt.Printf("%d of the next %d hours flagged, check the marine forecast before heading out\n", flagged, hours)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package schema describes the JSON document agwc prints with -format json.
+//
+// Every document names its shape in the Schema field. Within a version,
+// fields are only ever added: nothing is renamed, removed, or changes type
+// or meaning, so a consumer written against one release keeps working with
+// the next. Consumers should ignore fields they don't recognize. Anything
+// that would break that promise gets a new Version.
+package schema
+
+import "time"
+
+// Version is the value of Forecast.Schema for documents of this shape.
+const Version = "agwc.forecast/v1"
+
+// Forecast is one run of agwc: where it was for, which window it covers,
+// and the hourly values it displayed.
+type Forecast struct {
+	// Schema is always Version.
+	Schema string `json:"schema"`
+
+	// GeneratedAt is when agwc produced the document.
+	GeneratedAt time.Time `json:"generatedAt"`
+
+	Location Location `json:"location"`
+	Grid     Grid     `json:"grid"`
+	Window   Window   `json:"window"`
+
+	// Columns describes each entry of Hour.Values, in the same order.
+	Columns []Column `json:"columns"`
+
+	// Hours are on the hour, oldest first unless the run sorted them by a
+	// property.
+	Hours []Hour `json:"hours"`
+
+	// Alerts are the active NWS alerts for the location, present only when
+	// the run asked for them with -alerts.
+	Alerts []Alert `json:"alerts,omitempty"`
+
+	// Notices say which parts of the run, like Alerts, couldn't be fetched
+	// and were left out.
+	Notices []string `json:"notices,omitempty"`
+
+	// Page is present when serve mode split Hours across several
+	// responses, and says how to get the next one.
+	Page *Page `json:"page,omitempty"`
+}
+
+// Page is where a paginated response falls in the whole window.
+type Page struct {
+	// Limit is the most hours a response holds, and Total how many the
+	// whole window has.
+	Limit int `json:"limit"`
+	Total int `json:"total"`
+
+	// Next is the cursor for the following page, missing on the last one.
+	Next string `json:"next,omitempty"`
+}
+
+// Location is the address as asked for and where the geocoder put it.
+type Location struct {
+	Address   string  `json:"address"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Grid is the NWS forecast grid cell the location falls in.
+type Grid struct {
+	Office              string `json:"office"`
+	X                   int    `json:"x"`
+	Y                   int    `json:"y"`
+	ForecastGridDataURL string `json:"forecastGridDataURL"`
+
+	// IssuedAt is when NWS last updated the gridded forecast.
+	IssuedAt time.Time `json:"issuedAt"`
+}
+
+// Window is the span of time the run covers.
+type Window struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// TimeZone is the IANA name of the zone the run displayed times in.
+	// Times in the document are always RFC 3339 with their own offset.
+	TimeZone string `json:"timeZone"`
+}
+
+// Column is one displayed property.
+type Column struct {
+	// Name is an NWS gridpoint property, like "temperature", or the name of
+	// a -derive column.
+	Name string `json:"name"`
+
+	// Label is Name in the language a server was asked for with ?lang=,
+	// for showing to people. It's left out of agwc -format json.
+	Label string `json:"label,omitempty"`
+
+	// Unit is the unit the values are in, like "C", "F", "kph", or "%",
+	// and may be empty for unitless values.
+	Unit string `json:"unit"`
+
+	// Derived is set for columns computed from other columns.
+	Derived bool `json:"derived,omitempty"`
+
+	// Source is where the values come from, like an NWS gridpoint or the
+	// columns a derived one is computed from, and Computation what was
+	// done to them, like a -derive expression, if anything.
+	Source      string `json:"source,omitempty"`
+	Computation string `json:"computation,omitempty"`
+
+	// Coverage is the fraction of Hours, from 0 to 1, that have a value
+	// for this column, so a sparse series can be told apart from a full
+	// one without counting nulls.
+	Coverage *float64 `json:"coverage,omitempty"`
+}
+
+// Alert is an active NWS watch, warning, or advisory.
+type Alert struct {
+	ID    string `json:"id"`
+	Event string `json:"event"`
+
+	// Severity and Urgency are as NWS spells them, like "Severe" and
+	// "Expected".
+	Severity string `json:"severity"`
+	Urgency  string `json:"urgency"`
+
+	Headline string    `json:"headline"`
+	Onset    time.Time `json:"onset"`
+
+	// Ends is missing for alerts that last until further notice.
+	Ends *time.Time `json:"ends,omitempty"`
+}
+
+// Hour is one row of the forecast.
+type Hour struct {
+	Time time.Time `json:"time"`
+
+	// Observed is set for hours taken from station observations rather
+	// than the forecast.
+	Observed bool `json:"observed,omitempty"`
+
+	// Values lines up with Forecast.Columns. A null value means there was
+	// no data for that hour.
+	Values []*float64 `json:"values"`
+
+	// LeadHours is how far the hour is past Grid.IssuedAt, for forecast
+	// hours. LowConfidence is set once that's beyond the run's
+	// -low-confidence-after, where hourly detail is more guess than
+	// forecast.
+	LeadHours     *int `json:"leadHours,omitempty"`
+	LowConfidence bool `json:"lowConfidence,omitempty"`
+
+	// PrecipType is what precipitation will fall as, one of "none",
+	// "rain", "snow", "sleet" or "freezing rain", present only for
+	// forecast hours of runs with -precip-type.
+	PrecipType string `json:"precipType,omitempty"`
+}
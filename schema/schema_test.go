@@ -0,0 +1,41 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// a document written by the first release of this version has to keep
+// decoding into these types without losing anything, and encode back the
+// same way. if this fails, the change needs a new Version instead.
+func TestV1Compatibility(t *testing.T) {
+	golden, err := os.ReadFile("testdata/v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(golden))
+	dec.DisallowUnknownFields()
+
+	f := Forecast{}
+
+	err = dec.Decode(&f)
+	if err != nil {
+		t.Fatalf("v1 document no longer decodes: %s", err)
+	}
+
+	if f.Schema != Version {
+		t.Fatalf("v1 document has schema %q but Version is %q", f.Schema, Version)
+	}
+
+	encoded, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(bytes.TrimSpace(encoded), bytes.TrimSpace(golden)) {
+		t.Fatalf("v1 document does not round trip, got:\n%s", encoded)
+	}
+}
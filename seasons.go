@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a bundle of -properties for part of the year, like
+// {"months": "nov-mar", "properties": "temperature,windChill,snowfallAmount"}.
+// a season without months covers whatever months the others don't.
+type seasonConfig struct {
+	Months     string `json:"months,omitempty"`
+	Properties string `json:"properties"`
+}
+
+// a month as a number, like 11, or a name, like nov or november
+func parseMonth(s string) (time.Month, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if n, err := strconv.Atoi(s); err == nil && n >= 1 && n <= 12 {
+		return time.Month(n), nil
+	}
+
+	for m := time.January; m <= time.December; m++ {
+		name := strings.ToLower(m.String())
+		if s == name || s == name[:3] {
+			return m, nil
+		}
+	}
+
+	return 0, fmt.Errorf("'%s' is not a month", s)
+}
+
+// "nov-mar" or "6-8" wrap around the end of the year as needed, and a
+// single month is just that month
+func parseMonths(s string) (map[time.Month]bool, error) {
+	from, to := s, s
+	if i := strings.Index(s, "-"); i >= 0 {
+		from, to = s[:i], s[i+1:]
+	}
+
+	first, err := parseMonth(from)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := parseMonth(to)
+	if err != nil {
+		return nil, err
+	}
+
+	months := map[time.Month]bool{}
+	for m := first; ; m = m%12 + 1 {
+		months[m] = true
+
+		if m == last {
+			break
+		}
+	}
+
+	return months, nil
+}
+
+type season struct {
+	name       string
+	months     map[time.Month]bool
+	properties string
+}
+
+// the "seasons" from the config, set by configureSeasons, the catch all
+// last
+var seasons = []season{}
+
+func configureSeasons(cfg config) error {
+	parsed := []season{}
+	fallback := []season{}
+
+	for name, s := range cfg.Seasons {
+		for _, p := range strings.Split(s.Properties, ",") {
+			if _, ok := propertyRegistry[p]; !ok {
+				return fmt.Errorf("season '%s' property '%s' is not in %v", name, p, permittedProperties())
+			}
+		}
+
+		if s.Months == "" {
+			fallback = append(fallback, season{name: name, properties: s.Properties})
+			continue
+		}
+
+		months, err := parseMonths(s.Months)
+		if err != nil {
+			return fmt.Errorf("season '%s' months: %w", name, err)
+		}
+
+		parsed = append(parsed, season{name: name, months: months, properties: s.Properties})
+	}
+
+	if len(fallback) > 1 {
+		return fmt.Errorf("only one season can leave out months, but %d do", len(fallback))
+	}
+
+	// the same month in two seasons would be picked from at random
+	sort.Slice(parsed, func(i, j int) bool { return parsed[i].name < parsed[j].name })
+	for i := range parsed {
+		for j := i + 1; j < len(parsed); j++ {
+			for m := range parsed[i].months {
+				if parsed[j].months[m] {
+					return fmt.Errorf("seasons '%s' and '%s' both have %s", parsed[i].name, parsed[j].name, m)
+				}
+			}
+		}
+	}
+
+	seasons = append(parsed, fallback...)
+
+	return nil
+}
+
+// the season the given time is in, if the config has one for it
+func currentSeason(at time.Time) (season, bool) {
+	for _, s := range seasons {
+		if s.months == nil || s.months[at.Month()] {
+			return s, true
+		}
+	}
+
+	return season{}, false
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// secrets in the config can be written in place, or as a reference to
+// somewhere safer that's looked up when they're needed:
+//
+//	env:NAME           an environment variable
+//	file:/path         the contents of a file, minus trailing newlines
+//	keyring:account    the OS keyring entry for service "agwc", not on windows
+var secretSources = []string{"env:", "file:", "keyring:"}
+
+func isSecretReference(s string) bool {
+	for _, prefix := range secretSources {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func resolveSecret(s string) (string, error) {
+	var (
+		value string
+		err   error
+	)
+
+	switch {
+	case strings.HasPrefix(s, "env:"):
+		name := strings.TrimPrefix(s, "env:")
+
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+
+		value = v
+	case strings.HasPrefix(s, "file:"):
+		data, err := os.ReadFile(strings.TrimPrefix(s, "file:"))
+		if err != nil {
+			return "", fmt.Errorf("could not read secret file: %w", err)
+		}
+
+		value = strings.TrimRight(string(data), "\r\n")
+	case strings.HasPrefix(s, "keyring:"):
+		value, err = keyringLookup(strings.TrimPrefix(s, "keyring:"))
+		if err != nil {
+			return "", err
+		}
+	default:
+		value = s
+	}
+
+	registerSecret(value)
+
+	return value, nil
+}
+
+// the platform's own keyring tool, so there's nothing to link against, or
+// nil where there isn't one agwc knows how to ask, like on windows
+func keyringCommand(account string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("security", "find-generic-password", "-s", "agwc", "-a", account, "-w")
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("secret-tool", "lookup", "service", "agwc", "account", account)
+	default:
+		return nil
+	}
+}
+
+func keyringUnsupported() error {
+	return fmt.Errorf("keyring secrets are not supported on %s, use env: or file: instead", runtime.GOOS)
+}
+
+func keyringLookup(account string) (string, error) {
+	cmd := keyringCommand(account)
+	if cmd == nil {
+		return "", keyringUnsupported()
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not find '%s' in the keyring: %w", account, err)
+	}
+
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// a keyring reference where there's no keyring would only fail once the
+// secret is needed, like when an alert goes out, so the config is turned
+// away as soon as it's loaded instead
+func checkSecretReferences(cfg config) error {
+	if keyringCommand("") != nil {
+		return nil
+	}
+
+	for _, r := range cfg.Notify.Rules {
+		for i := range r.Transports {
+			for _, s := range r.Transports[i].secrets() {
+				if strings.HasPrefix(*s, "keyring:") {
+					return fmt.Errorf("rule '%s' has a %s transport secret in the keyring: %w", r.Name, r.Transports[i].Type, keyringUnsupported())
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// every secret we've resolved, so nothing we print can give one away
+var knownSecrets = struct {
+	sync.Mutex
+	values []string
+}{}
+
+func registerSecret(s string) {
+	// too short to be worth hiding, and would mangle everything else
+	if len(s) < 4 {
+		return
+	}
+
+	knownSecrets.Lock()
+	defer knownSecrets.Unlock()
+
+	knownSecrets.values = append(knownSecrets.values, s)
+}
+
+func redact(s string) string {
+	knownSecrets.Lock()
+	defer knownSecrets.Unlock()
+
+	for _, secret := range knownSecrets.values {
+		s = strings.ReplaceAll(s, secret, redactedSecret)
+	}
+
+	return s
+}
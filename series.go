@@ -0,0 +1,13 @@
+package main
+
+import "github.com/packrat386/agwc/forecast"
+
+// the forecast package's, by the names the rest of agwc has always used
+type (
+	series       = forecast.Series
+	weatherPoint = forecast.Point
+)
+
+func newSeries(points []weatherPoint) series {
+	return forecast.NewSeries(points)
+}
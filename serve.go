@@ -0,0 +1,390 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//go:embed web/dashboard.html
+var dashboardHTML string
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(dashboardHTML))
+
+// somewhere being recorded, with its forecasts and observations
+type skillLocation struct {
+	name        string
+	coordinates coordinates
+	grid        gridPoint
+	station     station
+}
+
+type locationSkill struct {
+	Name       string          `json:"name"`
+	Grid       string          `json:"grid"`
+	Station    string          `json:"station"`
+	Properties []propertySkill `json:"properties"`
+}
+
+func (l skillLocation) skill() (locationSkill, error) {
+	forecasts, err := readForecastHistory(l.grid.office, l.grid.x, l.grid.y)
+	if err != nil {
+		return locationSkill{}, err
+	}
+
+	observations, err := readObservationHistory(l.station.id)
+	if err != nil {
+		return locationSkill{}, err
+	}
+
+	return locationSkill{
+		Name:       l.name,
+		Grid:       gridHistoryName(l.grid.office, l.grid.x, l.grid.y),
+		Station:    l.station.id,
+		Properties: forecastSkill(forecasts, observations),
+	}, nil
+}
+
+// bars already laid out, since templates are no place for arithmetic
+type skillChart struct {
+	Property, Unit      string
+	Width, Height, Base int
+	Bars                []skillBar
+}
+
+type skillBar struct {
+	X, Y, W, H, LabelY int
+	Label, Title       string
+}
+
+const (
+	chartBarWidth  = 24
+	chartBarGap    = 6
+	chartMaxHeight = 120
+)
+
+func newSkillChart(lang string, p propertySkill) skillChart {
+	c := skillChart{
+		Property: translate(lang, p.Property),
+		Unit:     p.Unit,
+		Width:    len(p.Leads) * (chartBarWidth + chartBarGap),
+		Height:   chartMaxHeight + 20,
+		Base:     chartMaxHeight,
+	}
+
+	worst := 0.0
+	for _, l := range p.Leads {
+		if l.MAE > worst {
+			worst = l.MAE
+		}
+	}
+
+	for i, l := range p.Leads {
+		h := 0
+		if worst > 0 {
+			h = int(l.MAE / worst * chartMaxHeight)
+		}
+
+		c.Bars = append(c.Bars, skillBar{
+			X:      i * (chartBarWidth + chartBarGap),
+			Y:      chartMaxHeight - h,
+			W:      chartBarWidth,
+			H:      h,
+			LabelY: chartMaxHeight + 14,
+			Label:  fmt.Sprintf("%dh", l.LeadHours),
+			Title:  fmt.Sprintf(translate(lang, "%d-%dh ahead: mae %.2f, bias %+.2f, %d forecasts"), l.LeadHours, l.LeadHours+skillLeadBucket, l.MAE, l.Bias, l.Count),
+		})
+	}
+
+	return c
+}
+
+func skillHandler(locations []skillLocation, each func(w http.ResponseWriter, lang string, skills []locationSkill)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		system, err := requestUnitSystem(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		lang, err := requestLanguage(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Language", lang)
+
+		skills := []locationSkill{}
+
+		key, limited := requestKey(r)
+
+		for _, l := range locations {
+			if limited && !key.allows(l.name) {
+				continue
+			}
+
+			s, err := l.skill()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if system != nil {
+				s.Properties = convertSkill(*system, s.Properties)
+			}
+
+			for i, p := range s.Properties {
+				s.Properties[i].Label = translate(lang, p.Property)
+			}
+
+			skills = append(skills, s)
+		}
+
+		each(w, lang, skills)
+	}
+}
+
+// ?units= is a -unit-system name, or freedom, and without it skill is in
+// whatever units it was recorded in
+func requestUnitSystem(r *http.Request) (*unitSystem, error) {
+	name := r.URL.Query().Get("units")
+	if name == "" {
+		return nil, nil
+	}
+
+	if name == "freedom" {
+		name = "us"
+	}
+
+	system, ok := unitSystems[name]
+	if !ok {
+		return nil, fmt.Errorf("units '%s' is not freedom or one of %v", name, unitSystemNames())
+	}
+
+	return &system, nil
+}
+
+// ?lang= is one of servedLanguages, or english without it
+func requestLanguage(r *http.Request) (string, error) {
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		return servedLanguages[0], nil
+	}
+
+	// en-US and es_MX are english and spanish
+	base := strings.ToLower(lang)
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+
+	if indexOf(servedLanguages, base) < 0 {
+		return "", fmt.Errorf("language '%s' is not in %v", lang, servedLanguages)
+	}
+
+	return base, nil
+}
+
+// errors are differences, so a degree of error is 1.8 degrees F, not 33.8
+func convertSkill(system unitSystem, properties []propertySkill) []propertySkill {
+	converted := []propertySkill{}
+
+	for _, p := range properties {
+		c, ok := system.conversions[p.Unit]
+		if !ok {
+			converted = append(converted, p)
+			continue
+		}
+
+		leads := []leadSkill{}
+		for _, l := range p.Leads {
+			l.MAE, _ = system.convertDifference(l.MAE, p.Unit)
+			l.Bias, _ = system.convertDifference(l.Bias, p.Unit)
+			leads = append(leads, l)
+		}
+
+		converted = append(converted, propertySkill{Property: p.Property, Unit: c.to.Code(), Leads: leads})
+	}
+
+	return converted
+}
+
+func serveSkillJSON(w http.ResponseWriter, lang string, skills []locationSkill) {
+	w.Header().Set("Content-Type", "application/json")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(skills)
+}
+
+// what the dashboard template is given, which translates its own text with
+// {{.T "..."}}
+type dashboardPage struct {
+	Lang      string
+	Locations []dashboardLocation
+}
+
+type dashboardLocation struct {
+	Name, Grid, Station string
+	Charts              []skillChart
+}
+
+func (p dashboardPage) T(english string) string {
+	return translate(p.Lang, english)
+}
+
+func serveDashboard(w http.ResponseWriter, lang string, skills []locationSkill) {
+	page := dashboardPage{Lang: lang}
+	for _, s := range skills {
+		l := dashboardLocation{Name: s.Name, Grid: s.Grid, Station: s.Station}
+		for _, p := range s.Properties {
+			l.Charts = append(l.Charts, newSkillChart(lang, p))
+		}
+
+		page.Locations = append(page.Locations, l)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	err := dashboardTemplate.Execute(w, page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// serves how good the recorded forecasts have been, as a dashboard at / and
+// as JSON at /api/skill, to anyone or with -auth only to those with a key.
+// both take ?units= and ?lang=, so one server suits everyone in a house.
+func runServe(flagset *flag.FlagSet) func() {
+	var (
+		listen    string
+		addresses stringList
+		pinned    string
+		auth      bool
+		rateLimit int
+		adminKey  string
+		keysPath  string
+	)
+
+	flagset.StringVar(&listen, "listen", "localhost:8080", "host:port to serve on")
+	flagset.Var(&addresses, "address", "address being recorded, may be repeated")
+	flagset.StringVar(&pinned, "station", "", "station recorded instead of the nearest one, with a single address")
+	flagset.BoolVar(&auth, "auth", false, "require an API key, given as a bearer token or ?key=")
+	flagset.IntVar(&rateLimit, "rate-limit", 60, "requests a minute for keys that don't have their own limit")
+	flagset.StringVar(&adminKey, "admin-key", "", "enables issuing and revoking keys at /admin/keys with this as the bearer token, may be a secret reference like env:NAME")
+	flagset.StringVar(&keysPath, "keys", "", "file to keep API keys in, instead of serve-keys.json in the data directory")
+
+	return func() {
+		if len(addresses) == 0 {
+			errorAndQuit(fmt.Errorf("need at least one -address"))
+		}
+
+		if pinned != "" && len(addresses) > 1 {
+			errorAndQuit(fmt.Errorf("station can only be pinned with a single address"))
+		}
+
+		locations := []skillLocation{}
+		for _, a := range addresses {
+			c, err := getAddressCoordinates(a)
+			if err != nil {
+				errorAndQuit(err)
+			}
+
+			grid, s, err := recordTargetAt(c, pinned)
+			if err != nil {
+				errorAndQuit(err)
+			}
+
+			locations = append(locations, skillLocation{name: a, coordinates: c, grid: grid, station: s})
+		}
+
+		if rateLimit < 1 {
+			errorAndQuit(fmt.Errorf("rate-limit must be at least 1"))
+		}
+
+		var err error
+
+		if adminKey != "" {
+			adminKey, err = resolveSecret(adminKey)
+			if err != nil {
+				errorAndQuit(fmt.Errorf("could not resolve admin key: %w", err))
+			}
+		}
+
+		var store *keyStore
+		if auth || adminKey != "" {
+			if keysPath == "" {
+				keysPath, err = keyStorePath()
+				if err != nil {
+					errorAndQuit(err)
+				}
+			}
+
+			store, err = loadKeyStore(keysPath)
+			if err != nil {
+				errorAndQuit(err)
+			}
+		}
+
+		// the feed and forecasts are in agwc's defaults here, since the units
+		// are global and requests come in at the same time
+		var freedom bool
+		selectUnitSystem(defaultUnitSystem, &freedom)
+
+		loc, err := time.LoadLocation(defaultDisplayTZ)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		rules, err := loadDecisionRules()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/api/skill", skillHandler(locations, serveSkillJSON))
+		mux.Handle("/api/decisions", decisionsHandler(locations, rules))
+		mux.Handle("/api/irrigation", irrigationHandler(locations))
+		mux.Handle("/api/forecast", forecastHandler(locations, freedom, loc))
+		mux.Handle("/feed", feedHandler(locations, freedom, loc))
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+
+			skillHandler(locations, serveDashboard)(w, r)
+		})
+
+		var handler http.Handler = mux
+		if auth {
+			handler = requireKey(store, rateLimit, mux)
+		}
+
+		// the admin endpoint has its own key, so it's outside of -auth
+		if adminKey != "" {
+			outer := http.NewServeMux()
+			outer.Handle("/admin/keys", keysAdminHandler(store, adminKey, locations))
+			outer.Handle("/", handler)
+			handler = outer
+		}
+
+		server := &http.Server{
+			Addr:              listen,
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		fmt.Printf("serving forecast skill at http://%s/, press ctrl-c to stop\n", listen)
+
+		err = server.ListenAndServe()
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not serve: %w", err))
+		}
+	}
+}
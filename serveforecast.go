@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/packrat386/agwc/schema"
+)
+
+// hours in a serve mode forecast response unless ?limit= says otherwise,
+// and the most it can say, so a week of hours doesn't land on a small
+// device all at once
+const (
+	defaultPageLimit = 48
+	maxPageLimit     = 500
+)
+
+// the most ?hours= can ask for, the 16 days the grid ever reaches, since
+// every hour is a row whether the grid has it or not
+const maxForecastHours = 16 * 24
+
+// the hours of the page a request asks for, out of all of them, from the
+// ?cursor= a previous page gave as next or else the first. a cursor is when
+// the page starts rather than a count of pages, so it keeps its place as
+// the window moves along with the clock.
+func paginateHours(hours []schema.Hour, query map[string][]string) ([]schema.Hour, *schema.Page, error) {
+	get := func(name string) string {
+		if v := query[name]; len(v) > 0 {
+			return v[0]
+		}
+
+		return ""
+	}
+
+	limit := defaultPageLimit
+	if s := get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return nil, nil, fmt.Errorf("limit must be from 1 to %d, got '%s'", maxPageLimit, s)
+		}
+
+		limit = n
+	}
+
+	start := 0
+
+	if get("cursor") != "" {
+		unix, err := strconv.ParseInt(get("cursor"), 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cursor '%s' did not come from this server", get("cursor"))
+		}
+
+		at := time.Unix(unix, 0)
+
+		start = len(hours)
+		for i, h := range hours {
+			if !h.Time.Before(at) {
+				start = i
+				break
+			}
+		}
+	}
+
+	end := start + limit
+	if end > len(hours) {
+		end = len(hours)
+	}
+
+	page := &schema.Page{Limit: limit, Total: len(hours)}
+	if end < len(hours) {
+		page.Next = strconv.FormatInt(hours[end].Time.Unix(), 10)
+	}
+
+	return hours[start:end], page, nil
+}
+
+// the forecast document -format json prints, for ?location= or the only
+// one there is, over ?hours= from now with ?properties= and ?units=, a page
+// at a time
+func forecastHandler(locations []skillLocation, freedom bool, loc *time.Location) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		name := query.Get("location")
+		if name == "" && len(locations) == 1 {
+			name = locations[0].name
+		}
+
+		properties := []string{"temperature"}
+		if p := query.Get("properties"); p != "" {
+			properties = strings.Split(p, ",")
+		}
+
+		for _, p := range properties {
+			if _, ok := propertyRegistry[p]; !ok {
+				http.Error(w, fmt.Sprintf("requested property '%s' is not in %v", p, permittedProperties()), http.StatusBadRequest)
+				return
+			}
+		}
+
+		hours := 7 * 24
+		if s := query.Get("hours"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 || n > maxForecastHours {
+				http.Error(w, fmt.Sprintf("hours must be from 1 to %d, got '%s'", maxForecastHours, s), http.StatusBadRequest)
+				return
+			}
+
+			hours = n
+		}
+
+		system, err := requestUnitSystem(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		lang, err := requestLanguage(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Language", lang)
+
+		key, limited := requestKey(r)
+
+		for _, l := range locations {
+			if l.name != name || (limited && !key.allows(l.name)) {
+				continue
+			}
+
+			now := time.Now()
+
+			req := forecastRequest{
+				address:         l.name,
+				properties:      properties,
+				format:          "json",
+				start:           now,
+				end:             now.Add(time.Duration(hours-1) * time.Hour),
+				displayTimeZone: loc,
+				freedom:         freedom || system != nil,
+				system:          system,
+				lang:            lang,
+			}
+
+			forecast, err := getWeatherData(l.grid.forecastGridDataURL, req.fetchProperties())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+
+			req.issuedAt = forecast.updateTime
+
+			doc := newForecastDocument(req, l.coordinates, l.grid, forecast, buildRows(req, forecast.properties))
+
+			doc.Hours, doc.Page, err = paginateHours(doc.Hours, query)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			writeJSON(w, http.StatusOK, doc)
+
+			return
+		}
+
+		http.Error(w, fmt.Sprintf("no location '%s'", name), http.StatusNotFound)
+	}
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// API keys for serve -auth, for sharing a server with friends and family
+// without sharing everything. only a hash of each key is kept, so the key
+// itself is only ever seen when it's issued.
+type apiKey struct {
+	Name string `json:"name"`
+	Hash string `json:"hash"`
+
+	// requests a minute, or the server's -rate-limit if unset
+	RateLimit int `json:"rateLimit,omitempty"`
+
+	// names of the served locations it can see, or all of them if empty
+	Locations []string `json:"locations,omitempty"`
+
+	Created time.Time  `json:"created"`
+	Revoked *time.Time `json:"revoked,omitempty"`
+}
+
+func (k apiKey) allows(location string) bool {
+	return len(k.Locations) == 0 || indexOf(k.Locations, location) >= 0
+}
+
+// what the admin endpoint says about a key, which never includes the hash
+type apiKeyInfo struct {
+	Name      string     `json:"name"`
+	Key       string     `json:"key,omitempty"`
+	RateLimit int        `json:"rateLimit,omitempty"`
+	Locations []string   `json:"locations,omitempty"`
+	Created   time.Time  `json:"created"`
+	Revoked   *time.Time `json:"revoked,omitempty"`
+}
+
+func (k apiKey) info() apiKeyInfo {
+	return apiKeyInfo{Name: k.Name, RateLimit: k.RateLimit, Locations: k.Locations, Created: k.Created, Revoked: k.Revoked}
+}
+
+type keyStore struct {
+	sync.Mutex
+	path string
+	keys []apiKey
+
+	// a bucket of requests per key, refilled a little at a time
+	buckets map[string]*rateBucket
+}
+
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func keyStorePath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "serve-keys.json"), nil
+}
+
+// a missing file is no keys yet
+func loadKeyStore(path string) (*keyStore, error) {
+	s := &keyStore{path: path, buckets: map[string]*rateBucket{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read API keys: %w", err)
+	}
+
+	err = json.Unmarshal(data, &s.keys)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse API keys %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// has to be called with the lock held
+func (s *keyStore) save() error {
+	err := os.MkdirAll(filepath.Dir(s.path), 0o755)
+	if err != nil {
+		return fmt.Errorf("could not create data directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s.keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode API keys: %w", err)
+	}
+
+	err = os.WriteFile(s.path, append(data, '\n'), 0o600)
+	if err != nil {
+		return fmt.Errorf("could not write API keys: %w", err)
+	}
+
+	return nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *keyStore) issue(name string, rateLimit int, locations []string) (apiKeyInfo, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, k := range s.keys {
+		if k.Name == name && k.Revoked == nil {
+			return apiKeyInfo{}, fmt.Errorf("there's already a key named '%s'", name)
+		}
+	}
+
+	secret := make([]byte, 24)
+
+	_, err := rand.Read(secret)
+	if err != nil {
+		return apiKeyInfo{}, fmt.Errorf("could not generate key: %w", err)
+	}
+
+	key := "agwc_" + hex.EncodeToString(secret)
+
+	k := apiKey{
+		Name:      name,
+		Hash:      hashKey(key),
+		RateLimit: rateLimit,
+		Locations: locations,
+		Created:   time.Now().UTC(),
+	}
+
+	s.keys = append(s.keys, k)
+
+	err = s.save()
+	if err != nil {
+		s.keys = s.keys[:len(s.keys)-1]
+		return apiKeyInfo{}, err
+	}
+
+	info := k.info()
+	info.Key = key
+
+	return info, nil
+}
+
+// revoked keys are kept, so it's clear what a key that stopped working was
+func (s *keyStore) revoke(name string) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now().UTC()
+
+	for i, k := range s.keys {
+		if k.Name == name && k.Revoked == nil {
+			s.keys[i].Revoked = &now
+			return true, s.save()
+		}
+	}
+
+	return false, nil
+}
+
+func (s *keyStore) list() []apiKeyInfo {
+	s.Lock()
+	defer s.Unlock()
+
+	infos := []apiKeyInfo{}
+	for _, k := range s.keys {
+		infos = append(infos, k.info())
+	}
+
+	return infos
+}
+
+func (s *keyStore) find(key string) (apiKey, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	hash := hashKey(key)
+
+	for _, k := range s.keys {
+		if k.Revoked == nil && subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hash)) == 1 {
+			return k, true
+		}
+	}
+
+	return apiKey{}, false
+}
+
+// takes a request from the key's bucket, or says how long until there's
+// one to take
+func (s *keyStore) take(k apiKey, defaultLimit int, now time.Time) (bool, time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	limit := float64(defaultLimit)
+	if k.RateLimit > 0 {
+		limit = float64(k.RateLimit)
+	}
+
+	b := s.buckets[k.Hash]
+	if b == nil {
+		b = &rateBucket{tokens: limit, last: now}
+		s.buckets[k.Hash] = b
+	}
+
+	b.tokens = math.Min(limit, b.tokens+now.Sub(b.last).Minutes()*limit)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / limit * float64(time.Minute))
+	}
+
+	b.tokens--
+
+	return true, 0
+}
+
+type apiKeyContextKey struct{}
+
+// the key a request was made with, or false without -auth
+func requestKey(r *http.Request) (apiKey, bool) {
+	k, ok := r.Context().Value(apiKeyContextKey{}).(apiKey)
+	return k, ok
+}
+
+// as a bearer token, or as ?key= so the dashboard works from a bookmark
+func bearerToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	return r.URL.Query().Get("key")
+}
+
+func requireKey(store *keyStore, defaultLimit int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		k, ok := store.find(bearerToken(r))
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="agwc"`)
+			http.Error(w, "missing or unknown API key", http.StatusUnauthorized)
+			return
+		}
+
+		allowed, wait := store.take(k, defaultLimit, time.Now())
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, k)))
+	})
+}
+
+// GET lists the keys, POST issues one from {"name", "rateLimit",
+// "locations"} and answers with the key, and DELETE ?name= revokes one
+func keysAdminHandler(store *keyStore, adminKey string, locations []skillLocation) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(bearerToken(r)), []byte(adminKey)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="agwc admin"`)
+			http.Error(w, "missing or wrong admin key", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, store.list())
+		case http.MethodPost:
+			body := struct {
+				Name      string   `json:"name"`
+				RateLimit int      `json:"rateLimit"`
+				Locations []string `json:"locations"`
+			}{}
+
+			err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("could not parse request body: %s", err), http.StatusBadRequest)
+				return
+			}
+
+			if body.Name == "" {
+				http.Error(w, "name cannot be empty", http.StatusBadRequest)
+				return
+			}
+
+			if body.RateLimit < 0 {
+				http.Error(w, "rateLimit cannot be negative", http.StatusBadRequest)
+				return
+			}
+
+			served := []string{}
+			for _, l := range locations {
+				served = append(served, l.name)
+			}
+
+			for _, l := range body.Locations {
+				if indexOf(served, l) < 0 {
+					http.Error(w, fmt.Sprintf("location '%s' is not in %v", l, served), http.StatusBadRequest)
+					return
+				}
+			}
+
+			info, err := store.issue(body.Name, body.RateLimit, body.Locations)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			writeJSON(w, http.StatusCreated, info)
+		case http.MethodDelete:
+			found, err := store.revoke(r.URL.Query().Get("name"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if !found {
+				http.Error(w, "no such key", http.StatusNotFound)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
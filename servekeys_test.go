@@ -0,0 +1,115 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKeyStoreTake(t *testing.T) {
+	s := &keyStore{buckets: map[string]*rateBucket{}}
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	defaulted := apiKey{Name: "defaulted", Hash: "a"}
+	limited := apiKey{Name: "limited", Hash: "b", RateLimit: 2}
+
+	takeAll := func(k apiKey, at time.Duration) int {
+		n := 0
+		for {
+			ok, _ := s.take(k, 60, start.Add(at))
+			if !ok {
+				return n
+			}
+
+			n++
+		}
+	}
+
+	type step struct {
+		key  apiKey
+		at   time.Duration
+		want bool
+		wait time.Duration
+	}
+
+	// a new bucket starts full, at the key's own limit if it has one
+	if n := takeAll(defaulted, 0); n != 60 {
+		t.Errorf("took %d from a new bucket of 60", n)
+	}
+
+	if n := takeAll(limited, 0); n != 2 {
+		t.Errorf("took %d from a new bucket of 2", n)
+	}
+
+	for i, st := range []step{
+		// 60 a minute refills one a second
+		{defaulted, 0, false, time.Second},
+		{defaulted, 250 * time.Millisecond, false, 750 * time.Millisecond},
+		{defaulted, time.Second, true, 0},
+		{defaulted, time.Second, false, time.Second},
+
+		// 2 a minute refills one every 30 seconds
+		{limited, 10 * time.Second, false, 20 * time.Second},
+		{limited, 30 * time.Second, true, 0},
+		{limited, 31 * time.Second, false, 29 * time.Second},
+	} {
+		ok, wait := s.take(st.key, 60, start.Add(st.at))
+		if ok != st.want || wait < st.wait-time.Millisecond || wait > st.wait+time.Millisecond {
+			t.Errorf("step %d, %s at %s: took %t, wait %s, wanted %t, %s", i, st.key.Name, st.at, ok, wait, st.want, st.wait)
+		}
+	}
+
+	// however long it sits, a bucket never holds more than the limit
+	if n := takeAll(defaulted, time.Hour); n != 60 {
+		t.Errorf("took %d after an hour, wanted the limit of 60", n)
+	}
+}
+
+func TestKeyStoreIssueAndRevoke(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serve-keys.json")
+
+	s, err := loadKeyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := s.issue("mom", 30, []string{"cabin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.issue("mom", 0, nil)
+	if err == nil {
+		t.Errorf("issued a second key named mom")
+	}
+
+	// only the hash is kept, and it survives a restart
+	s, err = loadKeyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k, ok := s.find(info.Key)
+	if !ok || k.Name != "mom" || k.Hash == info.Key || !k.allows("cabin") || k.allows("home") {
+		t.Errorf("found %+v, %t", k, ok)
+	}
+
+	if _, ok := s.find(info.Key + "x"); ok {
+		t.Errorf("found a key that was never issued")
+	}
+
+	revoked, err := s.revoke("mom")
+	if err != nil || !revoked {
+		t.Fatalf("revoked %t, %v", revoked, err)
+	}
+
+	if _, ok := s.find(info.Key); ok {
+		t.Errorf("found a revoked key")
+	}
+
+	// the name's free again once its key is revoked
+	_, err = s.issue("mom", 0, nil)
+	if err != nil {
+		t.Errorf("could not reissue mom: %s", err)
+	}
+}
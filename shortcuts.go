@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// hours in the list, at most
+const shortcutsHours = 24
+
+// one level of strings, since iOS Shortcuts can pull a key out of a
+// dictionary but does badly with anything nested deeper or with numbers
+// that need a unit put back on. every hour has its values as the table
+// shows them under the column names, plus "time" and a "display" line
+// ready to show or speak.
+type shortcutsOutput struct {
+	Location  string              `json:"location"`
+	Issued    string              `json:"issued"`
+	Condition string              `json:"condition"`
+	Display   string              `json:"display"`
+	Hours     []map[string]string `json:"hours"`
+}
+
+func writeForecastShortcuts(w io.Writer, req forecastRequest, forecast gridForecast, rows []displayRow) error {
+	upcoming := []displayRow{}
+	for _, r := range rows {
+		if !r.observed && len(upcoming) < shortcutsHours {
+			upcoming = append(upcoming, r)
+		}
+	}
+
+	if len(upcoming) == 0 {
+		return fmt.Errorf("no hours match your criteria in the requested window")
+	}
+
+	columns := req.columns()
+	visible := req.visibleColumns()
+
+	display := func(r displayRow) string {
+		parts := []string{}
+		for _, i := range visible {
+			parts = append(parts, fmt.Sprintf("%s %s", columns[i], r.values[i]))
+		}
+
+		return strings.Join(parts, ", ")
+	}
+
+	current := upcoming[0]
+	c := condition(forecast, current.at, current.at.Add(time.Hour))
+	now := strings.ReplaceAll(c, "-", " ")
+
+	out := shortcutsOutput{
+		Location:  req.address,
+		Condition: now,
+		Display:   strings.TrimSpace(fmt.Sprintf("%s %s: %s", conditionGlyphs[c], now, display(current))),
+		Hours:     []map[string]string{},
+	}
+
+	if !forecast.updateTime.IsZero() {
+		out.Issued = forecast.updateTime.In(req.displayTimeZone).Format("Mon 15:04")
+	}
+
+	for _, r := range upcoming {
+		at := r.at.In(req.displayTimeZone).Format("Mon 15:04")
+
+		hour := map[string]string{
+			"time":    at,
+			"display": at + " " + display(r),
+		}
+
+		for _, i := range visible {
+			hour[columns[i]] = r.values[i]
+		}
+
+		out.Hours = append(out.Hours, hour)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	err := enc.Encode(out)
+	if err != nil {
+		return fmt.Errorf("could not write shortcuts JSON: %w", err)
+	}
+
+	return nil
+}
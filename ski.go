@@ -0,0 +1,272 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/packrat386/agwc/units"
+)
+
+const skiDays = 3
+
+// the standard atmosphere cools about this much per km of height, which is
+// a fair guess on a mountain when there's nothing better
+const lapseRate = 6.5
+
+// lifts run from about 9 to 4, and snow that falls after they close is
+// what's fresh in the morning
+const (
+	liftsOpen  = 9
+	liftsClose = 16
+)
+
+// gusts that tend to get exposed chairs and gondolas put on wind hold
+const liftHoldGust = 65
+
+var skiProperties = []string{"snowfallAmount", "temperature", "windSpeed", "windGust", "visibility"}
+
+type skiDay struct {
+	day        time.Time
+	overnight  float64
+	daytime    float64
+	base       seriesRange
+	summit     *float64
+	windChill  *float64
+	gust       *float64
+	visibility *float64
+}
+
+// the NWS wind chill, which is only defined when it's cold and windy enough
+// to matter
+func windChillCelsius(temperature, wind float64) *float64 {
+	if temperature > 10 || wind <= 4.8 {
+		return nil
+	}
+
+	v := 13.12 + 0.6215*temperature - 11.37*math.Pow(wind, 0.16) + 0.3965*temperature*math.Pow(wind, 0.16)
+
+	return &v
+}
+
+// the grid's temperature moved from its elevation to another, or as it is
+// when either isn't known
+func atElevation(temperature float64, from, to *float64) float64 {
+	if from == nil || to == nil {
+		return temperature
+	}
+
+	return temperature - lapseRate*(*to-*from)/1000
+}
+
+func skiForecast(day time.Time, forecast gridForecast, summit *float64) skiDay {
+	d := skiDay{day: day}
+
+	open := day.Add(liftsOpen * time.Hour)
+	closed := day.Add(liftsClose * time.Hour)
+
+	d.overnight, _ = totalOver(forecast.properties["snowfallAmount"], closed.AddDate(0, 0, -1), open)
+	d.daytime, _ = totalOver(forecast.properties["snowfallAmount"], open, closed)
+
+	temperatures := []*float64{}
+	summits := []*float64{}
+	chills := []*float64{}
+	gusts := []*float64{}
+	visibilities := []*float64{}
+
+	for at := open; at.Before(closed); at = at.Add(time.Hour) {
+		t, ok := findPointAt(forecast.properties["temperature"], at)
+		if !ok || t.Value == nil {
+			continue
+		}
+
+		temperatures = append(temperatures, t.Value)
+
+		top := atElevation(*t.Value, forecast.elevation, summit)
+		summits = append(summits, &top)
+
+		// the chill is for whoever's up top on the chair, which is where
+		// it's coldest
+		if w, ok := findPointAt(forecast.properties["windSpeed"], at); ok && w.Value != nil {
+			chills = append(chills, windChillCelsius(top, *w.Value))
+		}
+
+		if g, ok := findPointAt(forecast.properties["windGust"], at); ok {
+			gusts = append(gusts, g.Value)
+		}
+
+		if v, ok := findPointAt(forecast.properties["visibility"], at); ok {
+			visibilities = append(visibilities, v.Value)
+		}
+	}
+
+	d.base = rangeOf(temperatures)
+	d.summit = rangeOf(summits).min
+	d.windChill = rangeOf(chills).min
+	d.gust = rangeOf(gusts).max
+	d.visibility = rangeOf(visibilities).min
+
+	return d
+}
+
+// heights of mountains are in feet or meters, even in unit systems that
+// put other distances in miles
+func elevationUnit(freedom bool) string {
+	if c, ok := activeUnitSystem.conversions["wmoUnit:m"]; ok && freedom && c.unit == toFeet.unit {
+		return "wmoUnit:ft"
+	}
+
+	return "wmoUnit:m"
+}
+
+func formatElevation(meters float64, freedom bool) string {
+	unit := units.MustParse(elevationUnit(freedom))
+
+	v, _ := units.Convert(meters, units.MustParse("wmoUnit:m"), unit)
+
+	return formatNumber(v, 0) + " " + unit.Symbol()
+}
+
+// like "3500m" or "11500ft", or a bare number in the units being displayed
+func parseElevation(s string, freedom bool) (*float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	unit := elevationUnit(freedom)
+
+	switch {
+	case strings.HasSuffix(s, "ft"):
+		unit, s = "wmoUnit:ft", strings.TrimSuffix(s, "ft")
+	case strings.HasSuffix(s, "m"):
+		unit, s = "wmoUnit:m", strings.TrimSuffix(s, "m")
+	}
+
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse elevation: %w", err)
+	}
+
+	meters, err := units.Convert(v, units.MustParse(unit), units.MustParse("wmoUnit:m"))
+	if err != nil {
+		return nil, fmt.Errorf("could not convert elevation: %w", err)
+	}
+
+	return &meters, nil
+}
+
+func runSki(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		summitFlag   string
+		displaytz    string
+		freedom      bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address or named location of the resort")
+	flagset.StringVar(&summitFlag, "summit", "", "elevation of the summit, like 3500m or 11500ft, to adjust temperatures up top from the forecast grid's elevation")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone of the resort and in which to display days")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		summit, err := parseElevation(summitFlag, freedom)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, skiProperties)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		snow := func(mm float64) string {
+			return formatWeatherValue("snowfallAmount", weatherPoint{Value: &mm, Unit: "wmoUnit:mm"}, freedom)
+		}
+
+		t := newTable(os.Stdout, []int{10, 12, 12, 17, 11, 11, 11, 11}, []string{"day", "new snow", "on the day", "base", "summit", "wind chill", "gust", "visibility"})
+
+		now := time.Now().In(loc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+		days := []skiDay{}
+		total := 0.0
+
+		for i := 0; i < skiDays; i++ {
+			d := skiForecast(today.AddDate(0, 0, i), forecast, summit)
+			days = append(days, d)
+			total += d.overnight + d.daytime
+
+			base := "No Data"
+			if d.base.min != nil {
+				base = formatCelsius(d.base.min, freedom) + " to " + formatCelsius(d.base.max, freedom)
+			}
+
+			t.row([]string{
+				d.day.Format("Mon Jan 02"),
+				snow(d.overnight),
+				snow(d.daytime),
+				base,
+				formatCelsius(d.summit, freedom),
+				formatCelsius(d.windChill, freedom),
+				formatKph(d.gust, freedom),
+				formatWeatherValue("visibility", weatherPoint{Value: d.visibility, Unit: "wmoUnit:m"}, freedom),
+			}, nil)
+		}
+
+		t.end()
+
+		fmt.Println()
+
+		switch {
+		case summit != nil && forecast.elevation != nil:
+			fmt.Printf("summit temperatures are for %s, from the forecast grid at %s cooling %s C per km up\n", formatElevation(*summit, freedom), formatElevation(*forecast.elevation, freedom), formatNumber(lapseRate, 1))
+		case summit != nil:
+			fmt.Println("the forecast grid didn't say its elevation, so summit temperatures aren't adjusted")
+		case forecast.elevation != nil:
+			fmt.Printf("temperatures are for the forecast grid at %s, use -summit to adjust them for the top\n", formatElevation(*forecast.elevation, freedom))
+		}
+
+		fmt.Printf("%s of snow over the next %d days\n", snow(total), skiDays)
+
+		best := days[0]
+		for _, d := range days[1:] {
+			if d.overnight > best.overnight {
+				best = d
+			}
+		}
+
+		if best.overnight > 0 {
+			fmt.Printf("freshest morning is %s, with %s overnight\n", best.day.Format("Mon Jan 02"), snow(best.overnight))
+		}
+
+		for _, d := range days {
+			if d.gust != nil && *d.gust >= liftHoldGust {
+				fmt.Printf("gusts to %s on %s may put lifts on wind hold\n", formatKph(d.gust, freedom), d.day.Format("Mon Jan 02"))
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// forecasts are judged against the nearest observation within this much of
+// the hour they were for, since stations report a few minutes either side
+const skillMatchWindow = 30 * time.Minute
+
+// lead times are grouped this coarsely, or a week of hours is too noisy to
+// read anything from
+const skillLeadBucket = 6
+
+type leadSkill struct {
+	LeadHours int     `json:"leadHours"`
+	Count     int     `json:"count"`
+	MAE       float64 `json:"mae"`
+	Bias      float64 `json:"bias"`
+}
+
+type propertySkill struct {
+	Property string      `json:"property"`
+	Label    string      `json:"label,omitempty"`
+	Unit     string      `json:"unit"`
+	Leads    []leadSkill `json:"leads"`
+}
+
+// how far off each recorded forecast was from what was then observed, by
+// how far ahead it was forecast. bias is forecast minus observed, so a warm
+// forecast has a positive bias.
+func forecastSkill(forecasts []recordedForecast, observations []recordedObservation) []propertySkill {
+	sort.Slice(observations, func(i, j int) bool { return observations[i].Time.Before(observations[j].Time) })
+
+	type key struct {
+		property string
+		lead     int
+	}
+
+	type sums struct {
+		count       int
+		absErr, err float64
+	}
+
+	totals := map[key]*sums{}
+	units := map[string]string{}
+
+	for _, f := range forecasts {
+		o, ok := nearestObservation(observations, f.Time)
+		if !ok {
+			continue
+		}
+
+		for property, forecast := range f.Values {
+			observed, ok := o.Values[property]
+			if !ok || observed.Unit != forecast.Unit {
+				continue
+			}
+
+			k := key{property: property, lead: f.LeadHours / skillLeadBucket * skillLeadBucket}
+			if totals[k] == nil {
+				totals[k] = &sums{}
+			}
+
+			diff := forecast.Value - observed.Value
+
+			totals[k].count++
+			totals[k].absErr += math.Abs(diff)
+			totals[k].err += diff
+
+			units[property] = forecast.Unit
+		}
+	}
+
+	byProperty := map[string]*propertySkill{}
+
+	for k, s := range totals {
+		p := byProperty[k.property]
+		if p == nil {
+			p = &propertySkill{Property: k.property, Unit: units[k.property]}
+			byProperty[k.property] = p
+		}
+
+		p.Leads = append(p.Leads, leadSkill{
+			LeadHours: k.lead,
+			Count:     s.count,
+			MAE:       s.absErr / float64(s.count),
+			Bias:      s.err / float64(s.count),
+		})
+	}
+
+	skills := []propertySkill{}
+	for _, p := range byProperty {
+		sort.Slice(p.Leads, func(i, j int) bool { return p.Leads[i].LeadHours < p.Leads[j].LeadHours })
+		skills = append(skills, *p)
+	}
+
+	sort.Slice(skills, func(i, j int) bool { return skills[i].Property < skills[j].Property })
+
+	return skills
+}
+
+// observations have to be sorted by time
+func nearestObservation(observations []recordedObservation, at time.Time) (recordedObservation, bool) {
+	i := sort.Search(len(observations), func(i int) bool { return !observations[i].Time.Before(at) })
+
+	best, found := recordedObservation{}, false
+	bestGap := skillMatchWindow + 1
+
+	for _, j := range []int{i - 1, i} {
+		if j < 0 || j >= len(observations) {
+			continue
+		}
+
+		gap := observations[j].Time.Sub(at)
+		if gap < 0 {
+			gap = -gap
+		}
+
+		if gap <= skillMatchWindow && gap < bestGap {
+			best, found, bestGap = observations[j], true, gap
+		}
+	}
+
+	return best, found
+}
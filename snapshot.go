@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const snapshotKind = "agwc.snapshot/v1"
+
+// everything needed to show a forecast again somewhere else: the flags it was
+// asked for with, pinned to the window they meant at the time, and every
+// upstream response that went into it
+type snapshot struct {
+	Kind      string             `json:"kind"`
+	TakenAt   time.Time          `json:"takenAt"`
+	Args      []string           `json:"args"`
+	Responses []recordedResponse `json:"responses"`
+}
+
+type recordedResponse struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	Accept      string      `json:"accept,omitempty"`
+	RequestBody []byte      `json:"requestBody,omitempty"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body"`
+}
+
+func recordKey(method, url, accept string, body []byte) string {
+	return method + " " + url + " " + accept + " " + string(body)
+}
+
+// reads the body of a request without using it up
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.GetBody == nil {
+		return nil, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return io.ReadAll(body)
+}
+
+// sits behind the fetch coordinator, so each distinct request is recorded
+// once however many parts of the run asked for it
+type recordingTransport struct {
+	next http.RoundTripper
+
+	mu        sync.Mutex
+	responses []recordedResponse
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.responses = append(t.responses, recordedResponse{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Accept:      req.Header.Get("Accept"),
+		RequestBody: reqBody,
+		Status:      res.StatusCode,
+		Header:      res.Header.Clone(),
+		Body:        body,
+	})
+	t.mu.Unlock()
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	return res, nil
+}
+
+// answers only from a snapshot, so rendering one never touches the network
+type replayTransport struct {
+	responses map[string]recordedResponse
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := t.responses[recordKey(req.Method, req.URL.String(), req.Header.Get("Accept"), reqBody)]
+	if !ok {
+		return nil, fmt.Errorf("%s is not in the snapshot, try taking it again with the same flags", req.URL)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}, nil
+}
+
+// swaps out what the fetch coordinator talks to, keeping its dedup in front
+func setUpstream(wrap func(next http.RoundTripper) http.RoundTripper) {
+	fc, ok := httpClient.Transport.(*fetchCoordinator)
+	if !ok {
+		httpClient.Transport = wrap(httpClient.Transport)
+		return
+	}
+
+	fc.next = wrap(fc.next)
+}
+
+func writeSnapshot(path string, s snapshot) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create snapshot file: %w", err)
+	}
+	defer f.Close()
+
+	zw := gzip.NewWriter(f)
+
+	err = json.NewEncoder(zw).Encode(s)
+	if err != nil {
+		return fmt.Errorf("could not write snapshot: %w", err)
+	}
+
+	err = zw.Close()
+	if err != nil {
+		return fmt.Errorf("could not write snapshot: %w", err)
+	}
+
+	return nil
+}
+
+func readSnapshot(path string) (snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return snapshot{}, fmt.Errorf("could not open snapshot file: %w", err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return snapshot{}, fmt.Errorf("could not read snapshot %s: %w", path, err)
+	}
+
+	s := snapshot{}
+
+	err = json.NewDecoder(zr).Decode(&s)
+	if err != nil {
+		return snapshot{}, fmt.Errorf("could not parse snapshot %s: %w", path, err)
+	}
+
+	if s.Kind != snapshotKind {
+		return snapshot{}, fmt.Errorf("%s is a '%s', expected '%s'", path, s.Kind, snapshotKind)
+	}
+
+	return s, nil
+}
+
+// takes -o out of the arguments, leaving the rest for the forecast
+func splitOutputFlag(args []string) (string, []string) {
+	output := ""
+	rest := []string{}
+
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; {
+		case (a == "-o" || a == "--o") && i+1 < len(args):
+			output = args[i+1]
+			i++
+		case strings.HasPrefix(a, "-o=") || strings.HasPrefix(a, "--o="):
+			output = a[strings.Index(a, "=")+1:]
+		default:
+			rest = append(rest, a)
+		}
+	}
+
+	return output, rest
+}
+
+func runSnapshot(args []string) {
+	output, rest := splitOutputFlag(args[1:])
+	if output == "" {
+		errorAndQuit(fmt.Errorf("snapshot needs -o with a file to write, e.g. 'agwc snapshot -o trip.agwc -address ...'"))
+	}
+
+	forecastArgs := append([]string{args[0]}, rest...)
+
+	req, err := getForecastRequest(forecastArgs)
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	recorder := &recordingTransport{}
+	setUpstream(func(next http.RoundTripper) http.RoundTripper {
+		recorder.next = next
+		return recorder
+	})
+
+	coordinates, err := getAddressCoordinates(req.address)
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	req, err = req.resolveWindow(coordinates)
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	// "the next 12 hours" means something different by the time it's
+	// rendered, so pin it down
+	rest = append(rest, "-start", req.start.Format(time.RFC3339), "-end", req.end.Format(time.RFC3339))
+
+	runForecast(append([]string{args[0]}, rest...))
+
+	err = writeSnapshot(output, snapshot{
+		Kind:      snapshotKind,
+		TakenAt:   time.Now().UTC(),
+		Args:      rest,
+		Responses: recorder.responses,
+	})
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	fmt.Println()
+	fmt.Printf("saved snapshot with %d responses to %s\n", len(recorder.responses), output)
+}
+
+func runRender(args []string) {
+	if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+		errorAndQuit(fmt.Errorf("expected 'agwc render <snapshot>', optionally followed by forecast flags to change like -format, -freedom or -displaytz"))
+	}
+
+	s, err := readSnapshot(args[1])
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	responses := map[string]recordedResponse{}
+	for _, r := range s.Responses {
+		responses[recordKey(r.Method, r.URL, r.Accept, r.RequestBody)] = r
+	}
+
+	setUpstream(func(http.RoundTripper) http.RoundTripper {
+		return replayTransport{responses: responses}
+	})
+
+	// later flags win, so anything given here overrides the snapshot's
+	forecastArgs := append([]string{args[0]}, s.Args...)
+	forecastArgs = append(forecastArgs, args[2:]...)
+
+	runForecast(forecastArgs)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+const snowDays = 3
+
+type snowDayOdds struct {
+	day         time.Time
+	overnight   float64
+	daytime     float64
+	low         *float64
+	gust        *float64
+	probability float64
+}
+
+// snow that falls before the buses run is what closes schools, snow during
+// the day mostly means an early dismissal, so the night before counts for
+// more. cold makes it stick and wind drifts it back over the roads.
+func snowDayChance(day time.Time, forecast gridForecast) snowDayOdds {
+	odds := snowDayOdds{day: day}
+
+	eveningBefore := day.Add(-6 * time.Hour)
+	busTime := day.Add(7 * time.Hour)
+	dismissal := day.Add(15 * time.Hour)
+
+	odds.overnight, _ = totalOver(forecast.properties["snowfallAmount"], eveningBefore, busTime)
+	odds.daytime, _ = totalOver(forecast.properties["snowfallAmount"], busTime, dismissal)
+
+	lows := []*float64{}
+	gusts := []*float64{}
+
+	for at := eveningBefore; at.Before(dismissal); at = at.Add(time.Hour) {
+		if p, ok := findPointAt(forecast.properties["temperature"], at); ok {
+			lows = append(lows, p.Value)
+		}
+
+		if p, ok := findPointAt(forecast.properties["windGust"], at); ok {
+			gusts = append(gusts, p.Value)
+		}
+	}
+
+	odds.low = rangeOf(lows).min
+	odds.gust = rangeOf(gusts).max
+
+	// effective cm of snow, where about 8 of them is a coin flip
+	effective := odds.overnight/10 + 0.4*odds.daytime/10
+
+	if odds.low != nil && *odds.low < -5 {
+		effective *= 1.3
+	} else if odds.low != nil && *odds.low > 1 {
+		effective *= 0.5
+	}
+
+	if odds.gust != nil && *odds.gust >= 40 {
+		effective *= 1.25
+	}
+
+	odds.probability = 100 * (1 - math.Exp(-effective*math.Ln2/8))
+
+	// nobody has ever been certain of a snow day
+	if odds.probability > 95 {
+		odds.probability = 95
+	}
+
+	return odds
+}
+
+func snowDayVerdict(o snowDayOdds) string {
+	switch wd := o.day.Weekday(); {
+	case wd == time.Saturday || wd == time.Sunday:
+		return "it's the weekend anyway"
+	case o.probability >= 75:
+		return "pajamas inside out, spoon under the pillow"
+	case o.probability >= 40:
+		return "worth staying up for the announcement"
+	case o.probability >= 10:
+		return "maybe a delay"
+	default:
+		return "do your homework"
+	}
+}
+
+func runSnowDay(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		displaytz    string
+		freedom      bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address of the school")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone of the school day and in which to display times")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, []string{"snowfallAmount", "temperature", "windGust"})
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		snow := func(mm float64) string {
+			return formatWeatherValue("snowfallAmount", weatherPoint{Value: &mm, Unit: "wmoUnit:mm"}, freedom)
+		}
+
+		widths := []int{10, 15, 15, 11, 15, 6, 42}
+
+		t := newTable(os.Stdout, widths, []string{"day", "overnight snow", "daytime snow", "low", "gust", "odds", ""})
+
+		now := time.Now().In(loc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+		for d := 1; d <= snowDays; d++ {
+			o := snowDayChance(today.AddDate(0, 0, d), forecast)
+
+			t.row([]string{
+				o.day.Format("Mon Jan 02"),
+				snow(o.overnight),
+				snow(o.daytime),
+				formatCelsius(o.low, freedom),
+				formatKph(o.gust, freedom),
+				formatNumber(o.probability, 0) + "%",
+				snowDayVerdict(o),
+			}, nil)
+		}
+
+		t.end()
+	}
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// what the fake api.weather.gov does to a request instead of answering it
+var soakFaults = []string{"timeout", "429", "500", "malformed"}
+
+// a stand in for api.weather.gov that answers the requests the daemon's
+// notify rules and recording jobs make, from the bench fixture, and now
+// and then breaks one the way the real one does
+type fakeNWS struct {
+	rate    float64
+	timeout time.Duration
+
+	mu       sync.Mutex
+	random   *rand.Rand
+	requests int
+	faults   map[string]int
+}
+
+func (f *fakeNWS) fault() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests++
+
+	if f.random.Float64() >= f.rate {
+		return ""
+	}
+
+	kind := soakFaults[f.random.Intn(len(soakFaults))]
+	f.faults[kind]++
+
+	return kind
+}
+
+func (f *fakeNWS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body []byte
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/points/"):
+		body = []byte(`{"properties": {
+			"gridId": "TST", "gridX": 10, "gridY": 20,
+			"forecastGridData": "https://api.weather.gov/gridpoints/TST/10,20",
+			"forecast": "https://api.weather.gov/gridpoints/TST/10,20/forecast",
+			"forecastHourly": "https://api.weather.gov/gridpoints/TST/10,20/forecast/hourly",
+			"observationStations": "https://api.weather.gov/gridpoints/TST/10,20/stations",
+			"radarStation": "KTST",
+			"forecastZone": "https://api.weather.gov/zones/forecast/TSZ001"
+		}}`)
+	case strings.HasPrefix(r.URL.Path, "/gridpoints/"):
+		body = benchFixture
+	case r.URL.Path == "/alerts/active":
+		w.Header().Set("ETag", `"soak"`)
+		if r.Header.Get("If-None-Match") == `"soak"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		body = []byte(`{"type": "FeatureCollection", "features": []}`)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	switch f.fault() {
+	case "timeout":
+		// longer than the client waits, unless it hangs up first
+		select {
+		case <-time.After(f.timeout + time.Second):
+		case <-r.Context().Done():
+			return
+		}
+	case "429":
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+		return
+	case "500":
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	case "malformed":
+		body = body[:len(body)/2]
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.Write(body)
+}
+
+// sends everything for api.weather.gov to the fake instead, keeping the
+// URLs the rest of agwc checks for
+type fakeUpstreamTransport struct {
+	host string
+	next http.RoundTripper
+}
+
+func (t fakeUpstreamTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != "api.weather.gov" {
+		return nil, fmt.Errorf("soak has no fake for %s", req.URL.Host)
+	}
+
+	req = req.Clone(req.Context())
+	req.URL.Scheme = "http"
+	req.URL.Host = t.host
+
+	return t.next.RoundTrip(req)
+}
+
+type soakSample struct {
+	elapsed    time.Duration
+	cycles     int
+	failed     int
+	goroutines int
+	heap       uint64
+	gcs        uint32
+}
+
+func takeSoakSample(start time.Time, cycles, failed int) soakSample {
+	runtime.GC()
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	return soakSample{
+		elapsed:    time.Since(start).Round(time.Second),
+		cycles:     cycles,
+		failed:     failed,
+		goroutines: runtime.NumGoroutine(),
+		heap:       m.HeapAlloc,
+		gcs:        m.NumGC,
+	}
+}
+
+func (s soakSample) cells() []string {
+	return []string{
+		s.elapsed.String(),
+		strconv.Itoa(s.cycles),
+		strconv.Itoa(s.failed),
+		strconv.Itoa(s.goroutines),
+		fmt.Sprintf("%.1f MiB", float64(s.heap)/(1<<20)),
+		strconv.Itoa(int(s.gcs)),
+	}
+}
+
+// runs what the daemon does over and over, finding the grid, fetching and
+// parsing the forecast and polling alerts, against a fake api.weather.gov
+// that times out, rate limits and garbles some of its answers, and reports
+// goroutines and memory along the way. goroutines or heap that keep
+// growing mean something would eventually take down a daemon left running
+// on a small machine.
+func runSoak(flagset *flag.FlagSet) func() {
+	var (
+		hours    float64
+		interval time.Duration
+		every    time.Duration
+		rate     float64
+		timeout  time.Duration
+		seed     int64
+	)
+
+	flagset.Float64Var(&hours, "hours", 24, "how long to run for")
+	flagset.DurationVar(&interval, "interval", 5*time.Second, "how often to run the fetches, the daemon's minute sped up")
+	flagset.DurationVar(&every, "report", 10*time.Minute, "how often to report goroutines and memory")
+	flagset.Float64Var(&rate, "faults", 0.1, fmt.Sprintf("fraction of requests to break, evenly with each of %v", soakFaults))
+	flagset.DurationVar(&timeout, "timeout", 5*time.Second, "how long requests wait for the fake before giving up")
+	flagset.Int64Var(&seed, "seed", 1, "seed for which requests break, to repeat a run")
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+
+	return func() {
+		if hours <= 0 {
+			errorAndQuit(fmt.Errorf("hours must be positive, got %g", hours))
+		}
+
+		if interval <= 0 || every <= 0 || timeout <= 0 {
+			errorAndQuit(fmt.Errorf("interval, report and timeout must be positive"))
+		}
+
+		if rate < 0 || rate > 1 {
+			errorAndQuit(fmt.Errorf("faults must be between 0 and 1, got %g", rate))
+		}
+
+		fake := &fakeNWS{rate: rate, timeout: timeout, random: rand.New(rand.NewSource(seed)), faults: map[string]int{}}
+
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		transport := &http.Transport{MaxIdleConnsPerHost: defaultTransportSettings.maxIdleConnsPerHost, IdleConnTimeout: defaultTransportSettings.idleConnTimeout}
+
+		// everything goes to the fake, and nothing comes from or goes to the
+		// cache. fetches are shared between cycles the way they are between a
+		// daemon's rules and requests, since that's where a leak would be
+		httpClient = &http.Client{
+			Transport: newFetchCoordinator(userAgentTransport{next: fakeUpstreamTransport{host: strings.TrimPrefix(server.URL, "http://"), next: transport}, userAgent: userAgent}),
+			Timeout:   timeout,
+		}
+		useCache = false
+
+		c := coordinates{latitude: 41.8781, longitude: -87.6298}
+		poller := &alertPoller{url: activeAlertsURL(c), interval: interval}
+
+		cycle := func() error {
+			grid, err := getGridPoint(c)
+			if err != nil {
+				return fmt.Errorf("could not get grid: %w", err)
+			}
+
+			_, err = getWeatherData(grid.forecastGridDataURL, benchProperties)
+			if err != nil {
+				return fmt.Errorf("could not get forecast: %w", err)
+			}
+
+			_, _, _, err = poller.poll()
+			if err != nil {
+				return fmt.Errorf("could not poll alerts: %w", err)
+			}
+
+			return nil
+		}
+
+		duration := time.Duration(hours * float64(time.Hour))
+		start := time.Now()
+
+		fmt.Printf("soaking for %s against a fake api.weather.gov at %s, breaking %.0f%% of requests\n", duration, server.URL, rate*100)
+
+		cycles, failed := 0, 0
+		errorKinds := map[string]int{}
+
+		// the first cycle starts up the transport's and server's goroutines, so
+		// count from after it
+		err := cycle()
+		cycles++
+		if err != nil {
+			failed++
+			errorKinds[soakErrorKind(err)]++
+		}
+
+		baseline := takeSoakSample(start, cycles, failed)
+
+		t := newTable(os.Stdout, []int{10, 8, 8, 10, 10, 6}, []string{"elapsed", "cycles", "failed", "goroutines", "heap", "gcs"})
+		t.row(baseline.cells(), nil)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		nextReport := start.Add(every)
+		last := baseline
+		reports := []soakSample{baseline}
+
+		for time.Since(start) < duration {
+			<-ticker.C
+
+			err := cycle()
+			cycles++
+			if err != nil {
+				failed++
+				errorKinds[soakErrorKind(err)]++
+			}
+
+			if time.Now().After(nextReport) {
+				last = takeSoakSample(start, cycles, failed)
+				t.row(last.cells(), nil)
+				reports = append(reports, last)
+				nextReport = nextReport.Add(every)
+			}
+		}
+
+		// idle connections each hold a couple of goroutines until they time out,
+		// which isn't a leak
+		transport.CloseIdleConnections()
+		server.CloseClientConnections()
+		time.Sleep(100 * time.Millisecond)
+
+		last = takeSoakSample(start, cycles, failed)
+		t.row(last.cells(), nil)
+		reports = append(reports, last)
+		t.end()
+
+		fake.mu.Lock()
+		fmt.Println()
+		fmt.Printf("%d requests, faults injected:", fake.requests)
+		for _, kind := range soakFaults {
+			fmt.Printf(" %s %d", kind, fake.faults[kind])
+		}
+		fmt.Println()
+		fake.mu.Unlock()
+
+		kinds := []string{}
+		for kind := range errorKinds {
+			kinds = append(kinds, kind)
+		}
+
+		sort.Strings(kinds)
+
+		for _, kind := range kinds {
+			fmt.Printf("failed cycles, %s: %d\n", kind, errorKinds[kind])
+		}
+
+		leaking := false
+
+		if heapGrowing(reports) {
+			fmt.Printf("heap grew from %.1f MiB to %.1f MiB and kept growing, something is leaking\n", float64(baseline.heap)/(1<<20), float64(last.heap)/(1<<20))
+			leaking = true
+		} else {
+			fmt.Printf("heap %.1f MiB to %.1f MiB\n", float64(baseline.heap)/(1<<20), float64(last.heap)/(1<<20))
+		}
+
+		// a couple either way is the runtime's own business
+		if last.goroutines > baseline.goroutines+2 {
+			fmt.Printf("goroutines grew from %d to %d, something is leaking\n", baseline.goroutines, last.goroutines)
+			leaking = true
+		} else {
+			fmt.Printf("goroutines %d to %d\n", baseline.goroutines, last.goroutines)
+		}
+
+		if leaking {
+			quit(1)
+		}
+
+		fmt.Println("no leaks")
+	}
+}
+
+// how many reports in a row the heap has to grow for, and by how much
+// overall, before it counts as a leak rather than caches filling up or the
+// GC's timing
+const (
+	soakHeapReports = 4
+	soakHeapGrowth  = 1.5
+)
+
+// whether the heap grew at each of the last few reports and ended up well
+// above where it started
+func heapGrowing(reports []soakSample) bool {
+	if len(reports) < soakHeapReports+1 {
+		return false
+	}
+
+	recent := reports[len(reports)-soakHeapReports-1:]
+	for i := 1; i < len(recent); i++ {
+		if recent[i].heap <= recent[i-1].heap {
+			return false
+		}
+	}
+
+	return float64(reports[len(reports)-1].heap) > float64(reports[0].heap)*soakHeapGrowth
+}
+
+// failures by the step that failed, since the details vary by request
+func soakErrorKind(err error) string {
+	kind := err.Error()
+	if i := strings.Index(kind, ":"); i >= 0 {
+		kind = kind[:i]
+	}
+
+	return kind
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// the sun is far enough down for a properly dark sky
+const astronomicalDarkness = -18.0
+
+type nightConditions struct {
+	start          time.Time
+	end            time.Time
+	skyCover       *float64
+	moonLit        float64
+	moonUpHours    int
+	dewpointSpread *float64
+	score          *float64
+}
+
+// from astronomical dusk on the given day to astronomical dawn the next,
+// falling back to sunset and sunrise where it never gets truly dark
+func darkWindow(day time.Time, c coordinates) (time.Time, time.Time, bool) {
+	_, dusk, ok := sunTimes(day, c, astronomicalDarkness)
+	dawn, _, nextOK := sunTimes(day.AddDate(0, 0, 1), c, astronomicalDarkness)
+	if ok && nextOK {
+		return dusk, dawn, true
+	}
+
+	_, dusk, ok = sunriseSunset(day, c)
+	dawn, _, nextOK = sunriseSunset(day.AddDate(0, 0, 1), c)
+
+	return dusk, dawn, ok && nextOK
+}
+
+// scores a night from 0 to 10 by averaging each dark hour, where clouds,
+// a bright moon above the horizon, and humid hazy air all take points off
+func assessNight(start, end time.Time, c coordinates, forecast gridForecast) nightConditions {
+	n := nightConditions{start: start, end: end, moonLit: moonIllumination(start.Add(end.Sub(start) / 2))}
+
+	var skyTotal, spreadTotal, scoreTotal float64
+	var skyCount, spreadCount, scoreCount int
+
+	for at := start; at.Before(end); at = at.Add(time.Hour) {
+		moonFactor := 1.0
+		if moonAltitude(at, c) > 0 {
+			n.moonUpHours++
+			moonFactor = 1 - 0.7*moonIllumination(at)
+		}
+
+		sky, ok := findPointAt(forecast.properties["skyCover"], at)
+		if !ok || sky.Value == nil {
+			continue
+		}
+
+		skyTotal += *sky.Value
+		skyCount++
+
+		transparency := 1.0
+
+		temperature, tok := findPointAt(forecast.properties["temperature"], at)
+		dewpoint, dok := findPointAt(forecast.properties["dewpoint"], at)
+		if tok && dok && temperature.Value != nil && dewpoint.Value != nil {
+			spread := *temperature.Value - *dewpoint.Value
+
+			spreadTotal += spread
+			spreadCount++
+
+			// a spread under a couple degrees C means haze or dew, ten or
+			// more is nice dry air
+			transparency = math.Max(0.4, math.Min(1, 0.4+0.6*(spread-2)/8))
+		}
+
+		scoreTotal += 10 * (1 - *sky.Value/100) * moonFactor * transparency
+		scoreCount++
+	}
+
+	if skyCount > 0 {
+		v := skyTotal / float64(skyCount)
+		n.skyCover = &v
+	}
+
+	if spreadCount > 0 {
+		v := spreadTotal / float64(spreadCount)
+		n.dewpointSpread = &v
+	}
+
+	if scoreCount > 0 {
+		v := scoreTotal / float64(scoreCount)
+		n.score = &v
+	}
+
+	return n
+}
+
+func runStars(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		nights       int
+		displaytz    string
+		freedom      bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address at which to go stargazing")
+	flagset.IntVar(&nights, "nights", 3, "number of nights to score")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display times")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, []string{"skyCover", "temperature", "dewpoint"})
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		widths := []int{10, 11, 15, 8, 8, 15, 5}
+
+		t := newTable(os.Stdout, widths, []string{"night", "dark", "skyCover", "moon lit", "moon up", "dewpt spread", "score"})
+
+		today := time.Now().In(loc)
+
+		for d := 0; d < nights; d++ {
+			day := today.AddDate(0, 0, d)
+
+			start, end, ok := darkWindow(day, coordinates)
+			if !ok {
+				t.row([]string{day.Format("Mon Jan 02"), "never dark"}, nil)
+				continue
+			}
+
+			n := assessNight(start, end, coordinates, forecast)
+
+			sky := "No Data"
+			if n.skyCover != nil {
+				sky = formatNumber(*n.skyCover, kindPrecision[kindPercentage]) + "%"
+			}
+
+			spread := "No Data"
+			if n.dewpointSpread != nil {
+				// a temperature difference, so no offset when converting
+				v, unit := *n.dewpointSpread, "C"
+				if freedom {
+					v, unit = activeUnitSystem.convertDifference(v, "wmoUnit:degC")
+				}
+
+				spread = formatNumber(v, kindPrecision[kindTemperature]) + " " + unit
+			}
+
+			score := "-"
+			if n.score != nil {
+				score = formatNumber(*n.score, 1)
+			}
+
+			t.row([]string{
+				day.Format("Mon Jan 02"),
+				start.In(loc).Format("15:04") + "-" + end.In(loc).Format("15:04"),
+				sky,
+				formatNumber(n.moonLit*100, 0) + "%",
+				fmt.Sprintf("%dh", n.moonUpHours),
+				spread,
+				score,
+			}, nil)
+		}
+
+		t.end()
+	}
+}
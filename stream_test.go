@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+)
+
+// counts what's read of a response body
+type countingBody struct {
+	r    io.Reader
+	read *int
+}
+
+func (b countingBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	*b.read += n
+
+	return n, err
+}
+
+func (countingBody) Close() error { return nil }
+
+type countingTransport struct {
+	body []byte
+	read int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": []string{"application/geo+json"}},
+		Body:       countingBody{r: bytes.NewReader(t.body), read: &t.read},
+		Request:    req,
+	}, nil
+}
+
+func TestGetWeatherDataStopsReading(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/gridpoint.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := &countingTransport{body: fixture}
+
+	defer func(client *http.Client, cache bool) {
+		httpClient, useCache = client, cache
+	}(httpClient, useCache)
+
+	// through the coordinator, the way every run sends it
+	httpClient = &http.Client{Transport: newFetchCoordinator(transport)}
+	useCache = false
+
+	forecast, err := getWeatherData("https://api.weather.gov/gridpoints/LOT/76,73", []string{"temperature"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if forecast.properties["temperature"].Len() == 0 {
+		t.Fatalf("no temperatures")
+	}
+
+	// temperature is the first layer, so most of the response is left
+	if transport.read > len(fixture)/2 {
+		t.Errorf("read %d of %d bytes for the first layer", transport.read, len(fixture))
+	}
+}
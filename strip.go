@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// a line per day of the window with the condition glyph for each hour, like
+// "Sat 06/14 06:00 ☀☀☀⛅⛅☁☂☂", starting from the first hour shown that day
+func stripLines(req forecastRequest, forecast gridForecast) []string {
+	lines := []string{}
+
+	var day time.Time
+	glyphs := strings.Builder{}
+	label := ""
+
+	flush := func() {
+		if glyphs.Len() > 0 {
+			lines = append(lines, label+" "+glyphs.String())
+		}
+
+		glyphs.Reset()
+	}
+
+	for at := req.start.Truncate(time.Hour); !at.After(req.end.Truncate(time.Hour)); at = at.Add(time.Hour) {
+		local := at.In(req.displayTimeZone)
+		today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, req.displayTimeZone)
+
+		if !today.Equal(day) {
+			flush()
+
+			day = today
+			label = local.Format("Mon 01/02 15:04")
+		}
+
+		glyphs.WriteString(conditionGlyph(forecast, at, at.Add(time.Hour)))
+	}
+
+	flush()
+
+	return lines
+}
+
+func displayStrip(w io.Writer, req forecastRequest, forecast gridForecast) {
+	for _, line := range stripLines(req, forecast) {
+		fmt.Fprintln(w, line)
+	}
+}
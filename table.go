@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// a horizontal line across the table as the left corner, the fill, where it
+// crosses a column border, and the right corner. no fill means no line.
+type rule struct {
+	left, fill, cross, right string
+}
+
+type tableStyle struct {
+	// what goes before, between and after cells in a row
+	left, sep, right string
+
+	top, header, between, bottom rule
+}
+
+var tableStyles = map[string]tableStyle{
+	"ascii": {
+		left:   " ",
+		sep:    " | ",
+		header: rule{fill: "-"},
+	},
+	"unicode": {
+		left:   "│ ",
+		sep:    " │ ",
+		right:  " │",
+		top:    rule{"┌", "─", "┬", "┐"},
+		header: rule{"├", "─", "┼", "┤"},
+		bottom: rule{"└", "─", "┴", "┘"},
+	},
+	"compact": {
+		sep: "  ",
+	},
+	"grid": {
+		left:    "| ",
+		sep:     " | ",
+		right:   " |",
+		top:     rule{"+", "-", "+", "+"},
+		header:  rule{"+", "=", "+", "+"},
+		between: rule{"+", "-", "+", "+"},
+		bottom:  rule{"+", "-", "+", "+"},
+	},
+}
+
+var activeStyle = tableStyles["ascii"]
+
+func tableStyleNames() []string {
+	names := []string{}
+	for name := range tableStyles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func setTableStyle(name string) error {
+	s, ok := tableStyles[name]
+	if !ok {
+		return fmt.Errorf("table style '%s' is not in %v", name, tableStyleNames())
+	}
+
+	activeStyle = s
+
+	return nil
+}
+
+// lets any subcommand take -style with a single line
+type tableStyleFlag struct{}
+
+func (tableStyleFlag) String() string { return "" }
+
+func (tableStyleFlag) Set(name string) error { return setTableStyle(name) }
+
+func (r rule) draw(widths []int) string {
+	// without corners the line just runs the width of the table
+	if r.left == "" {
+		return strings.Repeat(r.fill, totalWidth(widths))
+	}
+
+	segments := []string{}
+	for _, w := range widths {
+		segments = append(segments, strings.Repeat(r.fill, w+2))
+	}
+
+	return r.left + strings.Join(segments, r.cross) + r.right
+}
+
+type table struct {
+	w      io.Writer
+	widths []int
+	rows   int
+}
+
+// prints the header straight away, then rows as they're added
+func newTable(w io.Writer, widths []int, header []string) *table {
+	t := &table{w: w, widths: widths}
+
+	t.line(activeStyle.top)
+	fmt.Fprintln(w, formatRow(widths, header, nil))
+	t.line(activeStyle.header)
+
+	return t
+}
+
+func (t *table) line(r rule) {
+	if r.fill != "" {
+		fmt.Fprintln(t.w, r.draw(t.widths))
+	}
+}
+
+func (t *table) row(cells []string, styles []string) {
+	if t.rows > 0 {
+		t.line(activeStyle.between)
+	}
+
+	fmt.Fprintln(t.w, formatRow(t.widths, cells, styles))
+	t.rows++
+}
+
+// a line across the table, like the one above a row of totals
+func (t *table) rule() {
+	t.line(activeStyle.header)
+	t.rows = 0
+}
+
+// a line with some text in the middle, like the one between what was
+// observed and what's forecast
+func (t *table) divider(text string) {
+	fill := "-"
+	for _, r := range []rule{activeStyle.between, activeStyle.header} {
+		if r.fill != "" {
+			fill = r.fill
+			break
+		}
+	}
+
+	fmt.Fprintln(t.w, centerText(text, totalWidth(t.widths), []rune(fill)[0]))
+	t.rows = 0
+}
+
+func (t *table) end() {
+	t.line(activeStyle.bottom)
+}
+
+func totalWidth(widths []int) int {
+	total := runewidth.StringWidth(activeStyle.left) + runewidth.StringWidth(activeStyle.right)
+	for i, w := range widths {
+		if i > 0 {
+			total += runewidth.StringWidth(activeStyle.sep)
+		}
+
+		total += w
+	}
+
+	return total
+}
+
+func formatRow(widths []int, cells []string, styles []string) string {
+	var b strings.Builder
+
+	b.WriteString(activeStyle.left)
+
+	for i, width := range widths {
+		if i > 0 {
+			b.WriteString(activeStyle.sep)
+		}
+
+		// short rows still get their borders
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+
+		padded := padCell(cell, width)
+
+		if styles != nil && i < len(styles) && styles[i] != "" {
+			padded = styles[i] + padded + styleReset
+		}
+
+		b.WriteString(padded)
+	}
+
+	b.WriteString(activeStyle.right)
+
+	return b.String()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// hours after the current one that templates get in .Hours
+const templateHours = 6
+
+// what -template sees, with every value formatted as the table shows it
+// and already escaped for where it's going. .Primary is the first visible
+// column, which is what most status bars want.
+type templateData struct {
+	Time      string
+	Condition string
+	Glyph     string
+	Issued    string
+	Columns   []string
+	Primary   string
+	Values    map[string]string
+	Hours     []templateHour
+}
+
+type templateHour struct {
+	Time    string
+	Primary string
+	Values  map[string]string
+}
+
+// known good layouts for the status bars that take a pipe of text, each
+// with the escaping its markup needs
+var templatePresets = map[string]struct {
+	text   string
+	escape func(string) string
+}{
+	"conky": {
+		text: "${font :size=14}{{.Glyph}} {{.Primary}}${font}\n" +
+			"{{.Condition}}\n" +
+			"{{range .Hours}}{{.Time}}${goto 80}{{.Primary}}\n{{end}}",
+		// conky takes $ as the start of a variable in execp output
+		escape: strings.NewReplacer("$", "$$").Replace,
+	},
+	"xmobar": {
+		text: "{{.Glyph}} <fc=#ebcb8b>{{.Primary}}</fc> {{.Condition}}\n",
+		// xmobar has no entities, only raw spans
+		escape: strings.NewReplacer("<", "<raw=1:</>").Replace,
+	},
+	"template": {
+		escape: func(s string) string { return s },
+	},
+}
+
+func parseForecastTemplate(format, text string) (*template.Template, error) {
+	if text == "" {
+		text = templatePresets[format].text
+	}
+
+	if text == "" {
+		return nil, fmt.Errorf("-format template needs a -template")
+	}
+
+	t, err := template.New(format).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template: %w", err)
+	}
+
+	return t, nil
+}
+
+func newTemplateData(req forecastRequest, forecast gridForecast, rows []displayRow, escape func(string) string) (templateData, bool) {
+	upcoming := []displayRow{}
+	for _, r := range rows {
+		if !r.observed {
+			upcoming = append(upcoming, r)
+		}
+	}
+
+	if len(upcoming) == 0 {
+		return templateData{}, false
+	}
+
+	columns := req.columns()
+	visible := req.visibleColumns()
+
+	values := func(r displayRow) (string, map[string]string) {
+		primary := ""
+		m := map[string]string{}
+
+		for n, i := range visible {
+			m[columns[i]] = escape(r.values[i])
+
+			if n == 0 {
+				primary = m[columns[i]]
+			}
+		}
+
+		return primary, m
+	}
+
+	current := upcoming[0]
+	c := condition(forecast, current.at, current.at.Add(time.Hour))
+
+	data := templateData{
+		Time:      current.at.In(req.displayTimeZone).Format("15:04"),
+		Condition: escape(strings.ReplaceAll(c, "-", " ")),
+		Glyph:     conditionGlyphs[c],
+		Hours:     []templateHour{},
+	}
+
+	if !forecast.updateTime.IsZero() {
+		data.Issued = forecast.updateTime.In(req.displayTimeZone).Format("15:04")
+	}
+
+	for _, i := range visible {
+		data.Columns = append(data.Columns, columns[i])
+	}
+
+	data.Primary, data.Values = values(current)
+
+	for _, r := range upcoming[1:] {
+		if len(data.Hours) == templateHours {
+			break
+		}
+
+		h := templateHour{Time: r.at.In(req.displayTimeZone).Format("15:04")}
+		h.Primary, h.Values = values(r)
+
+		data.Hours = append(data.Hours, h)
+	}
+
+	return data, true
+}
+
+func writeForecastTemplate(w io.Writer, req forecastRequest, forecast gridForecast, rows []displayRow) error {
+	t, err := parseForecastTemplate(req.format, req.template)
+	if err != nil {
+		return err
+	}
+
+	data, ok := newTemplateData(req, forecast, rows, templatePresets[req.format].escape)
+	if !ok {
+		return fmt.Errorf("no hours match your criteria in the requested window")
+	}
+
+	err = t.Execute(w, data)
+	if err != nil {
+		return fmt.Errorf("could not fill in template: %w", err)
+	}
+
+	return nil
+}
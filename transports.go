@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+type notification struct {
+	rule    string
+	title   string
+	message string
+	alerts  []alert
+
+	// anything severe or worse, for transports that can make more noise
+	urgent bool
+}
+
+func newNotification(rule string, alerts []alert, loc *time.Location) notification {
+	n := notification{
+		rule:    rule,
+		message: notifyMessage(alerts, loc),
+		alerts:  alerts,
+	}
+
+	if len(alerts) == 1 {
+		n.title = rule + ": " + alerts[0].event
+	} else {
+		n.title = fmt.Sprintf("%s: %d alerts", rule, len(alerts))
+	}
+
+	for _, a := range alerts {
+		if levelOf(severities, a.severity) >= levelOf(severities, "Severe") {
+			n.urgent = true
+		}
+	}
+
+	return n
+}
+
+// somewhere a notification can go
+type notifier interface {
+	notify(n notification) error
+}
+
+// where a rule's notifications go. which fields matter depends on the type.
+type transportConfig struct {
+	// one of stdout, desktop, webhook, slack, email, pushover or ntfy
+	Type string `json:"type"`
+
+	// for webhook, slack and ntfy, where to post, e.g. a slack incoming
+	// webhook or https://ntfy.sh/<topic>
+	URL string `json:"url,omitempty"`
+
+	// for email, host:port of the SMTP server, and who it's to and from
+	SMTP     string   `json:"smtp,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+
+	// for pushover, the application token and user key
+	Token string `json:"token,omitempty"`
+	User  string `json:"user,omitempty"`
+
+	// any of the password, token, user, or a slack or webhook url, can be
+	// a reference like "env:NAME", "file:/path" or "keyring:account" rather
+	// than the secret itself
+}
+
+func newNotifier(c transportConfig) (notifier, error) {
+	for _, s := range c.secrets() {
+		v, err := resolveSecret(*s)
+		if err != nil {
+			return nil, fmt.Errorf("could not load %s transport secret: %w", c.Type, err)
+		}
+
+		*s = v
+	}
+
+	switch c.Type {
+	case "", "stdout":
+		return stdoutNotifier{}, nil
+	case "desktop":
+		return desktopNotifier{}, nil
+	case "webhook":
+		if c.URL == "" {
+			return nil, fmt.Errorf("webhook transport needs a url")
+		}
+
+		return webhookNotifier{url: c.URL}, nil
+	case "slack":
+		if c.URL == "" {
+			return nil, fmt.Errorf("slack transport needs an incoming webhook url")
+		}
+
+		return slackNotifier{url: c.URL}, nil
+	case "email":
+		if c.SMTP == "" || c.From == "" || len(c.To) == 0 {
+			return nil, fmt.Errorf("email transport needs smtp, from and to")
+		}
+
+		return emailNotifier{config: c}, nil
+	case "pushover":
+		if c.Token == "" || c.User == "" {
+			return nil, fmt.Errorf("pushover transport needs a token and user")
+		}
+
+		return pushoverNotifier{token: c.Token, user: c.User}, nil
+	case "ntfy":
+		if c.URL == "" {
+			return nil, fmt.Errorf("ntfy transport needs a topic url, e.g. https://ntfy.sh/<topic>")
+		}
+
+		return ntfyNotifier{url: c.URL}, nil
+	default:
+		return nil, fmt.Errorf("transport type '%s' is not one of stdout, desktop, webhook, slack, email, pushover or ntfy", c.Type)
+	}
+}
+
+type stdoutNotifier struct{}
+
+func (stdoutNotifier) notify(n notification) error {
+	fmt.Printf("[%s] %s:\n%s\n", time.Now().Format("15:04:05"), n.rule, n.message)
+	return nil
+}
+
+type desktopNotifier struct{}
+
+func (desktopNotifier) notify(n notification) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", n.message, n.title)
+		cmd = exec.Command("osascript", "-e", script)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		urgency := "normal"
+		if n.urgent {
+			urgency = "critical"
+		}
+
+		cmd = exec.Command("notify-send", "-u", urgency, n.title, n.message)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", toastScript(n))
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("could not show desktop notification: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// shows a toast through the WinRT notification API, which powershell can
+// reach without installing anything. the app id is powershell's own, since
+// toasts from an unregistered app are dropped.
+func toastScript(n notification) string {
+	quote := func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+
+	scenario := ""
+	if n.urgent {
+		scenario = ` scenario="urgent"`
+	}
+
+	lines := []string{
+		"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null",
+		"[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null",
+		"$xml = New-Object Windows.Data.Xml.Dom.XmlDocument",
+		"$xml.LoadXml(" + quote(`<toast`+scenario+`><visual><binding template="ToastGeneric"><text></text><text></text></binding></visual></toast>`) + ")",
+		"$text = $xml.GetElementsByTagName('text')",
+		"$text.Item(0).AppendChild($xml.CreateTextNode(" + quote(n.title) + ")) | Out-Null",
+		"$text.Item(1).AppendChild($xml.CreateTextNode(" + quote(n.message) + ")) | Out-Null",
+		"$app = '{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\\WindowsPowerShell\\v1.0\\powershell.exe'",
+		"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($app).Show([Windows.UI.Notifications.ToastNotification]::new($xml))",
+	}
+
+	return strings.Join(lines, "; ")
+}
+
+// posts the request and treats anything but a 2xx as a failure
+func postNotification(req *http.Request) error {
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("notification to %s failed with %s", req.URL.Host, res.Status)
+	}
+
+	return nil
+}
+
+func postJSON(target string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("could not encode notification: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", target, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return postNotification(req)
+}
+
+type webhookNotifier struct {
+	url string
+}
+
+func (w webhookNotifier) notify(n notification) error {
+	type webhookAlert struct {
+		ID       string    `json:"id"`
+		Event    string    `json:"event"`
+		Headline string    `json:"headline"`
+		Severity string    `json:"severity"`
+		Urgency  string    `json:"urgency"`
+		Areas    string    `json:"areas"`
+		Onset    time.Time `json:"onset"`
+		Ends     time.Time `json:"ends"`
+	}
+
+	body := struct {
+		Rule    string         `json:"rule"`
+		Title   string         `json:"title"`
+		Message string         `json:"message"`
+		Urgent  bool           `json:"urgent"`
+		Alerts  []webhookAlert `json:"alerts"`
+	}{
+		Rule:    n.rule,
+		Title:   n.title,
+		Message: n.message,
+		Urgent:  n.urgent,
+	}
+
+	for _, a := range n.alerts {
+		body.Alerts = append(body.Alerts, webhookAlert{
+			ID:       a.id,
+			Event:    a.event,
+			Headline: a.headline,
+			Severity: a.severity,
+			Urgency:  a.urgency,
+			Areas:    a.areas,
+			Onset:    a.onset,
+			Ends:     a.ends,
+		})
+	}
+
+	return postJSON(w.url, body)
+}
+
+type slackNotifier struct {
+	url string
+}
+
+func (s slackNotifier) notify(n notification) error {
+	return postJSON(s.url, map[string]string{"text": "*" + n.title + "*\n" + n.message})
+}
+
+type emailNotifier struct {
+	config transportConfig
+}
+
+func (e emailNotifier) notify(n notification) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.config.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", n.title)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&b, "\r\n%s\r\n", strings.ReplaceAll(n.message, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		host := strings.Split(e.config.SMTP, ":")[0]
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, host)
+	}
+
+	err := smtp.SendMail(e.config.SMTP, auth, e.config.From, e.config.To, []byte(b.String()))
+	if err != nil {
+		return fmt.Errorf("could not send email: %w", err)
+	}
+
+	return nil
+}
+
+type pushoverNotifier struct {
+	token, user string
+}
+
+func (p pushoverNotifier) notify(n notification) error {
+	form := url.Values{
+		"token":   []string{p.token},
+		"user":    []string{p.user},
+		"title":   []string{n.title},
+		"message": []string{n.message},
+	}
+
+	if n.urgent {
+		form.Set("priority", "1")
+	}
+
+	req, err := http.NewRequest("POST", "https://api.pushover.net/1/messages.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return postNotification(req)
+}
+
+type ntfyNotifier struct {
+	url string
+}
+
+func (t ntfyNotifier) notify(n notification) error {
+	req, err := http.NewRequest("POST", t.url, strings.NewReader(n.message))
+	if err != nil {
+		return fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	req.Header.Set("Title", n.title)
+	req.Header.Set("Tags", "warning")
+
+	if n.urgent {
+		req.Header.Set("Priority", "urgent")
+	}
+
+	return postNotification(req)
+}
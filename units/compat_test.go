@@ -0,0 +1,46 @@
+package units_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/packrat386/agwc/units"
+)
+
+// the units package's v1 API, which callers mostly use through Parse and
+// Convert.
+var (
+	_ func(string) (units.Unit, error)                       = units.Parse
+	_ func(string) units.Unit                                = units.MustParse
+	_ func(float64, units.Unit, units.Unit) (float64, error) = units.Convert
+	_ func(float64, units.Unit, units.Unit) (float64, error) = units.ConvertDifference
+
+	_ func(units.Unit) string               = units.Unit.Code
+	_ func(units.Unit) string               = units.Unit.String
+	_ func(units.Unit) string               = units.Unit.Canonical
+	_ func(units.Unit) string               = units.Unit.Symbol
+	_ func(units.Unit, units.Unit) bool     = units.Unit.Compatible
+	_ func(units.Unit, float64, int) string = units.Unit.Format
+)
+
+func TestConvertAndCompatible(t *testing.T) {
+	c, f := units.MustParse("wmoUnit:degC"), units.MustParse("wmoUnit:degF")
+
+	v, err := units.Convert(100, c, f)
+	if err != nil || math.Abs(v-212) > 1e-9 {
+		t.Errorf("100 degC is %g degF, %v", v, err)
+	}
+
+	v, err = units.ConvertDifference(10, c, f)
+	if err != nil || math.Abs(v-18) > 1e-9 {
+		t.Errorf("a 10 degC difference is %g degF, %v", v, err)
+	}
+
+	if c.Compatible(units.MustParse("wmoUnit:km_h-1")) {
+		t.Errorf("degC is compatible with km/h")
+	}
+
+	if got := units.MustParse("wmoUnit:km_h-1").Canonical(); got != "wmoUnit:km_h-1" {
+		t.Errorf("km_h-1 canonical is %q", got)
+	}
+}
@@ -0,0 +1,348 @@
+// Package units parses the WMO unit codes api.weather.gov labels its values
+// with, like "wmoUnit:degC" or "wmoUnit:km_h-1", and converts and formats
+// values in them.
+//
+// A code is a namespace ("wmoUnit:" or the older "unit:") followed by one or
+// more factors joined by underscores, each a unit symbol with an optional
+// integer exponent, so "kg_m-2" is kilograms per square meter. Parse also
+// takes codes without a namespace and the usual variations on the rest, like
+// "m s^-1", "m/s" or "m**2", and Canonical gives them back in the first form.
+package units
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// the base quantities a unit is made of, as exponents
+type dimensions struct {
+	length, mass, time, temperature, angle int
+}
+
+func (d dimensions) add(o dimensions, exponent int) dimensions {
+	return dimensions{
+		length:      d.length + o.length*exponent,
+		mass:        d.mass + o.mass*exponent,
+		time:        d.time + o.time*exponent,
+		temperature: d.temperature + o.temperature*exponent,
+		angle:       d.angle + o.angle*exponent,
+	}
+}
+
+// a single symbol, by how many of the SI base unit it is
+type atom struct {
+	symbol string
+	scale  float64
+	offset float64
+	dims   dimensions
+}
+
+var (
+	length      = dimensions{length: 1}
+	mass        = dimensions{mass: 1}
+	duration    = dimensions{time: 1}
+	temperature = dimensions{temperature: 1}
+	angle       = dimensions{angle: 1}
+	pressure    = dimensions{mass: 1, length: -1, time: -2}
+	energy      = dimensions{mass: 1, length: 2, time: -2}
+	watts       = dimensions{mass: 1, length: 2, time: -3}
+	speed       = dimensions{length: 1, time: -1}
+)
+
+var atoms = map[string]atom{
+	"m":     {"m", 1, 0, length},
+	"km":    {"km", 1000, 0, length},
+	"cm":    {"cm", 0.01, 0, length},
+	"mm":    {"mm", 0.001, 0, length},
+	"ft":    {"ft", 0.3048, 0, length},
+	"in":    {"in", 0.0254, 0, length},
+	"mi":    {"mi", 1609.344, 0, length},
+	"nmi":   {"nmi", 1852, 0, length},
+	"s":     {"s", 1, 0, duration},
+	"min":   {"min", 60, 0, duration},
+	"h":     {"h", 3600, 0, duration},
+	"d":     {"d", 86400, 0, duration},
+	"g":     {"g", 0.001, 0, mass},
+	"kg":    {"kg", 1, 0, mass},
+	"Pa":    {"Pa", 1, 0, pressure},
+	"hPa":   {"hPa", 100, 0, pressure},
+	"kPa":   {"kPa", 1000, 0, pressure},
+	"J":     {"J", 1, 0, energy},
+	"W":     {"W", 1, 0, watts},
+	"kt":    {"kt", 1852.0 / 3600, 0, speed},
+	"K":     {"K", 1, 0, temperature},
+	"degC":  {"°C", 1, 273.15, temperature},
+	"degF":  {"°F", 5.0 / 9, 273.15 - 32*5.0/9, temperature},
+	"rad":   {"rad", 1, 0, angle},
+	"deg":   {"°", math.Pi / 180, 0, angle},
+	"1":     {"", 1, 0, dimensions{}},
+	"%":     {"%", 0.01, 0, dimensions{}},
+	"ratio": {"", 1, 0, dimensions{}},
+}
+
+// codes that aren't made of factors, mostly because of their underscores
+var aliases = map[string]string{
+	"degrees":        "deg",
+	"degree":         "deg",
+	"degree_(angle)": "deg",
+	"percent":        "%",
+	"n_mile":         "nmi",
+	"knot":           "kt",
+	"knots":          "kt",
+}
+
+// how the canonical code writes an atom, where that isn't its key
+var canonicalNames = map[string]string{
+	"deg": "degree_(angle)",
+	"%":   "percent",
+}
+
+var namespaces = []string{"wmoUnit:", "unit:"}
+
+var (
+	factorMatcher    = regexp.MustCompile(`^([A-Za-z%()]+|1)(?:\^?(-?\d+))?$`)
+	factorSeparators = regexp.MustCompile(`[_ .·*]`)
+)
+
+type factor struct {
+	name     string
+	atom     atom
+	exponent int
+}
+
+// Unit is a parsed unit code. The zero Unit is dimensionless.
+type Unit struct {
+	code    string
+	factors []factor
+	scale   float64
+	offset  float64
+	dims    dimensions
+}
+
+// Parse reads a unit code like "wmoUnit:km_h-1".
+func Parse(code string) (Unit, error) {
+	rest, err := stripNamespace(code)
+	if err != nil {
+		return Unit{}, err
+	}
+
+	if alias, ok := aliases[rest]; ok {
+		rest = alias
+	}
+
+	if rest == "" {
+		return Unit{}, fmt.Errorf("unit code '%s' has no unit", code)
+	}
+
+	u := Unit{code: code, scale: 1}
+
+	// "a/b" is "a_b-1", and "a/b_c" is "a_b-1_c-1"
+	numerator, denominator, divided := strings.Cut(rest, "/")
+
+	err = u.addFactors(code, numerator, 1)
+	if err != nil {
+		return Unit{}, err
+	}
+
+	if divided {
+		err = u.addFactors(code, denominator, -1)
+		if err != nil {
+			return Unit{}, err
+		}
+	}
+
+	// an offset only means something for a lone unit like degC, in a
+	// compound like degC_h-1 it's a difference
+	if len(u.factors) == 1 && u.factors[0].exponent == 1 {
+		u.offset = u.factors[0].atom.offset
+	}
+
+	return u, nil
+}
+
+// "wmoUnit:" and "unit:" are the same thing, and some layers leave it off
+func stripNamespace(code string) (string, error) {
+	for _, ns := range namespaces {
+		if len(code) >= len(ns) && strings.EqualFold(code[:len(ns)], ns) {
+			return code[len(ns):], nil
+		}
+	}
+
+	if i := strings.Index(code, ":"); i >= 0 {
+		return "", fmt.Errorf("unit code '%s' is not in one of the namespaces %v", code, namespaces)
+	}
+
+	return code, nil
+}
+
+func (u *Unit) addFactors(code string, part string, sign int) error {
+	for _, f := range factorSeparators.Split(strings.ReplaceAll(part, "**", "^"), -1) {
+		m := factorMatcher.FindStringSubmatch(f)
+		if m == nil {
+			return fmt.Errorf("could not parse '%s' in unit code '%s'", f, code)
+		}
+
+		name := m[1]
+		if alias, ok := aliases[name]; ok {
+			name = alias
+		}
+
+		a, ok := atoms[name]
+		if !ok {
+			return fmt.Errorf("unknown unit '%s' in unit code '%s'", m[1], code)
+		}
+
+		exponent := 1
+		if m[2] != "" {
+			exponent, _ = strconv.Atoi(m[2])
+		}
+
+		if exponent == 0 {
+			return fmt.Errorf("zero exponent on '%s' in unit code '%s'", m[1], code)
+		}
+
+		exponent *= sign
+
+		u.factors = append(u.factors, factor{name: name, atom: a, exponent: exponent})
+		u.scale *= math.Pow(a.scale, float64(exponent))
+		u.dims = u.dims.add(a.dims, exponent)
+	}
+
+	return nil
+}
+
+// MustParse is like Parse but panics if the code can't be parsed. It's for
+// units known when the program is written.
+func MustParse(code string) Unit {
+	u, err := Parse(code)
+	if err != nil {
+		panic(err)
+	}
+
+	return u
+}
+
+// Code is the unit code as it was given to Parse.
+func (u Unit) Code() string {
+	return u.code
+}
+
+func (u Unit) String() string {
+	return u.code
+}
+
+// Canonical is the code as api.weather.gov usually writes it, like
+// "wmoUnit:km_h-1" for "unit:km/h" or "km h^-1".
+func (u Unit) Canonical() string {
+	if len(u.factors) == 1 && u.factors[0].exponent == 1 {
+		if name, ok := canonicalNames[u.factors[0].name]; ok {
+			return namespaces[0] + name
+		}
+	}
+
+	parts := []string{}
+
+	for _, f := range u.factors {
+		// "1/s" is just s-1
+		if f.name == "1" && len(u.factors) > 1 {
+			continue
+		}
+
+		name := f.name
+		if name == "%" {
+			name = "percent"
+		}
+
+		if f.exponent != 1 {
+			name += strconv.Itoa(f.exponent)
+		}
+
+		parts = append(parts, name)
+	}
+
+	return namespaces[0] + strings.Join(parts, "_")
+}
+
+// Compatible reports whether values in u can be converted into o.
+func (u Unit) Compatible(o Unit) bool {
+	return u.dims == o.dims
+}
+
+// Convert converts v from one unit into another.
+func Convert(v float64, from, to Unit) (float64, error) {
+	if !from.Compatible(to) {
+		return 0, fmt.Errorf("cannot convert %s to %s", from, to)
+	}
+
+	return (v*from.scale + from.offset - to.offset) / to.scale, nil
+}
+
+// ConvertDifference converts the difference between two values, like a
+// dewpoint spread, which scales but doesn't take the offset.
+func ConvertDifference(v float64, from, to Unit) (float64, error) {
+	if !from.Compatible(to) {
+		return 0, fmt.Errorf("cannot convert %s to %s", from, to)
+	}
+
+	return v * from.scale / to.scale, nil
+}
+
+var superscripts = strings.NewReplacer(
+	"-", "⁻", "0", "⁰", "1", "¹", "2", "²", "3", "³", "4", "⁴",
+	"5", "⁵", "6", "⁶", "7", "⁷", "8", "⁸", "9", "⁹",
+)
+
+func raised(symbol string, exponent int) string {
+	if exponent == 1 {
+		return symbol
+	}
+
+	return symbol + superscripts.Replace(strconv.Itoa(exponent))
+}
+
+// Symbol is the unit as it's usually written, like "km/h" or "kg/m²".
+func (u Unit) Symbol() string {
+	numerator, denominator := []string{}, []string{}
+
+	for _, f := range u.factors {
+		if f.atom.symbol == "" {
+			continue
+		}
+
+		if f.exponent > 0 {
+			numerator = append(numerator, raised(f.atom.symbol, f.exponent))
+		} else {
+			denominator = append(denominator, raised(f.atom.symbol, -f.exponent))
+		}
+	}
+
+	s := strings.Join(numerator, "·")
+
+	if len(denominator) > 0 {
+		if s == "" {
+			s = "1"
+		}
+
+		s += "/" + strings.Join(denominator, "·")
+	}
+
+	return s
+}
+
+// Format writes v with the given number of decimal places followed by the
+// unit's symbol.
+func (u Unit) Format(v float64, precision int) string {
+	s := strconv.FormatFloat(v, 'f', precision, 64)
+
+	switch symbol := u.Symbol(); symbol {
+	case "":
+		return s
+	case "%", "°":
+		return s + symbol
+	default:
+		return s + " " + symbol
+	}
+}
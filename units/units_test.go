@@ -0,0 +1,163 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		code   string
+		symbol string
+	}{
+		{"wmoUnit:degC", "°C"},
+		{"unit:degC", "°C"},
+		{"wmoUnit:km_h-1", "km/h"},
+		{"wmoUnit:m_s-1", "m/s"},
+		{"wmoUnit:kg_m-2", "kg/m²"},
+		{"wmoUnit:J_kg-1", "J/kg"},
+		{"wmoUnit:m2_s-2", "m²/s²"},
+		{"wmoUnit:W_m-2", "W/m²"},
+		{"wmoUnit:s-1", "1/s"},
+		{"wmoUnit:percent", "%"},
+		{"wmoUnit:degree_(angle)", "°"},
+		{"wmoUnit:Pa", "Pa"},
+		{"wmoUnit:1", ""},
+	}
+
+	for _, c := range cases {
+		u, err := Parse(c.code)
+		if err != nil {
+			t.Errorf("Parse(%q): %s", c.code, err)
+			continue
+		}
+
+		if u.Symbol() != c.symbol {
+			t.Errorf("Parse(%q).Symbol() = %q, want %q", c.code, u.Symbol(), c.symbol)
+		}
+
+		if u.Code() != c.code {
+			t.Errorf("Parse(%q).Code() = %q", c.code, u.Code())
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	cases := []struct {
+		code      string
+		canonical string
+	}{
+		{"wmoUnit:km_h-1", "wmoUnit:km_h-1"},
+		{"unit:km_h-1", "wmoUnit:km_h-1"},
+		{"WMOUNIT:degC", "wmoUnit:degC"},
+		{"degC", "wmoUnit:degC"},
+		{"km/h", "wmoUnit:km_h-1"},
+		{"unit:m s^-1", "wmoUnit:m_s-1"},
+		{"m.s-1", "wmoUnit:m_s-1"},
+		{"kg m**-2", "wmoUnit:kg_m-2"},
+		{"m^2/s^2", "wmoUnit:m2_s-2"},
+		{"J/kg", "wmoUnit:J_kg-1"},
+		{"1/s", "wmoUnit:s-1"},
+		{"unit:percent", "wmoUnit:percent"},
+		{"%", "wmoUnit:percent"},
+		{"degree_(angle)", "wmoUnit:degree_(angle)"},
+		{"unit:degrees", "wmoUnit:degree_(angle)"},
+	}
+
+	for _, c := range cases {
+		u, err := Parse(c.code)
+		if err != nil {
+			t.Errorf("Parse(%q): %s", c.code, err)
+			continue
+		}
+
+		if u.Canonical() != c.canonical {
+			t.Errorf("Parse(%q).Canonical() = %q, want %q", c.code, u.Canonical(), c.canonical)
+		}
+
+		again, err := Parse(u.Canonical())
+		if err != nil || again.Canonical() != u.Canonical() {
+			t.Errorf("canonical %q for %q does not parse back the same", u.Canonical(), c.code)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, code := range []string{"", "nws:degC", "wmoUnit:", "wmoUnit:m/", "wmoUnit:furlong", "wmoUnit:m0", "wmoUnit:km__h-1", "wmoUnit:m-"} {
+		if _, err := Parse(code); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", code)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		v        float64
+		from, to string
+		want     float64
+	}{
+		{0, "wmoUnit:degC", "wmoUnit:degF", 32},
+		{100, "wmoUnit:degC", "wmoUnit:degF", 212},
+		{-40, "wmoUnit:degF", "wmoUnit:degC", -40},
+		{0, "wmoUnit:degC", "wmoUnit:K", 273.15},
+		{36, "wmoUnit:km_h-1", "wmoUnit:m_s-1", 10},
+		{1.852, "wmoUnit:km_h-1", "wmoUnit:kt", 1},
+		{25.4, "wmoUnit:mm", "wmoUnit:in", 1},
+		{1013.25, "wmoUnit:hPa", "wmoUnit:Pa", 101325},
+		{1, "wmoUnit:km", "wmoUnit:m", 1000},
+		{50, "wmoUnit:percent", "wmoUnit:1", 0.5},
+		{180, "wmoUnit:degree_(angle)", "wmoUnit:rad", math.Pi},
+		{1, "wmoUnit:kg_m-2", "wmoUnit:g_cm-2", 0.1},
+	}
+
+	for _, c := range cases {
+		got, err := Convert(c.v, MustParse(c.from), MustParse(c.to))
+		if err != nil {
+			t.Errorf("Convert(%v, %s, %s): %s", c.v, c.from, c.to, err)
+			continue
+		}
+
+		if math.Abs(got-c.want) > 1e-9*math.Max(1, math.Abs(c.want)) {
+			t.Errorf("Convert(%v, %s, %s) = %v, want %v", c.v, c.from, c.to, got, c.want)
+		}
+	}
+}
+
+func TestConvertIncompatible(t *testing.T) {
+	_, err := Convert(1, MustParse("wmoUnit:m"), MustParse("wmoUnit:s"))
+	if err == nil {
+		t.Fatal("converting meters to seconds succeeded")
+	}
+}
+
+func TestConvertDifference(t *testing.T) {
+	got, err := ConvertDifference(10, MustParse("wmoUnit:degC"), MustParse("wmoUnit:degF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(got-18) > 1e-9 {
+		t.Fatalf("a 10 C difference is %v F, want 18", got)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		code      string
+		v         float64
+		precision int
+		want      string
+	}{
+		{"wmoUnit:degC", 21.46, 1, "21.5 °C"},
+		{"wmoUnit:percent", 40, 0, "40%"},
+		{"wmoUnit:degree_(angle)", 270, 0, "270°"},
+		{"wmoUnit:km_h-1", 12, 0, "12 km/h"},
+		{"wmoUnit:1", 0.25, 2, "0.25"},
+	}
+
+	for _, c := range cases {
+		if got := MustParse(c.code).Format(c.v, c.precision); got != c.want {
+			t.Errorf("%s Format(%v, %d) = %q, want %q", c.code, c.v, c.precision, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/packrat386/agwc/units"
+)
+
+// where a metric unit ends up, and how it's displayed there
+type unitConversion struct {
+	unit string
+	to   units.Unit
+}
+
+var (
+	toFahrenheit      = unitConversion{"F", units.MustParse("wmoUnit:degF")}
+	toKelvin          = unitConversion{"K", units.MustParse("wmoUnit:K")}
+	toMph             = unitConversion{"mph", units.MustParse("wmoUnit:mi_h-1")}
+	toKnots           = unitConversion{"kt", units.MustParse("wmoUnit:kt")}
+	toMetersPerSecond = unitConversion{"m/s", units.MustParse("wmoUnit:m_s-1")}
+	toInches          = unitConversion{"in", units.MustParse("wmoUnit:in")}
+	toFeet            = unitConversion{"ft", units.MustParse("wmoUnit:ft")}
+	toMiles           = unitConversion{"mi", units.MustParse("wmoUnit:mi")}
+	toNauticalMiles   = unitConversion{"nmi", units.MustParse("wmoUnit:nmi")}
+)
+
+// what each metric unit turns into, anything not listed is left alone
+type unitSystem struct {
+	name        string
+	conversions map[string]unitConversion
+}
+
+var unitSystems = map[string]unitSystem{
+	"metric": {name: "metric", conversions: map[string]unitConversion{}},
+	"us": {name: "us", conversions: map[string]unitConversion{
+		"wmoUnit:degC":   toFahrenheit,
+		"wmoUnit:km_h-1": toMph,
+		"wmoUnit:mm":     toInches,
+		"wmoUnit:m":      toFeet,
+		"wmoUnit:km":     toMiles,
+	}},
+	// road signs in miles, thermometers in celsius
+	"uk": {name: "uk", conversions: map[string]unitConversion{
+		"wmoUnit:km_h-1": toMph,
+		"wmoUnit:m":      toMiles,
+		"wmoUnit:km":     toMiles,
+	}},
+	"si": {name: "si", conversions: map[string]unitConversion{
+		"wmoUnit:degC":   toKelvin,
+		"wmoUnit:km_h-1": toMetersPerSecond,
+	}},
+	"nautical": {name: "nautical", conversions: map[string]unitConversion{
+		"wmoUnit:km_h-1": toKnots,
+		"wmoUnit:m":      toNauticalMiles,
+		"wmoUnit:km":     toNauticalMiles,
+	}},
+}
+
+// what liberate converts to, set by -unit-system or -freedom
+var activeUnitSystem = unitSystems["us"]
+
+func unitSystemNames() []string {
+	names := []string{}
+	for name := range unitSystems {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (s unitSystem) convert(p weatherPoint) weatherPoint {
+	c, ok := s.conversions[p.Unit]
+	if !ok || p.Value == nil {
+		return p
+	}
+
+	from, err := units.Parse(p.Unit)
+	if err != nil {
+		return p
+	}
+
+	v, err := units.Convert(*p.Value, from, c.to)
+	if err != nil {
+		return p
+	}
+
+	return weatherPoint{StartTime: p.StartTime, EndTime: p.EndTime, Value: &v, Unit: c.unit}
+}
+
+// differences like a dewpoint spread scale but don't take the offset
+func (s unitSystem) convertDifference(v float64, unit string) (float64, string) {
+	c, ok := s.conversions[unit]
+	if !ok {
+		return v, displayUnit(unit)
+	}
+
+	converted, err := units.ConvertDifference(v, units.MustParse(unit), c.to)
+	if err != nil {
+		return v, displayUnit(unit)
+	}
+
+	return converted, c.unit
+}
+
+// the unit values in the given metric unit end up in
+func (s unitSystem) unit(unit string) string {
+	if c, ok := s.conversions[unit]; ok {
+		return c.unit
+	}
+
+	return displayUnit(unit)
+}
+
+func selectUnitSystem(name string, freedom *bool) error {
+	s, ok := unitSystems[name]
+	if !ok {
+		return fmt.Errorf("unit system '%s' is not in %v", name, unitSystemNames())
+	}
+
+	activeUnitSystem = s
+	*freedom = name != "metric"
+
+	return nil
+}
+
+type unitSystemFlag struct{ freedom *bool }
+
+func (unitSystemFlag) String() string { return "" }
+
+func (f unitSystemFlag) Set(name string) error { return selectUnitSystem(name, f.freedom) }
+
+// -freedom is what -unit-system us used to be called
+type freedomFlag struct{ freedom *bool }
+
+func (freedomFlag) String() string { return "" }
+
+func (freedomFlag) IsBoolFlag() bool { return true }
+
+func (f freedomFlag) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+
+	if b {
+		return selectUnitSystem("us", f.freedom)
+	}
+
+	return selectUnitSystem("metric", f.freedom)
+}
+
+// lets any subcommand take -unit-system and -freedom with a single line,
+// starting out in the given system
+func unitSystemFlags(flagset *flag.FlagSet, freedom *bool, system string) {
+	selectUnitSystem(system, freedom)
+
+	flagset.Var(unitSystemFlag{freedom}, "unit-system", fmt.Sprintf("unit `system` to show values in, one of %v (default %s)", unitSystemNames(), system))
+	flagset.Var(freedomFlag{freedom}, "freedom", "use freedom units, same as -unit-system us")
+}
+
+// the same unit can come as "unit:degC", "wmoUnit:degC" or just "degC", and
+// the conversions only know it as wmoUnit:degC
+func normalizeUnit(code string) string {
+	u, err := units.Parse(code)
+	if err != nil {
+		return code
+	}
+
+	return u.Canonical()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// set by release builds with -ldflags "-X main.version=...", see
+// .goreleaser.yaml
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+type buildVersion struct {
+	version  string
+	commit   string
+	date     string
+	modified bool
+}
+
+// what the release stamped in, or else whatever go build recorded about the
+// module and checkout, so go install'd and local builds can still say
+func currentVersion() buildVersion {
+	v := buildVersion{version: version, commit: commit, date: date}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return v
+	}
+
+	if v.version == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		v.version = info.Main.Version
+	}
+
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if v.commit == "" {
+				v.commit = s.Value
+			}
+		case "vcs.time":
+			if v.date == "" {
+				v.date = s.Value
+			}
+		case "vcs.modified":
+			v.modified = s.Value == "true"
+		}
+	}
+
+	return v
+}
+
+func (v buildVersion) String() string {
+	s := v.version
+	if s == "" {
+		s = "dev"
+	}
+
+	if v.commit != "" {
+		commit := v.commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+
+		if v.modified {
+			commit += "-dirty"
+		}
+
+		s += " (" + commit
+		if v.date != "" {
+			s += ", " + v.date
+		}
+		s += ")"
+	}
+
+	return s
+}
+
+func runVersion(flagset *flag.FlagSet) func() {
+	return func() {
+		fmt.Printf("agwc %s %s/%s %s\n", currentVersion(), runtime.GOOS, runtime.GOARCH, runtime.Version())
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// a whole forecast query by name, like "morning" for the location,
+// properties, window, format and units looked at every morning
+type viewConfig struct {
+	// the arguments to agwc, like ["-location", "home", "-hours", "4"]
+	Args []string `json:"args"`
+}
+
+// view's own subcommands, which a view can't be named since they'd be
+// found first
+var viewSubcommands = []string{"list", "save", "delete"}
+
+func runView(args []string) {
+	if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+		errorAndQuit(fmt.Errorf("expected 'agwc view <name>', optionally followed by forecast flags to change, or one of %v", viewSubcommands))
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	v, ok := cfg.Views[args[1]]
+	if !ok {
+		errorAndQuit(fmt.Errorf("view '%s' is not in %v", args[1], viewNames(cfg)))
+	}
+
+	// later flags win, so anything given here overrides the view's
+	forecastArgs := append([]string{args[0]}, v.Args...)
+	forecastArgs = append(forecastArgs, args[2:]...)
+
+	runForecast(forecastArgs)
+}
+
+func viewNames(cfg config) []string {
+	names := []string{}
+	for name := range cfg.Views {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func runViewList(flagset *flag.FlagSet) func() {
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+
+	return func() {
+		cfg, err := loadConfig()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		if len(cfg.Views) == 0 {
+			fmt.Println("no views, save one with 'agwc view save <name> [forecast flags]'")
+			return
+		}
+
+		t := newTable(os.Stdout, []int{15, 70}, []string{"view", "args"})
+
+		for _, name := range viewNames(cfg) {
+			quoted := []string{}
+			for _, a := range cfg.Views[name].Args {
+				quoted = append(quoted, shellQuote(a))
+			}
+
+			t.row([]string{name, strings.Join(quoted, " ")}, nil)
+		}
+
+		t.end()
+	}
+}
+
+// saves the flags after the name as the view, replacing any view by that
+// name, once they parse as a forecast
+func runViewSave(args []string) {
+	if len(args) < 3 || strings.HasPrefix(args[1], "-") {
+		errorAndQuit(fmt.Errorf("expected 'agwc view save <name>' followed by the forecast flags to save"))
+	}
+
+	name := args[1]
+	if indexOf(viewSubcommands, name) >= 0 {
+		errorAndQuit(fmt.Errorf("a view can't be named '%s', since 'agwc view %s' is taken", name, name))
+	}
+
+	_, err := getForecastRequest(append([]string{args[0]}, args[2:]...))
+	if err != nil {
+		errorAndQuit(fmt.Errorf("view '%s' would not run: %w", name, err))
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	if cfg.Views == nil {
+		cfg.Views = map[string]viewConfig{}
+	}
+
+	_, replaced := cfg.Views[name]
+	cfg.Views[name] = viewConfig{Args: append([]string{}, args[2:]...)}
+
+	err = saveConfig(cfg)
+	if err != nil {
+		errorAndQuit(err)
+	}
+
+	if replaced {
+		fmt.Printf("replaced view '%s'\n", name)
+	} else {
+		fmt.Printf("saved view '%s', run it with 'agwc view %s'\n", name, name)
+	}
+}
+
+func runViewDelete(flagset *flag.FlagSet) func() {
+	return func() {
+		if flagset.NArg() != 1 {
+			errorAndQuit(fmt.Errorf("expected exactly one view to delete"))
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		name := flagset.Arg(0)
+		if _, ok := cfg.Views[name]; !ok {
+			errorAndQuit(fmt.Errorf("view '%s' is not in %v", name, viewNames(cfg)))
+		}
+
+		delete(cfg.Views, name)
+
+		err = saveConfig(cfg)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		fmt.Printf("deleted view '%s'\n", name)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// -watch keeps the table up to date, but every table it draws comes from a
+// single issuance, so hours from an old forecast never sit next to hours
+// from a new one
+func watchForecast(req forecastRequest, grid gridPoint) {
+	var (
+		issued time.Time
+		shown  []displayRow
+	)
+
+	fmt.Printf("watching the forecast at %s every %s, press ctrl-c to stop\n", grid.forecastGridDataURL, req.watchInterval)
+
+	for {
+		forgetFetches()
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, req.fetchProperties())
+		if err != nil {
+			// a blip upstream shouldn't end a long watch
+			fmt.Printf("[%s] could not check the forecast: %s\n", time.Now().In(req.displayTimeZone).Format("15:04:05"), err)
+		} else if shown == nil || !forecast.updateTime.Equal(issued) {
+			req.issuedAt = forecast.updateTime
+
+			rows := buildRows(req, forecast.properties)
+			if req.sortProperty != "" {
+				sortRows(rows, indexOf(req.columns(), req.sortProperty), req.sortDescending)
+			}
+
+			fmt.Println()
+			fmt.Printf("[%s] forecast issued %s\n", time.Now().In(req.displayTimeZone).Format("15:04:05"), forecast.updateTime.In(req.displayTimeZone).Format(time.Stamp))
+
+			if req.watchDiff && shown != nil {
+				changes := changedHours(req, shown, rows)
+				if len(changes) == 0 {
+					fmt.Println("no displayed hours changed")
+				}
+
+				for _, c := range changes {
+					fmt.Println(c)
+				}
+			}
+
+			if len(rows) == 0 {
+				fmt.Println("no hours match your criteria in the requested window")
+			} else {
+				render(os.Stdout, req, rows)
+			}
+
+			issued = forecast.updateTime
+			shown = rows
+		}
+
+		time.Sleep(req.watchInterval)
+	}
+}
+
+// one line per hour shown in both tables whose visible values differ,
+// like "Jan  2 15:00 temperature 51 F -> 54 F"
+func changedHours(req forecastRequest, before, after []displayRow) []string {
+	columns := req.columns()
+
+	previous := map[int64]displayRow{}
+	for _, r := range before {
+		previous[r.at.Unix()] = r
+	}
+
+	changes := []string{}
+	for _, r := range after {
+		old, ok := previous[r.at.Unix()]
+		if !ok {
+			continue
+		}
+
+		diffs := []string{}
+		for _, i := range req.visibleColumns() {
+			if old.values[i] != r.values[i] {
+				diffs = append(diffs, fmt.Sprintf("%s %s -> %s", columns[i], old.values[i], r.values[i]))
+			}
+		}
+
+		if len(diffs) > 0 {
+			changes = append(changes, r.at.In(req.displayTimeZone).Format("Jan _2 15:04")+" "+strings.Join(diffs, ", "))
+		}
+	}
+
+	return changes
+}
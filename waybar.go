@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// hours in the tooltip, at most
+const waybarHours = 12
+
+// what a Waybar custom module with "return-type": "json" reads, one object
+// per line. i3blocks takes the same with "format=json".
+type waybarOutput struct {
+	Text    string `json:"text"`
+	Tooltip string `json:"tooltip"`
+	Class   string `json:"class"`
+}
+
+// the text and tooltip are Pango markup
+var waybarEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// the coming hour's condition glyph and first column for the bar, with the
+// class as the condition for styling, and the coming hours as a monospace
+// table in the tooltip
+func writeForecastWaybar(w io.Writer, req forecastRequest, forecast gridForecast, rows []displayRow) error {
+	upcoming := []displayRow{}
+	for _, r := range rows {
+		if !r.observed && len(upcoming) < waybarHours {
+			upcoming = append(upcoming, r)
+		}
+	}
+
+	if len(upcoming) == 0 {
+		return fmt.Errorf("no hours match your criteria in the requested window")
+	}
+
+	current := upcoming[0]
+	columns := req.columns()
+	visible := req.visibleColumns()
+
+	class := condition(forecast, current.at, current.at.Add(time.Hour))
+
+	text := conditionGlyphs[class]
+	if len(visible) > 0 {
+		text += " " + current.values[visible[0]]
+	}
+
+	header := []string{"time"}
+	for _, i := range visible {
+		header = append(header, columns[i])
+	}
+
+	cells := [][]string{header}
+	for _, r := range upcoming {
+		line := []string{r.at.In(req.displayTimeZone).Format("15:04")}
+		for _, i := range visible {
+			line = append(line, r.values[i])
+		}
+
+		cells = append(cells, line)
+	}
+
+	widths := make([]int, len(header))
+	for _, line := range cells {
+		for i, c := range line {
+			if width := runewidth.StringWidth(c); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+
+	lines := []string{}
+	for _, line := range cells {
+		padded := []string{}
+		for i, c := range line {
+			padded = append(padded, runewidth.FillLeft(c, widths[i]))
+		}
+
+		lines = append(lines, strings.Join(padded, "  "))
+	}
+
+	out := waybarOutput{
+		Text:    waybarEscaper.Replace(text),
+		Tooltip: "<tt>" + waybarEscaper.Replace(strings.Join(lines, "\n")) + "</tt>",
+		Class:   class,
+	}
+
+	// one line, with the markup left readable
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	err := enc.Encode(out)
+	if err != nil {
+		return fmt.Errorf("could not write waybar JSON: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// what the week layout needs, whatever -properties says
+var weekProperties = []string{"temperature", "quantitativePrecipitation", "probabilityOfPrecipitation", "probabilityOfThunder", "snowfallAmount", "skyCover"}
+
+type weekDay struct {
+	day       time.Time
+	high, low *float64
+	precip    *float64
+	chance    *float64
+	glyph     string
+}
+
+var conditionGlyphs = map[string]string{
+	"thunder":       "⛈",
+	"snow":          "❄",
+	"rain":          "☂",
+	"cloudy":        "☁",
+	"partly-cloudy": "⛅",
+	"clear":         "☀",
+}
+
+func conditionGlyph(forecast gridForecast, start, end time.Time) string {
+	return conditionGlyphs[condition(forecast, start, end)]
+}
+
+// the worst of the span wins, since that's what you plan around
+func condition(forecast gridForecast, start, end time.Time) string {
+	most := func(property string) float64 {
+		m := 0.0
+		for at := start; at.Before(end); at = at.Add(time.Hour) {
+			if p, ok := findPointAt(forecast.properties[property], at); ok && p.Value != nil {
+				m = math.Max(m, *p.Value)
+			}
+		}
+
+		return m
+	}
+
+	snow, _ := totalOver(forecast.properties["snowfallAmount"], start, end)
+
+	sky, hours := 0.0, 0
+	for at := start; at.Before(end); at = at.Add(time.Hour) {
+		if p, ok := findPointAt(forecast.properties["skyCover"], at); ok && p.Value != nil {
+			sky += *p.Value
+			hours++
+		}
+	}
+
+	if hours > 0 {
+		sky /= float64(hours)
+	}
+
+	switch {
+	case most("probabilityOfThunder") >= 30:
+		return "thunder"
+	case snow > 0 && most("probabilityOfPrecipitation") >= 30:
+		return "snow"
+	case most("probabilityOfPrecipitation") >= 50:
+		return "rain"
+	case sky >= 70:
+		return "cloudy"
+	case sky >= 30:
+		return "partly-cloudy"
+	default:
+		return "clear"
+	}
+}
+
+func weekDays(req forecastRequest, forecast gridForecast) []weekDay {
+	local := req.start.In(req.displayTimeZone)
+	first := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, req.displayTimeZone)
+
+	week := req
+	week.start = first
+	week.end = first.AddDate(0, 0, 7).Add(-time.Hour)
+
+	extremes := map[time.Time]dailyExtremes{}
+	for _, d := range forecastDailyExtremes(week, forecast.properties["temperature"]) {
+		extremes[d.day] = d
+	}
+
+	days := []weekDay{}
+
+	for i := 0; i < 7; i++ {
+		start := first.AddDate(0, 0, i)
+		end := first.AddDate(0, 0, i+1)
+
+		d := weekDay{day: start, glyph: conditionGlyph(forecast, start, end)}
+
+		// the extremes come in F
+		if e, ok := extremes[start]; ok && e.high != nil {
+			high := weatherPoint{Value: fahrenheitToCelsius(*e.high), Unit: "wmoUnit:degC"}
+			low := weatherPoint{Value: fahrenheitToCelsius(*e.low), Unit: "wmoUnit:degC"}
+			high, low = req.liberate(high), req.liberate(low)
+
+			d.high, d.low = high.Value, low.Value
+		}
+
+		if total, ok := totalOver(forecast.properties["quantitativePrecipitation"], start, end); ok {
+			p := req.liberate(weatherPoint{Value: &total, Unit: "wmoUnit:mm"})
+
+			d.precip = p.Value
+		}
+
+		for at := start; at.Before(end); at = at.Add(time.Hour) {
+			p, ok := findPointAt(forecast.properties["probabilityOfPrecipitation"], at)
+			if ok && p.Value != nil && (d.chance == nil || *p.Value > *d.chance) {
+				d.chance = p.Value
+			}
+		}
+
+		days = append(days, d)
+	}
+
+	return days
+}
+
+// seven days across, each with its condition, high and low, precipitation
+// total and best chance of it
+func displayWeek(w io.Writer, req forecastRequest, forecast gridForecast) {
+	days := weekDays(req, forecast)
+
+	temperatureUnit := req.columnUnit(kindUnits[kindTemperature])
+	precipUnit := req.columnUnit(kindUnits[kindPrecipitation])
+
+	widths := []int{6}
+	header := []string{""}
+
+	for _, d := range days {
+		widths = append(widths, 9)
+		header = append(header, d.day.Format("Mon 01/02"))
+	}
+
+	value := func(v *float64, precision int, unit string) string {
+		if v == nil {
+			return "-"
+		}
+
+		return formatNumber(*v, precision) + unit
+	}
+
+	rows := [][]string{{""}, {"high"}, {"low"}, {"precip"}, {"chance"}}
+
+	for _, d := range days {
+		rows[0] = append(rows[0], d.glyph)
+		rows[1] = append(rows[1], value(d.high, kindPrecision[kindTemperature], temperatureUnit))
+		rows[2] = append(rows[2], value(d.low, kindPrecision[kindTemperature], temperatureUnit))
+		rows[3] = append(rows[3], value(d.precip, kindPrecision[kindPrecipitation], precipUnit))
+		rows[4] = append(rows[4], value(d.chance, kindPrecision[kindProbability], "%"))
+	}
+
+	fmt.Fprintf(w, "week of %s, %s\n", days[0].day.Format("Mon Jan 2"), req.displayTimeZone)
+
+	t := newTable(w, widths, header)
+	for _, r := range rows {
+		t.row(r, nil)
+	}
+
+	t.end()
+}
@@ -0,0 +1,505 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// what a day is judged on, in metric until it's displayed
+type whenDay struct {
+	day    time.Time
+	high   *float64
+	low    *float64
+	precip *float64
+	source string
+}
+
+type whenCriterion struct {
+	field string
+	op    string
+	value float64
+}
+
+var whenFields = []string{"high", "low", "precip"}
+
+func (c whenCriterion) String() string {
+	return c.field + c.op + formatNumber(c.value, 2)
+}
+
+// like "high>=70,low>=50,precip<0.1", in the units being displayed
+func parseWhenCriteria(s string) ([]whenCriterion, error) {
+	criteria := []whenCriterion{}
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+
+		i := strings.IndexAny(part, "<>")
+		if i < 0 {
+			return nil, fmt.Errorf("criterion '%s' must look like high>=70", part)
+		}
+
+		c := whenCriterion{field: part[:i], op: part[i : i+1]}
+		rest := part[i+1:]
+
+		if strings.HasPrefix(rest, "=") {
+			c.op += "="
+			rest = rest[1:]
+		}
+
+		if indexOf(whenFields, c.field) < 0 {
+			return nil, fmt.Errorf("criterion field '%s' is not in %v", c.field, whenFields)
+		}
+
+		v, err := strconv.ParseFloat(rest, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse value in criterion '%s': %w", part, err)
+		}
+
+		c.value = v
+		criteria = append(criteria, c)
+	}
+
+	return criteria, nil
+}
+
+func (c whenCriterion) met(v *float64) bool {
+	if v == nil {
+		return false
+	}
+
+	switch c.op {
+	case "<":
+		return *v < c.value
+	case "<=":
+		return *v <= c.value
+	case ">":
+		return *v > c.value
+	default:
+		return *v >= c.value
+	}
+}
+
+// "jun 1 - aug 31" is the next time those dates come around, and
+// "2025-06-01 - 2025-08-31" is exactly that
+func parseDateRange(s string, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	from, to, ok := strings.Cut(s, " - ")
+	if !ok {
+		from, to, ok = strings.Cut(s, " to ")
+	}
+
+	if !ok {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must look like 'jun 1 - aug 31', got '%s'", s)
+	}
+
+	today := time.Date(now.In(loc).Year(), now.In(loc).Month(), now.In(loc).Day(), 0, 0, 0, 0, loc)
+
+	parse := func(s string) (time.Time, bool, error) {
+		s = strings.TrimSpace(s)
+
+		if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+			return t, true, nil
+		}
+
+		// time.Parse wants "Jun", not "jun" or "JUN"
+		if len(s) >= 3 {
+			s = strings.ToUpper(s[:1]) + strings.ToLower(s[1:3]) + s[3:]
+		}
+
+		for _, layout := range []string{"Jan 2", "January 2"} {
+			if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+				return time.Date(today.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc), false, nil
+			}
+		}
+
+		return time.Time{}, false, fmt.Errorf("'%s' is not a date like 'jun 1' or 2025-06-01", s)
+	}
+
+	start, startYear, err := parse(from)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	end, endYear, err := parse(to)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if !endYear && end.Before(start) {
+		end = end.AddDate(1, 0, 0)
+	}
+
+	if !startYear && !endYear && end.Before(today) {
+		start, end = start.AddDate(1, 0, 0), end.AddDate(1, 0, 0)
+	}
+
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("range ends on %s, before it starts on %s", end.Format("Jan 2 2006"), start.Format("Jan 2 2006"))
+	}
+
+	return start, end, nil
+}
+
+// the station's 1991-2020 daily normals from ACIS, which are the same every
+// year, in F and inches
+func getDailyNormals(stationID string, start, end time.Time) ([]whenDay, error) {
+	elem := func(name string) map[string]interface{} {
+		return map[string]interface{}{"name": name, "normal": "1"}
+	}
+
+	params := map[string]interface{}{
+		"sid":   stationID + " 5",
+		"sdate": start.Format("2006-01-02"),
+		"edate": end.Format("2006-01-02"),
+		"elems": []interface{}{elem("maxt"), elem("mint"), elem("pcpn")},
+	}
+
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode ACIS request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", "https://data.rcc-acis.org/StnData", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize HTTP request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute HTTP request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	body := struct {
+		Error string     `json:"error"`
+		Data  [][]string `json:"data"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse HTTP response body: %w", err)
+	}
+
+	if body.Error != "" {
+		return nil, fmt.Errorf("climate normals unavailable for %s: %s", stationID, body.Error)
+	}
+
+	days := []whenDay{}
+	for _, row := range body.Data {
+		if len(row) != 4 {
+			return nil, fmt.Errorf("unexpected climate normals for %s", stationID)
+		}
+
+		day, err := time.ParseInLocation("2006-01-02", row[0], start.Location())
+		if err != nil {
+			return nil, fmt.Errorf("could not parse date in climate normals: %w", err)
+		}
+
+		d := whenDay{day: day, source: "normals"}
+
+		if high := parseNormal(row[1]); high != nil {
+			d.high = fahrenheitToCelsius(*high)
+		}
+
+		if low := parseNormal(row[2]); low != nil {
+			d.low = fahrenheitToCelsius(*low)
+		}
+
+		if precip := parseNormal(row[3]); precip != nil {
+			mm := *precip * 25.4
+			d.precip = &mm
+		}
+
+		days = append(days, d)
+	}
+
+	return days, nil
+}
+
+// ACIS says M for missing and T for a trace, which is as good as none
+func parseNormal(s string) *float64 {
+	if s == "T" {
+		zero := 0.0
+		return &zero
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &f
+}
+
+// the forecast instead of normals for days it covers all the way through
+func applyForecastDays(days []whenDay, forecast gridForecast) {
+	temperatures := forecast.properties["temperature"]
+	if temperatures.Len() == 0 {
+		return
+	}
+
+	covered := temperatures.At(temperatures.Len() - 1).EndTime
+
+	for i, d := range days {
+		end := d.day.AddDate(0, 0, 1)
+		if end.After(covered) || d.day.Before(time.Now().Add(-24*time.Hour)) {
+			continue
+		}
+
+		var high, low *float64
+		for at := d.day; at.Before(end); at = at.Add(time.Hour) {
+			p, ok := findPointAt(temperatures, at)
+			if !ok || p.Value == nil {
+				continue
+			}
+
+			v := *p.Value
+			if high == nil || v > *high {
+				high = &v
+			}
+
+			if low == nil || v < *low {
+				low = &v
+			}
+		}
+
+		if high == nil {
+			continue
+		}
+
+		days[i] = whenDay{day: d.day, high: high, low: low, source: "forecast"}
+
+		if total, ok := totalOver(forecast.properties["quantitativePrecipitation"], d.day, end); ok {
+			days[i].precip = &total
+		}
+	}
+}
+
+type whenWeek struct {
+	days    []whenDay
+	meeting int
+}
+
+func (w whenWeek) source() string {
+	source := w.days[0].source
+	for _, d := range w.days {
+		if d.source != source {
+			return "mixed"
+		}
+	}
+
+	return source
+}
+
+func (w whenWeek) average(value func(whenDay) *float64) *float64 {
+	total, n := 0.0, 0
+	for _, d := range w.days {
+		if v := value(d); v != nil {
+			total += *v
+			n++
+		}
+	}
+
+	if n == 0 {
+		return nil
+	}
+
+	avg := total / float64(n)
+
+	return &avg
+}
+
+func (w whenWeek) total(value func(whenDay) *float64) *float64 {
+	total, found := 0.0, false
+	for _, d := range w.days {
+		if v := value(d); v != nil {
+			total += *v
+			found = true
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	return &total
+}
+
+// weeks from the start of the range, with whatever's left over at the end
+// as a shorter one
+func rankWeeks(days []whenDay, criteria []whenCriterion, freedom bool) []whenWeek {
+	weeks := []whenWeek{}
+
+	for i := 0; i < len(days); i += 7 {
+		end := i + 7
+		if end > len(days) {
+			end = len(days)
+		}
+
+		w := whenWeek{days: days[i:end]}
+
+		for _, d := range w.days {
+			if dayMeets(d, criteria, freedom) {
+				w.meeting++
+			}
+		}
+
+		weeks = append(weeks, w)
+	}
+
+	sort.SliceStable(weeks, func(i, j int) bool {
+		return weeks[i].score() > weeks[j].score()
+	})
+
+	return weeks
+}
+
+func (w whenWeek) score() float64 {
+	return float64(w.meeting) / float64(len(w.days))
+}
+
+func dayMeets(d whenDay, criteria []whenCriterion, freedom bool) bool {
+	values := map[string]weatherPoint{
+		"high":   {Value: d.high, Unit: "wmoUnit:degC"},
+		"low":    {Value: d.low, Unit: "wmoUnit:degC"},
+		"precip": {Value: d.precip, Unit: "wmoUnit:mm"},
+	}
+
+	for _, c := range criteria {
+		p := values[c.field]
+		if freedom {
+			p = liberate(p)
+		}
+
+		if !c.met(p.Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func runWhen(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		dateRange    string
+		criteria     string
+		pinned       string
+		displaytz    string
+		freedom      bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address or named location to plan a trip to")
+	flagset.StringVar(&dateRange, "range", "", "dates to pick a week from, like 'jun 1 - aug 31' or '2025-06-01 - 2025-08-31'")
+	flagset.StringVar(&criteria, "criteria", "", fmt.Sprintf("what makes a good day in the displayed units, comma separated comparisons of %v, like 'high>=70,high<=85,precip<0.1'", whenFields))
+	flagset.StringVar(&pinned, "station", "", "station to use climate normals from instead of the nearest one")
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone the dates are in")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+
+	return func() {
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		if dateRange == "" || criteria == "" {
+			errorAndQuit(fmt.Errorf("need both -range and -criteria"))
+		}
+
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		start, end, err := parseDateRange(dateRange, time.Now(), loc)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		wanted, err := parseWhenCriteria(criteria)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		stations, err := getStations(grid, coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		s, err := selectStation(stations, pinned)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		days, err := getDailyNormals(s.id, start, end)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		if len(days) == 0 {
+			fmt.Println("no climate normals for the range")
+			quit(exitNoData)
+		}
+
+		// the forecast only reaches about a week out, so it's only worth
+		// asking for when the range starts before then
+		if start.Before(time.Now().AddDate(0, 0, 8)) {
+			forecast, err := getWeatherData(grid.forecastGridDataURL, []string{"temperature", "quantitativePrecipitation"})
+			if err != nil {
+				errorAndQuit(err)
+			}
+
+			applyForecastDays(days, forecast)
+		}
+
+		weeks := rankWeeks(days, wanted, freedom)
+
+		criteriaNames := []string{}
+		for _, c := range wanted {
+			criteriaNames = append(criteriaNames, c.String())
+		}
+
+		fmt.Printf("weeks ranked by days with %s\n", strings.Join(criteriaNames, ", "))
+
+		t := newTable(os.Stdout, []int{4, 15, 6, 8, 8, 8, 8}, []string{"rank", "week", "days", "high", "low", "precip", "source"})
+
+		for i, w := range weeks {
+			first, last := w.days[0].day, w.days[len(w.days)-1].day
+
+			t.row([]string{
+				strconv.Itoa(i + 1),
+				first.Format("Jan 2") + " - " + last.Format("Jan 2"),
+				fmt.Sprintf("%d/%d", w.meeting, len(w.days)),
+				formatCelsius(w.average(func(d whenDay) *float64 { return d.high }), freedom),
+				formatCelsius(w.average(func(d whenDay) *float64 { return d.low }), freedom),
+				formatWeatherValue("quantitativePrecipitation", weatherPoint{Value: w.total(func(d whenDay) *float64 { return d.precip }), Unit: "wmoUnit:mm"}, freedom),
+				w.source(),
+			}, nil)
+		}
+
+		t.end()
+
+		fmt.Println()
+		fmt.Printf("normals are the 1991-2020 averages at %s (%s), not a forecast; forecast days are from NWS\n", s.name, s.id)
+	}
+}
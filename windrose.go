@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// what the wind rose needs, whatever -properties says
+var windroseProperties = []string{"windSpeed", "windDirection"}
+
+var windroseSectors = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+
+// upper edges of the speed bins in km/h, with the last bin open ended.
+// anything under windroseCalm has no direction worth counting.
+var windroseBins = []float64{10, 20, 30, 40}
+
+const windroseCalm = 2
+
+// from lightest to strongest, one per bin
+var windroseShades = []string{"·", "░", "▒", "▓", "█"}
+
+// how many characters the busiest direction gets
+const windroseWidth = 40
+
+type windRose struct {
+	hours int
+	calm  int
+
+	// counts by sector and then speed bin
+	counts [][]int
+}
+
+func windroseSector(degrees float64) int {
+	return int(math.Mod(degrees+22.5+360, 360)/45) % len(windroseSectors)
+}
+
+func windroseBin(kph float64) int {
+	for i, edge := range windroseBins {
+		if kph < edge {
+			return i
+		}
+	}
+
+	return len(windroseBins)
+}
+
+// hours missing either speed or direction aren't counted at all
+func newWindRose(forecast gridForecast, start, end time.Time) windRose {
+	rose := windRose{counts: make([][]int, len(windroseSectors))}
+	for i := range rose.counts {
+		rose.counts[i] = make([]int, len(windroseBins)+1)
+	}
+
+	for at := start.Truncate(time.Hour); !at.After(end); at = at.Add(time.Hour) {
+		speed, ok := findPointAt(forecast.properties["windSpeed"], at)
+		if !ok || speed.Value == nil {
+			continue
+		}
+
+		rose.hours++
+
+		if *speed.Value < windroseCalm {
+			rose.calm++
+			continue
+		}
+
+		direction, ok := findPointAt(forecast.properties["windDirection"], at)
+		if !ok || direction.Value == nil {
+			rose.hours--
+			continue
+		}
+
+		rose.counts[windroseSector(*direction.Value)][windroseBin(*speed.Value)]++
+	}
+
+	return rose
+}
+
+// a bar per direction the wind comes from, shaded by how hard, with the
+// busiest direction taking the full width
+func displayWindRose(w io.Writer, req forecastRequest, forecast gridForecast) {
+	rose := newWindRose(forecast, req.start, req.end)
+
+	if rose.hours == 0 {
+		fmt.Fprintln(w, "no wind forecast in the requested window")
+		quit(exitNoRows)
+	}
+
+	busiest := 0
+	for _, bins := range rose.counts {
+		total := 0
+		for _, c := range bins {
+			total += c
+		}
+
+		if total > busiest {
+			busiest = total
+		}
+	}
+
+	percent := func(n int) string {
+		return formatNumber(100*float64(n)/float64(rose.hours), 0) + "%"
+	}
+
+	fmt.Fprintf(w, "wind rose for %d hours from %s to %s\n\n", rose.hours, req.start.In(req.displayTimeZone).Format(time.Stamp), req.end.In(req.displayTimeZone).Format(time.Stamp))
+
+	for i, sector := range windroseSectors {
+		bar := strings.Builder{}
+		total := 0
+
+		// rounding each bin on its own can lose a short one entirely, so
+		// the widths come from the running total
+		drawn := 0
+		for bin, c := range rose.counts[i] {
+			total += c
+
+			width := 0
+			if busiest > 0 {
+				width = int(math.Round(float64(total) / float64(busiest) * windroseWidth))
+			}
+
+			bar.WriteString(strings.Repeat(windroseShades[bin], width-drawn))
+			drawn = width
+		}
+
+		fmt.Fprintf(w, "%-3s %-*s %s\n", sector, windroseWidth, bar.String(), percent(total))
+	}
+
+	fmt.Fprintf(w, "calm %s\n\n", percent(rose.calm))
+
+	unit := req.columnUnit(kindUnits[kindSpeed])
+
+	edge := func(kph float64) string {
+		p := weatherPoint{Value: &kph, Unit: kindUnits[kindSpeed]}
+		p = req.liberate(p)
+
+		return formatNumber(*p.Value, 0)
+	}
+
+	legend := []string{}
+	lower := edge(windroseCalm)
+
+	for i, upper := range windroseBins {
+		legend = append(legend, fmt.Sprintf("%s %s-%s", windroseShades[i], lower, edge(upper)))
+		lower = edge(upper)
+	}
+
+	legend = append(legend, fmt.Sprintf("%s %s+", windroseShades[len(windroseBins)], lower))
+
+	fmt.Fprintf(w, "%s %s, by the direction it blows from\n", strings.Join(legend, "  "), unit)
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/packrat386/agwc/units"
+)
+
+var workWindowFormats = []string{"table", "ics"}
+
+// what the window is summarized by, on top of whatever the needs look at
+var workWindowProperties = []string{"temperature", "windSpeed", "probabilityOfPrecipitation", "quantitativePrecipitation"}
+
+// short names for the properties people put in -needs
+var workNeedAliases = map[string]string{
+	"temp":     "temperature",
+	"wind":     "windSpeed",
+	"gust":     "windGust",
+	"rh":       "relativeHumidity",
+	"humidity": "relativeHumidity",
+	"dew":      "dewpoint",
+	"pop":      "probabilityOfPrecipitation",
+	"sky":      "skyCover",
+}
+
+// units a -needs threshold can be given in, converted to what the API
+// sends before comparing. a bare number is already in that.
+var workNeedUnits = map[string]string{
+	"c":    "wmoUnit:degC",
+	"f":    "wmoUnit:degF",
+	"mph":  "wmoUnit:mi_h-1",
+	"kph":  "wmoUnit:km_h-1",
+	"km/h": "wmoUnit:km_h-1",
+	"kt":   "wmoUnit:kt",
+	"m/s":  "wmoUnit:m_s-1",
+	"%":    "wmoUnit:percent",
+	"mm":   "wmoUnit:mm",
+	"in":   "wmoUnit:in",
+}
+
+var workNeedMatcher = regexp.MustCompile(`^(?P<property>[A-Za-z]+)\s*(?P<operator><=|>=|<|>)\s*(?P<threshold>-?[\d.]+)\s*(?P<unit>[A-Za-z/%]*)$`)
+
+// e.g. "dry 6h, wind<20mph, temp>5C", where the window has to be at least
+// as long as the dry spell, or a bare duration if it doesn't need to be
+// dry, and every condition holds for every hour of it
+type workNeeds struct {
+	length     time.Duration
+	dry        bool
+	conditions []assertion
+}
+
+func parseWorkNeeds(s string) (workNeeds, error) {
+	needs := workNeeds{}
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+
+		fields := strings.Fields(part)
+		if len(fields) > 0 && fields[0] == "dry" {
+			needs.dry = true
+			fields = fields[1:]
+		}
+
+		switch {
+		case len(fields) == 0 && needs.dry:
+			continue
+		case len(fields) == 1 && !strings.ContainsAny(fields[0], "<>"):
+			length, err := time.ParseDuration(fields[0])
+			if err != nil {
+				return workNeeds{}, fmt.Errorf("could not parse length '%s': %w", fields[0], err)
+			}
+
+			needs.length = length
+			continue
+		}
+
+		c, err := parseWorkNeed(part)
+		if err != nil {
+			return workNeeds{}, err
+		}
+
+		needs.conditions = append(needs.conditions, c)
+	}
+
+	if needs.length < time.Hour {
+		return workNeeds{}, fmt.Errorf("need a length of at least an hour, like 'dry 6h' or '4h'")
+	}
+
+	return needs, nil
+}
+
+func parseWorkNeed(s string) (assertion, error) {
+	matches := workNeedMatcher.FindStringSubmatch(s)
+	if len(matches) == 0 {
+		return assertion{}, fmt.Errorf("'%s' is not a valid need, expected something like 'wind<20mph'", s)
+	}
+
+	a := assertion{
+		property: matches[workNeedMatcher.SubexpIndex("property")],
+		operator: matches[workNeedMatcher.SubexpIndex("operator")],
+	}
+
+	if p, ok := workNeedAliases[strings.ToLower(a.property)]; ok {
+		a.property = p
+	}
+
+	kind, ok := propertyRegistry[a.property]
+	if !ok {
+		return assertion{}, fmt.Errorf("property '%s' is not in %v, or one of its short names", a.property, permittedProperties())
+	}
+
+	v, err := strconv.ParseFloat(matches[workNeedMatcher.SubexpIndex("threshold")], 64)
+	if err != nil {
+		return assertion{}, fmt.Errorf("could not parse threshold in '%s': %w", s, err)
+	}
+
+	a.threshold = v
+
+	if unit := strings.ToLower(matches[workNeedMatcher.SubexpIndex("unit")]); unit != "" {
+		code, ok := workNeedUnits[unit]
+		if !ok {
+			return assertion{}, fmt.Errorf("unit '%s' in '%s' is not one agwc knows", unit, s)
+		}
+
+		if _, ok := kindUnits[kind]; !ok {
+			return assertion{}, fmt.Errorf("'%s' doesn't take units, give it a bare number", a.property)
+		}
+
+		a.threshold, err = units.Convert(v, units.MustParse(code), units.MustParse(kindUnits[kind]))
+		if err != nil {
+			return assertion{}, fmt.Errorf("'%s' is not a unit of %s", unit, kindNames[kind])
+		}
+	}
+
+	return a, nil
+}
+
+// missing data never counts as workable
+func (n workNeeds) workable(forecast gridForecast, at time.Time) bool {
+	if n.dry {
+		pop, ok := findPointAt(forecast.properties["probabilityOfPrecipitation"], at)
+		if !ok || pop.Value == nil || *pop.Value >= precipChanceThreshold {
+			return false
+		}
+
+		if qpf, ok := findPointAt(forecast.properties["quantitativePrecipitation"], at); ok && qpf.Value != nil && *qpf.Value > 0 {
+			return false
+		}
+	}
+
+	for _, c := range n.conditions {
+		p, ok := findPointAt(forecast.properties[c.property], at)
+		if !ok || p.Value == nil || !c.holds(*p.Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (n workNeeds) properties() []string {
+	properties := append([]string{}, workWindowProperties...)
+
+	for _, c := range n.conditions {
+		if indexOf(properties, c.property) < 0 {
+			properties = append(properties, c.property)
+		}
+	}
+
+	return properties
+}
+
+type workWindow struct {
+	start, end  time.Time
+	temperature seriesRange
+	wind        *float64
+	pop         *float64
+}
+
+// every run of workable hours at least as long as needed, as long as it
+// goes, since a pour that fits in a longer window has room to slip
+func findWorkWindows(needs workNeeds, forecast gridForecast, start, end time.Time) []workWindow {
+	windows := []workWindow{}
+
+	for at := start; at.Before(end); at = at.Add(time.Hour) {
+		if !needs.workable(forecast, at) {
+			continue
+		}
+
+		w := workWindow{start: at}
+		temperatures, winds, pops := []*float64{}, []*float64{}, []*float64{}
+
+		for ; at.Before(end) && needs.workable(forecast, at); at = at.Add(time.Hour) {
+			value := func(property string) *float64 {
+				p, _ := findPointAt(forecast.properties[property], at)
+				return p.Value
+			}
+
+			temperatures = append(temperatures, value("temperature"))
+			winds = append(winds, value("windSpeed"))
+			pops = append(pops, value("probabilityOfPrecipitation"))
+		}
+
+		w.end = at
+
+		if w.end.Sub(w.start) < needs.length {
+			continue
+		}
+
+		w.temperature = rangeOf(temperatures)
+		w.wind = rangeOf(winds).max
+		w.pop = rangeOf(pops).max
+
+		windows = append(windows, w)
+	}
+
+	return windows
+}
+
+// text values in iCalendar have their own escaping
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+func writeWorkWindowsICS(w io.Writer, address string, needsText string, windows []workWindow, now time.Time) {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//agwc//workwindow//EN",
+	}
+
+	stamp := func(t time.Time) string {
+		return t.UTC().Format("20060102T150405Z")
+	}
+
+	for _, win := range windows {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:%s-%s@agwc", stamp(win.start), stamp(win.end)),
+			"DTSTAMP:"+stamp(now),
+			"DTSTART:"+stamp(win.start),
+			"DTEND:"+stamp(win.end),
+			"SUMMARY:"+icsEscape(fmt.Sprintf("work window (%dh)", int(win.end.Sub(win.start).Hours()))),
+			"LOCATION:"+icsEscape(address),
+			"DESCRIPTION:"+icsEscape("forecast to meet "+needsText),
+			"END:VEVENT",
+		)
+	}
+
+	lines = append(lines, "END:VCALENDAR")
+
+	fmt.Fprint(w, strings.Join(lines, "\r\n")+"\r\n")
+}
+
+func runWorkWindow(flagset *flag.FlagSet) func() {
+	var (
+		queryAddress string
+		needsText    string
+		days         int
+		format       string
+		displaytz    string
+		freedom      bool
+	)
+
+	flagset.StringVar(&queryAddress, "address", "", "address or named location of the job site")
+	flagset.StringVar(&needsText, "needs", "", "what the work needs, like 'dry 6h, wind<20mph, temp>5C', with thresholds in C, F, mph, kph, kt, m/s, %, mm or in, or in the API's units if bare")
+	flagset.IntVar(&days, "days", 7, "number of days ahead to search")
+	flagset.StringVar(&format, "format", "table", fmt.Sprintf("how to print the windows, one of %v", workWindowFormats))
+	flagset.StringVar(&displaytz, "displaytz", defaultDisplayTZ, "time zone in which to display times")
+	unitSystemFlags(flagset, &freedom, defaultUnitSystem)
+	flagset.Var(tableStyleFlag{}, "style", fmt.Sprintf("how to draw the table, one of %v", tableStyleNames()))
+
+	return func() {
+		loc, err := time.LoadLocation(displaytz)
+		if err != nil {
+			errorAndQuit(fmt.Errorf("could not load display timezone: %w", err))
+		}
+
+		if queryAddress == "" {
+			errorAndQuit(fmt.Errorf("address cannot be empty"))
+		}
+
+		if indexOf(workWindowFormats, format) < 0 {
+			errorAndQuit(fmt.Errorf("format '%s' is not in %v", format, workWindowFormats))
+		}
+
+		needs, err := parseWorkNeeds(needsText)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		coordinates, err := getAddressCoordinates(queryAddress)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		grid, err := getGridPoint(coordinates)
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		forecast, err := getWeatherData(grid.forecastGridDataURL, needs.properties())
+		if err != nil {
+			errorAndQuit(err)
+		}
+
+		now := time.Now()
+		start := now.Truncate(time.Hour)
+
+		windows := findWorkWindows(needs, forecast, start, start.AddDate(0, 0, days))
+
+		if format == "ics" {
+			writeWorkWindowsICS(os.Stdout, queryAddress, needsText, windows, now)
+			return
+		}
+
+		if len(windows) == 0 {
+			fmt.Printf("no window in the next %d days meets '%s'\n", days, needsText)
+			quit(exitNoRows)
+		}
+
+		t := newTable(os.Stdout, []int{15, 15, 6, 17, 11, 11}, []string{"start", "end", "hours", "temperature", "wind", "precip"})
+
+		for _, w := range windows {
+			temperature := "No Data"
+			if w.temperature.min != nil {
+				temperature = formatCelsius(w.temperature.min, freedom) + " to " + formatCelsius(w.temperature.max, freedom)
+			}
+
+			t.row([]string{
+				w.start.In(loc).Format(time.Stamp),
+				w.end.In(loc).Format(time.Stamp),
+				strconv.Itoa(int(w.end.Sub(w.start).Hours())),
+				temperature,
+				formatKph(w.wind, freedom),
+				formatWeatherValue("probabilityOfPrecipitation", weatherPoint{Value: w.pop, Unit: "wmoUnit:percent"}, freedom),
+			}, nil)
+		}
+
+		t.end()
+	}
+}